@@ -0,0 +1,208 @@
+package commands
+
+import (
+	"fmt"
+	"proxmox-cli/config"
+	"proxmox-cli/output"
+	"proxmox-cli/services"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// backupGuest is the guest of a backup, which may be a VM or a container
+var backupGuest = guestKind{
+	noun:     "guest",
+	longNoun: "VM or container",
+	command:  "backup",
+}
+
+// BackupCommand creates the parent command for backup operations
+func BackupCommand() *cobra.Command {
+	var backupCmd = &cobra.Command{
+		Use:   "backup",
+		Short: "Manage vzdump backups of VMs and containers",
+	}
+
+	backupCmd.AddCommand(CreateBackupCommand())
+	backupCmd.AddCommand(ListBackupsCommand())
+	backupCmd.AddCommand(RestoreBackupCommand())
+	backupCmd.AddCommand(DeleteBackupCommand())
+	backupCmd.AddCommand(BackupJobCommand())
+
+	return backupCmd
+}
+
+// CreateBackupCommand backs up a VM or container
+func CreateBackupCommand() *cobra.Command {
+	var target guestTarget
+	var options services.BackupOptions
+	var wait bool
+	var timeout time.Duration
+
+	var cmd = &cobra.Command{
+		Use:   "create",
+		Short: "Back up a VM or container with vzdump",
+		Long: `Back up a VM or container with vzdump.
+
+The snapshot mode backs up running guests without downtime, suspend pauses them while
+their data is copied and stop shuts them down for the backup:
+  proxmox-cli backup create -i 100 --storage backups --mode stop --compress zstd --notes '{{guestname}} before upgrade' --wait`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := options.Validate(); err != nil {
+				exitWithError("Invalid backup options", err)
+			}
+			nodeName, vmid := target.resolve(backupGuest)
+
+			taskID, err := newBackupService().CreateBackup(nodeName, vmid, options)
+			if err != nil {
+				exitWithError("Failed to create backup", err)
+			}
+
+			finishTask(fmt.Sprintf("Backup of guest %d initiated", vmid), nodeName, taskID, wait, timeout)
+		},
+	}
+
+	addGuestTargetFlags(cmd, backupGuest, &target)
+	cmd.Flags().StringVar(&options.Mode, "mode", "", "Backup mode: snapshot, suspend or stop (default snapshot)")
+	cmd.Flags().StringVarP(&options.Storage, "storage", "s", "", "Storage to write the backup to")
+	cmd.Flags().StringVar(&options.Compress, "compress", "", "Compression: 0, gzip, lzo or zstd")
+	cmd.Flags().StringVar(&options.Notes, "notes", "", "Notes of the backup; {{guestname}}, {{vmid}}, {{node}} and {{cluster}} are replaced")
+	cmd.Flags().BoolVar(&options.Protected, "protected", false, "Protect the backup from removal and pruning")
+	addWaitFlags(cmd, &wait, &timeout)
+
+	return cmd
+}
+
+// ListBackupsCommand lists the backups on a node
+func ListBackupsCommand() *cobra.Command {
+	var nodeName string
+	var storageName string
+	var vmid int
+
+	var cmd = &cobra.Command{
+		Use:   "list",
+		Short: "List the backups on a node",
+		Long: `List the backups on a node, newest first.
+
+Without --storage all backup storage of the node is searched; --vmid limits the list
+to the backups of one guest.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			backups, err := newBackupService().ListBackups(nodeName, storageName, vmid)
+			if err != nil {
+				exitWithError("Failed to list backups", err)
+			}
+
+			if len(backups) == 0 && output.IsTable(config.Output) {
+				fmt.Println("No backups found")
+				return
+			}
+
+			columns := []output.Column[services.StorageContent]{
+				{Header: "VOLUME ID", Value: func(backup services.StorageContent) string { return backup.VolID }},
+				{Header: "VMID", Value: func(backup services.StorageContent) string { return fmt.Sprintf("%d", backup.VMID) }},
+				{Header: "TYPE", Value: func(backup services.StorageContent) string { return backup.Subtype }},
+				{Header: "SIZE", Value: func(backup services.StorageContent) string { return formatBytes(backup.Size) }},
+				{Header: "CREATED", Value: func(backup services.StorageContent) string { return formatTime(backup.CTime) }},
+				{Header: "PROTECTED", Wide: true, Value: func(backup services.StorageContent) string { return formatYesNo(backup.Protected) }},
+				{Header: "NOTES", Wide: true, Value: func(backup services.StorageContent) string { return backup.Notes }},
+			}
+			renderList(backups, columns)
+		},
+	}
+
+	cmd.Flags().StringVarP(&nodeName, "node", "n", "", "Name of the node")
+	cmd.Flags().StringVarP(&storageName, "storage", "s", "", "Storage to list (default all backup storage of the node)")
+	cmd.Flags().IntVarP(&vmid, "vmid", "i", 0, "Only list the backups of this VM ID")
+	//nolint:errcheck // Flags are defined above, so these cannot fail
+	_ = cmd.MarkFlagRequired("node")
+
+	return cmd
+}
+
+// RestoreBackupCommand restores a backup to a new VM or container
+func RestoreBackupCommand() *cobra.Command {
+	var nodeName string
+	var options services.RestoreOptions
+	var wait bool
+	var timeout time.Duration
+
+	var cmd = &cobra.Command{
+		Use:   "restore <volume>",
+		Short: "Restore a backup to a new VM or container",
+		Long: `Restore a backup to a new VM or container. Whether a VM or a container is created
+depends on the backup. Without --newid the next free ID of the cluster is used, and
+--force is needed to overwrite an existing guest:
+  proxmox-cli backup restore -n pve1 local:backup/vzdump-qemu-100-2024_01_31-02_00_00.vma.zst --newid 150 --unique --wait`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			volid := args[0]
+
+			guestType, vmid, taskID, err := newBackupService().RestoreBackup(nodeName, volid, options)
+			if err != nil {
+				exitWithError("Failed to restore backup", err)
+			}
+
+			guest := vmGuest
+			if guestType == services.GuestTypeLXC {
+				guest = containerGuest
+			}
+			finishTask(fmt.Sprintf("Restore of %s to %s %d initiated", volid, guest.noun, vmid), nodeName, taskID, wait, timeout)
+		},
+	}
+
+	cmd.Flags().StringVarP(&nodeName, "node", "n", "", "Node to restore the guest on")
+	cmd.Flags().IntVar(&options.VMID, "newid", 0, "ID of the restored guest (default next free ID)")
+	cmd.Flags().StringVarP(&options.Storage, "storage", "s", "", "Storage for the restored disks (default the storage in the backup)")
+	cmd.Flags().BoolVar(&options.Force, "force", false, "Overwrite an existing guest with the same ID")
+	cmd.Flags().BoolVar(&options.Unique, "unique", false, "Assign new MAC addresses to the network interfaces")
+	cmd.Flags().BoolVar(&options.Start, "start", false, "Start the guest after the restore")
+	addWaitFlags(cmd, &wait, &timeout)
+	//nolint:errcheck // Flags are defined above, so these cannot fail
+	_ = cmd.MarkFlagRequired("node")
+
+	return cmd
+}
+
+// DeleteBackupCommand deletes a backup volume
+func DeleteBackupCommand() *cobra.Command {
+	var nodeName string
+	var wait bool
+	var timeout time.Duration
+
+	var cmd = &cobra.Command{
+		Use:   "delete <volume>",
+		Short: "Delete a backup",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			volid := args[0]
+
+			taskID, err := newBackupService().DeleteBackup(nodeName, volid)
+			if err != nil {
+				exitWithError("Failed to delete backup", err)
+			}
+
+			if taskID == "" {
+				fmt.Printf("Backup %s deleted\n", volid)
+				return
+			}
+			finishTask(fmt.Sprintf("Deletion of backup %s initiated", volid), nodeName, taskID, wait, timeout)
+		},
+	}
+
+	cmd.Flags().StringVarP(&nodeName, "node", "n", "", "Name of the node")
+	addWaitFlags(cmd, &wait, &timeout)
+	//nolint:errcheck // Flags are defined above, so these cannot fail
+	_ = cmd.MarkFlagRequired("node")
+
+	return cmd
+}
+
+// newBackupService creates the backup service, exiting when that fails
+func newBackupService() *services.BackupService {
+	backupService, err := services.NewBackupService(config.Logger, config.Trust)
+	if err != nil {
+		exitWithError("Failed to initialize backup service", err)
+	}
+	return backupService
+}
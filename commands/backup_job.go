@@ -0,0 +1,257 @@
+package commands
+
+import (
+	"fmt"
+	"proxmox-cli/config"
+	"proxmox-cli/output"
+	"proxmox-cli/services"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// BackupJobCommand creates the parent command for scheduled backup jobs
+func BackupJobCommand() *cobra.Command {
+	var jobCmd = &cobra.Command{
+		Use:   "job",
+		Short: "Manage scheduled backup jobs of the cluster",
+	}
+
+	jobCmd.AddCommand(ListBackupJobsCommand())
+	jobCmd.AddCommand(ShowBackupJobCommand())
+	jobCmd.AddCommand(CreateBackupJobCommand())
+	jobCmd.AddCommand(UpdateBackupJobCommand())
+	jobCmd.AddCommand(DeleteBackupJobCommand())
+	jobCmd.AddCommand(RunBackupJobCommand())
+
+	return jobCmd
+}
+
+// ListBackupJobsCommand lists the scheduled backup jobs
+func ListBackupJobsCommand() *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   "list",
+		Short: "List the scheduled backup jobs",
+		Run: func(cmd *cobra.Command, args []string) {
+			jobs, err := newBackupService().ListJobs()
+			if err != nil {
+				exitWithError("Failed to list backup jobs", err)
+			}
+
+			if len(jobs) == 0 && output.IsTable(config.Output) {
+				fmt.Println("No backup jobs found")
+				return
+			}
+
+			columns := []output.Column[services.BackupJob]{
+				{Header: "ID", Value: func(job services.BackupJob) string { return job.ID }},
+				{Header: "SCHEDULE", Value: func(job services.BackupJob) string { return job.Schedule }},
+				{Header: "ENABLED", Value: func(job services.BackupJob) string { return formatJobEnabled(job) }},
+				{Header: "SELECTION", Value: func(job services.BackupJob) string { return job.Selection() }},
+				{Header: "STORAGE", Value: func(job services.BackupJob) string { return job.Storage }},
+				{Header: "NEXT RUN", Value: func(job services.BackupJob) string { return formatTime(job.NextRun) }},
+				{Header: "NODE", Wide: true, Value: func(job services.BackupJob) string { return job.Node }},
+				{Header: "MODE", Wide: true, Value: func(job services.BackupJob) string { return job.Mode }},
+				{Header: "RETENTION", Wide: true, Value: func(job services.BackupJob) string { return string(job.PruneBackups) }},
+				{Header: "COMMENT", Wide: true, Value: func(job services.BackupJob) string { return job.Comment }},
+			}
+			renderList(jobs, columns)
+		},
+	}
+
+	return cmd
+}
+
+// ShowBackupJobCommand shows the settings of a backup job
+func ShowBackupJobCommand() *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   "show <id>",
+		Short: "Show the settings of a backup job",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			job, err := newBackupService().GetJob(args[0])
+			if err != nil {
+				exitWithError("Failed to get backup job", err)
+			}
+
+			renderObject(job, func() {
+				fmt.Printf("Backup Job: %s\n", job.ID)
+				fmt.Println("================================================================================")
+				fmt.Printf("Schedule:        %s\n", job.Schedule)
+				fmt.Printf("Enabled:         %s\n", formatJobEnabled(*job))
+				fmt.Printf("Selection:       %s\n", job.Selection())
+				if job.Node != "" {
+					fmt.Printf("Node:            %s\n", job.Node)
+				}
+				fmt.Printf("Storage:         %s\n", job.Storage)
+				fmt.Printf("Mode:            %s\n", job.Mode)
+				fmt.Printf("Compression:     %s\n", job.Compress)
+				fmt.Printf("Retention:       %s\n", job.PruneBackups)
+				if job.NotesTemplate != "" {
+					fmt.Printf("Notes:           %s\n", job.NotesTemplate)
+				}
+				if job.Comment != "" {
+					fmt.Printf("Comment:         %s\n", job.Comment)
+				}
+				fmt.Printf("Next Run:        %s\n", formatTime(job.NextRun))
+			})
+		},
+	}
+
+	return cmd
+}
+
+// CreateBackupJobCommand creates a scheduled backup job
+func CreateBackupJobCommand() *cobra.Command {
+	var options services.BackupJobOptions
+	var enabled bool
+
+	var cmd = &cobra.Command{
+		Use:   "create [id]",
+		Short: "Create a scheduled backup job",
+		Long: `Create a scheduled backup job. Without an ID Proxmox generates one.
+
+The schedule is a calendar event such as "daily", "sat 02:00", "mon..fri 21:00" or
+"*-*-01 03:00" and is checked before the job is submitted. Guests are selected by
+--vmid, --pool or --all (with --exclude), and --prune-backups sets the retention:
+  proxmox-cli backup job create nightly --schedule 'mon..fri 21:00' --all --exclude 900 --storage backups --prune-backups keep-daily=7,keep-weekly=4`,
+		Args: cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			id := ""
+			if len(args) == 1 {
+				id = args[0]
+			}
+			if cmd.Flags().Changed("enabled") {
+				options.Enabled = &enabled
+			}
+
+			if err := newBackupService().CreateJob(id, options); err != nil {
+				exitWithError("Failed to create backup job", err)
+			}
+
+			if id == "" {
+				fmt.Println("Backup job created")
+				return
+			}
+			fmt.Printf("Backup job %s created\n", id)
+		},
+	}
+
+	addBackupJobFlags(cmd, &options, &enabled)
+	//nolint:errcheck // Flags are defined above, so these cannot fail
+	_ = cmd.MarkFlagRequired("schedule")
+
+	return cmd
+}
+
+// UpdateBackupJobCommand changes the settings of a backup job
+func UpdateBackupJobCommand() *cobra.Command {
+	var options services.BackupJobOptions
+	var enabled bool
+
+	var cmd = &cobra.Command{
+		Use:   "update <id>",
+		Short: "Change the settings of a backup job",
+		Long: `Change the settings of a backup job. Only the given settings are changed; selecting
+guests by --vmid, --pool or --all replaces the previous selection:
+  proxmox-cli backup job update nightly --schedule 'sat 02:00' --pool production
+  proxmox-cli backup job update nightly --enabled=false`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			id := args[0]
+			if cmd.Flags().Changed("enabled") {
+				options.Enabled = &enabled
+			}
+
+			if err := newBackupService().UpdateJob(id, options); err != nil {
+				exitWithError("Failed to update backup job", err)
+			}
+
+			fmt.Printf("Backup job %s updated\n", id)
+		},
+	}
+
+	addBackupJobFlags(cmd, &options, &enabled)
+
+	return cmd
+}
+
+// addBackupJobFlags adds the flags of the backup job settings
+func addBackupJobFlags(cmd *cobra.Command, options *services.BackupJobOptions, enabled *bool) {
+	cmd.Flags().StringVar(&options.Schedule, "schedule", "", "Calendar event of the job, e.g. 'sat 02:00'")
+	cmd.Flags().IntSliceVarP(&options.VMIDs, "vmid", "i", nil, "VM IDs of the guests to back up")
+	cmd.Flags().StringVar(&options.Pool, "pool", "", "Back up the guests of a resource pool")
+	cmd.Flags().BoolVar(&options.All, "all", false, "Back up all guests")
+	cmd.Flags().IntSliceVar(&options.Exclude, "exclude", nil, "VM IDs left out when backing up all guests")
+	cmd.Flags().StringVarP(&options.Node, "node", "n", "", "Only back up the guests of this node")
+	cmd.Flags().StringVarP(&options.Storage, "storage", "s", "", "Storage to write the backups to")
+	cmd.Flags().StringVar(&options.Mode, "mode", "", "Backup mode: snapshot, suspend or stop")
+	cmd.Flags().StringVar(&options.Compress, "compress", "", "Compression: 0, gzip, lzo or zstd")
+	cmd.Flags().StringVar((*string)(&options.PruneBackups), "prune-backups", "", "Retention, e.g. keep-last=3,keep-weekly=4")
+	cmd.Flags().StringVar(&options.Notes, "notes", "", "Notes of the backups; {{guestname}}, {{vmid}}, {{node}} and {{cluster}} are replaced")
+	cmd.Flags().StringVar(&options.Comment, "comment", "", "Comment of the job")
+	cmd.Flags().BoolVar(enabled, "enabled", true, "Run the job on its schedule")
+}
+
+// DeleteBackupJobCommand deletes a backup job
+func DeleteBackupJobCommand() *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   "delete <id>",
+		Short: "Delete a backup job",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			id := args[0]
+
+			if err := newBackupService().DeleteJob(id); err != nil {
+				exitWithError("Failed to delete backup job", err)
+			}
+
+			fmt.Printf("Backup job %s deleted\n", id)
+		},
+	}
+
+	return cmd
+}
+
+// RunBackupJobCommand runs a backup job immediately
+func RunBackupJobCommand() *cobra.Command {
+	var wait bool
+	var timeout time.Duration
+
+	var cmd = &cobra.Command{
+		Use:   "run-now <id>",
+		Short: "Run a backup job now",
+		Long: `Run a backup job now, outside its schedule. A vzdump task is started on the node
+of the job, or on every online node when the job is not restricted to one.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			id := args[0]
+
+			runs, err := newBackupService().RunJob(id)
+			for _, run := range runs {
+				fmt.Printf("Backup job %s started on %s. Task ID: %s\n", id, run.Node, run.UPID)
+			}
+			if err != nil {
+				exitWithError("Failed to run backup job", err)
+			}
+
+			if wait {
+				for _, run := range runs {
+					waitForTask(run.Node, run.UPID, timeout)
+				}
+			}
+		},
+	}
+
+	addWaitFlags(cmd, &wait, &timeout)
+
+	return cmd
+}
+
+// Helper function to show whether a backup job is enabled
+func formatJobEnabled(job services.BackupJob) string {
+	if job.IsEnabled() {
+		return "Yes"
+	}
+	return "No"
+}
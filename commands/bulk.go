@@ -0,0 +1,111 @@
+package commands
+
+import (
+	"fmt"
+	"proxmox-cli/config"
+	"proxmox-cli/output"
+	"proxmox-cli/services"
+	"sort"
+	"time"
+)
+
+// bulkAction is a power action run on many guests by runBulkAction
+type bulkAction struct {
+	// operation names the action in messages, e.g. "shutdown"
+	operation string
+	// skipStatus is the guest status in which the action is not needed, e.g. "running" for start
+	skipStatus string
+	run        func(nodeName string, vmid int) (string, error)
+	// client is the API client run sends its requests with, shared by all workers
+	client *services.APIClient
+}
+
+// runBulkAction runs an action on the guests matching selector, at most parallel at a time,
+// waits for each task and prints a summary. It exits non-zero when any action failed.
+func runBulkAction(guest guestKind, action bulkAction, selector services.GuestSelector, parallel int, timeout time.Duration) {
+	clusterService, err := services.NewClusterService(config.Logger, config.Trust)
+	if err != nil {
+		exitWithError("Failed to initialize cluster service", err)
+	}
+	taskService := services.NewTaskServiceWithDeps(config.Logger, config.Trust, action.client.HTTPService, action.client.SessionService)
+
+	guests, err := clusterService.SelectGuests(guest.guestType, selector)
+	if err != nil {
+		exitWithError("Failed to select "+guest.noun+"s", err)
+	}
+
+	toRun := []services.ClusterResource{}
+	results := []services.BulkResult{}
+	for _, selected := range guests {
+		if action.skipStatus != "" && selected.Status == action.skipStatus {
+			results = append(results, services.BulkResult{
+				VMID:   selected.VMID,
+				Name:   selected.Name,
+				Node:   selected.Node,
+				Result: services.BulkSkipped,
+				Detail: selected.Status,
+			})
+			continue
+		}
+		toRun = append(toRun, selected)
+	}
+
+	// Load the session before the workers start, so they share its secret store and ticket
+	if err = action.client.LoadSession(); err != nil {
+		exitWithError("Failed to load session", err)
+	}
+
+	progress := output.IsTable(config.Output)
+	if progress {
+		fmt.Printf("Running %s on %d %s(s), %d at a time\n", action.operation, len(toRun), guest.noun, parallel)
+	}
+
+	results = append(results, services.RunBulk(toRun, parallel, func(selected services.ClusterResource) (string, error) {
+		status, err := runAndWait(taskService, action, selected, timeout)
+		if progress {
+			if err != nil {
+				fmt.Printf("%s %d %s failed: %v\n", guest.title(), selected.VMID, action.operation, err)
+			} else {
+				fmt.Printf("%s %d %s finished: %s\n", guest.title(), selected.VMID, action.operation, status)
+			}
+		}
+		return status, err
+	})...)
+	sort.Slice(results, func(i, j int) bool { return results[i].VMID < results[j].VMID })
+
+	if progress {
+		fmt.Println()
+	}
+	columns := []output.Column[services.BulkResult]{
+		{Header: "VMID", Value: func(result services.BulkResult) string { return fmt.Sprintf("%d", result.VMID) }},
+		{Header: "NAME", Value: func(result services.BulkResult) string { return result.Name }},
+		{Header: "NODE", Value: func(result services.BulkResult) string { return result.Node }},
+		{Header: "RESULT", Value: func(result services.BulkResult) string { return result.Result }},
+		{Header: "DETAIL", Value: func(result services.BulkResult) string { return result.Detail }},
+	}
+	renderList(results, columns)
+
+	failed := 0
+	for _, result := range results {
+		if result.Result == services.BulkFailed {
+			failed++
+		}
+	}
+	if failed > 0 {
+		exitWithError("Bulk "+action.operation+" failed", fmt.Errorf("%d of %d %ss failed", failed, len(results), guest.noun))
+	}
+}
+
+// runAndWait runs the action on one guest and waits for its task, returning the task exit status
+func runAndWait(taskService *services.TaskService, action bulkAction, selected services.ClusterResource, timeout time.Duration) (string, error) {
+	upid, err := action.run(selected.Node, selected.VMID)
+	if err != nil {
+		return "", err
+	}
+
+	status, err := taskService.WaitForTask(selected.Node, upid, timeout, nil)
+	if err != nil {
+		return "", err
+	}
+	return status.ExitStatus, nil
+}
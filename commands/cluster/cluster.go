@@ -2,7 +2,9 @@ package cluster
 
 import (
 	"fmt"
+	"os"
 	"proxmox-cli/config"
+	"proxmox-cli/output"
 	"proxmox-cli/services"
 
 	"github.com/spf13/cobra"
@@ -35,16 +37,12 @@ func ResourcesCommand() *cobra.Command {
 		Run: func(cmd *cobra.Command, args []string) {
 			clusterService, err := services.NewClusterService(config.Logger, config.Trust)
 			if err != nil {
-				config.Logger.Error("Failed to initialize cluster service: ", err)
-				fmt.Println("Error: Failed to initialize cluster service")
-				return
+				exitWithError("Failed to initialize cluster service", err)
 			}
 
 			resources, err := clusterService.ListResources()
 			if err != nil {
-				config.Logger.Error("Failed to list cluster resources: ", err)
-				fmt.Println("Error: Failed to list cluster resources")
-				return
+				exitWithError("Failed to list cluster resources", err)
 			}
 
 			// Filter by type if specified
@@ -59,7 +57,7 @@ func ResourcesCommand() *cobra.Command {
 				filteredResources = resources
 			}
 
-			if len(filteredResources) == 0 {
+			if len(filteredResources) == 0 && output.IsTable(config.Output) {
 				if resourceType != "" {
 					fmt.Printf("No resources found of type: %s\n", resourceType)
 				} else {
@@ -68,13 +66,24 @@ func ResourcesCommand() *cobra.Command {
 				return
 			}
 
-			fmt.Printf("%-8s %-20s %-20s %-10s %-10s %-15s\n", "TYPE", "ID", "NAME", "NODE", "STATUS", "UPTIME")
-			fmt.Println("========================================================================================")
-			for _, resource := range filteredResources {
-				uptime := formatUptime(resource.Uptime)
-				fmt.Printf("%-8s %-20s %-20s %-10s %-10s %-15s\n",
-					resource.Type, resource.ID, resource.Name, resource.Node, resource.Status, uptime)
+			columns := []output.Column[services.ClusterResource]{
+				{Header: "TYPE", Value: func(resource services.ClusterResource) string { return resource.Type }},
+				{Header: "ID", Value: func(resource services.ClusterResource) string { return resource.ID }},
+				{Header: "NAME", Value: func(resource services.ClusterResource) string { return resource.Name }},
+				{Header: "NODE", Value: func(resource services.ClusterResource) string { return resource.Node }},
+				{Header: "STATUS", Value: func(resource services.ClusterResource) string { return resource.Status }},
+				{Header: "UPTIME", Value: func(resource services.ClusterResource) string { return formatUptime(resource.Uptime) }},
+				{Header: "CPU %", Wide: true, Value: func(resource services.ClusterResource) string {
+					return fmt.Sprintf("%.2f%%", resource.CPU*100)
+				}},
+				{Header: "MEMORY", Wide: true, Value: func(resource services.ClusterResource) string {
+					return formatPercent(resource.Mem, resource.MaxMem)
+				}},
+				{Header: "DISK", Wide: true, Value: func(resource services.ClusterResource) string {
+					return formatPercent(resource.Disk, resource.MaxDisk)
+				}},
 			}
+			renderList(filteredResources, columns)
 		},
 	}
 
@@ -91,49 +100,102 @@ func StatusCommand() *cobra.Command {
 		Run: func(cmd *cobra.Command, args []string) {
 			clusterService, err := services.NewClusterService(config.Logger, config.Trust)
 			if err != nil {
-				config.Logger.Error("Failed to initialize cluster service: ", err)
-				fmt.Println("Error: Failed to initialize cluster service")
-				return
+				exitWithError("Failed to initialize cluster service", err)
 			}
 
 			statuses, err := clusterService.GetStatus()
 			if err != nil {
-				config.Logger.Error("Failed to get cluster status: ", err)
-				fmt.Println("Error: Failed to get cluster status")
-				return
+				exitWithError("Failed to get cluster status", err)
 			}
 
-			if len(statuses) == 0 {
+			if len(statuses) == 0 && output.IsTable(config.Output) {
 				fmt.Println("No cluster status found")
 				return
 			}
 
-			fmt.Println("Cluster Status:")
-			fmt.Println("================================================================================")
-			for _, status := range statuses {
-				fmt.Printf("Type: %s\n", status.Type)
-				if status.Name != "" {
-					fmt.Printf("Name: %s\n", status.Name)
-				}
-				if status.Type == "cluster" {
-					fmt.Printf("Nodes: %d\n", status.Nodes)
-					fmt.Printf("Quorate: %d\n", status.Quorate)
-					fmt.Printf("Version: %d\n", status.Version)
-				}
-				if status.IP != "" {
-					fmt.Printf("IP: %s\n", status.IP)
-				}
-				if status.Online == 1 {
-					fmt.Println("Online: Yes")
-				}
-				fmt.Println("---")
+			columns := []output.Column[services.ClusterStatus]{
+				{Header: "TYPE", Value: func(status services.ClusterStatus) string { return status.Type }},
+				{Header: "NAME", Value: func(status services.ClusterStatus) string { return status.Name }},
+				{Header: "ID", Value: func(status services.ClusterStatus) string { return status.ID }},
+				{Header: "IP", Value: func(status services.ClusterStatus) string { return status.IP }},
+				{Header: "ONLINE", Value: func(status services.ClusterStatus) string { return fmt.Sprintf("%d", status.Online) }},
+				{Header: "NODES", Value: func(status services.ClusterStatus) string { return fmt.Sprintf("%d", status.Nodes) }},
+				{Header: "QUORATE", Value: func(status services.ClusterStatus) string { return fmt.Sprintf("%d", status.Quorate) }},
+				{Header: "VERSION", Value: func(status services.ClusterStatus) string { return fmt.Sprintf("%d", status.Version) }},
+			}
+
+			// csv keeps the columns; table and wide keep the detailed per-entry view
+			if config.Output == output.CSV {
+				renderList(statuses, columns)
+				return
 			}
+			renderObject(statuses, func() {
+				printClusterStatus(statuses)
+			})
 		},
 	}
 
 	return cmd
 }
 
+// printClusterStatus prints the detailed view of every cluster status entry
+func printClusterStatus(statuses []services.ClusterStatus) {
+	fmt.Println("Cluster Status:")
+	fmt.Println("================================================================================")
+	for _, status := range statuses {
+		fmt.Printf("Type: %s\n", status.Type)
+		if status.Name != "" {
+			fmt.Printf("Name: %s\n", status.Name)
+		}
+		if status.Type == "cluster" {
+			fmt.Printf("Nodes: %d\n", status.Nodes)
+			fmt.Printf("Quorate: %d\n", status.Quorate)
+			fmt.Printf("Version: %d\n", status.Version)
+		}
+		if status.IP != "" {
+			fmt.Printf("IP: %s\n", status.IP)
+		}
+		if status.Online == 1 {
+			fmt.Println("Online: Yes")
+		}
+		fmt.Println("---")
+	}
+}
+
+// renderList prints items in the format selected with --output
+func renderList[T any](items []T, columns []output.Column[T]) {
+	if err := output.RenderList(os.Stdout, config.Output, items, columns); err != nil {
+		config.Logger.Error("Failed to render output: ", err)
+		//nolint:errcheck // Nothing sensible can be done when printing the error fails
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+	}
+}
+
+// renderObject prints v in the format selected with --output, using table for the table formats
+func renderObject(v any, table func()) {
+	if err := output.RenderObject(os.Stdout, config.Output, v, table); err != nil {
+		config.Logger.Error("Failed to render output: ", err)
+		//nolint:errcheck // Nothing sensible can be done when printing the error fails
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+	}
+}
+
+// exitWithError prints a failed operation with the details of err to stderr, keeping stdout
+// to the selected output format, and exits with the matching code
+func exitWithError(message string, err error) {
+	config.Logger.Error(message+": ", err)
+	output.PrintError(os.Stderr, message, err)
+	os.Exit(output.ExitCode(err))
+}
+
+// Helper function to format a used/total pair as a percentage, empty when the total is unknown
+func formatPercent(used, total int64) string {
+	if total <= 0 {
+		return ""
+	}
+	return fmt.Sprintf("%.2f%%", float64(used)/float64(total)*100)
+}
+
 // Helper function to format uptime into human-readable format
 func formatUptime(seconds int64) string {
 	if seconds == 0 {
@@ -2,6 +2,11 @@ package cluster
 
 import (
 	"fmt"
+	"strings"
+	"time"
+
+	"proxmox-cli/config"
+	"proxmox-cli/services"
 
 	"github.com/spf13/cobra"
 )
@@ -13,7 +18,7 @@ func SDNCommand() *cobra.Command {
 		Short: "Manage Software Defined Networking (SDN) in Proxmox",
 	}
 
-	// Add the create-zone and delete-zone subcommands
+	// Add the zone, vnet and apply-config subcommands
 	sdnCmd.AddCommand(ZoneCommand())
 	sdnCmd.AddCommand(VnetCommand())
 	sdnCmd.AddCommand(applyZoneConfigCommand())
@@ -21,25 +26,203 @@ func SDNCommand() *cobra.Command {
 	return sdnCmd
 }
 
-// applyZoneConfigCommand creates a new Cobra command for applying the configuration of an SDN zone.
+// applyZoneConfigCommand creates a new Cobra command for applying the pending SDN configuration.
 func applyZoneConfigCommand() *cobra.Command {
-	var zoneName string
+	var dryRun bool
+	var timeout time.Duration
 
 	var applyZoneCmd = &cobra.Command{
 		Use:   "apply-config",
-		Short: "Apply the configuration of an SDN zone",
+		Short: "Apply the pending SDN configuration to all nodes",
+		Long: `Apply the pending SDN configuration to all nodes. Changes to zones, VNets and
+subnets only take effect once they are applied, and are always applied together.
+
+The pending zone, VNet and subnet changes are shown before they are applied;
+--dry-run only shows them. Changes to controllers, IPAMs and DNS are not shown but
+are applied as well, so the configuration is applied even when no change is shown.
+After applying, the command waits until every online node has reloaded its network
+and reports its zones as available, and exits non-zero, with instructions to revert
+the changes, when a node fails to deploy them.`,
 		Run: func(cmd *cobra.Command, args []string) {
-			if zoneName == "" {
-				fmt.Println("Error: zone name is required")
+			sdnService := newSDNService()
+
+			changes, err := sdnService.PendingChanges()
+			if err != nil {
+				exitWithError("Failed to get the pending SDN changes", err)
+			}
+
+			if dryRun {
+				renderObject(changes, func() { printSDNChanges(changes) })
 				return
 			}
+			// Controllers, IPAMs and DNS are not part of the diff, so apply even without changes
+			printSDNChanges(changes)
+
+			// The apply task and the network reloads share one --timeout
+			var deadline time.Time
+			if timeout > 0 {
+				deadline = time.Now().Add(timeout)
+			}
+			taskID, err := sdnService.Apply()
+			if err != nil {
+				exitWithError("Failed to apply SDN configuration", err)
+			}
+			fmt.Printf("SDN configuration apply initiated. Task ID: %s\n", taskID)
+
+			appliedAt, err := services.UPIDStartTime(taskID)
+			if err != nil {
+				exitWithError("Failed to apply SDN configuration", err)
+			}
+			if err := waitForApplyTask(taskID, timeLeft(deadline)); err != nil {
+				printSDNRollback(changes)
+				exitWithError("SDN configuration was not applied", err)
+			}
+
+			fmt.Println("Waiting for the nodes to reload their network and deploy the SDN zones...")
+			statuses, err := sdnService.WaitForZones(appliedAt, timeLeft(deadline))
+			for _, status := range statuses {
+				fmt.Printf("  %s: %s\n", status.Node, formatSDNNodeStatus(status))
+			}
+			if err != nil {
+				printSDNRollback(changes)
+				exitWithError("SDN configuration was not deployed", err)
+			}
 
-			// Logic to apply the configuration of an SDN zone
-			fmt.Printf("Applying configuration for SDN zone: Name=%s\n", zoneName)
+			fmt.Println("SDN configuration deployed on all nodes")
 		},
 	}
 
-	applyZoneCmd.Flags().StringVarP(&zoneName, "name", "n", "", "Name of the SDN zone to apply configuration")
+	applyZoneCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Only show the pending changes")
+	applyZoneCmd.Flags().DurationVar(&timeout, "timeout", 5*time.Minute, "Maximum time to wait for the nodes to deploy the configuration (0 waits indefinitely)")
 
 	return applyZoneCmd
 }
+
+// timeLeft returns the time left until deadline in whole seconds, or zero, which waits
+// indefinitely, when there is no deadline. A passed deadline leaves one second, so the next
+// wait checks once more and times out instead of never ending.
+func timeLeft(deadline time.Time) time.Duration {
+	if deadline.IsZero() {
+		return 0
+	}
+	return max(time.Until(deadline).Round(time.Second), time.Second)
+}
+
+// waitForApplyTask waits for the task that applies the SDN configuration to finish
+func waitForApplyTask(upid string, timeout time.Duration) error {
+	nodeName, err := services.UPIDNode(upid)
+	if err != nil {
+		return err
+	}
+
+	taskService, err := services.NewTaskService(config.Logger, config.Trust)
+	if err != nil {
+		exitWithError("Failed to initialize task service", err)
+	}
+
+	_, err = taskService.WaitForTask(nodeName, upid, timeout, nil)
+	return err
+}
+
+// printSDNChanges prints the pending changes as a diff: + for new objects and options,
+// - for deleted ones and ~ for changed ones
+func printSDNChanges(changes []services.SDNChange) {
+	if len(changes) == 0 {
+		fmt.Println("No pending zone, VNet or subnet changes")
+		return
+	}
+
+	fmt.Println("Pending SDN changes:")
+	for _, change := range changes {
+		fmt.Printf("%s %s\n", sdnChangeMarker(change.State), describeSDNObject(change))
+		for _, option := range change.Options {
+			switch {
+			case option.Old == "":
+				fmt.Printf("    + %s: %s\n", option.Option, option.New)
+			case option.New == "":
+				fmt.Printf("    - %s: %s\n", option.Option, option.Old)
+			default:
+				fmt.Printf("    ~ %s: %s -> %s\n", option.Option, option.Old, option.New)
+			}
+		}
+	}
+}
+
+// printSDNRollback prints how to revert the applied changes, since Proxmox keeps no
+// previous SDN configuration to return to
+func printSDNRollback(changes []services.SDNChange) {
+	fmt.Println("To roll back, revert these changes and run apply-config again:")
+	for _, change := range changes {
+		switch change.State {
+		case services.SDNStateNew:
+			fmt.Printf("  %s\n", sdnDeleteCommand(change))
+		case services.SDNStateDeleted:
+			fmt.Printf("  recreate %s with %s\n", describeSDNObject(change), formatSDNOptions(change.Options, false))
+		default:
+			fmt.Printf("  restore %s: %s\n", describeSDNObject(change), formatSDNOptions(change.Options, true))
+		}
+	}
+}
+
+// sdnDeleteCommand returns the command that deletes a new SDN object
+func sdnDeleteCommand(change services.SDNChange) string {
+	switch change.Kind {
+	case services.SDNKindZone:
+		return "proxmox-cli cluster sdn zone delete -n " + change.ID
+	case services.SDNKindVNet:
+		return "proxmox-cli cluster sdn vnet delete -n " + change.ID
+	default:
+		return fmt.Sprintf("proxmox-cli cluster sdn vnet subnet delete --vnet %s --subnet %s", change.VNet, change.ID)
+	}
+}
+
+// formatSDNOptions lists the previous values of changed options; with removals set,
+// options that were added are listed as to be removed
+func formatSDNOptions(options []services.SDNOptionChange, removals bool) string {
+	settings := []string{}
+	for _, option := range options {
+		if option.Old != "" {
+			settings = append(settings, option.Option+"="+option.Old)
+		} else if removals {
+			settings = append(settings, "remove "+option.Option)
+		}
+	}
+	if len(settings) == 0 {
+		return "its previous options"
+	}
+	return strings.Join(settings, ", ")
+}
+
+// Helper function to name an SDN object of a change
+func describeSDNObject(change services.SDNChange) string {
+	if change.Kind == services.SDNKindSubnet {
+		return fmt.Sprintf("subnet %s (vnet %s)", change.ID, change.VNet)
+	}
+	return change.Kind + " " + change.ID
+}
+
+// Helper function to show the state of a change as a diff marker
+func sdnChangeMarker(state string) string {
+	switch state {
+	case services.SDNStateNew:
+		return "+"
+	case services.SDNStateDeleted:
+		return "-"
+	}
+	return "~"
+}
+
+// Helper function to show the deployment state of a node
+func formatSDNNodeStatus(status services.SDNNodeStatus) string {
+	switch {
+	case status.Error != "":
+		return "network reload failed (" + status.Error + ")"
+	case len(status.Failed) > 0:
+		return "failed (" + strings.Join(status.Failed, ", ") + ")"
+	case status.Ready:
+		return "ready"
+	case !status.Reloaded:
+		return "waiting for the network reload"
+	}
+	return "waiting for " + strings.Join(status.Waiting, ", ")
+}
@@ -2,12 +2,12 @@ package cluster
 
 import (
 	"fmt"
+	"strings"
 
 	"proxmox-cli/config"
+	"proxmox-cli/output"
 	"proxmox-cli/services"
 
-	"encoding/json"
-
 	"github.com/spf13/cobra"
 )
 
@@ -16,39 +16,177 @@ func VnetCommand() *cobra.Command {
 	var vnetCmd = &cobra.Command{
 		Use:   "vnet",
 		Short: "Manage virtual networks (VNet) in Proxmox",
+		Long: `Manage the virtual networks (VNet) of SDN zones and their subnets. Changes to
+VNets are pending until they are applied with "cluster sdn apply-config".`,
 	}
 
 	// Add subcommands for VNet management
+	vnetCmd.AddCommand(listVnetsCommand())
+	vnetCmd.AddCommand(showVnetCommand())
 	vnetCmd.AddCommand(createVnetCommand())
-	vnetCmd.AddCommand(deleteVnetCommand())
 	vnetCmd.AddCommand(updateVnetCommand())
+	vnetCmd.AddCommand(deleteVnetCommand())
+	vnetCmd.AddCommand(subnetCommand())
 
 	return vnetCmd
 }
 
+// listVnetsCommand creates a new Cobra command for listing the VNets.
+func listVnetsCommand() *cobra.Command {
+	var pending bool
+
+	var listCmd = &cobra.Command{
+		Use:   "list",
+		Short: "List the virtual networks (VNet)",
+		Run: func(cmd *cobra.Command, args []string) {
+			vnets, err := newSDNService().ListVNets(pending)
+			if err != nil {
+				exitWithError("Failed to list VNets", err)
+			}
+
+			if len(vnets) == 0 && output.IsTable(config.Output) {
+				fmt.Println("No VNets found")
+				return
+			}
+
+			columns := []output.Column[services.SDNVNet]{
+				{Header: "VNET", Value: func(vnet services.SDNVNet) string { return vnet.VNet }},
+				{Header: "ZONE", Value: func(vnet services.SDNVNet) string { return vnet.Zone }},
+				{Header: "TAG", Value: func(vnet services.SDNVNet) string { return formatTag(vnet.Tag) }},
+				{Header: "ALIAS", Value: func(vnet services.SDNVNet) string { return vnet.Alias }},
+				{Header: "VLAN AWARE", Wide: true, Value: func(vnet services.SDNVNet) string { return formatYesNo(vnet.VLANAware) }},
+			}
+			if pending {
+				columns = append(columns, output.Column[services.SDNVNet]{
+					Header: "STATE", Value: func(vnet services.SDNVNet) string { return formatSDNState(vnet.State) },
+				})
+			}
+			renderList(vnets, columns)
+		},
+	}
+
+	listCmd.Flags().BoolVar(&pending, "pending", false, "Show the changes that are not applied yet")
+
+	return listCmd
+}
+
+// showVnetCommand creates a new Cobra command for showing a VNet with its subnets.
+func showVnetCommand() *cobra.Command {
+	var vnetName string
+	var pending bool
+
+	var showCmd = &cobra.Command{
+		Use:   "show",
+		Short: "Show the settings and subnets of a virtual network (VNet)",
+		Run: func(cmd *cobra.Command, args []string) {
+			sdnService := newSDNService()
+
+			vnet, err := sdnService.GetVNet(vnetName, pending)
+			if err != nil {
+				exitWithError("Failed to get VNet", err)
+			}
+			subnets, err := sdnService.ListSubnets(vnetName, pending)
+			if err != nil {
+				exitWithError("Failed to list subnets", err)
+			}
+
+			renderObject(struct {
+				*services.SDNVNet
+				Subnets []services.SDNSubnet `json:"subnets"`
+			}{vnet, subnets}, func() {
+				fmt.Printf("VNet: %s\n", vnet.VNet)
+				fmt.Println("================================================================================")
+				fmt.Printf("Zone:            %s\n", vnet.Zone)
+				fmt.Printf("Tag:             %s\n", formatTag(vnet.Tag))
+				if vnet.Alias != "" {
+					fmt.Printf("Alias:           %s\n", vnet.Alias)
+				}
+				fmt.Printf("VLAN Aware:      %s\n", formatYesNo(vnet.VLANAware))
+				if vnet.State != "" {
+					fmt.Printf("State:           %s\n", vnet.State)
+				}
+				for _, subnet := range subnets {
+					fmt.Printf("Subnet:          %s\n", formatSubnet(subnet))
+				}
+			})
+		},
+	}
+
+	showCmd.Flags().StringVarP(&vnetName, "name", "n", "", "Name of the VNet")
+	showCmd.Flags().BoolVar(&pending, "pending", false, "Show the changes that are not applied yet")
+	//nolint:errcheck // Flags are defined above, so these cannot fail
+	_ = showCmd.MarkFlagRequired("name")
+
+	return showCmd
+}
+
 // createVnetCommand creates a new Cobra command for creating a VNet.
 func createVnetCommand() *cobra.Command {
 	var vnetName string
+	var options services.SDNVNetOptions
 
 	var createCmd = &cobra.Command{
 		Use:   "create",
 		Short: "Create a new virtual network (VNet)",
+		Long: `Create a new VNet in an SDN zone. VNets of vlan and qinq zones need a VLAN --tag
+and those of vxlan and evpn zones a VXLAN --tag; VNets of simple zones have no tag:
+  proxmox-cli cluster sdn vnet create -n vnet100 --zone vlans --tag 100 --alias web`,
 		Run: func(cmd *cobra.Command, args []string) {
-			if vnetName == "" {
-				fmt.Println("Error: VNet name is required")
-				return
+			if err := newSDNService().CreateVNet(vnetName, options); err != nil {
+				exitWithError("Failed to create VNet", err)
 			}
 
-			// Logic to create a VNet
-			fmt.Printf("Creating VNet: Name=%s\n", vnetName)
+			fmt.Printf("VNet %s created; apply the SDN configuration to deploy it\n", vnetName)
 		},
 	}
 
 	createCmd.Flags().StringVarP(&vnetName, "name", "n", "", "Name of the VNet to create")
+	addVnetOptionFlags(createCmd, &options)
+	//nolint:errcheck // Flags are defined above, so these cannot fail
+	_ = createCmd.MarkFlagRequired("name")
+	//nolint:errcheck // Flags are defined above, so these cannot fail
+	_ = createCmd.MarkFlagRequired("zone")
 
 	return createCmd
 }
 
+// updateVnetCommand creates a new Cobra command for updating a VNet.
+func updateVnetCommand() *cobra.Command {
+	var vnetName string
+	var options services.SDNVNetOptions
+
+	var updateCmd = &cobra.Command{
+		Use:   "update",
+		Short: "Update the configuration of an existing virtual network (VNet)",
+		Long: `Update the options of an existing VNet. Only the given options change, and
+--delete removes options:
+  proxmox-cli cluster sdn vnet update -n vnet100 --tag 101 --delete alias`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := newSDNService().UpdateVNet(vnetName, options); err != nil {
+				exitWithError("Failed to update VNet", err)
+			}
+
+			fmt.Printf("VNet %s updated; apply the SDN configuration to deploy the change\n", vnetName)
+		},
+	}
+
+	updateCmd.Flags().StringVarP(&vnetName, "name", "n", "", "Name of the VNet to update")
+	addVnetOptionFlags(updateCmd, &options)
+	updateCmd.Flags().StringSliceVar(&options.Delete, "delete", nil, "Options to remove, e.g. alias,vlanaware")
+	//nolint:errcheck // Flags are defined above, so these cannot fail
+	_ = updateCmd.MarkFlagRequired("name")
+
+	return updateCmd
+}
+
+// addVnetOptionFlags adds the flags of the VNet options
+func addVnetOptionFlags(cmd *cobra.Command, options *services.SDNVNetOptions) {
+	cmd.Flags().StringVar(&options.Zone, "zone", "", "SDN zone of the VNet")
+	cmd.Flags().IntVar(&options.Tag, "tag", 0, "VLAN or VXLAN ID of the VNet")
+	cmd.Flags().StringVar(&options.Alias, "alias", "", "Alias of the VNet")
+	cmd.Flags().BoolVar(&options.VLANAware, "vlanaware", false, "Allow guests to use VLAN tags inside the VNet")
+}
+
 // deleteVnetCommand creates a new Cobra command for deleting a VNet.
 func deleteVnetCommand() *cobra.Command {
 	var vnetName string
@@ -57,92 +195,229 @@ func deleteVnetCommand() *cobra.Command {
 		Use:   "delete",
 		Short: "Delete an existing virtual network (VNet)",
 		Run: func(cmd *cobra.Command, args []string) {
-			if vnetName == "" {
-				fmt.Println("Error: VNet name is required")
-				return
+			if err := newSDNService().DeleteVNet(vnetName); err != nil {
+				exitWithError("Failed to delete VNet", err)
 			}
 
-			// Logic to delete a VNet
-			fmt.Printf("Deleting VNet: Name=%s\n", vnetName)
+			fmt.Printf("VNet %s deleted; apply the SDN configuration to remove it from the nodes\n", vnetName)
 		},
 	}
 
 	deleteCmd.Flags().StringVarP(&vnetName, "name", "n", "", "Name of the VNet to delete")
+	//nolint:errcheck // Flags are defined above, so these cannot fail
+	_ = deleteCmd.MarkFlagRequired("name")
 
 	return deleteCmd
 }
 
-// updateVnetCommand creates a new Cobra command for updating a VNet.
-func updateVnetCommand() *cobra.Command {
+// subnetCommand creates a new Cobra command for managing the subnets of a VNet.
+func subnetCommand() *cobra.Command {
+	var subnetCmd = &cobra.Command{
+		Use:   "subnet",
+		Short: "Manage the subnets of a virtual network (VNet)",
+		Long: `Manage the IP subnets of a VNet. Subnets are given by their network in CIDR
+notation, e.g. 10.0.0.0/24.`,
+	}
+
+	subnetCmd.AddCommand(listSubnetsCommand())
+	subnetCmd.AddCommand(createSubnetCommand())
+	subnetCmd.AddCommand(updateSubnetCommand())
+	subnetCmd.AddCommand(deleteSubnetCommand())
+
+	return subnetCmd
+}
+
+// listSubnetsCommand creates a new Cobra command for listing the subnets of a VNet.
+func listSubnetsCommand() *cobra.Command {
 	var vnetName string
-	var newConfig string
+	var pending bool
 
-	var updateCmd = &cobra.Command{
-		Use:   "update",
-		Short: "Update the configuration of an existing virtual network (VNet)",
+	var listCmd = &cobra.Command{
+		Use:   "list",
+		Short: "List the subnets of a VNet",
 		Run: func(cmd *cobra.Command, args []string) {
-			if vnetName == "" {
-				fmt.Println("Error: VNet name is required")
-				return
+			subnets, err := newSDNService().ListSubnets(vnetName, pending)
+			if err != nil {
+				exitWithError("Failed to list subnets", err)
 			}
 
-			if newConfig == "" {
-				fmt.Println("Error: New configuration is required")
+			if len(subnets) == 0 && output.IsTable(config.Output) {
+				fmt.Printf("No subnets found in VNet %s\n", vnetName)
 				return
 			}
 
-			// Logic to update a VNet
-			fmt.Printf("Updating VNet: Name=%s, NewConfig=%s\n", vnetName, newConfig)
+			columns := []output.Column[services.SDNSubnet]{
+				{Header: "SUBNET", Value: func(subnet services.SDNSubnet) string { return subnet.CIDR }},
+				{Header: "GATEWAY", Value: func(subnet services.SDNSubnet) string { return subnet.Gateway }},
+				{Header: "SNAT", Value: func(subnet services.SDNSubnet) string { return formatYesNo(subnet.SNAT) }},
+				{Header: "DHCP RANGES", Value: func(subnet services.SDNSubnet) string { return formatDHCPRanges(subnet.DHCPRanges) }},
+				{Header: "ID", Wide: true, Value: func(subnet services.SDNSubnet) string { return subnet.ID }},
+			}
+			if pending {
+				columns = append(columns, output.Column[services.SDNSubnet]{
+					Header: "STATE", Value: func(subnet services.SDNSubnet) string { return formatSDNState(subnet.State) },
+				})
+			}
+			renderList(subnets, columns)
 		},
 	}
 
-	updateCmd.Flags().StringVarP(&vnetName, "name", "n", "", "Name of the VNet to update")
-	updateCmd.Flags().StringVarP(&newConfig, "config", "c", "", "New configuration for the VNet")
+	listCmd.Flags().StringVar(&vnetName, "vnet", "", "Name of the VNet")
+	listCmd.Flags().BoolVar(&pending, "pending", false, "Show the changes that are not applied yet")
+	//nolint:errcheck // Flags are defined above, so these cannot fail
+	_ = listCmd.MarkFlagRequired("vnet")
 
-	return updateCmd
+	return listCmd
+}
+
+// createSubnetCommand creates a new Cobra command for creating a subnet in a VNet.
+func createSubnetCommand() *cobra.Command {
+	var vnetName, cidr string
+	var dhcpRanges []string
+	var options services.SDNSubnetOptions
+
+	var createCmd = &cobra.Command{
+		Use:   "create",
+		Short: "Create a subnet in a VNet",
+		Long: `Create a subnet in a VNet. The gateway and DHCP ranges must be addresses of
+the subnet:
+  proxmox-cli cluster sdn vnet subnet create --vnet vnet100 --subnet 10.0.0.0/24 --gateway 10.0.0.1 --snat --dhcp-range 10.0.0.100-10.0.0.200`,
+		Run: func(cmd *cobra.Command, args []string) {
+			options.DHCPRanges = parseDHCPRanges(dhcpRanges)
+			if err := newSDNService().CreateSubnet(vnetName, cidr, options); err != nil {
+				exitWithError("Failed to create subnet", err)
+			}
+
+			fmt.Printf("Subnet %s created in VNet %s; apply the SDN configuration to deploy it\n", cidr, vnetName)
+		},
+	}
+
+	addSubnetFlags(createCmd, &vnetName, &cidr, &dhcpRanges, &options)
+
+	return createCmd
 }
 
-// createVnet creates a new VNet via the Proxmox API.
-// TODO: This function will be integrated in a future implementation.
-//
-//lint:ignore U1000 This function will be implemented in a future release
-func createVnet(vnetName string, trust bool) bool {
-	sessionService, err := services.NewSessionService(config.Logger)
-	if err != nil {
-		config.Logger.Error("Error initializing session service: ", err)
-		return false
+// updateSubnetCommand creates a new Cobra command for updating a subnet of a VNet.
+func updateSubnetCommand() *cobra.Command {
+	var vnetName, cidr string
+	var dhcpRanges []string
+	var options services.SDNSubnetOptions
+
+	var updateCmd = &cobra.Command{
+		Use:   "update",
+		Short: "Update a subnet of a VNet",
+		Long: `Update the options of a subnet. Only the given options change, --dhcp-range
+replaces all DHCP ranges of the subnet and --delete removes options:
+  proxmox-cli cluster sdn vnet subnet update --vnet vnet100 --subnet 10.0.0.0/24 --delete snat`,
+		Run: func(cmd *cobra.Command, args []string) {
+			options.DHCPRanges = parseDHCPRanges(dhcpRanges)
+			if err := newSDNService().UpdateSubnet(vnetName, cidr, options); err != nil {
+				exitWithError("Failed to update subnet", err)
+			}
+
+			fmt.Printf("Subnet %s of VNet %s updated; apply the SDN configuration to deploy the change\n", cidr, vnetName)
+		},
 	}
 
-	sessionData, err := sessionService.ReadSessionFile()
-	if err != nil {
-		config.Logger.Error("Error reading session file: ", err)
-		return false
+	addSubnetFlags(updateCmd, &vnetName, &cidr, &dhcpRanges, &options)
+	updateCmd.Flags().StringSliceVar(&options.Delete, "delete", nil, "Options to remove, e.g. gateway,snat,dhcp-range")
+
+	return updateCmd
+}
+
+// addSubnetFlags adds the flags selecting a subnet and the flags of its options
+func addSubnetFlags(cmd *cobra.Command, vnetName, cidr *string, dhcpRanges *[]string, options *services.SDNSubnetOptions) {
+	cmd.Flags().StringVar(vnetName, "vnet", "", "Name of the VNet")
+	cmd.Flags().StringVar(cidr, "subnet", "", "Network of the subnet in CIDR notation, e.g. 10.0.0.0/24")
+	cmd.Flags().StringVar(&options.Gateway, "gateway", "", "Gateway address of the subnet")
+	cmd.Flags().BoolVar(&options.SNAT, "snat", false, "Masquerade traffic leaving the subnet")
+	cmd.Flags().StringArrayVar(dhcpRanges, "dhcp-range", nil, "DHCP range as <start>-<end>; can be repeated")
+	cmd.Flags().StringVar(&options.DHCPDNSServer, "dhcp-dns-server", "", "DNS server handed out by DHCP")
+	cmd.Flags().StringVar(&options.DNSZonePrefix, "dns-zone-prefix", "", "Prefix of the DNS domain of the subnet")
+	//nolint:errcheck // Flags are defined above, so these cannot fail
+	_ = cmd.MarkFlagRequired("vnet")
+	//nolint:errcheck // Flags are defined above, so these cannot fail
+	_ = cmd.MarkFlagRequired("subnet")
+}
+
+// deleteSubnetCommand creates a new Cobra command for deleting a subnet of a VNet.
+func deleteSubnetCommand() *cobra.Command {
+	var vnetName, cidr string
+
+	var deleteCmd = &cobra.Command{
+		Use:   "delete",
+		Short: "Delete a subnet of a VNet",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := newSDNService().DeleteSubnet(vnetName, cidr); err != nil {
+				exitWithError("Failed to delete subnet", err)
+			}
+
+			fmt.Printf("Subnet %s deleted from VNet %s; apply the SDN configuration to remove it from the nodes\n", cidr, vnetName)
+		},
 	}
 
-	uri := fmt.Sprintf("%s://%s:%d/api2/json/cluster/sdn", sessionData.HttpScheme, sessionData.Server, sessionData.Port)
+	deleteCmd.Flags().StringVar(&vnetName, "vnet", "", "Name of the VNet")
+	deleteCmd.Flags().StringVar(&cidr, "subnet", "", "Network of the subnet in CIDR notation, e.g. 10.0.0.0/24")
+	//nolint:errcheck // Flags are defined above, so these cannot fail
+	_ = deleteCmd.MarkFlagRequired("vnet")
+	//nolint:errcheck // Flags are defined above, so these cannot fail
+	_ = deleteCmd.MarkFlagRequired("subnet")
 
-	httpService := services.NewHttpService(config.Logger, trust)
+	return deleteCmd
+}
 
-	payload := fmt.Sprintf("name=%s", vnetName)
-	headers := map[string]string{
-		"Content-Type":        "application/x-www-form-urlencoded; charset=UTF-8",
-		"CSRFPreventionToken": sessionData.Response.Data.CSRFPreventionToken,
+// parseDHCPRanges parses the --dhcp-range flags, exiting when one is invalid
+func parseDHCPRanges(values []string) []services.DHCPRange {
+	var ranges []services.DHCPRange
+	for _, value := range values {
+		dhcpRange, err := services.ParseDHCPRange(value)
+		if err != nil {
+			exitWithError("Invalid DHCP range", err)
+		}
+		ranges = append(ranges, dhcpRange)
 	}
+	return ranges
+}
 
-	body, err := httpService.Post(uri, payload, headers, nil)
-	if err != nil {
-		config.Logger.Error("Error creating vnet: ", err)
-		return false
+// Helper function to show a 0/1 API flag
+func formatYesNo(flag int) string {
+	if flag == 1 {
+		return "Yes"
 	}
+	return "No"
+}
 
-	config.Logger.Info("Response: ", body)
+// Helper function to show the tag of a VNet, which VNets of simple zones do not have
+func formatTag(tag int) string {
+	if tag == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%d", tag)
+}
 
-	var resp services.SessionDataResponse
-	err = json.Unmarshal([]byte(body), &resp)
-	if err != nil {
-		config.Logger.Error("Error parsing response JSON: ", err)
-		return false
+// Helper function to show the DHCP ranges of a subnet
+func formatDHCPRanges(ranges []services.DHCPRange) string {
+	if len(ranges) == 0 {
+		return "-"
 	}
+	texts := make([]string, len(ranges))
+	for i, dhcpRange := range ranges {
+		texts[i] = dhcpRange.StartAddress + "-" + dhcpRange.EndAddress
+	}
+	return strings.Join(texts, ", ")
+}
 
-	return true
+// Helper function to show a subnet on one line of the VNet details
+func formatSubnet(subnet services.SDNSubnet) string {
+	text := subnet.CIDR
+	if subnet.Gateway != "" {
+		text += " gateway " + subnet.Gateway
+	}
+	if subnet.SNAT == 1 {
+		text += " snat"
+	}
+	if len(subnet.DHCPRanges) > 0 {
+		text += " dhcp " + formatDHCPRanges(subnet.DHCPRanges)
+	}
+	return text
 }
@@ -2,238 +2,263 @@ package cluster
 
 import (
 	"fmt"
+	"strings"
 
 	"proxmox-cli/config"
+	"proxmox-cli/output"
 	"proxmox-cli/services"
 
-	"encoding/json"
-
 	"github.com/spf13/cobra"
 )
 
-// ZoneCommand creates and returns the SDN (Software Defined Networking) management command
+// ZoneCommand creates and returns the SDN zone management command
 func ZoneCommand() *cobra.Command {
 	var zoneCmd = &cobra.Command{
-		Use:   "sdn",
-		Short: "Manage Software Defined Networking (SDN) in Proxmox",
+		Use:   "zone",
+		Short: "Manage SDN zones",
+		Long: `Manage SDN zones. Changes to zones are pending until they are applied
+with "cluster sdn apply-config".`,
 	}
 
-	// Add the create-zone, delete-zone, update-zone, and apply-config subcommands
+	zoneCmd.AddCommand(listZonesCommand())
+	zoneCmd.AddCommand(showZoneCommand())
 	zoneCmd.AddCommand(createZoneCommand())
-	zoneCmd.AddCommand(deleteZoneCommand())
 	zoneCmd.AddCommand(updateZoneCommand())
+	zoneCmd.AddCommand(deleteZoneCommand())
 
 	return zoneCmd
 }
 
-// createZoneCommand creates a new Cobra command for creating a new SDN zone.
-func createZoneCommand() *cobra.Command {
-	var zoneName string
+// listZonesCommand creates a new Cobra command for listing the SDN zones.
+func listZonesCommand() *cobra.Command {
 	var zoneType string
+	var pending bool
 
-	var createZoneCmd = &cobra.Command{
-		Use:   "create-zone",
-		Short: "Create a new SDN zone",
+	var listZonesCmd = &cobra.Command{
+		Use:   "list",
+		Short: "List the SDN zones",
 		Run: func(cmd *cobra.Command, args []string) {
-			if zoneName == "" || zoneType == "" {
-				fmt.Println("Error: zone name and type are required")
-				return
+			zones, err := newSDNService().ListZones(zoneType, pending)
+			if err != nil {
+				exitWithError("Failed to list SDN zones", err)
 			}
 
-			// Zone type can be : simple, vlan, vxlan, gre, ipsec, l2tpv3, vxlan-ipsec, l2tpv3-ipsec
-			// If the zone type is not one of the above, return an error
-			if zoneType != "simple" && zoneType != "vlan" && zoneType != "vxlan" && zoneType != "gre" && zoneType != "ipsec" && zoneType != "l2tpv3" && zoneType != "vxlan-ipsec" && zoneType != "l2tpv3-ipsec" {
-				fmt.Println("Error: invalid zone type")
+			if len(zones) == 0 && output.IsTable(config.Output) {
+				fmt.Println("No SDN zones found")
 				return
 			}
 
-			CreateZone(zoneName, zoneType, config.Trust)
-
-			// Logic to create a new SDN zone
-			fmt.Printf("Creating SDN zone: Name=%s, Type=%s\n", zoneName, zoneType)
+			columns := []output.Column[services.SDNZone]{
+				{Header: "ZONE", Value: func(zone services.SDNZone) string { return zone.Zone }},
+				{Header: "TYPE", Value: func(zone services.SDNZone) string { return zone.Type }},
+				{Header: "NODES", Value: func(zone services.SDNZone) string { return formatAllNodes(zone.Nodes) }},
+				{Header: "SETTINGS", Value: func(zone services.SDNZone) string { return zone.Settings() }},
+				{Header: "IPAM", Wide: true, Value: func(zone services.SDNZone) string { return zone.IPAM }},
+				{Header: "MTU", Wide: true, Value: func(zone services.SDNZone) string { return formatMTU(zone.MTU) }},
+			}
+			if pending {
+				columns = append(columns, output.Column[services.SDNZone]{
+					Header: "STATE", Value: func(zone services.SDNZone) string { return formatSDNState(zone.State) },
+				})
+			}
+			renderList(zones, columns)
 		},
 	}
 
-	createZoneCmd.Flags().StringVarP(&zoneName, "name", "n", "", "Name of the SDN zone")
-	createZoneCmd.Flags().StringVarP(&zoneType, "type", "t", "", "Type of the SDN zone")
+	listZonesCmd.Flags().StringVarP(&zoneType, "type", "t", "", "Only list zones of this type")
+	listZonesCmd.Flags().BoolVar(&pending, "pending", false, "Show the changes that are not applied yet")
 
-	return createZoneCmd
+	return listZonesCmd
 }
 
-// deleteZoneCommand creates a new Cobra command for deleting an SDN zone.
-func deleteZoneCommand() *cobra.Command {
+// showZoneCommand creates a new Cobra command for showing an SDN zone.
+func showZoneCommand() *cobra.Command {
 	var zoneName string
+	var pending bool
 
-	var deleteZoneCmd = &cobra.Command{
-		Use:   "delete-zone",
-		Short: "Delete an existing SDN zone",
+	var showZoneCmd = &cobra.Command{
+		Use:   "show",
+		Short: "Show the settings of an SDN zone",
 		Run: func(cmd *cobra.Command, args []string) {
-			if zoneName == "" {
-				fmt.Println("Error: zone name is required")
-				return
+			zone, err := newSDNService().GetZone(zoneName, pending)
+			if err != nil {
+				exitWithError("Failed to get SDN zone", err)
 			}
 
-			DeleteZone(zoneName, config.Trust)
-
-			// Logic to delete an SDN zone
-			fmt.Printf("Deleting SDN zone: Name=%s\n", zoneName)
+			renderObject(zone, func() {
+				fmt.Printf("SDN Zone: %s\n", zone.Zone)
+				fmt.Println("================================================================================")
+				fmt.Printf("Type:            %s\n", zone.Type)
+				fmt.Printf("Nodes:           %s\n", formatAllNodes(zone.Nodes))
+				if settings := zone.Settings(); settings != "" {
+					fmt.Printf("Settings:        %s\n", settings)
+				}
+				if zone.IPAM != "" {
+					fmt.Printf("IPAM:            %s\n", zone.IPAM)
+				}
+				if zone.DNS != "" {
+					fmt.Printf("DNS:             %s (zone %s, reverse %s)\n", zone.DNS, zone.DNSZone, zone.ReverseDNS)
+				}
+				fmt.Printf("MTU:             %s\n", formatMTU(zone.MTU))
+				if zone.State != "" {
+					fmt.Printf("State:           %s\n", zone.State)
+				}
+			})
 		},
 	}
 
-	deleteZoneCmd.Flags().StringVarP(&zoneName, "name", "n", "", "Name of the SDN zone to delete")
+	showZoneCmd.Flags().StringVarP(&zoneName, "name", "n", "", "Name of the SDN zone")
+	showZoneCmd.Flags().BoolVar(&pending, "pending", false, "Show the changes that are not applied yet")
+	//nolint:errcheck // Flags are defined above, so these cannot fail
+	_ = showZoneCmd.MarkFlagRequired("name")
 
-	return deleteZoneCmd
+	return showZoneCmd
 }
 
-// updateZoneCommand creates a new Cobra command for updating an existing SDN zone.
-func updateZoneCommand() *cobra.Command {
+// createZoneCommand creates a new Cobra command for creating a new SDN zone.
+func createZoneCommand() *cobra.Command {
 	var zoneName string
-	var newZoneType string
+	var zoneType string
+	var options services.SDNZoneOptions
 
-	var updateZoneCmd = &cobra.Command{
-		Use:   "update-zone",
-		Short: "Update an existing SDN zone",
+	var createZoneCmd = &cobra.Command{
+		Use:     "create",
+		Aliases: []string{"create-zone"},
+		Short:   "Create a new SDN zone",
+		Long: fmt.Sprintf(`Create a new SDN zone. The zone type is one of %s, and each
+type has its own options: vlan needs --bridge, qinq --bridge and --tag, vxlan --peers
+and evpn --controller and --vrf-vxlan:
+  proxmox-cli cluster sdn zone create -n vlans -t vlan --bridge vmbr0
+  proxmox-cli cluster sdn zone create -n overlay -t vxlan --peers 10.0.0.1,10.0.0.2,10.0.0.3 --mtu 1450`,
+			strings.Join(services.SDNZoneTypes, ", ")),
 		Run: func(cmd *cobra.Command, args []string) {
-			if zoneName == "" || newZoneType == "" {
-				fmt.Println("Error: zone name and new type are required")
-				return
+			if err := newSDNService().CreateZone(zoneName, zoneType, options); err != nil {
+				exitWithError("Failed to create SDN zone", err)
 			}
 
-			UpdateZone(zoneName, newZoneType, config.Trust)
-
-			// Logic to update an SDN zone
-			fmt.Printf("Updating SDN zone: Name=%s, New Type=%s\n", zoneName, newZoneType)
+			fmt.Printf("SDN zone %s created; apply the SDN configuration to deploy it\n", zoneName)
 		},
 	}
 
-	updateZoneCmd.Flags().StringVarP(&zoneName, "name", "n", "", "Name of the SDN zone to update")
-	updateZoneCmd.Flags().StringVarP(&newZoneType, "new-type", "t", "", "New type of the SDN zone")
+	createZoneCmd.Flags().StringVarP(&zoneName, "name", "n", "", "Name of the SDN zone")
+	createZoneCmd.Flags().StringVarP(&zoneType, "type", "t", "", "Type of the SDN zone: "+strings.Join(services.SDNZoneTypes, ", "))
+	addZoneOptionFlags(createZoneCmd, &options)
+	//nolint:errcheck // Flags are defined above, so these cannot fail
+	_ = createZoneCmd.MarkFlagRequired("name")
+	//nolint:errcheck // Flags are defined above, so these cannot fail
+	_ = createZoneCmd.MarkFlagRequired("type")
 
-	return updateZoneCmd
+	return createZoneCmd
 }
 
-// CreateZone creates a new SDN zone in the Proxmox cluster
-func CreateZone(zoneName string, zoneType string, trust bool) bool {
-	sessionService, err := services.NewSessionService(config.Logger)
-	if err != nil {
-		config.Logger.Error("Error initializing session service: ", err)
-		return false
-	}
-
-	sessionData, err := sessionService.ReadSessionFile()
-	if err != nil {
-		config.Logger.Error("Error reading session file: ", err)
-		return false
-	}
-
-	uri := fmt.Sprintf("%s://%s:%d/api2/extjs/cluster/zones", sessionData.HttpScheme, sessionData.Server, sessionData.Port)
-
-	httpService := services.NewHttpService(config.Logger, trust)
+// updateZoneCommand creates a new Cobra command for updating an existing SDN zone.
+func updateZoneCommand() *cobra.Command {
+	var zoneName string
+	var options services.SDNZoneOptions
 
-	payload := fmt.Sprintf("zone=%s&type=%s", zoneName, zoneType)
-	headers := map[string]string{
-		"Content-Type":        "application/x-www-form-urlencoded; charset=UTF-8",
-		"CSRFPreventionToken": sessionData.Response.Data.CSRFPreventionToken,
-	}
+	var updateZoneCmd = &cobra.Command{
+		Use:     "update",
+		Aliases: []string{"update-zone"},
+		Short:   "Update an existing SDN zone",
+		Long: `Update the options of an existing SDN zone. Only the given options change, and
+--delete removes options. The type of a zone cannot be changed:
+  proxmox-cli cluster sdn zone update -n overlay --mtu 1400 --delete dns`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := newSDNService().UpdateZone(zoneName, options); err != nil {
+				exitWithError("Failed to update SDN zone", err)
+			}
 
-	body, err := httpService.Post(uri, payload, headers, nil)
-	if err != nil {
-		config.Logger.Error("Error creating zone: ", err)
-		return false
+			fmt.Printf("SDN zone %s updated; apply the SDN configuration to deploy the change\n", zoneName)
+		},
 	}
 
-	config.Logger.Info("Response: ", body)
-
-	var resp services.SessionDataResponse
-	err = json.Unmarshal([]byte(body), &resp)
-	if err != nil {
-		config.Logger.Error("Error parsing response JSON: ", err)
-		return false
-	}
+	updateZoneCmd.Flags().StringVarP(&zoneName, "name", "n", "", "Name of the SDN zone to update")
+	addZoneOptionFlags(updateZoneCmd, &options)
+	updateZoneCmd.Flags().StringSliceVar(&options.Delete, "delete", nil, "Options to remove, e.g. dns,mtu")
+	//nolint:errcheck // Flags are defined above, so these cannot fail
+	_ = updateZoneCmd.MarkFlagRequired("name")
 
-	return true
+	return updateZoneCmd
 }
 
-// UpdateZone updates an existing SDN zone in the Proxmox cluster
-func UpdateZone(zoneName string, newZoneType string, trust bool) bool {
-	sessionService, err := services.NewSessionService(config.Logger)
-	if err != nil {
-		config.Logger.Error("Error initializing session service: ", err)
-		return false
-	}
-
-	sessionData, err := sessionService.ReadSessionFile()
-	if err != nil {
-		config.Logger.Error("Error reading session file: ", err)
-		return false
-	}
-
-	uri := fmt.Sprintf("%s://%s:%d/api2/extjs/cluster/zones", sessionData.HttpScheme, sessionData.Server, sessionData.Port)
+// addZoneOptionFlags adds the flags of the zone options; each type accepts only some of them
+func addZoneOptionFlags(cmd *cobra.Command, options *services.SDNZoneOptions) {
+	cmd.Flags().StringSliceVar(&options.Nodes, "nodes", nil, "Nodes to deploy the zone on (default all)")
+	cmd.Flags().StringVar(&options.IPAM, "ipam", "", "IPAM plugin of the zone, e.g. pve")
+	cmd.Flags().StringVar(&options.DNS, "dns", "", "DNS plugin of the zone")
+	cmd.Flags().StringVar(&options.ReverseDNS, "reverse-dns", "", "Reverse DNS plugin of the zone")
+	cmd.Flags().StringVar(&options.DNSZone, "dns-zone", "", "DNS domain of the zone")
+	cmd.Flags().IntVar(&options.MTU, "mtu", 0, "MTU of the zone")
+	cmd.Flags().StringVar(&options.Bridge, "bridge", "", "Bridge of vlan and qinq zones")
+	cmd.Flags().IntVar(&options.Tag, "tag", 0, "Service VLAN of qinq zones")
+	cmd.Flags().StringVar(&options.VLANProtocol, "vlan-protocol", "", "VLAN protocol of qinq zones: 802.1q or 802.1ad")
+	cmd.Flags().StringSliceVar(&options.Peers, "peers", nil, "Tunnel endpoint addresses of vxlan zones")
+	cmd.Flags().IntVar(&options.VXLANPort, "vxlan-port", 0, "UDP port of vxlan zones")
+	cmd.Flags().StringVar(&options.Controller, "controller", "", "EVPN controller of evpn zones")
+	cmd.Flags().IntVar(&options.VRFVXLAN, "vrf-vxlan", 0, "VRF VXLAN ID of evpn zones")
+	cmd.Flags().StringVar(&options.MAC, "mac", "", "Anycast gateway MAC address of evpn zones")
+	cmd.Flags().StringSliceVar(&options.ExitNodes, "exit-nodes", nil, "Exit nodes of evpn zones")
+	cmd.Flags().StringVar(&options.ExitNodesPrimary, "exit-nodes-primary", "", "Primary exit node of evpn zones")
+	cmd.Flags().BoolVar(&options.ExitNodesLocalRouting, "exit-nodes-local-routing", false, "Allow exit nodes of evpn zones to reach guests")
+	cmd.Flags().BoolVar(&options.AdvertiseSubnets, "advertise-subnets", false, "Advertise the subnets of evpn zones")
+	cmd.Flags().BoolVar(&options.DisableARPNDSuppression, "disable-arp-nd-suppression", false, "Disable ARP and ND suppression in evpn zones")
+	cmd.Flags().StringVar(&options.RTImport, "rt-import", "", "Route targets imported by evpn zones")
+	cmd.Flags().StringVar(&options.DHCP, "dhcp", "", "DHCP backend of simple zones: dnsmasq")
+}
 
-	httpService := services.NewHttpService(config.Logger, trust)
+// deleteZoneCommand creates a new Cobra command for deleting an SDN zone.
+func deleteZoneCommand() *cobra.Command {
+	var zoneName string
 
-	payload := fmt.Sprintf("name=%s&type=%s", zoneName, newZoneType)
-	headers := map[string]string{
-		"Content-Type":        "application/x-www-form-urlencoded; charset=UTF-8",
-		"CSRFPreventionToken": sessionData.Response.Data.CSRFPreventionToken,
-	}
+	var deleteZoneCmd = &cobra.Command{
+		Use:     "delete",
+		Aliases: []string{"delete-zone"},
+		Short:   "Delete an existing SDN zone",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := newSDNService().DeleteZone(zoneName); err != nil {
+				exitWithError("Failed to delete SDN zone", err)
+			}
 
-	body, err := httpService.Post(uri, payload, headers, nil)
-	if err != nil {
-		config.Logger.Error("Error updating zone: ", err)
-		return false
+			fmt.Printf("SDN zone %s deleted; apply the SDN configuration to remove it from the nodes\n", zoneName)
+		},
 	}
 
-	config.Logger.Info("Response: ", body)
-
-	var resp services.SessionDataResponse
-	err = json.Unmarshal([]byte(body), &resp)
-	if err != nil {
-		config.Logger.Error("Error parsing response JSON: ", err)
-		return false
-	}
+	deleteZoneCmd.Flags().StringVarP(&zoneName, "name", "n", "", "Name of the SDN zone to delete")
+	//nolint:errcheck // Flags are defined above, so these cannot fail
+	_ = deleteZoneCmd.MarkFlagRequired("name")
 
-	return true
+	return deleteZoneCmd
 }
 
-// DeleteZone deletes an existing SDN zone from the Proxmox cluster
-func DeleteZone(zoneName string, trust bool) bool {
-	sessionService, err := services.NewSessionService(config.Logger)
+// newSDNService creates the SDN service, exiting when that fails
+func newSDNService() *services.SDNService {
+	sdnService, err := services.NewSDNService(config.Logger, config.Trust)
 	if err != nil {
-		config.Logger.Error("Error initializing session service: ", err)
-		return false
+		exitWithError("Failed to initialize SDN service", err)
 	}
+	return sdnService
+}
 
-	sessionData, err := sessionService.ReadSessionFile()
-	if err != nil {
-		config.Logger.Error("Error reading session file: ", err)
-		return false
-	}
-
-	uri := fmt.Sprintf("%s://%s:%d/api2/extjs/cluster/zones", sessionData.HttpScheme, sessionData.Server, sessionData.Port)
-
-	httpService := services.NewHttpService(config.Logger, trust)
-
-	payload := fmt.Sprintf("name=%s", zoneName)
-	headers := map[string]string{
-		"Content-Type":        "application/x-www-form-urlencoded; charset=UTF-8",
-		"CSRFPreventionToken": sessionData.Response.Data.CSRFPreventionToken,
+// Helper function to show the nodes of an SDN object, which is deployed on all nodes when none are set
+func formatAllNodes(nodes string) string {
+	if nodes == "" {
+		return "all"
 	}
+	return nodes
+}
 
-	body, err := httpService.Post(uri, payload, headers, nil)
-	if err != nil {
-		config.Logger.Error("Error deleting zone: ", err)
-		return false
+// Helper function to show the pending state of an SDN object listed with its pending changes
+func formatSDNState(state string) string {
+	if state == "" {
+		return "applied"
 	}
+	return state
+}
 
-	config.Logger.Info("Response: ", body)
-
-	var resp services.SessionDataResponse
-	err = json.Unmarshal([]byte(body), &resp)
-	if err != nil {
-		config.Logger.Error("Error parsing response JSON: ", err)
-		return false
+// Helper function to show an MTU, which is chosen automatically when not set
+func formatMTU(mtu int) string {
+	if mtu == 0 {
+		return "auto"
 	}
-
-	return true
+	return fmt.Sprintf("%d", mtu)
 }
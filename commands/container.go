@@ -0,0 +1,337 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"proxmox-cli/config"
+	"proxmox-cli/output"
+	"proxmox-cli/services"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// ContainerCommand creates the parent command for container operations
+func ContainerCommand() *cobra.Command {
+	var ctCmd = &cobra.Command{
+		Use:     "ct",
+		Aliases: []string{"container"},
+		Short:   "Manage Proxmox containers (LXC)",
+	}
+
+	ctCmd.AddCommand(ListContainersCommand())
+	ctCmd.AddCommand(ContainerStatusCommand())
+	ctCmd.AddCommand(CreateContainerCommand())
+	ctCmd.AddCommand(ContainerConfigCommand())
+	ctCmd.AddCommand(CloneContainerCommand())
+	ctCmd.AddCommand(TemplateContainerCommand())
+	ctCmd.AddCommand(ContainerSnapshotCommand())
+	ctCmd.AddCommand(MigrateContainerCommand())
+	ctCmd.AddCommand(StartContainerCommand())
+	ctCmd.AddCommand(StopContainerCommand())
+	ctCmd.AddCommand(ShutdownContainerCommand())
+	ctCmd.AddCommand(RebootContainerCommand())
+	ctCmd.AddCommand(SuspendContainerCommand())
+	ctCmd.AddCommand(ResumeContainerCommand())
+	ctCmd.AddCommand(DeleteContainerCommand())
+
+	return ctCmd
+}
+
+// ListContainersCommand lists all containers on a node
+func ListContainersCommand() *cobra.Command {
+	var nodeName string
+
+	var cmd = &cobra.Command{
+		Use:   "list",
+		Short: "List all containers on a node",
+		Run: func(cmd *cobra.Command, args []string) {
+			containers, err := newContainerService().ListContainers(nodeName)
+			if err != nil {
+				exitWithError("Failed to list containers", err)
+			}
+
+			if len(containers) == 0 && output.IsTable(config.Output) {
+				fmt.Printf("No containers found on node: %s\n", nodeName)
+				return
+			}
+
+			columns := []output.Column[services.Container]{
+				{Header: "VMID", Value: func(ct services.Container) string { return fmt.Sprintf("%d", ct.VMID) }},
+				{Header: "NAME", Value: func(ct services.Container) string { return ct.Name }},
+				{Header: "STATUS", Value: func(ct services.Container) string { return ct.Status }},
+				{Header: "CPU %", Value: func(ct services.Container) string { return fmt.Sprintf("%.2f%%", ct.CPU*100) }},
+				{Header: "MEMORY", Value: func(ct services.Container) string { return formatPercent(ct.Mem, ct.MaxMem) }},
+				{Header: "UPTIME", Value: func(ct services.Container) string { return formatUptime(ct.Uptime) }},
+				{Header: "CPUS", Wide: true, Value: func(ct services.Container) string { return fmt.Sprintf("%g", ct.CPUs) }},
+				{Header: "MAX MEMORY", Wide: true, Value: func(ct services.Container) string { return formatBytes(ct.MaxMem) }},
+				{Header: "MAX DISK", Wide: true, Value: func(ct services.Container) string { return formatBytes(ct.MaxDisk) }},
+				{Header: "TAGS", Wide: true, Value: func(ct services.Container) string { return ct.Tags }},
+			}
+			renderList(containers, columns)
+		},
+	}
+
+	cmd.Flags().StringVarP(&nodeName, "node", "n", "", "Name of the node")
+	//nolint:errcheck // Flags are defined above, so these cannot fail
+	_ = cmd.MarkFlagRequired("node")
+
+	return cmd
+}
+
+// ContainerStatusCommand gets the status of a specific container
+func ContainerStatusCommand() *cobra.Command {
+	var target guestTarget
+
+	var cmd = &cobra.Command{
+		Use:   "status",
+		Short: "Get the status of a specific container",
+		Run: func(cmd *cobra.Command, args []string) {
+			nodeName, vmid := target.resolve(containerGuest)
+
+			status, err := newContainerService().GetContainerStatus(nodeName, vmid)
+			if err != nil {
+				exitWithError("Failed to get container status", err)
+			}
+
+			renderObject(status, func() {
+				fmt.Printf("Container Status for VMID: %d\n", vmid)
+				fmt.Println("================================================================================")
+				fmt.Printf("Name:            %s\n", status.Name)
+				fmt.Printf("Status:          %s\n", status.Status)
+				fmt.Printf("CPU Usage:       %.2f%%\n", status.CPU*100)
+				fmt.Printf("CPU Cores:       %g\n", status.CPUs)
+				if status.MaxMem > 0 {
+					fmt.Printf("Memory Used:     %s / %s (%.2f%%)\n",
+						formatBytes(status.Mem), formatBytes(status.MaxMem),
+						float64(status.Mem)/float64(status.MaxMem)*100)
+				}
+				if status.MaxSwap > 0 {
+					fmt.Printf("Swap Used:       %s / %s\n", formatBytes(status.Swap), formatBytes(status.MaxSwap))
+				}
+				if status.MaxDisk > 0 {
+					fmt.Printf("Root Disk Used:  %s / %s\n", formatBytes(status.Disk), formatBytes(status.MaxDisk))
+				}
+				fmt.Printf("Uptime:          %s\n", formatUptime(status.Uptime))
+			})
+		},
+	}
+
+	addGuestTargetFlags(cmd, containerGuest, &target)
+
+	return cmd
+}
+
+// CreateContainerCommand creates a container from an OS template
+func CreateContainerCommand() *cobra.Command {
+	var nodeName string
+	var vmid int
+	var ctConfig services.ContainerConfig
+	var mountPoints []string
+	var networks []string
+	var passwordSource PasswordSource
+	var wait bool
+	var timeout time.Duration
+
+	var cmd = &cobra.Command{
+		Use:   "create",
+		Short: "Create a container from an OS template",
+		Long: `Create a container on a node from an OS template. Without --vmid the next free ID of the
+cluster is used.
+
+Mount points and network interfaces are given as <slot>=<spec> and can be repeated:
+  proxmox-cli ct create -n pve1 --ostemplate local:vztmpl/debian-12-standard_12.2-1_amd64.tar.zst \
+    --hostname web --memory 1024 --cores 2 --rootfs local-lvm:8 --unprivileged \
+    --net net0=name=eth0,bridge=vmbr0,ip=dhcp --mount mp0=local-lvm:16,mp=/data
+
+The root password is read from --password-stdin, --password-file or $PROXMOX_CT_PASSWORD,
+so it does not end up in the shell history or the process list.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			var err error
+			if ctConfig.Password, err = ReadOptionalPassword(passwordSource, "PROXMOX_CT_PASSWORD", os.Stdin); err != nil {
+				exitWithError("Failed to read root password", err)
+			}
+			if ctConfig.MountPoints, err = parseKeyValues("--mount", mountPoints); err != nil {
+				exitWithError("Invalid mount point", err)
+			}
+			if ctConfig.Networks, err = parseKeyValues("--net", networks); err != nil {
+				exitWithError("Invalid network interface", err)
+			}
+
+			vmid, taskID, err := newContainerService().CreateContainer(nodeName, vmid, ctConfig)
+			if err != nil {
+				exitWithError("Failed to create container", err)
+			}
+
+			finishTask(fmt.Sprintf("Container %d creation initiated", vmid), nodeName, taskID, wait, timeout)
+		},
+	}
+
+	cmd.Flags().StringVarP(&nodeName, "node", "n", "", "Name of the node")
+	cmd.Flags().IntVarP(&vmid, "vmid", "i", 0, "Container ID (defaults to the next free ID)")
+	cmd.Flags().StringVar(&ctConfig.OSTemplate, "ostemplate", "", "OS template volume, e.g. local:vztmpl/debian-12-standard_12.2-1_amd64.tar.zst")
+	cmd.Flags().StringVar(&ctConfig.Hostname, "hostname", "", "Hostname of the container")
+	cmd.Flags().IntVar(&ctConfig.Memory, "memory", 0, "Memory in MiB")
+	cmd.Flags().IntVar(&ctConfig.Swap, "swap", 0, "Swap in MiB")
+	cmd.Flags().IntVar(&ctConfig.Cores, "cores", 0, "Number of cores")
+	cmd.Flags().StringVar(&ctConfig.RootFS, "rootfs", "", "Root volume, e.g. local-lvm:8 for an 8 GiB volume")
+	cmd.Flags().StringVar(&ctConfig.Storage, "storage", "", "Default storage for volumes given only by size")
+	cmd.Flags().StringArrayVar(&mountPoints, "mount", nil, "Mount point as <slot>=<spec>, e.g. mp0=local-lvm:16,mp=/data (repeatable)")
+	cmd.Flags().StringArrayVar(&networks, "net", nil, "Network interface as <slot>=<spec>, e.g. net0=name=eth0,bridge=vmbr0,ip=dhcp (repeatable)")
+	cmd.Flags().BoolVar(&ctConfig.Unprivileged, "unprivileged", false, "Create an unprivileged container")
+	cmd.Flags().BoolVar(&passwordSource.Stdin, "password-stdin", false, "Read the root password from the first line of standard input")
+	cmd.Flags().StringVar(&passwordSource.File, "password-file", "", "Read the root password from the first line of a file")
+	cmd.Flags().StringVar(&ctConfig.SSHPublicKeys, "ssh-public-keys", "", "Public SSH keys authorized for root")
+	cmd.Flags().StringVar(&ctConfig.Nameserver, "nameserver", "", "DNS server of the container")
+	cmd.Flags().StringVar(&ctConfig.Searchdomain, "searchdomain", "", "DNS search domain of the container")
+	cmd.Flags().StringVar(&ctConfig.Description, "description", "", "Description of the container")
+	addWaitFlags(cmd, &wait, &timeout)
+	cmd.MarkFlagsMutuallyExclusive("password-stdin", "password-file")
+	//nolint:errcheck // Flags are defined above, so these cannot fail
+	_ = cmd.MarkFlagRequired("node")
+	//nolint:errcheck // Flags are defined above, so these cannot fail
+	_ = cmd.MarkFlagRequired("ostemplate")
+
+	return cmd
+}
+
+// CloneContainerCommand clones a container or template
+func CloneContainerCommand() *cobra.Command {
+	return cloneCommand(containerGuest, "proxmox-cli ct clone -n pve1 -i 9000 --hostname test-42 --full --storage local-lvm --wait")
+}
+
+// TemplateContainerCommand converts a container into a template
+func TemplateContainerCommand() *cobra.Command {
+	var target guestTarget
+
+	var cmd = &cobra.Command{
+		Use:   "template",
+		Short: "Convert a stopped container into a template",
+		Run: func(cmd *cobra.Command, args []string) {
+			nodeName, vmid := target.resolve(containerGuest)
+
+			if err := newContainerService().ConvertToTemplate(nodeName, vmid); err != nil {
+				exitWithError("Failed to convert container to template", err)
+			}
+
+			fmt.Printf("Container %d converted to template\n", vmid)
+		},
+	}
+
+	addGuestTargetFlags(cmd, containerGuest, &target)
+
+	return cmd
+}
+
+// MigrateContainerCommand migrates a container to another node
+func MigrateContainerCommand() *cobra.Command {
+	var target guestTarget
+	var options services.ContainerMigrateOptions
+	var wait bool
+	var timeout time.Duration
+
+	var cmd = &cobra.Command{
+		Use:   "migrate",
+		Short: "Migrate a container to another node",
+		Long: `Migrate a container to another node.
+
+Containers cannot be migrated while running; --restart shuts a running container
+down, migrates it and starts it again on the target:
+  proxmox-cli ct migrate -n pve1 -i 200 --target pve2 --restart --shutdown-timeout 60 --wait`,
+		Run: func(cmd *cobra.Command, args []string) {
+			nodeName, vmid := target.resolve(containerGuest)
+
+			taskID, err := newContainerService().Migrate(nodeName, vmid, options)
+			if err != nil {
+				exitWithError("Failed to migrate container", err)
+			}
+			// The guest is about to change nodes, so its cached location must not be reused
+			forgetGuestLocations()
+
+			finishTask(fmt.Sprintf("Migration of container %d to %s initiated", vmid, options.Target), nodeName, taskID, wait, timeout)
+		},
+	}
+
+	addGuestTargetFlags(cmd, containerGuest, &target)
+	cmd.Flags().StringVar(&options.Target, "target", "", "Node to migrate the container to")
+	cmd.Flags().BoolVar(&options.Restart, "restart", false, "Shut a running container down and start it on the target")
+	cmd.Flags().IntVar(&options.Timeout, "shutdown-timeout", 0, "Seconds to wait for the shutdown of a restart migration")
+	cmd.Flags().StringVar(&options.TargetStorage, "target-storage", "", "Storage on the target for the container volumes")
+	addWaitFlags(cmd, &wait, &timeout)
+	//nolint:errcheck // Flags are defined above, so these cannot fail
+	_ = cmd.MarkFlagRequired("target")
+
+	return cmd
+}
+
+// StartContainerCommand starts a container
+func StartContainerCommand() *cobra.Command {
+	return containerActionCommand("start", "Start a container", "start", (*services.ContainerService).StartContainer)
+}
+
+// StopContainerCommand stops a container
+func StopContainerCommand() *cobra.Command {
+	return containerActionCommand("stop", "Stop a container immediately", "stop", (*services.ContainerService).StopContainer)
+}
+
+// ShutdownContainerCommand gracefully shuts down a container
+func ShutdownContainerCommand() *cobra.Command {
+	return containerActionCommand("shutdown", "Gracefully shutdown a container", "shutdown", (*services.ContainerService).ShutdownContainer)
+}
+
+// RebootContainerCommand reboots a container
+func RebootContainerCommand() *cobra.Command {
+	return containerActionCommand("reboot", "Reboot a container", "reboot", (*services.ContainerService).RebootContainer)
+}
+
+// SuspendContainerCommand suspends a container
+func SuspendContainerCommand() *cobra.Command {
+	return containerActionCommand("suspend", "Suspend (freeze) a container", "suspend", (*services.ContainerService).SuspendContainer)
+}
+
+// ResumeContainerCommand resumes a suspended container
+func ResumeContainerCommand() *cobra.Command {
+	return containerActionCommand("resume", "Resume a suspended container", "resume", (*services.ContainerService).ResumeContainer)
+}
+
+// DeleteContainerCommand deletes a container
+func DeleteContainerCommand() *cobra.Command {
+	return containerActionCommand("delete", "Delete a container", "deletion", (*services.ContainerService).DeleteContainer)
+}
+
+// containerActionCommand creates a command that runs a task on a container.
+// operation names the task in messages, e.g. "shutdown".
+func containerActionCommand(use, short, operation string, action func(*services.ContainerService, string, int) (string, error)) *cobra.Command {
+	var target guestTarget
+	var wait bool
+	var timeout time.Duration
+
+	var cmd = &cobra.Command{
+		Use:   use,
+		Short: short,
+		Run: func(cmd *cobra.Command, args []string) {
+			nodeName, vmid := target.resolve(containerGuest)
+
+			taskID, err := action(newContainerService(), nodeName, vmid)
+			if err != nil {
+				exitWithError(fmt.Sprintf("Failed to %s container", use), err)
+			}
+
+			finishTask(fmt.Sprintf("Container %d %s initiated", vmid, operation), nodeName, taskID, wait, timeout)
+		},
+	}
+
+	addGuestTargetFlags(cmd, containerGuest, &target)
+	addWaitFlags(cmd, &wait, &timeout)
+
+	return cmd
+}
+
+// newContainerService creates the container service, exiting when that fails
+func newContainerService() *services.ContainerService {
+	containerService, err := services.NewContainerService(config.Logger, config.Trust)
+	if err != nil {
+		exitWithError("Failed to initialize container service", err)
+	}
+	return containerService
+}
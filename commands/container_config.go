@@ -0,0 +1,130 @@
+package commands
+
+import (
+	"fmt"
+	"proxmox-cli/services"
+
+	"github.com/spf13/cobra"
+)
+
+// ContainerConfigCommand creates the parent command for container configuration operations
+func ContainerConfigCommand() *cobra.Command {
+	var configCmd = &cobra.Command{
+		Use:   "config",
+		Short: "Show and edit the configuration of a container",
+		Long: `Show and edit the configuration of a container.
+
+Changes to a running container that cannot be applied live are kept as pending
+changes until the container is restarted; 'config show --pending' lists them. Pass
+the digest printed by 'config show' to 'config set' or 'config unset' to make the
+change fail if someone else modified the configuration in the meantime.`,
+	}
+
+	configCmd.AddCommand(ShowContainerConfigCommand())
+	configCmd.AddCommand(SetContainerConfigCommand())
+	configCmd.AddCommand(UnsetContainerConfigCommand())
+
+	return configCmd
+}
+
+// ShowContainerConfigCommand shows the configuration of a container
+func ShowContainerConfigCommand() *cobra.Command {
+	var target guestTarget
+	var pending bool
+
+	var cmd = &cobra.Command{
+		Use:     "show",
+		Aliases: []string{"get"},
+		Short:   "Show the configuration of a container",
+		Run: func(cmd *cobra.Command, args []string) {
+			nodeName, vmid := target.resolve(containerGuest)
+
+			containerService := newContainerService()
+
+			ctConfig, err := containerService.GetConfig(nodeName, vmid)
+			if err != nil {
+				exitWithError("Failed to get container config", err)
+			}
+			if pending {
+				if ctConfig.Pending, err = containerService.GetPendingChanges(nodeName, vmid); err != nil {
+					exitWithError("Failed to get pending container changes", err)
+				}
+			}
+
+			renderObject(ctConfig, func() {
+				printContainerConfig(vmid, ctConfig, pending)
+			})
+		},
+	}
+
+	addGuestTargetFlags(cmd, containerGuest, &target)
+	cmd.Flags().BoolVar(&pending, "pending", false, "Also list changes that take effect after a restart")
+
+	return cmd
+}
+
+// SetContainerConfigCommand sets configuration keys of a container
+func SetContainerConfigCommand() *cobra.Command {
+	return setConfigCommand(containerGuest, "memory=2048 swap=512 net1=name=eth1,bridge=vmbr1,ip=dhcp")
+}
+
+// UnsetContainerConfigCommand removes configuration keys of a container
+func UnsetContainerConfigCommand() *cobra.Command {
+	return unsetConfigCommand(containerGuest, `Removing a mount point detaches it as an unused volume, it
+does not delete its data:
+  proxmox-cli ct config unset -n pve1 -i 200 net1 mp0`)
+}
+
+// printContainerConfig prints a container configuration as a table
+func printContainerConfig(vmid int, ctConfig *services.ContainerConfig, pending bool) {
+	fmt.Printf("Container Config for VMID: %d\n", vmid)
+	fmt.Println("================================================================================")
+	fmt.Printf("Hostname:        %s\n", ctConfig.Hostname)
+	if ctConfig.Memory > 0 {
+		fmt.Printf("Memory:          %d MiB\n", ctConfig.Memory)
+	}
+	if ctConfig.Swap > 0 {
+		fmt.Printf("Swap:            %d MiB\n", ctConfig.Swap)
+	}
+	if ctConfig.Cores > 0 {
+		fmt.Printf("Cores:           %d\n", ctConfig.Cores)
+	}
+	if ctConfig.OSType != "" {
+		fmt.Printf("OS Type:         %s\n", ctConfig.OSType)
+	}
+	if ctConfig.Arch != "" {
+		fmt.Printf("Architecture:    %s\n", ctConfig.Arch)
+	}
+	if ctConfig.Unprivileged {
+		fmt.Println("Unprivileged:    Yes")
+	} else {
+		fmt.Println("Unprivileged:    No")
+	}
+	if ctConfig.Description != "" {
+		fmt.Printf("Description:     %s\n", ctConfig.Description)
+	}
+
+	printConfigSection("Volumes", mergeConfigSections(map[string]string{"rootfs": ctConfig.RootFS}, ctConfig.MountPoints))
+	printConfigSection("Network", mergeConfigSections(ctConfig.Networks, map[string]string{
+		"nameserver":   ctConfig.Nameserver,
+		"searchdomain": ctConfig.Searchdomain,
+	}))
+	printConfigSection("Other", ctConfig.Other)
+
+	fmt.Printf("\nDigest:          %s\n", ctConfig.Digest)
+
+	if pending {
+		printPendingChanges(ctConfig.Pending)
+	}
+}
+
+// mergeConfigSections merges configuration groups into one for printing
+func mergeConfigSections(sections ...map[string]string) map[string]string {
+	merged := map[string]string{}
+	for _, section := range sections {
+		for key, value := range section {
+			merged[key] = value
+		}
+	}
+	return merged
+}
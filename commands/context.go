@@ -0,0 +1,167 @@
+package commands
+
+import (
+	"fmt"
+	"proxmox-cli/config"
+	"proxmox-cli/services"
+
+	"github.com/spf13/cobra"
+)
+
+// createsContextAnnotation marks commands that may select a context that does not exist yet,
+// such as login, which creates it
+const createsContextAnnotation = "createsContext"
+
+// ApplyContext selects the context chosen with --context for all services and
+// enables certificate trust when the selected context was saved with it. A context
+// selected with --context must exist unless cmd creates it.
+func ApplyContext(cmd *cobra.Command) error {
+	services.SelectedContext = config.Context
+
+	sessionService, err := services.NewSessionService(config.Logger)
+	if err != nil {
+		return err
+	}
+
+	_, createsContext := cmd.Annotations[createsContextAnnotation]
+	if config.Context != "" && config.Context != services.DefaultContext && !createsContext &&
+		!sessionService.ContextExists(config.Context) {
+		return fmt.Errorf("context %q does not exist", config.Context)
+	}
+
+	// Only the trust setting is read here; the credentials are read from their secret store
+	// once a command builds an API client
+	if !config.Trust && sessionService.Trusted() {
+		config.Trust = true
+	}
+
+	return nil
+}
+
+// ContextCommand creates the parent command for managing server contexts
+func ContextCommand() *cobra.Command {
+	var contextCmd = &cobra.Command{
+		Use:   "context",
+		Short: "Manage named server contexts",
+		Long: `Contexts store the server, port, scheme, trust setting and credentials of a
+Proxmox server under a name, so you can switch between clusters without logging
+in again. Log in with --context <name> to create or update a context.`,
+	}
+
+	contextCmd.AddCommand(ListContextsCommand())
+	contextCmd.AddCommand(UseContextCommand())
+	contextCmd.AddCommand(RenameContextCommand())
+	contextCmd.AddCommand(DeleteContextCommand())
+
+	return contextCmd
+}
+
+// ListContextsCommand lists all stored contexts
+func ListContextsCommand() *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   "list",
+		Short: "List all contexts",
+		Run: func(cmd *cobra.Command, args []string) {
+			sessionService, err := services.NewSessionService(config.Logger)
+			if err != nil {
+				exitWithError("Failed to initialize session service", err)
+			}
+
+			contexts, err := sessionService.ListContexts()
+			if err != nil {
+				exitWithError("Failed to list contexts", err)
+			}
+
+			if len(contexts) == 0 {
+				fmt.Println("No contexts found. Use 'login' to create one.")
+				return
+			}
+
+			fmt.Printf("%-8s %-20s %-30s %-20s\n", "CURRENT", "NAME", "SERVER", "USER")
+			fmt.Println("================================================================================")
+			for _, ctx := range contexts {
+				current := ""
+				if ctx.Current {
+					current = "*"
+				}
+				server := ""
+				if ctx.Server != "" {
+					server = fmt.Sprintf("%s:%d", ctx.Server, ctx.Port)
+				}
+
+				fmt.Printf("%-8s %-20s %-30s %-20s\n", current, ctx.Name, server, ctx.Username)
+			}
+		},
+	}
+
+	return cmd
+}
+
+// UseContextCommand switches the current context
+func UseContextCommand() *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   "use <name>",
+		Short: "Switch the current context",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			sessionService, err := services.NewSessionService(config.Logger)
+			if err != nil {
+				exitWithError("Failed to initialize session service", err)
+			}
+
+			if err = sessionService.UseContext(args[0]); err != nil {
+				exitWithError("Failed to switch context", err)
+			}
+
+			fmt.Printf("Switched to context %q.\n", args[0])
+		},
+	}
+
+	return cmd
+}
+
+// RenameContextCommand renames a stored context
+func RenameContextCommand() *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   "rename <old-name> <new-name>",
+		Short: "Rename a context",
+		Args:  cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			sessionService, err := services.NewSessionService(config.Logger)
+			if err != nil {
+				exitWithError("Failed to initialize session service", err)
+			}
+
+			if err = sessionService.RenameContext(args[0], args[1]); err != nil {
+				exitWithError("Failed to rename context", err)
+			}
+
+			fmt.Printf("Context %q renamed to %q.\n", args[0], args[1])
+		},
+	}
+
+	return cmd
+}
+
+// DeleteContextCommand deletes a stored context
+func DeleteContextCommand() *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   "delete <name>",
+		Short: "Delete a context and its stored credentials",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			sessionService, err := services.NewSessionService(config.Logger)
+			if err != nil {
+				exitWithError("Failed to initialize session service", err)
+			}
+
+			if err = sessionService.DeleteContext(args[0]); err != nil {
+				exitWithError("Failed to delete context", err)
+			}
+
+			fmt.Printf("Context %q deleted.\n", args[0])
+		},
+	}
+
+	return cmd
+}
@@ -0,0 +1,167 @@
+package commands
+
+import (
+	"fmt"
+	"proxmox-cli/config"
+	"proxmox-cli/services"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// guestService is implemented by VMService and ContainerService for the operations
+// whose commands are shared between VMs and containers
+type guestService interface {
+	UpdateConfig(nodeName string, vmid int, update services.ConfigUpdate) error
+	Clone(nodeName string, vmid int, options services.CloneOptions) (int, string, error)
+	ListSnapshots(nodeName string, vmid int) ([]services.Snapshot, error)
+	CreateSnapshot(nodeName string, vmid int, name, description string, vmState bool) (string, error)
+	RollbackSnapshot(nodeName string, vmid int, name string, start bool) (string, error)
+	DeleteSnapshot(nodeName string, vmid int, name string, force bool) (string, error)
+}
+
+// guestKind describes the kind of guest a shared command operates on
+type guestKind struct {
+	// noun names a guest in messages, e.g. "VM"
+	noun string
+	// longNoun names a guest in help texts, e.g. "virtual machine"
+	longNoun string
+	// command is the parent command, used in examples
+	command string
+	// guestType is the API path segment and cluster resource type of the guests
+	guestType string
+	// nameFlag is the flag and configuration key naming a guest, "name" or "hostname"
+	nameFlag string
+	// ramSnapshots reports whether snapshots can include the RAM state
+	ramSnapshots bool
+	newService   func() (guestService, error)
+}
+
+var vmGuest = guestKind{
+	noun:         "VM",
+	longNoun:     "virtual machine",
+	command:      "vm",
+	guestType:    services.GuestTypeQEMU,
+	nameFlag:     "name",
+	ramSnapshots: true,
+	newService: func() (guestService, error) {
+		vmService, err := services.NewVMService(config.Logger, config.Trust)
+		if err != nil {
+			return nil, err
+		}
+		return vmService, nil
+	},
+}
+
+var containerGuest = guestKind{
+	noun:      "container",
+	longNoun:  "container",
+	command:   "ct",
+	guestType: services.GuestTypeLXC,
+	nameFlag:  "hostname",
+	newService: func() (guestService, error) {
+		containerService, err := services.NewContainerService(config.Logger, config.Trust)
+		if err != nil {
+			return nil, err
+		}
+		return containerService, nil
+	},
+}
+
+// guestTarget is the VM or container a command operates on, given by --vmid as an ID or
+// a name. --node is optional: without it the guest is looked up in the cluster resources.
+type guestTarget struct {
+	node string
+	ref  string
+}
+
+// addGuestTargetFlags adds the --node and --vmid flags selecting the guest of a command
+func addGuestTargetFlags(cmd *cobra.Command, guest guestKind, target *guestTarget) {
+	cmd.Flags().StringVarP(&target.node, "node", "n", "", "Name of the node (looked up in the cluster when omitted)")
+	cmd.Flags().StringVarP(&target.ref, "vmid", "i", "", guest.title()+" ID or name")
+	//nolint:errcheck // Flags are defined above, so these cannot fail
+	_ = cmd.MarkFlagRequired("vmid")
+}
+
+// resolve returns the node and VM ID of the target, exiting when the guest cannot be found.
+// A VM ID given together with its node is used as is, without asking the cluster.
+func (t guestTarget) resolve(guest guestKind) (string, int) {
+	if vmid, err := strconv.Atoi(t.ref); err == nil && t.node != "" {
+		return t.node, vmid
+	}
+
+	clusterService, err := services.NewClusterService(config.Logger, config.Trust)
+	if err != nil {
+		exitWithError("Failed to initialize cluster service", err)
+	}
+
+	location, err := clusterService.ResolveGuest(guest.guestType, t.ref, t.node)
+	if err != nil {
+		exitWithError("Failed to find "+guest.noun, err)
+	}
+	return location.Node, location.VMID
+}
+
+// forgetGuestLocations drops the cached guest locations after a guest moved to another node
+func forgetGuestLocations() {
+	if clusterService, err := services.NewClusterService(config.Logger, config.Trust); err == nil {
+		clusterService.ForgetGuests()
+	}
+}
+
+// service creates the service of the guest kind, exiting when that fails
+func (g guestKind) service() guestService {
+	guestService, err := g.newService()
+	if err != nil {
+		exitWithError("Failed to initialize "+g.noun+" service", err)
+	}
+	return guestService
+}
+
+// title returns the noun of the guest kind for the start of a sentence
+func (g guestKind) title() string {
+	return strings.ToUpper(g.noun[:1]) + g.noun[1:]
+}
+
+// cloneCommand clones a guest or template; example is a command line shown in the help text
+func cloneCommand(guest guestKind, example string) *cobra.Command {
+	var target guestTarget
+	var options services.CloneOptions
+	var wait bool
+	var timeout time.Duration
+
+	var cmd = &cobra.Command{
+		Use:   "clone",
+		Short: fmt.Sprintf("Clone a %s or template", guest.longNoun),
+		Long: fmt.Sprintf(`Clone a %[1]s or template. Without --newid the next free ID of the cluster is used.
+
+Templates are cloned as linked clones unless --full is given; regular %[2]ss are always
+copied in full. A target storage can only be chosen for full clones:
+  %[3]s`, guest.longNoun, guest.noun, example),
+		Run: func(cmd *cobra.Command, args []string) {
+			nodeName, vmid := target.resolve(guest)
+
+			newID, taskID, err := guest.service().Clone(nodeName, vmid, options)
+			if err != nil {
+				exitWithError("Failed to clone "+guest.noun, err)
+			}
+
+			finishTask(fmt.Sprintf("Clone of %[1]s %[2]d to %[1]s %[3]d initiated", guest.noun, vmid, newID), nodeName, taskID, wait, timeout)
+		},
+	}
+
+	addGuestTargetFlags(cmd, guest, &target)
+	cmd.Flags().IntVar(&options.NewID, "newid", 0, "VM ID of the clone (defaults to the next free ID)")
+	cmd.Flags().StringVar(&options.Name, guest.nameFlag, "", "Name of the clone")
+	cmd.Flags().StringVar(&options.TargetNode, "target", "", "Node to create the clone on (defaults to the source node)")
+	cmd.Flags().StringVar(&options.Storage, "storage", "", "Target storage of a full clone")
+	cmd.Flags().BoolVar(&options.Full, "full", false, "Create a full copy instead of a linked clone")
+	cmd.Flags().StringVar(&options.Pool, "pool", "", "Resource pool to add the clone to")
+	cmd.Flags().StringVar(&options.Description, "description", "", "Description of the clone")
+	cmd.Flags().StringVar(&options.Snapshot, "snapshot", "", fmt.Sprintf("Clone the %s as it was at this snapshot", guest.noun))
+	addWaitFlags(cmd, &wait, &timeout)
+
+	return cmd
+}
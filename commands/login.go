@@ -1,8 +1,12 @@
 package commands
 
 import (
+	"bufio"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"strings"
 
 	"proxmox-cli/config"
 	"proxmox-cli/services"
@@ -23,27 +27,80 @@ func LoginCommand() *cobra.Command {
 	var httpScheme string
 	var port int
 	var logLevel bool
+	var tokenID string
+	var tokenSecret string
+	var realm string
+	var secretStore string
+	var passwordSource PasswordSource
 
 	var loginCmd = &cobra.Command{
-		Use:   "login",
-		Short: "Log in to a Proxmox server",
+		Use:         "login",
+		Annotations: map[string]string{createsContextAnnotation: "true"},
+		Short:       "Log in to a Proxmox server",
+		Long: `Log in to a Proxmox server with a username and password, or with an API token.
+
+The realm can be given with --realm or as part of the username (user@realm).
+Without either, the server's default realm is used. Accounts with two-factor
+authentication are prompted for a TOTP, Yubico OTP or recovery code.
+
+API tokens can be given with --token-id/--token-secret or through the
+PROXMOX_TOKEN_ID and PROXMOX_TOKEN_SECRET environment variables, which avoids
+the interactive password prompt in automation.
+
+The password is read from the first of these that is given: --password-stdin,
+--password-file, the PROXMOX_PASSWORD environment variable, or an interactive
+prompt when standard input is a terminal.
+
+The session file is only readable by you. With --secret-store (or
+PROXMOX_SECRET_STORE) the ticket and token secret are kept out of it:
+  secret-service  the desktop keyring (GNOME Keyring, KWallet) via secret-tool
+  encrypted-file  files encrypted with the passphrase in PROXMOX_SECRET_PASSPHRASE`,
 		Run: func(cmd *cobra.Command, args []string) {
 			config.Logger.Info("Logging in to Proxmox server...")
 			if logLevel {
 				config.SetLogLevel(logrus.InfoLevel)
 			}
-			fmt.Print("Enter Password: ")
-			passwordBytes, err := term.ReadPassword(int(os.Stdin.Fd())) //nolint:gosec // term.ReadPassword requires int; safe on all supported platforms
-			fmt.Println()
-			if err != nil {
-				config.Logger.Error("Error reading password: ", err)
-				return
+
+			if tokenID == "" {
+				tokenID = os.Getenv("PROXMOX_TOKEN_ID")
+			}
+			if tokenSecret == "" {
+				tokenSecret = os.Getenv("PROXMOX_TOKEN_SECRET")
+			}
+
+			if secretStore == "" {
+				secretStore = os.Getenv("PROXMOX_SECRET_STORE")
+			}
+			if err := services.ValidateSecretStore(secretStore); err != nil {
+				exitWithError("Invalid secret store", err)
 			}
-			password := string(passwordBytes)
+			services.SelectedSecretStore = secretStore
+
 			authService := services.NewAuthService(config.Logger, config.Trust)
-			err = authService.LoginToProxmox(server, port, httpScheme, username, password)
+
+			if tokenID != "" || tokenSecret != "" {
+				if tokenID == "" || tokenSecret == "" {
+					exitWithError("Invalid login options", errors.New("both token ID and token secret are required for API token login"))
+				}
+				err := authService.LoginWithAPIToken(server, port, httpScheme, tokenID, tokenSecret)
+				if err != nil {
+					exitWithError("Login failed", err)
+				}
+				return
+			}
+
+			if username == "" {
+				exitWithError("Invalid login options", errors.New("username is required unless an API token is provided"))
+			}
+
+			password, err := ReadPassword(passwordSource, os.Stdin)
+			if err != nil {
+				exitWithError("Failed to read password", err)
+			}
+			authService.TFAPrompt = TFAPrompt(passwordSource, os.Stdin, openTTY)
+			err = authService.LoginToProxmox(server, port, httpScheme, username, realm, password)
 			if err != nil {
-				config.Logger.Error("Login failed: ", err)
+				exitWithError("Login failed", err)
 			}
 		},
 	}
@@ -53,15 +110,203 @@ func LoginCommand() *cobra.Command {
 	loginCmd.Flags().IntVarP(&port, "port", "P", 8006, "Proxmox server port")
 	loginCmd.Flags().StringVarP(&httpScheme, "httpScheme", "S", "https", "HTTP scheme (http or https)")
 	loginCmd.Flags().BoolVarP(&logLevel, "show-log", "l", false, "Set the log level to error")
+	loginCmd.Flags().StringVarP(&realm, "realm", "r", "", "Authentication realm (e.g. pam, pve, or an LDAP/AD realm)")
+	loginCmd.Flags().StringVar(&tokenID, "token-id", "", "API token ID (user@realm!tokenname), defaults to $PROXMOX_TOKEN_ID")
+	loginCmd.Flags().StringVar(&tokenSecret, "token-secret", "", "API token secret, defaults to $PROXMOX_TOKEN_SECRET")
+	loginCmd.Flags().StringVar(&secretStore, "secret-store", "",
+		"Where to keep credentials: file, secret-service or encrypted-file, defaults to $PROXMOX_SECRET_STORE")
+	loginCmd.Flags().BoolVar(&passwordSource.Stdin, "password-stdin", false, "Read the password from the first line of standard input")
+	loginCmd.Flags().StringVar(&passwordSource.File, "password-file", "", "Read the password from the first line of a file")
+	loginCmd.MarkFlagsMutuallyExclusive("password-stdin", "password-file")
 
 	//nolint:errcheck // Flag is defined above, so this cannot fail
 	_ = loginCmd.MarkFlagRequired("server")
-	//nolint:errcheck // Flag is defined above, so this cannot fail
-	_ = loginCmd.MarkFlagRequired("username")
 
 	return loginCmd
 }
 
+// PasswordSource selects where login reads the password from
+type PasswordSource struct {
+	// Stdin reads the password from standard input (--password-stdin)
+	Stdin bool
+	// File reads the password from the first line of a file (--password-file)
+	File string
+}
+
+// ReadPassword returns the login password from the first source that is set:
+// standard input, the password file, $PROXMOX_PASSWORD, and finally an interactive prompt.
+// The prompt is only shown when stdin is a terminal, so automation fails instead of hanging.
+func ReadPassword(source PasswordSource, stdin io.Reader) (string, error) {
+	if source.Stdin && source.File != "" {
+		return "", errors.New("--password-stdin and --password-file cannot be used together")
+	}
+
+	if source.Stdin {
+		content, err := io.ReadAll(stdin)
+		if err != nil {
+			return "", fmt.Errorf("error reading password from standard input: %w", err)
+		}
+		return firstLine(string(content))
+	}
+
+	if source.File != "" {
+		//nolint:gosec // G304: The password file is chosen by the user
+		content, err := os.ReadFile(source.File) // #nosec G304 -- Password file is chosen by the user
+		if err != nil {
+			return "", fmt.Errorf("error reading password file: %w", err)
+		}
+		return firstLine(string(content))
+	}
+
+	if password := os.Getenv("PROXMOX_PASSWORD"); password != "" {
+		return password, nil
+	}
+
+	fd := int(os.Stdin.Fd()) //nolint:gosec // term functions require int; safe on all supported platforms
+	if !term.IsTerminal(fd) {
+		return "", errors.New("no password given: use --password-stdin, --password-file or PROXMOX_PASSWORD")
+	}
+	fmt.Print("Enter Password: ")
+	passwordBytes, err := term.ReadPassword(fd)
+	fmt.Println()
+	if err != nil {
+		return "", err
+	}
+	return string(passwordBytes), nil
+}
+
+// ReadOptionalPassword returns a password from standard input, the password file or the
+// environment variable env, and an empty password when none of them is set. It is used for
+// passwords that commands may leave out, so unlike ReadPassword it never prompts.
+func ReadOptionalPassword(source PasswordSource, env string, stdin io.Reader) (string, error) {
+	if source.Stdin || source.File != "" {
+		return ReadPassword(source, stdin)
+	}
+	return os.Getenv(env), nil
+}
+
+// firstLine returns the first line of content without its line ending
+func firstLine(content string) (string, error) {
+	line, _, _ := strings.Cut(content, "\n")
+	line = strings.TrimSuffix(line, "\r")
+	if line == "" {
+		return "", errors.New("the password is empty")
+	}
+	return line, nil
+}
+
+// openTTY opens the controlling terminal of the process
+func openTTY() (io.ReadCloser, error) {
+	return os.Open("/dev/tty")
+}
+
+// TFAPrompt returns the prompt that asks for the second factor of a two-factor login.
+// The code is read from stdin, unless --password-stdin already consumed it, in which case
+// it is read from the terminal returned by openTTY. Without a terminal the login fails
+// with an error instead of sending an empty code.
+func TFAPrompt(source PasswordSource, stdin io.Reader, openTTY func() (io.ReadCloser, error)) services.TFAPromptFunc {
+	return func(challenge services.TFAChallenge) (string, error) {
+		if !source.Stdin {
+			return readTFACode(challenge, stdin)
+		}
+
+		tty, err := openTTY()
+		if err != nil {
+			return "", fmt.Errorf("the account requires a two-factor code, which cannot be read after --password-stdin "+
+				"without a terminal: use --password-file or PROXMOX_PASSWORD instead (%w)", err)
+		}
+		defer tty.Close() //nolint:errcheck // Read-only terminal handle
+		return readTFACode(challenge, tty)
+	}
+}
+
+// readTFACode prompts for the second factor and reads it from input.
+// Codes may be prefixed with their type (e.g. recovery:abcd-1234); unprefixed codes
+// are sent as the first factor type the challenge accepts.
+func readTFACode(challenge services.TFAChallenge, input io.Reader) (string, error) {
+	defaultType := ""
+	switch {
+	case challenge.TOTP:
+		defaultType = "totp"
+	case challenge.Yubico:
+		defaultType = "yubico"
+	case challenge.Recovery:
+		defaultType = "recovery"
+	default:
+		return "", fmt.Errorf("only WebAuthn/U2F second factors are configured, which the CLI does not support")
+	}
+
+	prompt := fmt.Sprintf("Enter %s code", strings.ToUpper(defaultType))
+	if challenge.Recovery && defaultType != "recovery" {
+		prompt += " (or recovery:<code>)"
+	}
+	fmt.Print(prompt + ": ")
+
+	line, err := bufio.NewReader(input).ReadString('\n')
+	if err != nil && line == "" {
+		return "", fmt.Errorf("error reading two-factor code: %w", err)
+	}
+
+	code := strings.TrimSpace(line)
+	if code == "" {
+		return "", fmt.Errorf("no two-factor code entered")
+	}
+	for _, factor := range []string{"totp:", "yubico:", "recovery:"} {
+		if strings.HasPrefix(code, factor) {
+			return code, nil
+		}
+	}
+	return defaultType + ":" + code, nil
+}
+
+// RealmsCommand creates a new Cobra command for listing the authentication realms of a server.
+//
+// Returns:
+// - *cobra.Command: The realms command.
+func RealmsCommand() *cobra.Command {
+	var server string
+	var httpScheme string
+	var port int
+
+	var realmsCmd = &cobra.Command{
+		Use:   "realms",
+		Short: "List the authentication realms of a Proxmox server",
+		Run: func(cmd *cobra.Command, args []string) {
+			authService := services.NewAuthService(config.Logger, config.Trust)
+			realms, err := authService.ListRealms(server, port, httpScheme)
+			if err != nil {
+				exitWithError("Failed to list realms", err)
+			}
+
+			if len(realms) == 0 {
+				fmt.Println("No realms found")
+				return
+			}
+
+			fmt.Printf("%-15s %-10s %-8s %-10s %-30s\n", "REALM", "TYPE", "DEFAULT", "TFA", "COMMENT")
+			fmt.Println("================================================================================")
+			for _, realm := range realms {
+				isDefault := ""
+				if realm.Default == 1 {
+					isDefault = "Yes"
+				}
+
+				fmt.Printf("%-15s %-10s %-8s %-10s %-30s\n",
+					realm.Realm, realm.Type, isDefault, realm.TFA, realm.Comment)
+			}
+		},
+	}
+
+	realmsCmd.Flags().StringVarP(&server, "server", "s", "", "Proxmox server URL")
+	realmsCmd.Flags().IntVarP(&port, "port", "P", 8006, "Proxmox server port")
+	realmsCmd.Flags().StringVarP(&httpScheme, "httpScheme", "S", "https", "HTTP scheme (http or https)")
+
+	//nolint:errcheck // Flag is defined above, so this cannot fail
+	_ = realmsCmd.MarkFlagRequired("server")
+
+	return realmsCmd
+}
+
 // ValidateLoginCommand creates a new Cobra command for validating the current session.
 //
 // Returns:
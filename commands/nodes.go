@@ -2,7 +2,9 @@ package commands
 
 import (
 	"fmt"
+	"os"
 	"proxmox-cli/config"
+	"proxmox-cli/output"
 	"proxmox-cli/services"
 
 	"github.com/spf13/cobra"
@@ -18,6 +20,7 @@ func NodesCommand() *cobra.Command {
 	nodesCmd.AddCommand(ListNodesCommand())
 	nodesCmd.AddCommand(NodeStatusCommand())
 	nodesCmd.AddCommand(NodeVersionCommand())
+	nodesCmd.AddCommand(NodeNetworkCommand())
 
 	return nodesCmd
 }
@@ -30,36 +33,30 @@ func ListNodesCommand() *cobra.Command {
 		Run: func(cmd *cobra.Command, args []string) {
 			nodesService, err := services.NewNodesService(config.Logger, config.Trust)
 			if err != nil {
-				config.Logger.Error("Failed to initialize nodes service: ", err)
-				fmt.Println("Error: Failed to initialize nodes service")
-				return
+				exitWithError("Failed to initialize nodes service", err)
 			}
 
 			nodes, err := nodesService.ListNodes()
 			if err != nil {
-				config.Logger.Error("Failed to list nodes: ", err)
-				fmt.Println("Error: Failed to list nodes")
-				return
+				exitWithError("Failed to list nodes", err)
 			}
 
-			if len(nodes) == 0 {
+			if len(nodes) == 0 && output.IsTable(config.Output) {
 				fmt.Println("No nodes found")
 				return
 			}
 
-			fmt.Printf("%-20s %-10s %-10s %-15s %-15s\n", "NODE", "STATUS", "CPU %", "MEMORY", "UPTIME")
-			fmt.Println("================================================================================")
-			for _, node := range nodes {
-				cpuPercent := fmt.Sprintf("%.2f%%", node.CPU*100)
-				memUsage := ""
-				if node.MaxMem > 0 {
-					memUsage = fmt.Sprintf("%.2f%%", float64(node.Mem)/float64(node.MaxMem)*100)
-				}
-				uptime := formatUptime(node.Uptime)
-
-				fmt.Printf("%-20s %-10s %-10s %-15s %-15s\n",
-					node.Node, node.Status, cpuPercent, memUsage, uptime)
+			columns := []output.Column[services.Node]{
+				{Header: "NODE", Value: func(node services.Node) string { return node.Node }},
+				{Header: "STATUS", Value: func(node services.Node) string { return node.Status }},
+				{Header: "CPU %", Value: func(node services.Node) string { return fmt.Sprintf("%.2f%%", node.CPU*100) }},
+				{Header: "MEMORY", Value: func(node services.Node) string { return formatPercent(node.Mem, node.MaxMem) }},
+				{Header: "UPTIME", Value: func(node services.Node) string { return formatUptime(node.Uptime) }},
+				{Header: "MAX CPU", Wide: true, Value: func(node services.Node) string { return fmt.Sprintf("%d", node.MaxCPU) }},
+				{Header: "MAX MEMORY", Wide: true, Value: func(node services.Node) string { return formatBytes(node.MaxMem) }},
+				{Header: "LEVEL", Wide: true, Value: func(node services.Node) string { return node.Level }},
 			}
+			renderList(nodes, columns)
 		},
 	}
 
@@ -81,36 +78,17 @@ func NodeStatusCommand() *cobra.Command {
 
 			nodesService, err := services.NewNodesService(config.Logger, config.Trust)
 			if err != nil {
-				config.Logger.Error("Failed to initialize nodes service: ", err)
-				fmt.Println("Error: Failed to initialize nodes service")
-				return
+				exitWithError("Failed to initialize nodes service", err)
 			}
 
 			status, err := nodesService.GetNodeStatus(nodeName)
 			if err != nil {
-				config.Logger.Error("Failed to get node status: ", err)
-				fmt.Println("Error: Failed to get node status")
-				return
+				exitWithError("Failed to get node status", err)
 			}
 
-			fmt.Printf("Node Status for: %s\n", nodeName)
-			fmt.Println("================================================================================")
-			fmt.Printf("CPU Usage:       %.2f%%\n", status.CPU*100)
-			fmt.Printf("CPU Model:       %s\n", status.CPUInfo.Model)
-			fmt.Printf("CPU Cores:       %d\n", status.CPUInfo.CPUs)
-			fmt.Printf("Memory Used:     %s / %s (%.2f%%)\n",
-				formatBytes(status.Memory.Used), formatBytes(status.Memory.Total),
-				float64(status.Memory.Used)/float64(status.Memory.Total)*100)
-			fmt.Printf("Swap Used:       %s / %s\n",
-				formatBytes(status.Swap.Used), formatBytes(status.Swap.Total))
-			fmt.Printf("Root FS Used:    %s / %s (%.2f%%)\n",
-				formatBytes(status.RootFS.Used), formatBytes(status.RootFS.Total),
-				float64(status.RootFS.Used)/float64(status.RootFS.Total)*100)
-			fmt.Printf("Uptime:          %s\n", formatUptime(status.Uptime))
-			fmt.Printf("Load Average:    %.2f, %.2f, %.2f\n",
-				status.LoadAvg[0], status.LoadAvg[1], status.LoadAvg[2])
-			fmt.Printf("Kernel Version:  %s\n", status.KVersion)
-			fmt.Printf("PVE Version:     %s\n", status.PVEVersion)
+			renderObject(status, func() {
+				printNodeStatus(nodeName, status)
+			})
 		},
 	}
 
@@ -121,6 +99,28 @@ func NodeStatusCommand() *cobra.Command {
 	return cmd
 }
 
+// printNodeStatus prints the detailed status view of a node
+func printNodeStatus(nodeName string, status *services.NodeStatus) {
+	fmt.Printf("Node Status for: %s\n", nodeName)
+	fmt.Println("================================================================================")
+	fmt.Printf("CPU Usage:       %.2f%%\n", status.CPU*100)
+	fmt.Printf("CPU Model:       %s\n", status.CPUInfo.Model)
+	fmt.Printf("CPU Cores:       %d\n", status.CPUInfo.CPUs)
+	fmt.Printf("Memory Used:     %s / %s (%.2f%%)\n",
+		formatBytes(status.Memory.Used), formatBytes(status.Memory.Total),
+		float64(status.Memory.Used)/float64(status.Memory.Total)*100)
+	fmt.Printf("Swap Used:       %s / %s\n",
+		formatBytes(status.Swap.Used), formatBytes(status.Swap.Total))
+	fmt.Printf("Root FS Used:    %s / %s (%.2f%%)\n",
+		formatBytes(status.RootFS.Used), formatBytes(status.RootFS.Total),
+		float64(status.RootFS.Used)/float64(status.RootFS.Total)*100)
+	fmt.Printf("Uptime:          %s\n", formatUptime(status.Uptime))
+	fmt.Printf("Load Average:    %.2f, %.2f, %.2f\n",
+		status.LoadAvg[0], status.LoadAvg[1], status.LoadAvg[2])
+	fmt.Printf("Kernel Version:  %s\n", status.KVersion)
+	fmt.Printf("PVE Version:     %s\n", status.PVEVersion)
+}
+
 // NodeVersionCommand gets version information for a specific node
 func NodeVersionCommand() *cobra.Command {
 	var nodeName string
@@ -136,22 +136,20 @@ func NodeVersionCommand() *cobra.Command {
 
 			nodesService, err := services.NewNodesService(config.Logger, config.Trust)
 			if err != nil {
-				config.Logger.Error("Failed to initialize nodes service: ", err)
-				fmt.Println("Error: Failed to initialize nodes service")
-				return
+				exitWithError("Failed to initialize nodes service", err)
 			}
 
 			version, err := nodesService.GetNodeVersion(nodeName)
 			if err != nil {
-				config.Logger.Error("Failed to get node version: ", err)
-				fmt.Println("Error: Failed to get node version")
-				return
+				exitWithError("Failed to get node version", err)
 			}
 
-			fmt.Printf("Node: %s\n", nodeName)
-			fmt.Printf("Version: %s\n", version.Version)
-			fmt.Printf("Release: %s\n", version.Release)
-			fmt.Printf("Repo ID: %s\n", version.RepoID)
+			renderObject(version, func() {
+				fmt.Printf("Node: %s\n", nodeName)
+				fmt.Printf("Version: %s\n", version.Version)
+				fmt.Printf("Release: %s\n", version.Release)
+				fmt.Printf("Repo ID: %s\n", version.RepoID)
+			})
 		},
 	}
 
@@ -176,6 +174,40 @@ func formatBytes(bytes int64) string {
 	return fmt.Sprintf("%.2f %cB", float64(bytes)/float64(div), "KMGTPE"[exp])
 }
 
+// Helper function to format a used/total pair as a percentage, empty when the total is unknown
+func formatPercent(used, total int64) string {
+	if total <= 0 {
+		return ""
+	}
+	return fmt.Sprintf("%.2f%%", float64(used)/float64(total)*100)
+}
+
+// renderList prints items in the format selected with --output
+func renderList[T any](items []T, columns []output.Column[T]) {
+	if err := output.RenderList(os.Stdout, config.Output, items, columns); err != nil {
+		config.Logger.Error("Failed to render output: ", err)
+		//nolint:errcheck // Nothing sensible can be done when printing the error fails
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+	}
+}
+
+// renderObject prints v in the format selected with --output, using table for the table formats
+func renderObject(v any, table func()) {
+	if err := output.RenderObject(os.Stdout, config.Output, v, table); err != nil {
+		config.Logger.Error("Failed to render output: ", err)
+		//nolint:errcheck // Nothing sensible can be done when printing the error fails
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+	}
+}
+
+// exitWithError prints a failed operation with the details of err to stderr, keeping stdout
+// to the selected output format, and exits with the matching code
+func exitWithError(message string, err error) {
+	config.Logger.Error(message+": ", err)
+	output.PrintError(os.Stderr, message, err)
+	os.Exit(output.ExitCode(err))
+}
+
 // Helper function to format uptime into human-readable format
 func formatUptime(seconds int64) string {
 	if seconds == 0 {
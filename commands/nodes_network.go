@@ -0,0 +1,376 @@
+package commands
+
+import (
+	"fmt"
+	"proxmox-cli/config"
+	"proxmox-cli/output"
+	"proxmox-cli/services"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// NodeNetworkCommand creates the parent command for the network interfaces of a node
+func NodeNetworkCommand() *cobra.Command {
+	var networkCmd = &cobra.Command{
+		Use:   "network",
+		Short: "Manage the network interfaces of a node",
+		Long: `Manage the bridges, bonds and VLAN interfaces of a node. Changes are written to
+/etc/network/interfaces.new and only take effect once they are applied with
+"nodes network apply", or are discarded with "nodes network revert".`,
+	}
+
+	networkCmd.AddCommand(ListNetworkInterfacesCommand())
+	networkCmd.AddCommand(ShowNetworkInterfaceCommand())
+	networkCmd.AddCommand(CreateNetworkInterfaceCommand())
+	networkCmd.AddCommand(UpdateNetworkInterfaceCommand())
+	networkCmd.AddCommand(DeleteNetworkInterfaceCommand())
+	networkCmd.AddCommand(ApplyNetworkCommand())
+	networkCmd.AddCommand(RevertNetworkCommand())
+
+	return networkCmd
+}
+
+// ListNetworkInterfacesCommand lists the network interfaces of a node
+func ListNetworkInterfacesCommand() *cobra.Command {
+	var nodeName string
+	var ifaceType string
+
+	var cmd = &cobra.Command{
+		Use:   "list",
+		Short: "List the network interfaces of a node",
+		Run: func(cmd *cobra.Command, args []string) {
+			ifaces, changes, err := newNodesService().ListNetworkInterfaces(nodeName, ifaceType)
+			if err != nil {
+				exitWithError("Failed to list network interfaces", err)
+			}
+
+			if len(ifaces) == 0 && output.IsTable(config.Output) {
+				fmt.Println("No network interfaces found")
+				return
+			}
+
+			columns := []output.Column[services.NetworkInterface]{
+				{Header: "IFACE", Value: func(iface services.NetworkInterface) string { return iface.Iface }},
+				{Header: "TYPE", Value: func(iface services.NetworkInterface) string { return iface.Type }},
+				{Header: "ACTIVE", Value: func(iface services.NetworkInterface) string { return formatYesNo(iface.Active) }},
+				{Header: "AUTOSTART", Value: func(iface services.NetworkInterface) string { return formatYesNo(iface.Autostart) }},
+				{Header: "CIDR", Value: func(iface services.NetworkInterface) string { return iface.CIDR }},
+				{Header: "GATEWAY", Value: func(iface services.NetworkInterface) string { return iface.Gateway }},
+				{Header: "PORTS/SLAVES", Value: func(iface services.NetworkInterface) string { return formatInterfaceMembers(iface) }},
+				{Header: "CIDR6", Wide: true, Value: func(iface services.NetworkInterface) string { return iface.CIDR6 }},
+				{Header: "GATEWAY6", Wide: true, Value: func(iface services.NetworkInterface) string { return iface.Gateway6 }},
+				{Header: "MTU", Wide: true, Value: func(iface services.NetworkInterface) string { return formatInterfaceMTU(iface.MTU) }},
+				{Header: "COMMENTS", Wide: true, Value: func(iface services.NetworkInterface) string { return iface.Comments }},
+			}
+			renderList(ifaces, columns)
+
+			if changes != "" && output.IsTable(config.Output) {
+				fmt.Println("\nThe list includes pending changes; review them with \"nodes network apply --dry-run\"")
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&nodeName, "node", "n", "", "Name of the node")
+	cmd.Flags().StringVarP(&ifaceType, "type", "t", "", "Only list interfaces of this type, e.g. bridge, bond, vlan or eth")
+	//nolint:errcheck // Flags are defined above, so these cannot fail
+	_ = cmd.MarkFlagRequired("node")
+
+	return cmd
+}
+
+// ShowNetworkInterfaceCommand shows the configuration of a network interface
+func ShowNetworkInterfaceCommand() *cobra.Command {
+	var nodeName string
+
+	var cmd = &cobra.Command{
+		Use:   "show <iface>",
+		Short: "Show the configuration of a network interface",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			iface, err := newNodesService().GetNetworkInterface(nodeName, args[0])
+			if err != nil {
+				exitWithError("Failed to get network interface", err)
+			}
+
+			renderObject(iface, func() {
+				printNetworkInterface(iface)
+			})
+		},
+	}
+
+	cmd.Flags().StringVarP(&nodeName, "node", "n", "", "Name of the node")
+	//nolint:errcheck // Flags are defined above, so these cannot fail
+	_ = cmd.MarkFlagRequired("node")
+
+	return cmd
+}
+
+// printNetworkInterface prints the detailed view of a network interface
+func printNetworkInterface(iface *services.NetworkInterface) {
+	fmt.Printf("Interface: %s\n", iface.Iface)
+	fmt.Println("================================================================================")
+	fmt.Printf("Type:            %s\n", iface.Type)
+	fmt.Printf("Active:          %s\n", formatYesNo(iface.Active))
+	fmt.Printf("Autostart:       %s\n", formatYesNo(iface.Autostart))
+	if iface.CIDR != "" {
+		fmt.Printf("IPv4:            %s (gateway %s)\n", iface.CIDR, formatGateway(iface.Gateway))
+	}
+	if iface.CIDR6 != "" {
+		fmt.Printf("IPv6:            %s (gateway %s)\n", iface.CIDR6, formatGateway(iface.Gateway6))
+	}
+	fmt.Printf("MTU:             %s\n", formatInterfaceMTU(iface.MTU))
+	switch iface.Type {
+	case services.NetworkBridge:
+		fmt.Printf("Bridge Ports:    %s\n", formatInterfaceMembers(*iface))
+		fmt.Printf("VLAN Aware:      %s\n", formatYesNo(iface.BridgeVLANAware))
+	case services.NetworkBond:
+		fmt.Printf("Slaves:          %s\n", formatInterfaceMembers(*iface))
+		fmt.Printf("Bond Mode:       %s\n", iface.BondMode)
+		if iface.BondPrimary != "" {
+			fmt.Printf("Bond Primary:    %s\n", iface.BondPrimary)
+		}
+		if iface.BondXmitHashPolicy != "" {
+			fmt.Printf("Hash Policy:     %s\n", iface.BondXmitHashPolicy)
+		}
+	case services.NetworkVLAN:
+		if iface.VLANRawDevice != "" {
+			fmt.Printf("VLAN Device:     %s\n", iface.VLANRawDevice)
+			fmt.Printf("VLAN ID:         %d\n", iface.VLANID)
+		}
+	}
+	if iface.Comments != "" {
+		fmt.Printf("Comments:        %s\n", strings.TrimSpace(iface.Comments))
+	}
+}
+
+// CreateNetworkInterfaceCommand adds a bridge, bond or VLAN interface to a node
+func CreateNetworkInterfaceCommand() *cobra.Command {
+	var nodeName string
+	var ifaceType string
+	var autostart bool
+	var options services.NetworkInterfaceOptions
+
+	var cmd = &cobra.Command{
+		Use:   "create <iface>",
+		Short: "Create a bridge, bond or VLAN interface",
+		Long: fmt.Sprintf(`Create a network interface of type %s. Bridges take their ports
+from --bridge-ports, bonds need --slaves and VLAN interfaces are named after their device
+and tag (eno1.100) or need --vlan-raw-device and --vlan-id:
+  proxmox-cli nodes network create vmbr1 -n pve1 -t bridge --bridge-ports bond0 --bridge-vlan-aware --cidr 10.0.0.2/24
+  proxmox-cli nodes network create bond0 -n pve1 -t bond --slaves eno1,eno2 --bond-mode 802.3ad --bond-hash-policy layer3+4
+  proxmox-cli nodes network create vlan100 -n pve1 -t vlan --vlan-raw-device vmbr0 --vlan-id 100
+
+The interface is added to the pending configuration; apply it with "nodes network apply".`,
+			strings.Join(services.NetworkInterfaceTypes, ", ")),
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Autostart = &autostart
+
+			if err := newNodesService().CreateNetworkInterface(nodeName, args[0], ifaceType, options); err != nil {
+				exitWithError("Failed to create network interface", err)
+			}
+
+			fmt.Printf("Interface %s created on node %s; apply the network configuration to activate it\n", args[0], nodeName)
+		},
+	}
+
+	cmd.Flags().StringVarP(&nodeName, "node", "n", "", "Name of the node")
+	cmd.Flags().StringVarP(&ifaceType, "type", "t", "", "Type of the interface: "+strings.Join(services.NetworkInterfaceTypes, ", "))
+	cmd.Flags().BoolVar(&autostart, "autostart", true, "Bring the interface up at boot")
+	addNetworkInterfaceFlags(cmd, &options)
+	//nolint:errcheck // Flags are defined above, so these cannot fail
+	_ = cmd.MarkFlagRequired("node")
+	//nolint:errcheck // Flags are defined above, so these cannot fail
+	_ = cmd.MarkFlagRequired("type")
+
+	return cmd
+}
+
+// UpdateNetworkInterfaceCommand changes the configuration of a network interface
+func UpdateNetworkInterfaceCommand() *cobra.Command {
+	var nodeName string
+	var autostart bool
+	var options services.NetworkInterfaceOptions
+
+	var cmd = &cobra.Command{
+		Use:   "update <iface>",
+		Short: "Update the configuration of a network interface",
+		Long: `Update the configuration of a network interface. Only the given options change,
+and --delete removes options. The type of an interface cannot be changed:
+  proxmox-cli nodes network update vmbr0 -n pve1 --mtu 9000 --delete gateway6
+
+The change is added to the pending configuration; apply it with "nodes network apply".`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if cmd.Flags().Changed("autostart") {
+				options.Autostart = &autostart
+			}
+
+			if err := newNodesService().UpdateNetworkInterface(nodeName, args[0], options); err != nil {
+				exitWithError("Failed to update network interface", err)
+			}
+
+			fmt.Printf("Interface %s updated on node %s; apply the network configuration to activate the change\n", args[0], nodeName)
+		},
+	}
+
+	cmd.Flags().StringVarP(&nodeName, "node", "n", "", "Name of the node")
+	cmd.Flags().BoolVar(&autostart, "autostart", false, "Bring the interface up at boot")
+	addNetworkInterfaceFlags(cmd, &options)
+	cmd.Flags().StringSliceVar(&options.Delete, "delete", nil, "Options to remove, e.g. gateway,comments")
+	//nolint:errcheck // Flags are defined above, so these cannot fail
+	_ = cmd.MarkFlagRequired("node")
+
+	return cmd
+}
+
+// addNetworkInterfaceFlags adds the flags of the interface options; each type accepts only some of them
+func addNetworkInterfaceFlags(cmd *cobra.Command, options *services.NetworkInterfaceOptions) {
+	cmd.Flags().StringVar(&options.CIDR, "cidr", "", "IPv4 address with prefix length, e.g. 10.0.0.2/24")
+	cmd.Flags().StringVar(&options.Gateway, "gateway", "", "IPv4 default gateway")
+	cmd.Flags().StringVar(&options.CIDR6, "cidr6", "", "IPv6 address with prefix length")
+	cmd.Flags().StringVar(&options.Gateway6, "gateway6", "", "IPv6 default gateway")
+	cmd.Flags().IntVar(&options.MTU, "mtu", 0, "MTU of the interface")
+	cmd.Flags().StringSliceVar(&options.BridgePorts, "bridge-ports", nil, "Ports of a bridge, e.g. eno1 or bond0")
+	cmd.Flags().BoolVar(&options.BridgeVLANAware, "bridge-vlan-aware", false, "Make a bridge VLAN aware")
+	cmd.Flags().StringSliceVar(&options.Slaves, "slaves", nil, "Member interfaces of a bond, e.g. eno1,eno2")
+	cmd.Flags().StringVar(&options.BondMode, "bond-mode", "", "Mode of a bond: "+strings.Join(services.BondModes, ", "))
+	cmd.Flags().StringVar(&options.BondPrimary, "bond-primary", "", "Primary member of an active-backup bond")
+	cmd.Flags().StringVar(&options.BondXmitHashPolicy, "bond-hash-policy", "", "Transmit hash policy of balance-xor and 802.3ad bonds: layer2, layer2+3 or layer3+4")
+	cmd.Flags().StringVar(&options.VLANRawDevice, "vlan-raw-device", "", "Device of a VLAN interface")
+	cmd.Flags().IntVar(&options.VLANID, "vlan-id", 0, "Tag of a VLAN interface")
+	cmd.Flags().StringVar(&options.Comments, "comments", "", "Comments of the interface")
+}
+
+// DeleteNetworkInterfaceCommand removes a network interface from a node
+func DeleteNetworkInterfaceCommand() *cobra.Command {
+	var nodeName string
+
+	var cmd = &cobra.Command{
+		Use:   "delete <iface>",
+		Short: "Delete a network interface",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := newNodesService().DeleteNetworkInterface(nodeName, args[0]); err != nil {
+				exitWithError("Failed to delete network interface", err)
+			}
+
+			fmt.Printf("Interface %s deleted on node %s; apply the network configuration to remove it\n", args[0], nodeName)
+		},
+	}
+
+	cmd.Flags().StringVarP(&nodeName, "node", "n", "", "Name of the node")
+	//nolint:errcheck // Flags are defined above, so these cannot fail
+	_ = cmd.MarkFlagRequired("node")
+
+	return cmd
+}
+
+// ApplyNetworkCommand shows and applies the pending network configuration of a node
+func ApplyNetworkCommand() *cobra.Command {
+	var nodeName string
+	var dryRun bool
+	var wait bool
+	var timeout time.Duration
+
+	var cmd = &cobra.Command{
+		Use:   "apply",
+		Short: "Apply the pending network configuration of a node",
+		Long: `Show the pending changes to /etc/network/interfaces as a diff and reload the
+network of the node with them. --dry-run only shows the diff.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			nodesService := newNodesService()
+
+			_, changes, err := nodesService.ListNetworkInterfaces(nodeName, "")
+			if err != nil {
+				exitWithError("Failed to get the pending network changes", err)
+			}
+
+			if changes == "" {
+				fmt.Printf("No pending network changes on node %s\n", nodeName)
+				return
+			}
+			fmt.Print(changes)
+			if !strings.HasSuffix(changes, "\n") {
+				fmt.Println()
+			}
+			if dryRun {
+				return
+			}
+
+			taskID, err := nodesService.ApplyNetworkChanges(nodeName)
+			if err != nil {
+				exitWithError("Failed to apply network configuration", err)
+			}
+
+			finishTask(fmt.Sprintf("Network reload of node %s initiated", nodeName), nodeName, taskID, wait, timeout)
+		},
+	}
+
+	cmd.Flags().StringVarP(&nodeName, "node", "n", "", "Name of the node")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Only show the pending changes")
+	addWaitFlags(cmd, &wait, &timeout)
+	//nolint:errcheck // Flags are defined above, so these cannot fail
+	_ = cmd.MarkFlagRequired("node")
+
+	return cmd
+}
+
+// RevertNetworkCommand discards the pending network configuration of a node
+func RevertNetworkCommand() *cobra.Command {
+	var nodeName string
+
+	var cmd = &cobra.Command{
+		Use:   "revert",
+		Short: "Discard the pending network configuration of a node",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := newNodesService().RevertNetworkChanges(nodeName); err != nil {
+				exitWithError("Failed to revert network configuration", err)
+			}
+
+			fmt.Printf("Pending network changes on node %s discarded\n", nodeName)
+		},
+	}
+
+	cmd.Flags().StringVarP(&nodeName, "node", "n", "", "Name of the node")
+	//nolint:errcheck // Flags are defined above, so these cannot fail
+	_ = cmd.MarkFlagRequired("node")
+
+	return cmd
+}
+
+// newNodesService creates the nodes service, exiting when that fails
+func newNodesService() *services.NodesService {
+	nodesService, err := services.NewNodesService(config.Logger, config.Trust)
+	if err != nil {
+		exitWithError("Failed to initialize nodes service", err)
+	}
+	return nodesService
+}
+
+// Helper function to show the ports of a bridge or the members of a bond
+func formatInterfaceMembers(iface services.NetworkInterface) string {
+	members := iface.BridgePorts
+	if iface.Type == services.NetworkBond {
+		members = iface.Slaves
+	}
+	return strings.Join(strings.Fields(members), ",")
+}
+
+// Helper function to show an interface MTU, which is the kernel default when not set
+func formatInterfaceMTU(mtu int) string {
+	if mtu == 0 {
+		return "default"
+	}
+	return fmt.Sprintf("%d", mtu)
+}
+
+// Helper function to show a gateway, which is optional
+func formatGateway(gateway string) string {
+	if gateway == "" {
+		return "none"
+	}
+	return gateway
+}
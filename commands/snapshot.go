@@ -0,0 +1,178 @@
+package commands
+
+import (
+	"fmt"
+	"proxmox-cli/output"
+	"proxmox-cli/services"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// VMSnapshotCommand creates the parent command for VM snapshot operations
+func VMSnapshotCommand() *cobra.Command {
+	return snapshotCommand(vmGuest)
+}
+
+// ContainerSnapshotCommand creates the parent command for container snapshot operations
+func ContainerSnapshotCommand() *cobra.Command {
+	return snapshotCommand(containerGuest)
+}
+
+// snapshotCommand creates the snapshot command tree for a kind of guest
+func snapshotCommand(guest guestKind) *cobra.Command {
+	var snapshotCmd = &cobra.Command{
+		Use:   "snapshot",
+		Short: "Manage snapshots of a " + guest.longNoun,
+	}
+
+	snapshotCmd.AddCommand(listSnapshotsCommand(guest))
+	snapshotCmd.AddCommand(createSnapshotCommand(guest))
+	snapshotCmd.AddCommand(rollbackSnapshotCommand(guest))
+	snapshotCmd.AddCommand(deleteSnapshotCommand(guest))
+
+	return snapshotCmd
+}
+
+// listSnapshotsCommand lists the snapshots of a guest as a tree
+func listSnapshotsCommand(guest guestKind) *cobra.Command {
+	var target guestTarget
+
+	var cmd = &cobra.Command{
+		Use:   "list",
+		Short: "List the snapshots of a " + guest.longNoun + " as a tree",
+		Run: func(cmd *cobra.Command, args []string) {
+			nodeName, vmid := target.resolve(guest)
+
+			snapshots, err := guest.service().ListSnapshots(nodeName, vmid)
+			if err != nil {
+				exitWithError("Failed to list snapshots", err)
+			}
+
+			columns := []output.Column[services.SnapshotTreeEntry]{
+				{Header: "NAME", Value: formatSnapshotTreeName},
+				{Header: "DATE", Value: func(entry services.SnapshotTreeEntry) string { return formatTime(entry.SnapTime) }},
+				{Header: "RAM", Value: func(entry services.SnapshotTreeEntry) string { return formatYesNo(entry.VMState) }},
+				{Header: "DESCRIPTION", Value: func(entry services.SnapshotTreeEntry) string {
+					// Descriptions may span several lines; the table only shows the first
+					line, _, _ := strings.Cut(entry.Description, "\n")
+					return line
+				}},
+				{Header: "PARENT", Wide: true, Value: func(entry services.SnapshotTreeEntry) string { return entry.Parent }},
+			}
+			renderList(services.SnapshotTree(snapshots), columns)
+		},
+	}
+
+	addGuestTargetFlags(cmd, guest, &target)
+
+	return cmd
+}
+
+// createSnapshotCommand creates a snapshot of a guest
+func createSnapshotCommand(guest guestKind) *cobra.Command {
+	var target guestTarget
+	var description string
+	var vmState bool
+	var wait bool
+	var timeout time.Duration
+
+	var cmd = &cobra.Command{
+		Use:   "create <name>",
+		Short: "Create a snapshot of a " + guest.longNoun,
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			nodeName, vmid := target.resolve(guest)
+
+			taskID, err := guest.service().CreateSnapshot(nodeName, vmid, args[0], description, vmState)
+			if err != nil {
+				exitWithError("Failed to create snapshot", err)
+			}
+
+			finishTask(fmt.Sprintf("Snapshot %s of %s %d initiated", args[0], guest.noun, vmid), nodeName, taskID, wait, timeout)
+		},
+	}
+
+	addGuestTargetFlags(cmd, guest, &target)
+	cmd.Flags().StringVarP(&description, "description", "d", "", "Description of the snapshot")
+	if guest.ramSnapshots {
+		cmd.Flags().BoolVar(&vmState, "vmstate", false, "Include the RAM of the running "+guest.noun)
+	}
+	addWaitFlags(cmd, &wait, &timeout)
+
+	return cmd
+}
+
+// rollbackSnapshotCommand rolls a guest back to a snapshot
+func rollbackSnapshotCommand(guest guestKind) *cobra.Command {
+	var target guestTarget
+	var start bool
+	var wait bool
+	var timeout time.Duration
+
+	long := fmt.Sprintf("Roll a %s back to a snapshot. All changes since the snapshot are lost.", guest.longNoun)
+	if guest.ramSnapshots {
+		long += fmt.Sprintf(`
+Snapshots with RAM state resume the %[1]s as it was; use --start to start the %[1]s after
+rolling back to a snapshot without RAM state.`, guest.noun)
+	}
+
+	var cmd = &cobra.Command{
+		Use:   "rollback <name>",
+		Short: "Roll a " + guest.longNoun + " back to a snapshot",
+		Long:  long,
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			nodeName, vmid := target.resolve(guest)
+
+			taskID, err := guest.service().RollbackSnapshot(nodeName, vmid, args[0], start)
+			if err != nil {
+				exitWithError("Failed to roll back snapshot", err)
+			}
+
+			finishTask(fmt.Sprintf("Rollback of %s %d to snapshot %s initiated", guest.noun, vmid, args[0]), nodeName, taskID, wait, timeout)
+		},
+	}
+
+	addGuestTargetFlags(cmd, guest, &target)
+	cmd.Flags().BoolVar(&start, "start", false, "Start the "+guest.noun+" after the rollback")
+	addWaitFlags(cmd, &wait, &timeout)
+
+	return cmd
+}
+
+// deleteSnapshotCommand deletes a snapshot of a guest
+func deleteSnapshotCommand(guest guestKind) *cobra.Command {
+	var target guestTarget
+	var force bool
+	var wait bool
+	var timeout time.Duration
+
+	var cmd = &cobra.Command{
+		Use:   "delete <name>",
+		Short: "Delete a snapshot of a " + guest.longNoun,
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			nodeName, vmid := target.resolve(guest)
+
+			taskID, err := guest.service().DeleteSnapshot(nodeName, vmid, args[0], force)
+			if err != nil {
+				exitWithError("Failed to delete snapshot", err)
+			}
+
+			finishTask(fmt.Sprintf("Deletion of snapshot %s of %s %d initiated", args[0], guest.noun, vmid), nodeName, taskID, wait, timeout)
+		},
+	}
+
+	addGuestTargetFlags(cmd, guest, &target)
+	cmd.Flags().BoolVar(&force, "force", false, "Remove the snapshot from the configuration even if removing its data fails")
+	addWaitFlags(cmd, &wait, &timeout)
+
+	return cmd
+}
+
+// Helper function to indent a snapshot name by its depth in the snapshot tree
+func formatSnapshotTreeName(entry services.SnapshotTreeEntry) string {
+	return strings.Repeat("  ", entry.Depth) + "`-> " + entry.Name
+}
@@ -2,8 +2,11 @@ package commands
 
 import (
 	"fmt"
+	"os"
 	"proxmox-cli/config"
+	"proxmox-cli/output"
 	"proxmox-cli/services"
+	"strings"
 
 	"github.com/spf13/cobra"
 )
@@ -16,6 +19,10 @@ func StorageCommand() *cobra.Command {
 	}
 
 	storageCmd.AddCommand(ListStorageCommand())
+	storageCmd.AddCommand(ShowStorageCommand())
+	storageCmd.AddCommand(AddStorageCommand())
+	storageCmd.AddCommand(SetStorageCommand())
+	storageCmd.AddCommand(RemoveStorageCommand())
 	storageCmd.AddCommand(StorageContentCommand())
 
 	return storageCmd
@@ -29,38 +36,198 @@ func ListStorageCommand() *cobra.Command {
 		Run: func(cmd *cobra.Command, args []string) {
 			storageService, err := services.NewStorageService(config.Logger, config.Trust)
 			if err != nil {
-				config.Logger.Error("Failed to initialize storage service: ", err)
-				fmt.Println("Error: Failed to initialize storage service")
-				return
+				exitWithError("Failed to initialize storage service", err)
 			}
 
 			storages, err := storageService.ListStorage()
 			if err != nil {
-				config.Logger.Error("Failed to list storage: ", err)
-				fmt.Println("Error: Failed to list storage")
-				return
+				exitWithError("Failed to list storage", err)
 			}
 
-			if len(storages) == 0 {
+			if len(storages) == 0 && output.IsTable(config.Output) {
 				fmt.Println("No storage found")
 				return
 			}
 
-			fmt.Printf("%-20s %-15s %-10s %-10s %-30s\n", "STORAGE", "TYPE", "SHARED", "ACTIVE", "CONTENT")
-			fmt.Println("==========================================================================================")
-			for _, storage := range storages {
-				shared := "No"
-				if storage.Shared == 1 {
-					shared = "Yes"
-				}
-				active := "No"
-				if storage.Active == 1 {
-					active = "Yes"
+			columns := []output.Column[services.Storage]{
+				{Header: "STORAGE", Value: func(storage services.Storage) string { return storage.Storage }},
+				{Header: "TYPE", Value: func(storage services.Storage) string { return storage.Type }},
+				{Header: "SHARED", Value: func(storage services.Storage) string { return formatYesNo(storage.Shared) }},
+				{Header: "ACTIVE", Value: func(storage services.Storage) string { return formatYesNo(storage.Active) }},
+				{Header: "CONTENT", Value: func(storage services.Storage) string { return storage.Content }},
+				{Header: "ENABLED", Wide: true, Value: func(storage services.Storage) string { return formatYesNo(storage.Enabled) }},
+			}
+			renderList(storages, columns)
+		},
+	}
+
+	return cmd
+}
+
+// ShowStorageCommand shows the configuration of a storage
+func ShowStorageCommand() *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   "show <storage>",
+		Short: "Show the configuration of a storage",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			storageConfig, err := newStorageService().GetStorage(args[0])
+			if err != nil {
+				exitWithError("Failed to get storage", err)
+			}
+
+			renderObject(storageConfig, func() {
+				fmt.Printf("Storage: %s\n", args[0])
+				fmt.Println("================================================================================")
+				fmt.Printf("%-17s%s\n", "type:", storageConfig.Type())
+				for _, setting := range storageConfig.Settings() {
+					fmt.Printf("%-17s%s\n", setting.Option+":", setting.Value)
 				}
+			})
+		},
+	}
 
-				fmt.Printf("%-20s %-15s %-10s %-10s %-30s\n",
-					storage.Storage, storage.Type, shared, active, storage.Content)
+	return cmd
+}
+
+// AddStorageCommand adds a storage definition
+func AddStorageCommand() *cobra.Command {
+	var storageType string
+	var enabled bool
+	var options services.StorageOptions
+
+	var cmd = &cobra.Command{
+		Use:   "add <storage>",
+		Short: "Add a storage",
+		Long: fmt.Sprintf(`Add a storage to the cluster. The storage type is one of %s, and
+each type has its own options: dir needs --path, nfs --server and --export, cifs
+--server and --share, lvm --vgname, lvmthin --vgname and --thinpool, zfspool and rbd
+--pool and pbs --server, --datastore and --username:
+  proxmox-cli storage add isos -t nfs --server 10.0.0.5 --export /srv/isos --content iso,vztmpl
+  proxmox-cli storage add backups -t pbs --server pbs.example.com --datastore main --username backup@pbs --password-file pbs.pw --fingerprint ...`,
+			strings.Join(services.StorageTypes, ", ")),
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if cmd.Flags().Changed("enabled") {
+				options.Enabled = &enabled
 			}
+			readStoragePassword(cmd, &options)
+
+			if err := newStorageService().CreateStorage(args[0], storageType, options); err != nil {
+				exitWithError("Failed to add storage", err)
+			}
+
+			fmt.Printf("Storage %s added\n", args[0])
+		},
+	}
+
+	cmd.Flags().StringVarP(&storageType, "type", "t", "", "Type of the storage: "+strings.Join(services.StorageTypes, ", "))
+	cmd.Flags().BoolVar(&enabled, "enabled", true, "Enable the storage")
+	addStorageOptionFlags(cmd, &options)
+	//nolint:errcheck // Flags are defined above, so these cannot fail
+	_ = cmd.MarkFlagRequired("type")
+
+	return cmd
+}
+
+// SetStorageCommand changes the options of a storage definition
+func SetStorageCommand() *cobra.Command {
+	var enabled bool
+	var options services.StorageOptions
+
+	var cmd = &cobra.Command{
+		Use:   "set <storage>",
+		Short: "Update the options of a storage",
+		Long: `Update the options of a storage. Only the given options change, and --delete
+removes options. The type of a storage and options such as its path, export, volume
+group or pool cannot be changed:
+  proxmox-cli storage set local --content iso,vztmpl,backup --prune-backups keep-last=3
+  proxmox-cli storage set isos --enabled=false`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if cmd.Flags().Changed("enabled") {
+				options.Enabled = &enabled
+			}
+			readStoragePassword(cmd, &options)
+
+			if err := newStorageService().UpdateStorage(args[0], options); err != nil {
+				exitWithError("Failed to update storage", err)
+			}
+
+			fmt.Printf("Storage %s updated\n", args[0])
+		},
+	}
+
+	cmd.Flags().BoolVar(&enabled, "enabled", true, "Enable or disable the storage")
+	addStorageOptionFlags(cmd, &options)
+	cmd.Flags().StringSliceVar(&options.Delete, "delete", nil, "Options to remove, e.g. nodes,prune-backups")
+
+	return cmd
+}
+
+// addStorageOptionFlags adds the flags of the storage options; each type accepts only some of them
+func addStorageOptionFlags(cmd *cobra.Command, options *services.StorageOptions) {
+	cmd.Flags().StringSliceVar(&options.Content, "content", nil, "Content types, e.g. images,rootdir,iso,vztmpl,backup,snippets")
+	cmd.Flags().StringSliceVar(&options.Nodes, "nodes", nil, "Nodes the storage is available on (default all)")
+	cmd.Flags().BoolVar(&options.Shared, "shared", false, "Mark dir or lvm storage as shared by all nodes")
+	cmd.Flags().StringVar(&options.Path, "path", "", "Directory of dir storage, or mount point of nfs and cifs storage")
+	cmd.Flags().StringVar(&options.Server, "server", "", "Server of nfs, cifs and pbs storage")
+	cmd.Flags().StringVar(&options.Export, "export", "", "NFS export of nfs storage")
+	cmd.Flags().StringVar(&options.Share, "share", "", "Share of cifs storage")
+	cmd.Flags().StringVar(&options.Username, "username", "", "User of cifs, rbd and pbs storage")
+	cmd.Flags().String("password-file", "", "File holding the password of cifs and pbs storage")
+	cmd.Flags().StringVar(&options.Domain, "domain", "", "Domain of cifs storage")
+	cmd.Flags().StringVar(&options.SMBVersion, "smbversion", "", "SMB protocol version of cifs storage, e.g. 3")
+	cmd.Flags().StringVar(&options.Subdir, "subdir", "", "Subdirectory of the share of cifs storage")
+	cmd.Flags().StringVar(&options.MountOptions, "mount-options", "", "Mount options of nfs and cifs storage")
+	cmd.Flags().StringVar(&options.Preallocation, "preallocation", "", "Preallocation of file based disk images: off, metadata, falloc or full")
+	cmd.Flags().StringVar((*string)(&options.PruneBackups), "prune-backups", "", "Backup retention, e.g. keep-daily=7,keep-weekly=4")
+	cmd.Flags().StringVar(&options.VGName, "vgname", "", "Volume group of lvm and lvmthin storage")
+	cmd.Flags().StringVar(&options.Base, "base", "", "Base volume of lvm storage")
+	cmd.Flags().StringVar(&options.ThinPool, "thinpool", "", "Thin pool of lvmthin storage")
+	cmd.Flags().BoolVar(&options.SafeRemove, "saferemove", false, "Zero removed volumes of lvm storage")
+	cmd.Flags().StringVar(&options.Pool, "pool", "", "Pool of zfspool and rbd storage")
+	cmd.Flags().BoolVar(&options.Sparse, "sparse", false, "Use thin provisioned volumes on zfspool storage")
+	cmd.Flags().StringVar(&options.Blocksize, "blocksize", "", "Block size of zfspool volumes, e.g. 16k")
+	cmd.Flags().StringVar(&options.MountPoint, "mountpoint", "", "Mount point of the pool of zfspool storage")
+	cmd.Flags().StringSliceVar(&options.MonHost, "monhost", nil, "Ceph monitors of external rbd storage")
+	cmd.Flags().BoolVar(&options.KRBD, "krbd", false, "Access rbd storage through the kernel module")
+	cmd.Flags().StringVar(&options.Namespace, "namespace", "", "Namespace of rbd and pbs storage")
+	cmd.Flags().StringVar(&options.DataPool, "data-pool", "", "Data pool of erasure coded rbd storage")
+	cmd.Flags().StringVar(&options.Datastore, "datastore", "", "Datastore of pbs storage")
+	cmd.Flags().StringVar(&options.Fingerprint, "fingerprint", "", "Certificate fingerprint of the Proxmox Backup Server")
+	cmd.Flags().IntVar(&options.Port, "port", 0, "Port of the Proxmox Backup Server")
+}
+
+// readStoragePassword reads the password given with --password-file, exiting when that fails.
+// The password is read from a file so it does not end up in the shell history.
+func readStoragePassword(cmd *cobra.Command, options *services.StorageOptions) {
+	passwordFile, _ := cmd.Flags().GetString("password-file")
+	if passwordFile == "" {
+		return
+	}
+
+	password, err := os.ReadFile(passwordFile)
+	if err != nil {
+		exitWithError("Failed to read password file", err)
+	}
+	options.Password = strings.TrimRight(string(password), "\r\n")
+}
+
+// RemoveStorageCommand removes a storage definition
+func RemoveStorageCommand() *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   "remove <storage>",
+		Short: "Remove a storage",
+		Long: `Remove a storage from the cluster configuration. The data on the storage is
+not deleted, and guests with disks on it keep referring to it.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := newStorageService().DeleteStorage(args[0]); err != nil {
+				exitWithError("Failed to remove storage", err)
+			}
+
+			fmt.Printf("Storage %s removed\n", args[0])
 		},
 	}
 
@@ -83,34 +250,32 @@ func StorageContentCommand() *cobra.Command {
 
 			storageService, err := services.NewStorageService(config.Logger, config.Trust)
 			if err != nil {
-				config.Logger.Error("Failed to initialize storage service: ", err)
-				fmt.Println("Error: Failed to initialize storage service")
-				return
+				exitWithError("Failed to initialize storage service", err)
 			}
 
 			contents, err := storageService.ListStorageContent(nodeName, storageName)
 			if err != nil {
-				config.Logger.Error("Failed to list storage content: ", err)
-				fmt.Println("Error: Failed to list storage content")
-				return
+				exitWithError("Failed to list storage content", err)
 			}
 
-			if len(contents) == 0 {
+			if len(contents) == 0 && output.IsTable(config.Output) {
 				fmt.Printf("No content found in storage: %s\n", storageName)
 				return
 			}
 
-			fmt.Printf("%-50s %-15s %-15s %-8s\n", "VOLUME ID", "FORMAT", "SIZE", "VMID")
-			fmt.Println("==========================================================================================")
-			for _, content := range contents {
-				vmid := "-"
-				if content.VMID != 0 {
-					vmid = fmt.Sprintf("%d", content.VMID)
-				}
-
-				fmt.Printf("%-50s %-15s %-15s %-8s\n",
-					content.VolID, content.Format, formatBytes(content.Size), vmid)
+			columns := []output.Column[services.StorageContent]{
+				{Header: "VOLUME ID", Value: func(content services.StorageContent) string { return content.VolID }},
+				{Header: "FORMAT", Value: func(content services.StorageContent) string { return content.Format }},
+				{Header: "SIZE", Value: func(content services.StorageContent) string { return formatBytes(content.Size) }},
+				{Header: "VMID", Value: func(content services.StorageContent) string {
+					if content.VMID == 0 {
+						return "-"
+					}
+					return fmt.Sprintf("%d", content.VMID)
+				}},
+				{Header: "USED", Wide: true, Value: func(content services.StorageContent) string { return formatBytes(content.Used) }},
 			}
+			renderList(contents, columns)
 		},
 	}
 
@@ -123,3 +288,20 @@ func StorageContentCommand() *cobra.Command {
 
 	return cmd
 }
+
+// newStorageService creates the storage service, exiting when that fails
+func newStorageService() *services.StorageService {
+	storageService, err := services.NewStorageService(config.Logger, config.Trust)
+	if err != nil {
+		exitWithError("Failed to initialize storage service", err)
+	}
+	return storageService
+}
+
+// Helper function to format a Proxmox 0/1 flag as Yes or No
+func formatYesNo(flag int) string {
+	if flag == 1 {
+		return "Yes"
+	}
+	return "No"
+}
@@ -0,0 +1,246 @@
+package commands
+
+import (
+	"fmt"
+	"proxmox-cli/config"
+	"proxmox-cli/output"
+	"proxmox-cli/services"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// TaskCommand creates the parent command for task operations
+func TaskCommand() *cobra.Command {
+	var taskCmd = &cobra.Command{
+		Use:   "task",
+		Short: "Track Proxmox tasks",
+		Long: `Long running operations such as starting or deleting a VM run as Proxmox tasks,
+identified by a UPID. These commands list tasks and show, follow or stop a task by its UPID.`,
+	}
+
+	taskCmd.AddCommand(ListTasksCommand())
+	taskCmd.AddCommand(TaskStatusCommand())
+	taskCmd.AddCommand(TaskLogCommand())
+	taskCmd.AddCommand(StopTaskCommand())
+
+	return taskCmd
+}
+
+// ListTasksCommand lists the recent tasks of a node
+func ListTasksCommand() *cobra.Command {
+	var nodeName string
+	var options services.TaskListOptions
+
+	var cmd = &cobra.Command{
+		Use:   "list",
+		Short: "List recent tasks on a node",
+		Run: func(cmd *cobra.Command, args []string) {
+			taskService, err := services.NewTaskService(config.Logger, config.Trust)
+			if err != nil {
+				exitWithError("Failed to initialize task service", err)
+			}
+
+			tasks, err := taskService.ListTasks(nodeName, options)
+			if err != nil {
+				exitWithError("Failed to list tasks", err)
+			}
+
+			if len(tasks) == 0 && output.IsTable(config.Output) {
+				fmt.Printf("No tasks found on node: %s\n", nodeName)
+				return
+			}
+
+			columns := []output.Column[services.Task]{
+				{Header: "STARTED", Value: func(task services.Task) string { return formatTime(task.StartTime) }},
+				{Header: "TYPE", Value: func(task services.Task) string { return task.Type }},
+				{Header: "ID", Value: func(task services.Task) string { return task.ID }},
+				{Header: "USER", Value: func(task services.Task) string { return task.User }},
+				{Header: "STATUS", Value: func(task services.Task) string { return formatTaskListStatus(task) }},
+				{Header: "ENDED", Wide: true, Value: func(task services.Task) string { return formatTime(task.EndTime) }},
+				{Header: "UPID", Wide: true, Value: func(task services.Task) string { return task.UPID }},
+			}
+			renderList(tasks, columns)
+		},
+	}
+
+	cmd.Flags().StringVarP(&nodeName, "node", "n", "", "Name of the node")
+	cmd.Flags().IntVarP(&options.Limit, "limit", "l", 50, "Maximum number of tasks to list")
+	cmd.Flags().IntVarP(&options.VMID, "vmid", "i", 0, "Only list tasks of this VM ID")
+	cmd.Flags().BoolVar(&options.Errors, "errors", false, "Only list failed tasks")
+	//nolint:errcheck // Flags are defined above, so these cannot fail
+	_ = cmd.MarkFlagRequired("node")
+
+	return cmd
+}
+
+// TaskStatusCommand gets the status of a task
+func TaskStatusCommand() *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   "status <upid>",
+		Short: "Get the status of a task",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			upid := args[0]
+			nodeName, err := services.UPIDNode(upid)
+			if err != nil {
+				exitWithError("Invalid task ID", err)
+			}
+
+			taskService, err := services.NewTaskService(config.Logger, config.Trust)
+			if err != nil {
+				exitWithError("Failed to initialize task service", err)
+			}
+
+			status, err := taskService.GetTaskStatus(nodeName, upid)
+			if err != nil {
+				exitWithError("Failed to get task status", err)
+			}
+
+			renderObject(status, func() {
+				fmt.Printf("Task Status for: %s\n", upid)
+				fmt.Println("================================================================================")
+				fmt.Printf("Node:            %s\n", status.Node)
+				fmt.Printf("Type:            %s\n", status.Type)
+				if status.ID != "" {
+					fmt.Printf("ID:              %s\n", status.ID)
+				}
+				fmt.Printf("User:            %s\n", status.User)
+				fmt.Printf("Started:         %s\n", formatTime(status.StartTime))
+				fmt.Printf("Status:          %s\n", status.Status)
+				if !status.Running() {
+					fmt.Printf("Exit Status:     %s\n", status.ExitStatus)
+				}
+			})
+		},
+	}
+
+	return cmd
+}
+
+// TaskLogCommand prints the log of a task
+func TaskLogCommand() *cobra.Command {
+	var follow bool
+	var timeout time.Duration
+
+	var cmd = &cobra.Command{
+		Use:   "log <upid>",
+		Short: "Show the log of a task",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			upid := args[0]
+			nodeName, err := services.UPIDNode(upid)
+			if err != nil {
+				exitWithError("Invalid task ID", err)
+			}
+
+			if follow {
+				waitForTask(nodeName, upid, timeout)
+				return
+			}
+
+			taskService, err := services.NewTaskService(config.Logger, config.Trust)
+			if err != nil {
+				exitWithError("Failed to initialize task service", err)
+			}
+
+			// Page through the log, as Proxmox limits the number of lines per request
+			start := 0
+			for {
+				lines, err := taskService.GetTaskLog(nodeName, upid, start, services.TaskLogPageSize)
+				if err != nil {
+					exitWithError("Failed to get task log", err)
+				}
+				for _, line := range lines {
+					fmt.Println(line.T)
+					start = line.N
+				}
+				if len(lines) < services.TaskLogPageSize {
+					return
+				}
+			}
+		},
+	}
+
+	cmd.Flags().BoolVarP(&follow, "follow", "f", false, "Follow the log until the task finishes")
+	cmd.Flags().DurationVar(&timeout, "timeout", 0, "Maximum time to follow the task (0 waits indefinitely)")
+
+	return cmd
+}
+
+// StopTaskCommand stops a running task
+func StopTaskCommand() *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   "stop <upid>",
+		Short: "Stop a running task",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			upid := args[0]
+			nodeName, err := services.UPIDNode(upid)
+			if err != nil {
+				exitWithError("Invalid task ID", err)
+			}
+
+			taskService, err := services.NewTaskService(config.Logger, config.Trust)
+			if err != nil {
+				exitWithError("Failed to initialize task service", err)
+			}
+
+			if err = taskService.StopTask(nodeName, upid); err != nil {
+				exitWithError("Failed to stop task", err)
+			}
+
+			fmt.Printf("Stop requested for task: %s\n", upid)
+		},
+	}
+
+	return cmd
+}
+
+// addWaitFlags adds the --wait and --timeout flags to a command that starts a task
+func addWaitFlags(cmd *cobra.Command, wait *bool, timeout *time.Duration) {
+	cmd.Flags().BoolVarP(wait, "wait", "w", false, "Wait for the task to finish and show its log")
+	cmd.Flags().DurationVar(timeout, "timeout", 5*time.Minute, "Maximum time to wait with --wait (0 waits indefinitely)")
+}
+
+// finishTask reports a task started by a command. With --wait it blocks until the
+// task finishes, streaming its log, and exits non-zero when the task fails.
+func finishTask(message, nodeName, taskID string, wait bool, timeout time.Duration) {
+	fmt.Printf("%s. Task ID: %s\n", message, taskID)
+	if wait {
+		waitForTask(nodeName, taskID, timeout)
+	}
+}
+
+// waitForTask follows a task until it finishes and exits non-zero when it fails or times out
+func waitForTask(nodeName, upid string, timeout time.Duration) {
+	taskService, err := services.NewTaskService(config.Logger, config.Trust)
+	if err != nil {
+		exitWithError("Failed to initialize task service", err)
+	}
+
+	status, err := taskService.WaitForTask(nodeName, upid, timeout, func(line services.TaskLogLine) {
+		fmt.Println(line.T)
+	})
+	if err != nil {
+		exitWithError("Task did not complete", err)
+	}
+
+	fmt.Printf("Task finished: %s\n", status.ExitStatus)
+}
+
+// Helper function to show the outcome of a task list entry
+func formatTaskListStatus(task services.Task) string {
+	if task.EndTime == 0 {
+		return "running"
+	}
+	return task.Status
+}
+
+// Helper function to format a Unix timestamp as local time
+func formatTime(timestamp int64) string {
+	if timestamp == 0 {
+		return "-"
+	}
+	return time.Unix(timestamp, 0).Format("2006-01-02 15:04:05")
+}
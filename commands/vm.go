@@ -1,9 +1,13 @@
 package commands
 
 import (
+	"errors"
 	"fmt"
 	"proxmox-cli/config"
+	"proxmox-cli/output"
 	"proxmox-cli/services"
+	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 )
@@ -17,6 +21,12 @@ func VMCommand() *cobra.Command {
 
 	vmCmd.AddCommand(ListVMsCommand())
 	vmCmd.AddCommand(VMStatusCommand())
+	vmCmd.AddCommand(CreateVMCommand())
+	vmCmd.AddCommand(VMConfigCommand())
+	vmCmd.AddCommand(CloneVMCommand())
+	vmCmd.AddCommand(TemplateVMCommand())
+	vmCmd.AddCommand(VMSnapshotCommand())
+	vmCmd.AddCommand(MigrateVMCommand())
 	vmCmd.AddCommand(StartVMCommand())
 	vmCmd.AddCommand(StopVMCommand())
 	vmCmd.AddCommand(ShutdownVMCommand())
@@ -44,36 +54,31 @@ func ListVMsCommand() *cobra.Command {
 
 			vmService, err := services.NewVMService(config.Logger, config.Trust)
 			if err != nil {
-				config.Logger.Error("Failed to initialize VM service: ", err)
-				fmt.Println("Error: Failed to initialize VM service")
-				return
+				exitWithError("Failed to initialize VM service", err)
 			}
 
 			vms, err := vmService.ListVMs(nodeName)
 			if err != nil {
-				config.Logger.Error("Failed to list VMs: ", err)
-				fmt.Println("Error: Failed to list VMs")
-				return
+				exitWithError("Failed to list VMs", err)
 			}
 
-			if len(vms) == 0 {
+			if len(vms) == 0 && output.IsTable(config.Output) {
 				fmt.Printf("No VMs found on node: %s\n", nodeName)
 				return
 			}
 
-			fmt.Printf("%-8s %-20s %-10s %-10s %-15s %-15s\n", "VMID", "NAME", "STATUS", "CPU %", "MEMORY", "UPTIME")
-			fmt.Println("========================================================================================")
-			for _, vm := range vms {
-				cpuPercent := fmt.Sprintf("%.2f%%", vm.CPU*100)
-				memUsage := ""
-				if vm.MaxMem > 0 {
-					memUsage = fmt.Sprintf("%.2f%%", float64(vm.Mem)/float64(vm.MaxMem)*100)
-				}
-				uptime := formatUptime(vm.Uptime)
-
-				fmt.Printf("%-8d %-20s %-10s %-10s %-15s %-15s\n",
-					vm.VMID, vm.Name, vm.Status, cpuPercent, memUsage, uptime)
+			columns := []output.Column[services.VM]{
+				{Header: "VMID", Value: func(vm services.VM) string { return fmt.Sprintf("%d", vm.VMID) }},
+				{Header: "NAME", Value: func(vm services.VM) string { return vm.Name }},
+				{Header: "STATUS", Value: func(vm services.VM) string { return vm.Status }},
+				{Header: "CPU %", Value: func(vm services.VM) string { return fmt.Sprintf("%.2f%%", vm.CPU*100) }},
+				{Header: "MEMORY", Value: func(vm services.VM) string { return formatPercent(vm.Mem, vm.MaxMem) }},
+				{Header: "UPTIME", Value: func(vm services.VM) string { return formatUptime(vm.Uptime) }},
+				{Header: "CPUS", Wide: true, Value: func(vm services.VM) string { return fmt.Sprintf("%d", vm.CPUs) }},
+				{Header: "MAX MEMORY", Wide: true, Value: func(vm services.VM) string { return formatBytes(vm.MaxMem) }},
+				{Header: "MAX DISK", Wide: true, Value: func(vm services.VM) string { return formatBytes(vm.MaxDisk) }},
 			}
+			renderList(vms, columns)
 		},
 	}
 
@@ -86,394 +91,301 @@ func ListVMsCommand() *cobra.Command {
 
 // VMStatusCommand gets the status of a specific VM
 func VMStatusCommand() *cobra.Command {
-	var nodeName string
-	var vmid int
+	var target guestTarget
 
 	var cmd = &cobra.Command{
 		Use:   "status",
 		Short: "Get the status of a specific VM",
 		Run: func(cmd *cobra.Command, args []string) {
-			if nodeName == "" {
-				fmt.Println("Error: node name is required")
-				return
-			}
-			if vmid == 0 {
-				fmt.Println("Error: VM ID is required")
-				return
-			}
+			nodeName, vmid := target.resolve(vmGuest)
 
 			vmService, err := services.NewVMService(config.Logger, config.Trust)
 			if err != nil {
-				config.Logger.Error("Failed to initialize VM service: ", err)
-				fmt.Println("Error: Failed to initialize VM service")
-				return
+				exitWithError("Failed to initialize VM service", err)
 			}
 
 			status, err := vmService.GetVMStatus(nodeName, vmid)
 			if err != nil {
-				config.Logger.Error("Failed to get VM status: ", err)
-				fmt.Println("Error: Failed to get VM status")
-				return
-			}
-
-			fmt.Printf("VM Status for VMID: %d\n", vmid)
-			fmt.Println("================================================================================")
-			fmt.Printf("Name:            %s\n", status.Name)
-			fmt.Printf("Status:          %s\n", status.Status)
-			fmt.Printf("QMP Status:      %s\n", status.QMPStatus)
-			fmt.Printf("CPU Usage:       %.2f%%\n", status.CPU*100)
-			fmt.Printf("CPU Cores:       %d\n", status.CPUs)
-			if status.MaxMem > 0 {
-				fmt.Printf("Memory Used:     %s / %s (%.2f%%)\n",
-					formatBytes(status.Mem), formatBytes(status.MaxMem),
-					float64(status.Mem)/float64(status.MaxMem)*100)
-			}
-			fmt.Printf("Uptime:          %s\n", formatUptime(status.Uptime))
+				exitWithError("Failed to get VM status", err)
+			}
+
+			renderObject(status, func() {
+				fmt.Printf("VM Status for VMID: %d\n", vmid)
+				fmt.Println("================================================================================")
+				fmt.Printf("Name:            %s\n", status.Name)
+				fmt.Printf("Status:          %s\n", status.Status)
+				fmt.Printf("QMP Status:      %s\n", status.QMPStatus)
+				fmt.Printf("CPU Usage:       %.2f%%\n", status.CPU*100)
+				fmt.Printf("CPU Cores:       %d\n", status.CPUs)
+				if status.MaxMem > 0 {
+					fmt.Printf("Memory Used:     %s / %s (%.2f%%)\n",
+						formatBytes(status.Mem), formatBytes(status.MaxMem),
+						float64(status.Mem)/float64(status.MaxMem)*100)
+				}
+				fmt.Printf("Uptime:          %s\n", formatUptime(status.Uptime))
+			})
 		},
 	}
 
-	cmd.Flags().StringVarP(&nodeName, "node", "n", "", "Name of the node")
-	cmd.Flags().IntVarP(&vmid, "vmid", "i", 0, "VM ID")
-	//nolint:errcheck // Flags are defined above, so these cannot fail
-	_ = cmd.MarkFlagRequired("node")
-	//nolint:errcheck // Flags are defined above, so these cannot fail
-	_ = cmd.MarkFlagRequired("vmid")
+	addGuestTargetFlags(cmd, vmGuest, &target)
 
 	return cmd
 }
 
-// StartVMCommand starts a VM
-func StartVMCommand() *cobra.Command {
+// CreateVMCommand creates a VM
+func CreateVMCommand() *cobra.Command {
 	var nodeName string
 	var vmid int
+	var vmConfig services.VMConfig
+	var disks []string
+	var networks []string
+	var wait bool
+	var timeout time.Duration
 
 	var cmd = &cobra.Command{
-		Use:   "start",
-		Short: "Start a virtual machine",
+		Use:   "create",
+		Short: "Create a virtual machine",
+		Long: `Create a virtual machine on a node. Without --vmid the next free ID of the cluster is used.
+
+Disks and network interfaces are given as <slot>=<spec> and can be repeated:
+  proxmox-cli vm create -n pve1 --name web --memory 2048 --cores 2 \
+    --disk scsi0=local-lvm:32 --net net0=virtio,bridge=vmbr0 \
+    --iso local:iso/debian-12.iso --boot "scsi0;ide2;net0"`,
 		Run: func(cmd *cobra.Command, args []string) {
-			if nodeName == "" || vmid == 0 {
-				fmt.Println("Error: node name and VM ID are required")
-				return
+			var err error
+			if vmConfig.Disks, err = parseKeyValues("--disk", disks); err != nil {
+				exitWithError("Invalid disk", err)
+			}
+			if vmConfig.Networks, err = parseKeyValues("--net", networks); err != nil {
+				exitWithError("Invalid network interface", err)
 			}
 
 			vmService, err := services.NewVMService(config.Logger, config.Trust)
 			if err != nil {
-				config.Logger.Error("Failed to initialize VM service: ", err)
-				fmt.Println("Error: Failed to initialize VM service")
-				return
+				exitWithError("Failed to initialize VM service", err)
 			}
 
-			taskID, err := vmService.StartVM(nodeName, vmid)
+			vmid, taskID, err := vmService.CreateVM(nodeName, vmid, vmConfig)
 			if err != nil {
-				config.Logger.Error("Failed to start VM: ", err)
-				fmt.Println("Error: Failed to start VM")
-				return
+				exitWithError("Failed to create VM", err)
 			}
 
-			fmt.Printf("VM %d start initiated. Task ID: %s\n", vmid, taskID)
+			finishTask(fmt.Sprintf("VM %d creation initiated", vmid), nodeName, taskID, wait, timeout)
 		},
 	}
 
 	cmd.Flags().StringVarP(&nodeName, "node", "n", "", "Name of the node")
-	cmd.Flags().IntVarP(&vmid, "vmid", "i", 0, "VM ID")
+	cmd.Flags().IntVarP(&vmid, "vmid", "i", 0, "VM ID (defaults to the next free ID)")
+	cmd.Flags().StringVar(&vmConfig.Name, "name", "", "Name of the VM")
+	cmd.Flags().IntVar(&vmConfig.Memory, "memory", 0, "Memory in MiB")
+	cmd.Flags().IntVar(&vmConfig.Cores, "cores", 0, "Number of cores per socket")
+	cmd.Flags().IntVar(&vmConfig.Sockets, "sockets", 0, "Number of CPU sockets")
+	cmd.Flags().StringVar(&vmConfig.OSType, "ostype", "", "Guest OS type (e.g. l26, win11, other)")
+	cmd.Flags().StringArrayVar(&disks, "disk", nil, "Disk as <slot>=<spec>, e.g. scsi0=local-lvm:32 (repeatable)")
+	cmd.Flags().StringArrayVar(&networks, "net", nil, "Network interface as <slot>=<spec>, e.g. net0=virtio,bridge=vmbr0 (repeatable)")
+	cmd.Flags().StringVar(&vmConfig.CDROM, "iso", "", "ISO image for the CD-ROM drive, e.g. local:iso/debian-12.iso")
+	cmd.Flags().StringVar(&vmConfig.Boot, "boot", "", "Boot order, e.g. \"scsi0;ide2;net0\"")
+	cmd.Flags().StringVar(&vmConfig.Description, "description", "", "Description of the VM")
+	addWaitFlags(cmd, &wait, &timeout)
 	//nolint:errcheck // Flags are defined above, so these cannot fail
 	_ = cmd.MarkFlagRequired("node")
-	//nolint:errcheck // Flags are defined above, so these cannot fail
-	_ = cmd.MarkFlagRequired("vmid")
 
 	return cmd
 }
 
-// StopVMCommand stops a VM
-func StopVMCommand() *cobra.Command {
-	var nodeName string
-	var vmid int
-
-	var cmd = &cobra.Command{
-		Use:   "stop",
-		Short: "Stop a virtual machine",
-		Run: func(cmd *cobra.Command, args []string) {
-			if nodeName == "" || vmid == 0 {
-				fmt.Println("Error: node name and VM ID are required")
-				return
-			}
-
-			vmService, err := services.NewVMService(config.Logger, config.Trust)
-			if err != nil {
-				config.Logger.Error("Failed to initialize VM service: ", err)
-				fmt.Println("Error: Failed to initialize VM service")
-				return
-			}
-
-			taskID, err := vmService.StopVM(nodeName, vmid)
-			if err != nil {
-				config.Logger.Error("Failed to stop VM: ", err)
-				fmt.Println("Error: Failed to stop VM")
-				return
-			}
-
-			fmt.Printf("VM %d stop initiated. Task ID: %s\n", vmid, taskID)
-		},
-	}
-
-	cmd.Flags().StringVarP(&nodeName, "node", "n", "", "Name of the node")
-	cmd.Flags().IntVarP(&vmid, "vmid", "i", 0, "VM ID")
-	//nolint:errcheck // Flags are defined above, so these cannot fail
-	_ = cmd.MarkFlagRequired("node")
-	//nolint:errcheck // Flags are defined above, so these cannot fail
-	_ = cmd.MarkFlagRequired("vmid")
-
-	return cmd
+// CloneVMCommand clones a VM or template
+func CloneVMCommand() *cobra.Command {
+	return cloneCommand(vmGuest, "proxmox-cli vm clone -n pve1 -i 9000 --name test-42 --full --storage local-lvm --wait")
 }
 
-// ShutdownVMCommand gracefully shuts down a VM
-func ShutdownVMCommand() *cobra.Command {
-	var nodeName string
-	var vmid int
+// TemplateVMCommand converts a VM into a template
+func TemplateVMCommand() *cobra.Command {
+	var target guestTarget
+	var wait bool
+	var timeout time.Duration
 
 	var cmd = &cobra.Command{
-		Use:   "shutdown",
-		Short: "Gracefully shutdown a virtual machine",
+		Use:   "template",
+		Short: "Convert a stopped virtual machine into a template",
 		Run: func(cmd *cobra.Command, args []string) {
-			if nodeName == "" || vmid == 0 {
-				fmt.Println("Error: node name and VM ID are required")
-				return
-			}
+			nodeName, vmid := target.resolve(vmGuest)
 
 			vmService, err := services.NewVMService(config.Logger, config.Trust)
 			if err != nil {
-				config.Logger.Error("Failed to initialize VM service: ", err)
-				fmt.Println("Error: Failed to initialize VM service")
-				return
+				exitWithError("Failed to initialize VM service", err)
 			}
 
-			taskID, err := vmService.ShutdownVM(nodeName, vmid)
+			taskID, err := vmService.ConvertToTemplate(nodeName, vmid)
 			if err != nil {
-				config.Logger.Error("Failed to shutdown VM: ", err)
-				fmt.Println("Error: Failed to shutdown VM")
-				return
+				exitWithError("Failed to convert VM to template", err)
 			}
 
-			fmt.Printf("VM %d shutdown initiated. Task ID: %s\n", vmid, taskID)
+			finishTask(fmt.Sprintf("VM %d conversion to template initiated", vmid), nodeName, taskID, wait, timeout)
 		},
 	}
 
-	cmd.Flags().StringVarP(&nodeName, "node", "n", "", "Name of the node")
-	cmd.Flags().IntVarP(&vmid, "vmid", "i", 0, "VM ID")
-	//nolint:errcheck // Flags are defined above, so these cannot fail
-	_ = cmd.MarkFlagRequired("node")
-	//nolint:errcheck // Flags are defined above, so these cannot fail
-	_ = cmd.MarkFlagRequired("vmid")
+	addGuestTargetFlags(cmd, vmGuest, &target)
+	addWaitFlags(cmd, &wait, &timeout)
 
 	return cmd
 }
 
-// RebootVMCommand reboots a VM
-func RebootVMCommand() *cobra.Command {
-	var nodeName string
-	var vmid int
-
-	var cmd = &cobra.Command{
-		Use:   "reboot",
-		Short: "Reboot a virtual machine",
-		Run: func(cmd *cobra.Command, args []string) {
-			if nodeName == "" || vmid == 0 {
-				fmt.Println("Error: node name and VM ID are required")
-				return
-			}
-
-			vmService, err := services.NewVMService(config.Logger, config.Trust)
-			if err != nil {
-				config.Logger.Error("Failed to initialize VM service: ", err)
-				fmt.Println("Error: Failed to initialize VM service")
-				return
-			}
-
-			taskID, err := vmService.RebootVM(nodeName, vmid)
-			if err != nil {
-				config.Logger.Error("Failed to reboot VM: ", err)
-				fmt.Println("Error: Failed to reboot VM")
-				return
-			}
+// StartVMCommand starts one or many VMs
+func StartVMCommand() *cobra.Command {
+	return vmPowerCommand("start", "Start virtual machines", "running", (*services.VMService).StartVM)
+}
 
-			fmt.Printf("VM %d reboot initiated. Task ID: %s\n", vmid, taskID)
-		},
-	}
+// StopVMCommand stops one or many VMs
+func StopVMCommand() *cobra.Command {
+	return vmPowerCommand("stop", "Stop virtual machines", "stopped", (*services.VMService).StopVM)
+}
 
-	cmd.Flags().StringVarP(&nodeName, "node", "n", "", "Name of the node")
-	cmd.Flags().IntVarP(&vmid, "vmid", "i", 0, "VM ID")
-	//nolint:errcheck // Flags are defined above, so these cannot fail
-	_ = cmd.MarkFlagRequired("node")
-	//nolint:errcheck // Flags are defined above, so these cannot fail
-	_ = cmd.MarkFlagRequired("vmid")
+// ShutdownVMCommand gracefully shuts down one or many VMs
+func ShutdownVMCommand() *cobra.Command {
+	return vmPowerCommand("shutdown", "Gracefully shutdown virtual machines", "stopped", (*services.VMService).ShutdownVM)
+}
 
-	return cmd
+// RebootVMCommand reboots one or many VMs
+func RebootVMCommand() *cobra.Command {
+	return vmPowerCommand("reboot", "Reboot virtual machines", "stopped", (*services.VMService).RebootVM)
 }
 
-// ResetVMCommand resets a VM
+// ResetVMCommand resets one or many VMs
 func ResetVMCommand() *cobra.Command {
-	var nodeName string
-	var vmid int
-
-	var cmd = &cobra.Command{
-		Use:   "reset",
-		Short: "Reset a virtual machine",
-		Run: func(cmd *cobra.Command, args []string) {
-			if nodeName == "" || vmid == 0 {
-				fmt.Println("Error: node name and VM ID are required")
-				return
-			}
-
-			vmService, err := services.NewVMService(config.Logger, config.Trust)
-			if err != nil {
-				config.Logger.Error("Failed to initialize VM service: ", err)
-				fmt.Println("Error: Failed to initialize VM service")
-				return
-			}
-
-			taskID, err := vmService.ResetVM(nodeName, vmid)
-			if err != nil {
-				config.Logger.Error("Failed to reset VM: ", err)
-				fmt.Println("Error: Failed to reset VM")
-				return
-			}
-
-			fmt.Printf("VM %d reset initiated. Task ID: %s\n", vmid, taskID)
-		},
-	}
+	return vmPowerCommand("reset", "Reset virtual machines", "stopped", (*services.VMService).ResetVM)
+}
 
-	cmd.Flags().StringVarP(&nodeName, "node", "n", "", "Name of the node")
-	cmd.Flags().IntVarP(&vmid, "vmid", "i", 0, "VM ID")
-	//nolint:errcheck // Flags are defined above, so these cannot fail
-	_ = cmd.MarkFlagRequired("node")
-	//nolint:errcheck // Flags are defined above, so these cannot fail
-	_ = cmd.MarkFlagRequired("vmid")
+// SuspendVMCommand suspends one or many VMs
+func SuspendVMCommand() *cobra.Command {
+	return vmPowerCommand("suspend", "Suspend virtual machines", "stopped", (*services.VMService).SuspendVM)
+}
 
-	return cmd
+// ResumeVMCommand resumes one or many suspended VMs
+func ResumeVMCommand() *cobra.Command {
+	return vmPowerCommand("resume", "Resume suspended virtual machines", "stopped", (*services.VMService).ResumeVM)
 }
 
-// SuspendVMCommand suspends a VM
-func SuspendVMCommand() *cobra.Command {
-	var nodeName string
-	var vmid int
+// vmPowerCommand creates a command that runs a power action on the VM given by --vmid or,
+// given VM IDs, ranges, name patterns or a selector, on many VMs in parallel.
+// VMs in skipStatus are skipped by bulk runs, as the action does not apply to them.
+func vmPowerCommand(use, short, skipStatus string, action func(*services.VMService, string, int) (string, error)) *cobra.Command {
+	var target guestTarget
+	var selector services.GuestSelector
+	var parallel int
+	var wait bool
+	var timeout time.Duration
 
 	var cmd = &cobra.Command{
-		Use:   "suspend",
-		Short: "Suspend a virtual machine",
+		Use:   use + " [vmid|range|pattern]...",
+		Short: short,
+		Long: fmt.Sprintf(`%s.
+
+Give --vmid for a single VM, or list VM IDs, ranges such as 100-120 and name patterns
+such as 'web-*', or select VMs with --all, --node, --pool or --tag; --node also restricts
+the others to one node. Bulk runs handle --parallel VMs at a time, skip VMs that are %s,
+always wait for each task up to --timeout (--wait only applies to --vmid) and print a
+summary of the results:
+  proxmox-cli vm %s 100-120 'web-*' --parallel 8
+  proxmox-cli vm %s --all --node pve1`, short, skipStatus, use, use),
 		Run: func(cmd *cobra.Command, args []string) {
-			if nodeName == "" || vmid == 0 {
-				fmt.Println("Error: node name and VM ID are required")
-				return
-			}
-
 			vmService, err := services.NewVMService(config.Logger, config.Trust)
 			if err != nil {
-				config.Logger.Error("Failed to initialize VM service: ", err)
-				fmt.Println("Error: Failed to initialize VM service")
-				return
+				exitWithError("Failed to initialize VM service", err)
 			}
 
-			taskID, err := vmService.SuspendVM(nodeName, vmid)
-			if err != nil {
-				config.Logger.Error("Failed to suspend VM: ", err)
-				fmt.Println("Error: Failed to suspend VM")
-				return
+			selector.Refs = args
+			// With --vmid, --node is the node of that VM rather than a selector
+			if target.ref == "" {
+				selector.Node = target.node
 			}
-
-			fmt.Printf("VM %d suspend initiated. Task ID: %s\n", vmid, taskID)
-		},
-	}
-
-	cmd.Flags().StringVarP(&nodeName, "node", "n", "", "Name of the node")
-	cmd.Flags().IntVarP(&vmid, "vmid", "i", 0, "VM ID")
-	//nolint:errcheck // Flags are defined above, so these cannot fail
-	_ = cmd.MarkFlagRequired("node")
-	//nolint:errcheck // Flags are defined above, so these cannot fail
-	_ = cmd.MarkFlagRequired("vmid")
-
-	return cmd
-}
-
-// ResumeVMCommand resumes a suspended VM
-func ResumeVMCommand() *cobra.Command {
-	var nodeName string
-	var vmid int
-
-	var cmd = &cobra.Command{
-		Use:   "resume",
-		Short: "Resume a suspended virtual machine",
-		Run: func(cmd *cobra.Command, args []string) {
-			if nodeName == "" || vmid == 0 {
-				fmt.Println("Error: node name and VM ID are required")
+			if !selector.Empty() {
+				if target.ref != "" {
+					exitWithError("Invalid selection", errors.New("--vmid cannot be combined with VM lists or selectors"))
+				}
+				if cmd.Flags().Changed("wait") {
+					exitWithError("Invalid options", errors.New("bulk runs always wait for each task: limit the wait with --timeout instead of --wait"))
+				}
+				runBulkAction(vmGuest, bulkAction{
+					operation:  use,
+					skipStatus: skipStatus,
+					run: func(nodeName string, vmid int) (string, error) {
+						return action(vmService, nodeName, vmid)
+					},
+					client: vmService.Client,
+				}, selector, parallel, timeout)
 				return
 			}
 
-			vmService, err := services.NewVMService(config.Logger, config.Trust)
-			if err != nil {
-				config.Logger.Error("Failed to initialize VM service: ", err)
-				fmt.Println("Error: Failed to initialize VM service")
-				return
+			if target.ref == "" {
+				exitWithError("Invalid selection", errors.New("give --vmid, VM IDs, ranges or name patterns, or select VMs with --all, --node, --pool or --tag"))
 			}
+			nodeName, vmid := target.resolve(vmGuest)
 
-			taskID, err := vmService.ResumeVM(nodeName, vmid)
+			taskID, err := action(vmService, nodeName, vmid)
 			if err != nil {
-				config.Logger.Error("Failed to resume VM: ", err)
-				fmt.Println("Error: Failed to resume VM")
-				return
+				exitWithError(fmt.Sprintf("Failed to %s VM", use), err)
 			}
 
-			fmt.Printf("VM %d resume initiated. Task ID: %s\n", vmid, taskID)
+			finishTask(fmt.Sprintf("VM %d %s initiated", vmid, use), nodeName, taskID, wait, timeout)
 		},
 	}
 
-	cmd.Flags().StringVarP(&nodeName, "node", "n", "", "Name of the node")
-	cmd.Flags().IntVarP(&vmid, "vmid", "i", 0, "VM ID")
-	//nolint:errcheck // Flags are defined above, so these cannot fail
-	_ = cmd.MarkFlagRequired("node")
-	//nolint:errcheck // Flags are defined above, so these cannot fail
-	_ = cmd.MarkFlagRequired("vmid")
+	cmd.Flags().StringVarP(&target.node, "node", "n", "", "Name of the node (looked up in the cluster when omitted); selects the VMs of this node in bulk runs")
+	cmd.Flags().StringVarP(&target.ref, "vmid", "i", "", "VM ID or name")
+	cmd.Flags().BoolVar(&selector.All, "all", false, "Select all VMs")
+	cmd.Flags().StringVar(&selector.Pool, "pool", "", "Select the VMs of a resource pool")
+	cmd.Flags().StringVar(&selector.Tag, "tag", "", "Select the VMs with a tag")
+	cmd.Flags().IntVar(&parallel, "parallel", 4, "Number of VMs a bulk run handles at a time")
+	addWaitFlags(cmd, &wait, &timeout)
 
 	return cmd
 }
 
 // DeleteVMCommand deletes a VM
 func DeleteVMCommand() *cobra.Command {
-	var nodeName string
-	var vmid int
+	var target guestTarget
+	var wait bool
+	var timeout time.Duration
 
 	var cmd = &cobra.Command{
 		Use:   "delete",
 		Short: "Delete a virtual machine",
 		Run: func(cmd *cobra.Command, args []string) {
-			if nodeName == "" || vmid == 0 {
-				fmt.Println("Error: node name and VM ID are required")
-				return
-			}
+			nodeName, vmid := target.resolve(vmGuest)
 
 			vmService, err := services.NewVMService(config.Logger, config.Trust)
 			if err != nil {
-				config.Logger.Error("Failed to initialize VM service: ", err)
-				fmt.Println("Error: Failed to initialize VM service")
-				return
+				exitWithError("Failed to initialize VM service", err)
 			}
 
 			taskID, err := vmService.DeleteVM(nodeName, vmid)
 			if err != nil {
-				config.Logger.Error("Failed to delete VM: ", err)
-				fmt.Println("Error: Failed to delete VM")
-				return
+				exitWithError("Failed to delete VM", err)
 			}
 
-			fmt.Printf("VM %d deletion initiated. Task ID: %s\n", vmid, taskID)
+			finishTask(fmt.Sprintf("VM %d deletion initiated", vmid), nodeName, taskID, wait, timeout)
 		},
 	}
 
-	cmd.Flags().StringVarP(&nodeName, "node", "n", "", "Name of the node")
-	cmd.Flags().IntVarP(&vmid, "vmid", "i", 0, "VM ID")
-	//nolint:errcheck // Flags are defined above, so these cannot fail
-	_ = cmd.MarkFlagRequired("node")
-	//nolint:errcheck // Flags are defined above, so these cannot fail
-	_ = cmd.MarkFlagRequired("vmid")
+	addGuestTargetFlags(cmd, vmGuest, &target)
+	addWaitFlags(cmd, &wait, &timeout)
 
 	return cmd
 }
+
+// parseKeyValues parses <key>=<value> pairs given as flags or arguments into a map.
+// source names where the values came from in error messages, e.g. "--disk".
+func parseKeyValues(source string, values []string) (map[string]string, error) {
+	result := make(map[string]string, len(values))
+	for _, value := range values {
+		key, spec, ok := strings.Cut(value, "=")
+		if !ok || key == "" || spec == "" {
+			return nil, fmt.Errorf("invalid %s %q: expected <key>=<value>", source, value)
+		}
+		if _, exists := result[key]; exists {
+			return nil, fmt.Errorf("%s %s is given more than once", source, key)
+		}
+		result[key] = spec
+	}
+	return result, nil
+}
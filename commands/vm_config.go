@@ -0,0 +1,231 @@
+package commands
+
+import (
+	"fmt"
+	"proxmox-cli/config"
+	"proxmox-cli/services"
+	"sort"
+
+	"github.com/spf13/cobra"
+)
+
+// VMConfigCommand creates the parent command for VM configuration operations
+func VMConfigCommand() *cobra.Command {
+	var configCmd = &cobra.Command{
+		Use:   "config",
+		Short: "Show and edit the configuration of a virtual machine",
+		Long: `Show and edit the configuration of a virtual machine.
+
+Changes to a running VM that cannot be applied live are kept as pending changes
+until the VM is restarted; 'config show --pending' lists them. Pass the digest
+printed by 'config show' to 'config set' or 'config unset' to make the change
+fail if someone else modified the configuration in the meantime.`,
+	}
+
+	configCmd.AddCommand(ShowVMConfigCommand())
+	configCmd.AddCommand(SetVMConfigCommand())
+	configCmd.AddCommand(UnsetVMConfigCommand())
+
+	return configCmd
+}
+
+// ShowVMConfigCommand shows the configuration of a VM
+func ShowVMConfigCommand() *cobra.Command {
+	var target guestTarget
+	var pending bool
+
+	var cmd = &cobra.Command{
+		Use:     "show",
+		Aliases: []string{"get"},
+		Short:   "Show the configuration of a virtual machine",
+		Run: func(cmd *cobra.Command, args []string) {
+			nodeName, vmid := target.resolve(vmGuest)
+
+			vmService, err := services.NewVMService(config.Logger, config.Trust)
+			if err != nil {
+				exitWithError("Failed to initialize VM service", err)
+			}
+
+			vmConfig, err := vmService.GetConfig(nodeName, vmid)
+			if err != nil {
+				exitWithError("Failed to get VM config", err)
+			}
+			if pending {
+				if vmConfig.Pending, err = vmService.GetPendingChanges(nodeName, vmid); err != nil {
+					exitWithError("Failed to get pending VM changes", err)
+				}
+			}
+
+			renderObject(vmConfig, func() {
+				printVMConfig(vmid, vmConfig, pending)
+			})
+		},
+	}
+
+	addGuestTargetFlags(cmd, vmGuest, &target)
+	cmd.Flags().BoolVar(&pending, "pending", false, "Also list changes that take effect after a restart")
+
+	return cmd
+}
+
+// SetVMConfigCommand sets configuration keys of a VM
+func SetVMConfigCommand() *cobra.Command {
+	return setConfigCommand(vmGuest, "memory=4096 cores=4 net1=virtio,bridge=vmbr1")
+}
+
+// UnsetVMConfigCommand removes configuration keys of a VM
+func UnsetVMConfigCommand() *cobra.Command {
+	return unsetConfigCommand(vmGuest, `Removing a disk detaches it as an unused disk, it does not
+delete its data:
+  proxmox-cli vm config unset -n pve1 -i 100 net1 ide2`)
+}
+
+// setConfigCommand sets configuration keys of a guest; example lists keys to set in the help text
+func setConfigCommand(guest guestKind, example string) *cobra.Command {
+	var target guestTarget
+	var digest string
+
+	var cmd = &cobra.Command{
+		Use:   "set <key>=<value>...",
+		Short: "Set configuration keys of a " + guest.longNoun,
+		Long: fmt.Sprintf(`Set configuration keys of a %s, using the Proxmox key names:
+  proxmox-cli %s config set -n pve1 -i 100 %s`, guest.longNoun, guest.command, example),
+		Args: cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			values, err := parseKeyValues("argument", args)
+			if err != nil {
+				exitWithError("Invalid configuration", err)
+			}
+			nodeName, vmid := target.resolve(guest)
+
+			updateGuestConfig(guest, nodeName, vmid, services.ConfigUpdate{Set: values, Digest: digest})
+		},
+	}
+
+	addConfigUpdateFlags(cmd, guest, &target, &digest)
+
+	return cmd
+}
+
+// unsetConfigCommand removes configuration keys of a guest; details explain removals in the help text
+func unsetConfigCommand(guest guestKind, details string) *cobra.Command {
+	var target guestTarget
+	var digest string
+
+	var cmd = &cobra.Command{
+		Use:   "unset <key>...",
+		Short: "Remove configuration keys of a " + guest.longNoun,
+		Long:  fmt.Sprintf("Remove configuration keys of a %s. %s", guest.longNoun, details),
+		Args:  cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			nodeName, vmid := target.resolve(guest)
+
+			updateGuestConfig(guest, nodeName, vmid, services.ConfigUpdate{Delete: args, Digest: digest})
+		},
+	}
+
+	addConfigUpdateFlags(cmd, guest, &target, &digest)
+
+	return cmd
+}
+
+// addConfigUpdateFlags adds the flags shared by the commands that change a guest configuration
+func addConfigUpdateFlags(cmd *cobra.Command, guest guestKind, target *guestTarget, digest *string) {
+	addGuestTargetFlags(cmd, guest, target)
+	cmd.Flags().StringVar(digest, "digest", "", "Only apply the change if the configuration still has this digest")
+}
+
+// updateGuestConfig applies a configuration change and reports the result
+func updateGuestConfig(guest guestKind, nodeName string, vmid int, update services.ConfigUpdate) {
+	if err := guest.service().UpdateConfig(nodeName, vmid, update); err != nil {
+		exitWithError("Failed to update "+guest.noun+" config", err)
+	}
+
+	fmt.Printf("%s %d configuration updated\n", guest.title(), vmid)
+}
+
+// printVMConfig prints a VM configuration as a table
+func printVMConfig(vmid int, vmConfig *services.VMConfig, pending bool) {
+	fmt.Printf("VM Config for VMID: %d\n", vmid)
+	fmt.Println("================================================================================")
+	fmt.Printf("Name:            %s\n", vmConfig.Name)
+	if vmConfig.Memory > 0 {
+		fmt.Printf("Memory:          %d MiB\n", vmConfig.Memory)
+	}
+	if vmConfig.Sockets > 0 {
+		fmt.Printf("Sockets:         %d\n", vmConfig.Sockets)
+	}
+	if vmConfig.Cores > 0 {
+		fmt.Printf("Cores:           %d\n", vmConfig.Cores)
+	}
+	if vmConfig.OSType != "" {
+		fmt.Printf("OS Type:         %s\n", vmConfig.OSType)
+	}
+	if vmConfig.Boot != "" {
+		fmt.Printf("Boot:            %s\n", vmConfig.Boot)
+	}
+	if vmConfig.Description != "" {
+		fmt.Printf("Description:     %s\n", vmConfig.Description)
+	}
+
+	printConfigSection("Disks", vmConfig.Disks)
+	printConfigSection("Network", vmConfig.Networks)
+	if ci := vmConfig.CloudInit; ci != nil {
+		values := map[string]string{
+			"citype":       ci.Type,
+			"ciuser":       ci.User,
+			"cicustom":     ci.Custom,
+			"sshkeys":      ci.SSHKeys,
+			"nameserver":   ci.Nameserver,
+			"searchdomain": ci.Searchdomain,
+		}
+		if ci.Password != "" {
+			values["cipassword"] = "(set)"
+		}
+		for key, value := range ci.IPConfig {
+			values[key] = value
+		}
+		printConfigSection("Cloud-Init", values)
+	}
+	printConfigSection("Other", vmConfig.Other)
+
+	fmt.Printf("\nDigest:          %s\n", vmConfig.Digest)
+
+	if pending {
+		printPendingChanges(vmConfig.Pending)
+	}
+}
+
+// printPendingChanges prints the configuration changes that await a restart
+func printPendingChanges(changes []services.PendingChange) {
+	fmt.Println("\nPending Changes:")
+	if len(changes) == 0 {
+		fmt.Println("  (none)")
+	}
+	for _, change := range changes {
+		if change.Delete {
+			fmt.Printf("  %-14s %s -> (removed)\n", change.Key+":", change.Value)
+		} else {
+			fmt.Printf("  %-14s %s -> %s\n", change.Key+":", change.Value, change.Pending)
+		}
+	}
+}
+
+// printConfigSection prints the non-empty values of a configuration group sorted by key
+func printConfigSection(title string, values map[string]string) {
+	keys := make([]string, 0, len(values))
+	for key, value := range values {
+		if value != "" {
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) == 0 {
+		return
+	}
+	sort.Strings(keys)
+
+	fmt.Printf("\n%s:\n", title)
+	for _, key := range keys {
+		fmt.Printf("  %-14s %s\n", key+":", values[key])
+	}
+}
@@ -0,0 +1,95 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"proxmox-cli/config"
+	"proxmox-cli/services"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// MigrateVMCommand migrates a VM to another node
+func MigrateVMCommand() *cobra.Command {
+	var target guestTarget
+	var options services.MigrateOptions
+	var checkOnly bool
+	var wait bool
+	var timeout time.Duration
+
+	var cmd = &cobra.Command{
+		Use:   "migrate",
+		Short: "Migrate a virtual machine to another node",
+		Long: `Migrate a virtual machine to another node.
+
+A preflight check runs first and lists the local disks and resources that would
+block the migration; the migration is only started when nothing blocks it. Use
+--check to run the preflight check alone, and --wait to follow the migration
+progress in the task log:
+  proxmox-cli vm migrate -n pve1 -i 100 --target pve2 --online --with-local-disks --wait`,
+		Run: func(cmd *cobra.Command, args []string) {
+			nodeName, vmid := target.resolve(vmGuest)
+
+			vmService, err := services.NewVMService(config.Logger, config.Trust)
+			if err != nil {
+				exitWithError("Failed to initialize VM service", err)
+			}
+
+			preflight, err := vmService.MigrationPreflight(nodeName, vmid, options.Target)
+			if err != nil {
+				exitWithError("Failed to check VM migration", err)
+			}
+
+			printMigrationPreflight(preflight)
+			if blockers := preflight.Blockers(options); len(blockers) > 0 {
+				exitWithError("Migration blocked", errors.New(strings.Join(blockers, "; ")))
+			}
+			if checkOnly {
+				fmt.Printf("VM %d can be migrated to %s\n", vmid, options.Target)
+				return
+			}
+
+			taskID, err := vmService.Migrate(nodeName, vmid, options)
+			if err != nil {
+				exitWithError("Failed to migrate VM", err)
+			}
+			// The guest is about to change nodes, so its cached location must not be reused
+			forgetGuestLocations()
+
+			finishTask(fmt.Sprintf("Migration of VM %d to %s initiated", vmid, options.Target), nodeName, taskID, wait, timeout)
+		},
+	}
+
+	addGuestTargetFlags(cmd, vmGuest, &target)
+	cmd.Flags().StringVar(&options.Target, "target", "", "Name of the target node")
+	cmd.Flags().BoolVar(&options.Online, "online", false, "Migrate a running VM without stopping it")
+	cmd.Flags().BoolVar(&options.WithLocalDisks, "with-local-disks", false, "Copy disks on node-local storage to the target")
+	cmd.Flags().StringVar(&options.TargetStorage, "target-storage", "", "Storage on the target node for the local disks")
+	cmd.Flags().BoolVar(&checkOnly, "check", false, "Only run the preflight check")
+	addWaitFlags(cmd, &wait, &timeout)
+	//nolint:errcheck // Flags are defined above, so these cannot fail
+	_ = cmd.MarkFlagRequired("target")
+
+	return cmd
+}
+
+// printMigrationPreflight lists the local disks and resources found by the preflight check
+func printMigrationPreflight(preflight *services.MigrationPreflight) {
+	if len(preflight.LocalDisks) == 0 && len(preflight.LocalResources) == 0 {
+		return
+	}
+
+	fmt.Println("Preflight check:")
+	for _, disk := range preflight.LocalDisks {
+		drive := disk.DriveName
+		if disk.IsUnused == 1 || drive == "" {
+			drive = "unused"
+		}
+		fmt.Printf("  local disk:      %s (%s, %s)\n", disk.VolID, drive, formatBytes(disk.Size))
+	}
+	for _, resource := range preflight.LocalResources {
+		fmt.Printf("  local resource:  %s\n", resource)
+	}
+}
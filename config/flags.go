@@ -2,3 +2,9 @@ package config
 
 // Trust is a global flag for trusting SSL certificates
 var Trust bool
+
+// Context is a global flag selecting the named server context to use
+var Context string
+
+// Output is a global flag selecting the output format of list and status commands
+var Output string
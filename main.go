@@ -6,6 +6,7 @@ import (
 	"proxmox-cli/commands"
 	"proxmox-cli/commands/cluster"
 	"proxmox-cli/config"
+	"proxmox-cli/output"
 
 	"github.com/spf13/cobra"
 )
@@ -18,18 +19,38 @@ func main() {
 It supports managing nodes, virtual machines, containers, storage, networking, and more.`,
 	}
 
-	// Add persistent trust flag
+	// Add persistent trust, context and output flags
 	rootCmd.PersistentFlags().BoolVarP(&config.Trust, "trust", "t", false, "Trust SSL certificates")
+	rootCmd.PersistentFlags().StringVar(&config.Context, "context", "", "Name of the server context to use (defaults to the current context)")
+	rootCmd.PersistentFlags().StringVarP(&config.Output, "output", "o", output.Table,
+		"Output format: table, wide, json, yaml, csv, go-template=<template> or jsonpath=<expression>")
+
+	rootCmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		if err := output.ValidateFormat(config.Output); err != nil {
+			return err
+		}
+		if err := commands.ApplyContext(cmd); err != nil {
+			// The flags were parsed correctly, so usage would not help here
+			cmd.SilenceUsage = true
+			return err
+		}
+		return nil
+	}
 
 	// Authentication commands
 	rootCmd.AddCommand(commands.LoginCommand())
 	rootCmd.AddCommand(commands.ValidateLoginCommand())
+	rootCmd.AddCommand(commands.RealmsCommand())
+	rootCmd.AddCommand(commands.ContextCommand())
 
 	// Resource management commands
 	rootCmd.AddCommand(commands.NodesCommand())
 	rootCmd.AddCommand(commands.VMCommand())
+	rootCmd.AddCommand(commands.ContainerCommand())
 	rootCmd.AddCommand(commands.StorageCommand())
+	rootCmd.AddCommand(commands.BackupCommand())
 	rootCmd.AddCommand(cluster.ClusterCommand())
+	rootCmd.AddCommand(commands.TaskCommand())
 
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
@@ -0,0 +1,61 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"proxmox-cli/services"
+)
+
+// Exit codes of failed commands, so scripts can tell the kind of failure apart
+const (
+	// ExitFailure covers local failures such as a missing session, network errors and failed tasks
+	ExitFailure = 1
+	// ExitAPIError means the Proxmox API rejected the request
+	ExitAPIError = 2
+	// ExitAuthError means the credentials were rejected or lack the required permission
+	ExitAuthError = 3
+)
+
+// ExitCode returns the exit code matching err
+func ExitCode(err error) int {
+	apiErr, ok := services.AsAPIError(err)
+	if !ok {
+		return ExitFailure
+	}
+	if apiErr.IsUnauthorized() || apiErr.IsForbidden() {
+		return ExitAuthError
+	}
+	return ExitAPIError
+}
+
+// PrintError writes "Error: <message>" followed by the details of err. Proxmox API errors
+// show their status code and message, with one line per rejected parameter.
+func PrintError(w io.Writer, message string, err error) {
+	apiErr, ok := services.AsAPIError(err)
+	if !ok {
+		//nolint:errcheck // Nothing sensible can be done when printing the error fails
+		fmt.Fprintf(w, "Error: %s: %v\n", message, err)
+		return
+	}
+
+	//nolint:errcheck // Nothing sensible can be done when printing the error fails
+	fmt.Fprintf(w, "Error: %s: %s (HTTP %d)\n", message, apiErr.Message, apiErr.StatusCode)
+
+	params := make([]string, 0, len(apiErr.Errors))
+	for param := range apiErr.Errors {
+		params = append(params, param)
+	}
+	sort.Strings(params)
+	for _, param := range params {
+		//nolint:errcheck // Nothing sensible can be done when printing the error fails
+		fmt.Fprintf(w, "  %s: %s\n", param, strings.TrimSpace(apiErr.Errors[param]))
+	}
+
+	if apiErr.IsUnauthorized() {
+		//nolint:errcheck // Nothing sensible can be done when printing the error fails
+		fmt.Fprintln(w, "Your session may have expired; run 'proxmox-cli login' again.")
+	}
+}
@@ -0,0 +1,172 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// executeJSONPath evaluates a kubectl-style JSONPath template such as
+// "{[*].name}" or "VM {.vmid} is {.status}". Text outside braces is copied as is
+// and every expression may select several values, which are joined by spaces.
+//
+// Supported expressions are field access (.name), wildcards (.* and [*]) and
+// array indexes ([0], [-1]); the root is the rendered value itself.
+func executeJSONPath(tmpl string, data any) (string, error) {
+	var out strings.Builder
+
+	for len(tmpl) > 0 {
+		start := strings.Index(tmpl, "{")
+		if start < 0 {
+			out.WriteString(tmpl)
+			break
+		}
+		end := strings.Index(tmpl[start:], "}")
+		if end < 0 {
+			return "", fmt.Errorf("invalid jsonpath %q: unclosed '{'", tmpl)
+		}
+		end += start
+
+		out.WriteString(tmpl[:start])
+
+		values, err := evalJSONPath(tmpl[start+1:end], data)
+		if err != nil {
+			return "", err
+		}
+		for i, value := range values {
+			if i > 0 {
+				out.WriteString(" ")
+			}
+			out.WriteString(formatJSONPathValue(value))
+		}
+
+		tmpl = tmpl[end+1:]
+	}
+
+	return out.String(), nil
+}
+
+func evalJSONPath(expr string, data any) ([]any, error) {
+	path := strings.TrimPrefix(strings.TrimSpace(expr), "$")
+	current := []any{data}
+
+	for len(path) > 0 {
+		var next []any
+
+		switch path[0] {
+		case '[':
+			end := strings.Index(path, "]")
+			if end < 0 {
+				return nil, fmt.Errorf("invalid jsonpath %q: unclosed '['", expr)
+			}
+			selector := strings.TrimSpace(path[1:end])
+			path = path[end+1:]
+
+			for _, value := range current {
+				selected, err := selectIndex(value, selector, expr)
+				if err != nil {
+					return nil, err
+				}
+				next = append(next, selected...)
+			}
+		case '.':
+			path = path[1:]
+			end := strings.IndexAny(path, ".[")
+			if end < 0 {
+				end = len(path)
+			}
+			name := path[:end]
+			path = path[end:]
+
+			// ".[*]" and a bare "." refer to the current value
+			if name == "" {
+				next = current
+				break
+			}
+			for _, value := range current {
+				next = append(next, selectField(value, name)...)
+			}
+		default:
+			return nil, fmt.Errorf("invalid jsonpath %q: expected '.' or '[' at %q", expr, path)
+		}
+
+		current = next
+	}
+
+	return current, nil
+}
+
+func selectIndex(value any, selector, expr string) ([]any, error) {
+	if selector == "*" {
+		return allValues(value), nil
+	}
+
+	index, err := strconv.Atoi(selector)
+	if err != nil {
+		return nil, fmt.Errorf("invalid jsonpath %q: unsupported selector [%s]", expr, selector)
+	}
+
+	items, ok := value.([]any)
+	if !ok {
+		return nil, nil
+	}
+	if index < 0 {
+		index += len(items)
+	}
+	if index < 0 || index >= len(items) {
+		return nil, nil
+	}
+	return []any{items[index]}, nil
+}
+
+func selectField(value any, name string) []any {
+	if name == "*" {
+		return allValues(value)
+	}
+
+	fields, ok := value.(map[string]any)
+	if !ok {
+		return nil
+	}
+	if field, found := fields[name]; found {
+		return []any{field}
+	}
+	return nil
+}
+
+// allValues returns the elements of a list or the values of an object in key order
+func allValues(value any) []any {
+	switch v := value.(type) {
+	case []any:
+		return v
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		values := make([]any, 0, len(keys))
+		for _, key := range keys {
+			values = append(values, v[key])
+		}
+		return values
+	}
+	return nil
+}
+
+func formatJSONPathValue(value any) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case nil:
+		return ""
+	case map[string]any, []any:
+		//nolint:errcheck // Values decoded from JSON always marshal back
+		data, _ := json.Marshal(v)
+		return string(data)
+	}
+	return fmt.Sprint(value)
+}
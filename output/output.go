@@ -0,0 +1,266 @@
+// Package output renders command results in the format selected with --output.
+//
+// List commands describe their table with Columns and hand the typed items to
+// RenderList; status commands hand their typed struct to RenderObject together
+// with the function that prints the human-readable view.
+package output
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Supported output formats. Template formats carry their template after an '=' sign.
+const (
+	Table      = "table"
+	Wide       = "wide"
+	JSON       = "json"
+	YAML       = "yaml"
+	CSV        = "csv"
+	GoTemplate = "go-template"
+	JSONPath   = "jsonpath"
+)
+
+// Column describes one column of table, wide and csv output
+type Column[T any] struct {
+	Header string
+	// Wide columns are only shown with -o wide; csv output always includes them
+	Wide  bool
+	Value func(item T) string
+}
+
+// ValidateFormat checks that format is one of the supported output formats
+func ValidateFormat(format string) error {
+	name, arg, hasArg := strings.Cut(format, "=")
+	switch name {
+	case Table, Wide, JSON, YAML, CSV:
+		if hasArg {
+			return fmt.Errorf("output format %q does not take an argument", name)
+		}
+		return nil
+	case GoTemplate, "template", JSONPath:
+		if arg == "" {
+			return fmt.Errorf("output format %q requires an argument, e.g. %s=<expression>", name, name)
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown output format %q (use table, wide, json, yaml, csv, go-template=... or jsonpath=...)", format)
+}
+
+// IsTable reports whether format renders a human-readable table.
+// Commands use it to decide whether to print informational messages such as "No VMs found".
+func IsTable(format string) bool {
+	return format == "" || format == Table || format == Wide
+}
+
+// RenderList writes items in the given format. Table formats use columns; the
+// structured formats serialize the typed items directly.
+func RenderList[T any](w io.Writer, format string, items []T, columns []Column[T]) error {
+	if items == nil {
+		items = []T{}
+	}
+
+	switch format {
+	case "", Table:
+		return writeTable(w, items, columns, false)
+	case Wide:
+		return writeTable(w, items, columns, true)
+	case CSV:
+		return writeCSV(w, items, columns)
+	}
+	return renderStructured(w, format, items)
+}
+
+// RenderObject writes a single value in the given format. For the table formats
+// the value is printed by table, which keeps the command's existing detailed view.
+func RenderObject(w io.Writer, format string, v any, table func()) error {
+	switch format {
+	case "", Table, Wide:
+		table()
+		return nil
+	case CSV:
+		return fmt.Errorf("csv output is only supported for lists")
+	}
+	return renderStructured(w, format, v)
+}
+
+func renderStructured(w io.Writer, format string, v any) error {
+	name, arg, _ := strings.Cut(format, "=")
+
+	switch name {
+	case JSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(v)
+	case YAML:
+		generic, err := toGeneric(v)
+		if err != nil {
+			return err
+		}
+		encoder := yaml.NewEncoder(w)
+		encoder.SetIndent(2)
+		if err = encoder.Encode(generic); err != nil {
+			return err
+		}
+		return encoder.Close()
+	case GoTemplate, "template":
+		tmpl, err := template.New("output").Parse(arg)
+		if err != nil {
+			return fmt.Errorf("invalid template: %w", err)
+		}
+		generic, err := toGeneric(v)
+		if err != nil {
+			return err
+		}
+		if err = tmpl.Execute(w, generic); err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w)
+		return err
+	case JSONPath:
+		generic, err := toGeneric(v)
+		if err != nil {
+			return err
+		}
+		result, err := executeJSONPath(arg, generic)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, result)
+		return err
+	}
+
+	return ValidateFormat(format)
+}
+
+// toGeneric converts v to maps and slices keyed by its JSON field names, so that
+// yaml, templates and jsonpath expressions use the same names as the json output
+func toGeneric(v any) (any, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var generic any
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err = decoder.Decode(&generic); err != nil {
+		return nil, err
+	}
+	return numbersToNative(generic), nil
+}
+
+// numbersToNative replaces json.Number values with int64 or float64 so they render naturally
+func numbersToNative(v any) any {
+	switch value := v.(type) {
+	case map[string]any:
+		for key, item := range value {
+			value[key] = numbersToNative(item)
+		}
+	case []any:
+		for i, item := range value {
+			value[i] = numbersToNative(item)
+		}
+	case json.Number:
+		if i, err := value.Int64(); err == nil {
+			return i
+		}
+		if f, err := value.Float64(); err == nil {
+			return f
+		}
+		return value.String()
+	}
+	return v
+}
+
+func visibleColumns[T any](columns []Column[T], wide bool) []Column[T] {
+	visible := make([]Column[T], 0, len(columns))
+	for _, column := range columns {
+		if wide || !column.Wide {
+			visible = append(visible, column)
+		}
+	}
+	return visible
+}
+
+// writeTable prints the columns padded to their widest value, followed by the
+// separator line used throughout the CLI
+func writeTable[T any](w io.Writer, items []T, columns []Column[T], wide bool) error {
+	columns = visibleColumns(columns, wide)
+
+	rows := make([][]string, len(items))
+	widths := make([]int, len(columns))
+	for i, column := range columns {
+		widths[i] = len(column.Header)
+	}
+	for r, item := range items {
+		rows[r] = make([]string, len(columns))
+		for i, column := range columns {
+			rows[r][i] = column.Value(item)
+			widths[i] = max(widths[i], len(rows[r][i]))
+		}
+	}
+
+	headers := make([]string, len(columns))
+	for i, column := range columns {
+		headers[i] = column.Header
+	}
+
+	total := 0
+	for _, width := range widths {
+		total += width + 1
+	}
+
+	if _, err := fmt.Fprintln(w, formatRow(headers, widths)); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintln(w, strings.Repeat("=", max(total-1, 0))); err != nil {
+		return err
+	}
+	for _, row := range rows {
+		if _, err := fmt.Fprintln(w, formatRow(row, widths)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func formatRow(values []string, widths []int) string {
+	cells := make([]string, len(values))
+	for i, value := range values {
+		cells[i] = fmt.Sprintf("%-*s", widths[i], value)
+	}
+	return strings.TrimRight(strings.Join(cells, " "), " ")
+}
+
+func writeCSV[T any](w io.Writer, items []T, columns []Column[T]) error {
+	writer := csv.NewWriter(w)
+
+	headers := make([]string, len(columns))
+	for i, column := range columns {
+		headers[i] = column.Header
+	}
+	if err := writer.Write(headers); err != nil {
+		return err
+	}
+
+	for _, item := range items {
+		row := make([]string, len(columns))
+		for i, column := range columns {
+			row[i] = column.Value(item)
+		}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
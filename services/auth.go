@@ -3,8 +3,11 @@ package services
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"net/url"
+	"strings"
+	"time"
 
 	"github.com/sirupsen/logrus"
 )
@@ -15,6 +18,7 @@ type AuthService struct {
 	Trust          bool
 	HTTPService    HTTPServiceInterface
 	SessionService SessionServiceInterface
+	TFAPrompt      TFAPromptFunc
 }
 
 // NewAuthService creates an AuthService with real HTTP and Session services (for production use).
@@ -46,33 +50,118 @@ func mustNewSessionService(logger *logrus.Logger) SessionServiceInterface {
 	return ss
 }
 
-// LoginToProxmox authenticates with the Proxmox server and stores the session data
-func (a *AuthService) LoginToProxmox(server string, port int, httpScheme, username, password string) error {
-	uri := fmt.Sprintf("%s://%s:%d/api2/json/access/ticket", httpScheme, server, port)
-	payload := fmt.Sprintf("username=%s&password=%s&realm=pam&new-format=1", username, password)
-	headers := URLEncodedHeader
+// Realm represents an authentication realm (domain) configured on the Proxmox server
+type Realm struct {
+	Realm   string `json:"realm"`
+	Type    string `json:"type"`
+	Comment string `json:"comment,omitempty"`
+	Default int    `json:"default,omitempty"`
+	TFA     string `json:"tfa,omitempty"`
+}
+
+// RealmListResponse represents the API response for the realm list
+type RealmListResponse struct {
+	Data []Realm `json:"data"`
+}
+
+// TFAChallenge describes the second factors the server accepts to finish a two-factor login
+type TFAChallenge struct {
+	TOTP     bool
+	Recovery bool
+	Yubico   bool
+	WebAuthn bool
+}
+
+// TFAPromptFunc asks the user for a second factor. It returns the response in the
+// "<type>:<code>" form expected by Proxmox, e.g. "totp:123456" or "recovery:abcd-1234".
+type TFAPromptFunc func(challenge TFAChallenge) (string, error)
+
+// ticketResponse is the /access/ticket response including the two-factor marker
+type ticketResponse struct {
+	Data struct {
+		Username            string `json:"username"`
+		Ticket              string `json:"ticket"`
+		CSRFPreventionToken string `json:"CSRFPreventionToken"`
+		NeedTFA             int    `json:"NeedTFA,omitempty"`
+	} `json:"data"`
+}
 
-	body, err := a.HTTPService.Post(uri, payload, headers, nil)
+// ListRealms retrieves the authentication realms available on a server.
+// The endpoint does not require authentication, so it can be used before logging in.
+func (a *AuthService) ListRealms(server string, port int, httpScheme string) ([]Realm, error) {
+	uri := fmt.Sprintf("%s://%s:%d/api2/json/access/domains", httpScheme, server, port)
+
+	resp, err := a.HTTPService.Get(uri, nil, nil)
 	if err != nil {
-		a.Logger.Error("Error logging in: ", err)
-		return err
+		a.Logger.Error("Error listing realms: ", err)
+		return nil, err
 	}
+	//nolint:errcheck // Best effort close in defer
+	defer func() { _ = resp.Body.Close() }()
 
-	a.Logger.Info("Response: ", body)
+	bodyBytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		a.Logger.Error("Error reading response body: ", err)
+		return nil, err
+	}
 
-	var resp SessionDataResponse
-	err = json.Unmarshal([]byte(body), &resp)
+	if err = checkStatus(resp, bodyBytes); err != nil {
+		a.Logger.Error("Error listing realms: ", err)
+		return nil, err
+	}
+
+	var result RealmListResponse
+	err = json.Unmarshal(bodyBytes, &result)
 	if err != nil {
 		a.Logger.Error("Error parsing response JSON: ", err)
+		return nil, err
+	}
+
+	return result.Data, nil
+}
+
+// LoginToProxmox authenticates with the Proxmox server and stores the session data.
+// When realm is empty, the realm is taken from a user@realm username or, failing that,
+// the server's default realm. If the account requires two-factor authentication, TFAPrompt
+// is used to ask for the second factor.
+func (a *AuthService) LoginToProxmox(server string, port int, httpScheme, username, realm, password string) error {
+	uri := fmt.Sprintf("%s://%s:%d/api2/json/access/ticket", httpScheme, server, port)
+
+	form := url.Values{}
+	form.Set("username", username)
+	form.Set("password", password)
+	form.Set("new-format", "1")
+	if !strings.Contains(username, "@") {
+		if realm == "" {
+			realm = a.defaultRealm(server, port, httpScheme)
+		}
+		form.Set("realm", realm)
+	} else if realm != "" {
+		form.Set("realm", realm)
+	}
+
+	resp, err := a.requestTicket(uri, form)
+	if err != nil {
 		return err
 	}
 
+	if resp.Data.NeedTFA == 1 {
+		resp, err = a.finishTFALogin(uri, resp)
+		if err != nil {
+			return err
+		}
+	}
+
 	sessionData := SessionData{
 		Server:     server,
 		Port:       port,
 		HttpScheme: httpScheme,
-		Response:   resp,
+		Trust:      a.Trust,
+		IssuedAt:   time.Now().Unix(),
 	}
+	sessionData.Response.Data.Username = resp.Data.Username
+	sessionData.Response.Data.Ticket = resp.Data.Ticket
+	sessionData.Response.Data.CSRFPreventionToken = resp.Data.CSRFPreventionToken
 
 	err = a.SessionService.WriteSessionFile(sessionData)
 	if err != nil {
@@ -80,6 +169,165 @@ func (a *AuthService) LoginToProxmox(server string, port int, httpScheme, userna
 		return err
 	}
 	a.Logger.Info("Authenticated!")
+	return a.useLoggedInContext()
+}
+
+// requestTicket posts credentials to /access/ticket and parses the response
+func (a *AuthService) requestTicket(uri string, form url.Values) (*ticketResponse, error) {
+	body, err := a.HTTPService.Post(uri, form.Encode(), URLEncodedHeader, nil)
+	if err != nil {
+		a.Logger.Error("Error logging in: ", err)
+		return nil, err
+	}
+
+	a.Logger.Info("Response: ", body)
+
+	var resp ticketResponse
+	err = json.Unmarshal([]byte(body), &resp)
+	if err != nil {
+		a.Logger.Error("Error parsing response JSON: ", err)
+		return nil, err
+	}
+
+	if resp.Data.Ticket == "" {
+		return nil, fmt.Errorf("authentication failed: invalid credentials")
+	}
+
+	return &resp, nil
+}
+
+// finishTFALogin answers the two-factor challenge contained in a partial ticket
+func (a *AuthService) finishTFALogin(uri string, partial *ticketResponse) (*ticketResponse, error) {
+	if a.TFAPrompt == nil {
+		return nil, fmt.Errorf("two-factor authentication is required but no prompt is available")
+	}
+
+	response, err := a.TFAPrompt(ParseTFAChallenge(partial.Data.Ticket))
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{}
+	form.Set("username", partial.Data.Username)
+	form.Set("password", response)
+	form.Set("tfa-challenge", partial.Data.Ticket)
+	form.Set("new-format", "1")
+
+	resp, err := a.requestTicket(uri, form)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Data.NeedTFA == 1 {
+		return nil, fmt.Errorf("two-factor authentication failed")
+	}
+
+	return resp, nil
+}
+
+// defaultRealm returns the realm the server marks as default, falling back to pam
+func (a *AuthService) defaultRealm(server string, port int, httpScheme string) string {
+	realms, err := a.ListRealms(server, port, httpScheme)
+	if err != nil {
+		a.Logger.Info("Realm discovery failed, using pam: ", err)
+		return "pam"
+	}
+
+	for _, realm := range realms {
+		if realm.Default == 1 {
+			return realm.Realm
+		}
+	}
+	return "pam"
+}
+
+// ParseTFAChallenge extracts the accepted second factors from a partial "!tfa!" ticket.
+// Unknown or unparsable challenges are treated as TOTP-only.
+func ParseTFAChallenge(ticket string) TFAChallenge {
+	challenge := TFAChallenge{TOTP: true}
+
+	_, encoded, found := strings.Cut(ticket, "!tfa!")
+	if !found {
+		return challenge
+	}
+	encoded, _, _ = strings.Cut(encoded, ":")
+
+	decoded, err := url.QueryUnescape(encoded)
+	if err != nil {
+		return challenge
+	}
+
+	var raw map[string]json.RawMessage
+	if err = json.Unmarshal([]byte(decoded), &raw); err != nil {
+		return challenge
+	}
+
+	challenge.TOTP = string(raw["totp"]) == "true"
+	challenge.Yubico = string(raw["yubico"]) == "true"
+	_, challenge.WebAuthn = raw["webauthn"]
+	if recovery, ok := raw["recovery"]; ok && string(recovery) != "null" && string(recovery) != "false" {
+		challenge.Recovery = true
+	}
+
+	return challenge
+}
+
+// LoginWithAPIToken verifies a Proxmox API token against the server and stores it as the session.
+// tokenID has the form user@realm!tokenname.
+func (a *AuthService) LoginWithAPIToken(server string, port int, httpScheme, tokenID, tokenSecret string) error {
+	if !strings.Contains(tokenID, "!") {
+		return fmt.Errorf("invalid token ID %q: expected user@realm!tokenname", tokenID)
+	}
+
+	sessionData := SessionData{
+		Server:      server,
+		Port:        port,
+		HttpScheme:  httpScheme,
+		TokenID:     tokenID,
+		TokenSecret: tokenSecret,
+		Trust:       a.Trust,
+	}
+	sessionData.Response.Data.Username = TokenUser(tokenID)
+
+	if err := a.verifyAPIToken(sessionData); err != nil {
+		a.Logger.Error("Error verifying API token: ", err)
+		return err
+	}
+
+	err := a.SessionService.WriteSessionFile(sessionData)
+	if err != nil {
+		a.Logger.Error("Error writing session data to file: ", err)
+		return err
+	}
+	a.Logger.Info("Authenticated with API token!")
+	return a.useLoggedInContext()
+}
+
+// useLoggedInContext makes the context that was just logged in to the current one
+func (a *AuthService) useLoggedInContext() error {
+	err := a.SessionService.UseContext(a.SessionService.ContextName())
+	if err != nil {
+		a.Logger.Error("Error switching to the logged in context: ", err)
+	}
+	return err
+}
+
+// verifyAPIToken checks that the server accepts the API token stored in sessionData
+func (a *AuthService) verifyAPIToken(sessionData SessionData) error {
+	uri := fmt.Sprintf("%s://%s:%d/api2/json/version", sessionData.HttpScheme, sessionData.Server, sessionData.Port)
+
+	resp, err := a.HTTPService.Get(uri, sessionData.AuthHeaders(nil, false), nil)
+	if err != nil {
+		return err
+	}
+	//nolint:errcheck // Best effort close in defer
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		//nolint:errcheck // Body is only used to enrich the error message
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("API token rejected: %w", NewAPIError(resp.StatusCode, resp.Status, body))
+	}
+
 	return nil
 }
 
@@ -91,32 +339,65 @@ func (a *AuthService) ValidateSession() bool {
 		return false
 	}
 
-	uri := fmt.Sprintf("%s://%s:%d/api2/json/access/ticket", sessionData.HttpScheme, sessionData.Server, sessionData.Port)
+	// API tokens do not expire like tickets, so there is nothing to refresh
+	if sessionData.UsesAPIToken() {
+		if err = a.verifyAPIToken(sessionData); err != nil {
+			a.Logger.Error("Error validating API token: ", err)
+			return false
+		}
+		return true
+	}
+
+	if err = a.renewTicket(sessionData); err != nil {
+		a.Logger.Error("Error validating session: ", err)
+		return false
+	}
 
-	payload := fmt.Sprintf("username=%s&password=%s", sessionData.Response.Data.Username, url.QueryEscape(sessionData.Response.Data.Ticket))
-	headers := map[string]string{
-		"Content-Type":        "application/x-www-form-urlencoded; charset=UTF-8",
-		"CSRFPreventionToken": sessionData.Response.Data.CSRFPreventionToken,
+	return true
+}
+
+// RenewTicket exchanges the ticket of the current session for a new one and stores it.
+// Tickets are valid for two hours and can only be renewed while they are still valid.
+func (a *AuthService) RenewTicket() error {
+	sessionData, err := a.SessionService.ReadSessionFile()
+	if err != nil {
+		a.Logger.Error("Error reading session file: ", err)
+		return err
 	}
 
-	cookies := []*http.Cookie{
-		{
-			Name:  "PVEAuthCookie",
-			Value: url.QueryEscape(sessionData.Response.Data.Ticket),
-		},
+	if sessionData.UsesAPIToken() {
+		return nil
 	}
 
-	body, err := a.HTTPService.Post(uri, payload, headers, cookies)
+	return a.renewTicket(sessionData)
+}
+
+func (a *AuthService) renewTicket(sessionData SessionData) error {
+	uri := fmt.Sprintf("%s://%s:%d/api2/json/access/ticket", sessionData.HttpScheme, sessionData.Server, sessionData.Port)
+
+	// A valid ticket is accepted in place of the password
+	form := url.Values{}
+	form.Set("username", sessionData.Response.Data.Username)
+	form.Set("password", sessionData.Response.Data.Ticket)
+
+	body, err := a.HTTPService.Post(uri, form.Encode(), sessionData.AuthHeaders(URLEncodedHeader, true), sessionData.AuthCookies())
 	if err != nil {
-		a.Logger.Error("Error validating session: ", err)
-		return false
+		a.Logger.Error("Error renewing ticket: ", err)
+		return err
 	}
 
 	err = a.SessionService.UpdateSessionField("response", body)
 	if err != nil {
 		a.Logger.Error("Error updating session file: ", err)
-		return false
+		return err
 	}
 
-	return true
+	err = a.SessionService.UpdateSessionField("issuedAt", time.Now().Unix())
+	if err != nil {
+		a.Logger.Error("Error updating session file: ", err)
+		return err
+	}
+
+	a.Logger.Info("Ticket renewed")
+	return nil
 }
@@ -0,0 +1,246 @@
+package services
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Backup modes of vzdump
+const (
+	// BackupModeSnapshot backs up a running guest from a live snapshot
+	BackupModeSnapshot = "snapshot"
+	// BackupModeSuspend suspends the guest while its data is copied
+	BackupModeSuspend = "suspend"
+	// BackupModeStop stops the guest for the backup and starts it again afterwards
+	BackupModeStop = "stop"
+)
+
+// backupCompressions are the compression values vzdump accepts, "0" disabling compression
+var backupCompressions = []string{"0", "1", "gzip", "lzo", "zstd"}
+
+// BackupOptions describes a vzdump backup. Empty fields use the defaults of the node.
+type BackupOptions struct {
+	// Mode is BackupModeSnapshot, BackupModeSuspend or BackupModeStop
+	Mode    string
+	Storage string
+	// Compress is "0" for no compression, "gzip", "lzo" or "zstd"
+	Compress string
+	// Notes are stored with the backup and may contain {{guestname}}, {{vmid}}, {{node}} and {{cluster}}
+	Notes string
+	// Protected keeps the backup from being removed, manually or by pruning
+	Protected bool
+}
+
+// Validate checks the options before they are sent to the API
+func (o BackupOptions) Validate() error {
+	switch o.Mode {
+	case "", BackupModeSnapshot, BackupModeSuspend, BackupModeStop:
+	default:
+		return fmt.Errorf("invalid backup mode %q: use %s, %s or %s", o.Mode, BackupModeSnapshot, BackupModeSuspend, BackupModeStop)
+	}
+
+	if o.Compress != "" && !slices.Contains(backupCompressions, o.Compress) {
+		return fmt.Errorf("invalid compression %q: use one of %s", o.Compress, strings.Join(backupCompressions, ", "))
+	}
+
+	return nil
+}
+
+// params returns the vzdump parameters of the options that are set
+func (o BackupOptions) params() url.Values {
+	params := url.Values{}
+	setParam := func(key, value string) {
+		if value != "" {
+			params.Set(key, value)
+		}
+	}
+	setParam("mode", o.Mode)
+	setParam("storage", o.Storage)
+	setParam("compress", o.Compress)
+	setParam("notes-template", o.Notes)
+	if o.Protected {
+		params.Set("protected", "1")
+	}
+	return params
+}
+
+// RestoreOptions describes how a backup is restored
+type RestoreOptions struct {
+	// VMID is the ID of the restored guest, defaults to the next free ID of the cluster
+	VMID int
+	// Storage is the storage of the restored disks, defaults to the storage in the backup
+	Storage string
+	// Force overwrites an existing guest with the same ID
+	Force bool
+	// Unique assigns new MAC addresses to the network interfaces
+	Unique bool
+	// Start starts the guest after the restore
+	Start bool
+}
+
+// BackupService handles vzdump backups of VMs and containers
+type BackupService struct {
+	Logger *logrus.Logger
+	Trust  bool
+	Client *APIClient
+}
+
+// NewBackupService creates a new BackupService with real dependencies
+func NewBackupService(logger *logrus.Logger, trust bool) (*BackupService, error) {
+	client, err := NewAPIClient(logger, trust)
+	if err != nil {
+		return nil, err
+	}
+
+	return &BackupService{
+		Logger: logger,
+		Trust:  trust,
+		Client: client,
+	}, nil
+}
+
+// NewBackupServiceWithDeps creates a BackupService with injected dependencies (for testing)
+func NewBackupServiceWithDeps(logger *logrus.Logger, trust bool, httpService HTTPServiceInterface, sessionService SessionServiceInterface) *BackupService {
+	return &BackupService{
+		Logger: logger,
+		Trust:  trust,
+		Client: NewAPIClientWithDeps(logger, httpService, sessionService),
+	}
+}
+
+// CreateBackup backs up the VM or container vmid on a node with vzdump and returns the task UPID
+func (s *BackupService) CreateBackup(nodeName string, vmid int, options BackupOptions) (string, error) {
+	if err := options.Validate(); err != nil {
+		return "", err
+	}
+
+	params := options.params()
+	params.Set("vmid", strconv.Itoa(vmid))
+
+	upid, err := Post[string](s.Client, fmt.Sprintf("nodes/%s/vzdump", nodeName), params)
+	if err != nil {
+		s.Logger.Error("Error creating backup: ", err)
+		return "", err
+	}
+
+	return upid, nil
+}
+
+// ListBackups retrieves the backups on a storage of a node, or on all its backup storage
+// when storageName is empty, newest first. A VM ID other than 0 selects the backups of one guest.
+func (s *BackupService) ListBackups(nodeName, storageName string, vmid int) ([]StorageContent, error) {
+	storageService := &StorageService{Logger: s.Logger, Trust: s.Trust, Client: s.Client}
+
+	storageNames := []string{storageName}
+	if storageName == "" {
+		storages, err := storageService.ListNodeStorage(nodeName, "backup")
+		if err != nil {
+			return nil, err
+		}
+		storageNames = storageNames[:0]
+		for _, storage := range storages {
+			storageNames = append(storageNames, storage.Storage)
+		}
+	}
+
+	backups := []StorageContent{}
+	for _, name := range storageNames {
+		contents, err := storageService.ListStorageContentOfType(nodeName, name, "backup", vmid)
+		if err != nil {
+			return nil, err
+		}
+		backups = append(backups, contents...)
+	}
+
+	sort.SliceStable(backups, func(i, j int) bool { return backups[i].CTime > backups[j].CTime })
+	return backups, nil
+}
+
+// RestoreBackup restores the backup volume volid on a node to a new VM or container and
+// returns the guest type, the VM ID of the restored guest and the task UPID
+func (s *BackupService) RestoreBackup(nodeName, volid string, options RestoreOptions) (string, int, string, error) {
+	guestType, err := BackupGuestType(volid)
+	if err != nil {
+		return "", 0, "", err
+	}
+
+	vmid := options.VMID
+	if vmid == 0 {
+		if vmid, err = nextVMID(s.Client); err != nil {
+			return "", 0, "", err
+		}
+	}
+
+	params := url.Values{}
+	params.Set("vmid", strconv.Itoa(vmid))
+	if guestType == GuestTypeLXC {
+		params.Set("ostemplate", volid)
+		params.Set("restore", "1")
+	} else {
+		params.Set("archive", volid)
+	}
+	if options.Storage != "" {
+		params.Set("storage", options.Storage)
+	}
+	if options.Force {
+		params.Set("force", "1")
+	}
+	if options.Unique {
+		params.Set("unique", "1")
+	}
+	if options.Start {
+		params.Set("start", "1")
+	}
+
+	upid, err := Post[string](s.Client, fmt.Sprintf("nodes/%s/%s", nodeName, guestType), params)
+	if err != nil {
+		s.Logger.Error("Error restoring backup: ", err)
+		return "", 0, "", err
+	}
+
+	return guestType, vmid, upid, nil
+}
+
+// DeleteBackup removes the backup volume volid from its storage. Recent Proxmox versions
+// return the UPID of a task, older ones delete the volume directly and return "".
+func (s *BackupService) DeleteBackup(nodeName, volid string) (string, error) {
+	storageName, _, ok := strings.Cut(volid, ":")
+	if !ok || storageName == "" {
+		return "", fmt.Errorf("invalid backup volume %q: expected <storage>:<volume>", volid)
+	}
+
+	path := fmt.Sprintf("nodes/%s/storage/%s/content/%s", nodeName, storageName, url.PathEscape(volid))
+	upid, err := Delete[string](s.Client, path, nil)
+	if err != nil {
+		s.Logger.Error("Error deleting backup: ", err)
+		return "", err
+	}
+
+	return upid, nil
+}
+
+// backupVolumePattern matches the guest type in the names of vzdump archives, e.g.
+// vzdump-qemu-100-2024_01_31-02_00_00.vma.zst, and Proxmox Backup Server snapshots, e.g. backup/ct/200/...
+var backupVolumePattern = regexp.MustCompile(`vzdump-(qemu|lxc|openvz)-|backup/(vm|ct)/`)
+
+// BackupGuestType returns the guest type, GuestTypeQEMU or GuestTypeLXC, of a backup volume
+func BackupGuestType(volid string) (string, error) {
+	match := backupVolumePattern.FindStringSubmatch(volid)
+	if match == nil {
+		return "", fmt.Errorf("cannot tell whether %q is a VM or container backup", volid)
+	}
+
+	switch match[1] + match[2] {
+	case "qemu", "vm":
+		return GuestTypeQEMU, nil
+	default:
+		return GuestTypeLXC, nil
+	}
+}
@@ -0,0 +1,352 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// pruneKeys are the retention settings of prune-backups
+var pruneKeys = []string{"keep-all", "keep-last", "keep-hourly", "keep-daily", "keep-weekly", "keep-monthly", "keep-yearly"}
+
+// PruneSettings is the retention of a backup job in the prune-backups format, e.g.
+// "keep-last=3,keep-weekly=4". Proxmox returns it as a string or as an object.
+type PruneSettings string
+
+// UnmarshalJSON accepts prune settings as a string or as an object of keep options
+func (p *PruneSettings) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		*p = PruneSettings(text)
+		return nil
+	}
+
+	var settings map[string]any
+	if err := json.Unmarshal(data, &settings); err != nil {
+		return fmt.Errorf("invalid prune-backups: %w", err)
+	}
+	*p = PruneSettings(joinSettings(settings))
+	return nil
+}
+
+// Validate checks that the prune settings are a list of keep options with non-negative counts
+func (p PruneSettings) Validate() error {
+	if p == "" {
+		return nil
+	}
+
+	for _, setting := range strings.Split(string(p), ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(setting), "=")
+		if !slices.Contains(pruneKeys, key) {
+			return fmt.Errorf("invalid prune-backups setting %q: use %s", setting, strings.Join(pruneKeys, ", "))
+		}
+		if n, err := strconv.Atoi(value); err != nil || n < 0 {
+			return fmt.Errorf("invalid prune-backups setting %q: %s needs a count", setting, key)
+		}
+	}
+	return nil
+}
+
+// BackupJob is a scheduled vzdump job of the cluster
+type BackupJob struct {
+	ID       string `json:"id"`
+	Type     string `json:"type,omitempty"`
+	Schedule string `json:"schedule,omitempty"`
+	// Enabled is 0 for disabled jobs; jobs without it are enabled
+	Enabled *int `json:"enabled,omitempty"`
+	// Node restricts the job to the guests of one node
+	Node string `json:"node,omitempty"`
+	// VMID is the comma separated list of the VM IDs the job backs up
+	VMID string `json:"vmid,omitempty"`
+	Pool string `json:"pool,omitempty"`
+	All  int    `json:"all,omitempty"`
+	// Exclude is the comma separated list of the VM IDs left out of a job backing up all guests
+	Exclude       string        `json:"exclude,omitempty"`
+	Storage       string        `json:"storage,omitempty"`
+	Mode          string        `json:"mode,omitempty"`
+	Compress      string        `json:"compress,omitempty"`
+	PruneBackups  PruneSettings `json:"prune-backups,omitempty"`
+	NotesTemplate string        `json:"notes-template,omitempty"`
+	Comment       string        `json:"comment,omitempty"`
+	NextRun       int64         `json:"next-run,omitempty"`
+}
+
+// IsEnabled reports whether the job runs on its schedule
+func (j BackupJob) IsEnabled() bool {
+	return j.Enabled == nil || *j.Enabled != 0
+}
+
+// Selection describes the guests the job backs up
+func (j BackupJob) Selection() string {
+	switch {
+	case j.All == 1 && j.Exclude != "":
+		return "all except " + j.Exclude
+	case j.All == 1:
+		return "all"
+	case j.Pool != "":
+		return "pool " + j.Pool
+	}
+	return j.VMID
+}
+
+// BackupJobOptions describes a backup job to create, or the settings to change in an update.
+// Guests are selected by exactly one of VMIDs, Pool and All.
+type BackupJobOptions struct {
+	BackupOptions
+	// Schedule is a calendar event, e.g. "sat 02:00" or "mon..fri 21:00"
+	Schedule string
+	VMIDs    []int
+	Pool     string
+	All      bool
+	// Exclude lists VM IDs left out of a job selecting all guests
+	Exclude []int
+	// Node restricts the job to the guests of one node
+	Node         string
+	PruneBackups PruneSettings
+	Comment      string
+	// Enabled enables or disables the job when set
+	Enabled *bool
+}
+
+// selections returns the number of ways of selecting guests set in the options
+func (o BackupJobOptions) selections() int {
+	count := 0
+	for _, set := range []bool{len(o.VMIDs) > 0, o.Pool != "", o.All} {
+		if set {
+			count++
+		}
+	}
+	return count
+}
+
+// Validate checks the options of a new job, or of an update when creating is false,
+// including the calendar event syntax of the schedule
+func (o BackupJobOptions) Validate(creating bool) error {
+	if creating && o.Schedule == "" {
+		return fmt.Errorf("a schedule is required")
+	}
+	if o.Schedule != "" {
+		if err := ValidateCalendarEvent(o.Schedule); err != nil {
+			return err
+		}
+	}
+
+	switch selections := o.selections(); {
+	case selections > 1:
+		return fmt.Errorf("select guests by VM IDs, pool or all, not several of them")
+	case selections == 0 && creating:
+		return fmt.Errorf("a backup job must select guests by VM IDs, pool or all")
+	}
+	if len(o.Exclude) > 0 && !o.All {
+		return fmt.Errorf("excluded VM IDs can only be used when all guests are selected")
+	}
+
+	if err := o.BackupOptions.Validate(); err != nil {
+		return err
+	}
+	return o.PruneBackups.Validate()
+}
+
+// params returns the API parameters of the options. An update that selects guests in a
+// different way removes the previous selection, as the API rejects conflicting ones.
+func (o BackupJobOptions) params(creating bool) url.Values {
+	params := o.BackupOptions.params()
+	setParam := func(key, value string) {
+		if value != "" {
+			params.Set(key, value)
+		}
+	}
+	setParam("schedule", o.Schedule)
+	setParam("vmid", joinVMIDs(o.VMIDs))
+	setParam("pool", o.Pool)
+	setParam("exclude", joinVMIDs(o.Exclude))
+	setParam("node", o.Node)
+	setParam("prune-backups", string(o.PruneBackups))
+	setParam("comment", o.Comment)
+	if o.All {
+		params.Set("all", "1")
+	}
+	if o.Enabled != nil {
+		params.Set("enabled", boolParam(*o.Enabled))
+	}
+
+	if !creating && o.selections() == 1 {
+		deleted := []string{}
+		if len(o.VMIDs) == 0 {
+			deleted = append(deleted, "vmid")
+		}
+		if o.Pool == "" {
+			deleted = append(deleted, "pool")
+		}
+		if !o.All {
+			params.Set("all", "0")
+			deleted = append(deleted, "exclude")
+		}
+		params.Set("delete", strings.Join(deleted, ","))
+	}
+
+	return params
+}
+
+// BackupJobRun is a vzdump task started on a node by RunJob
+type BackupJobRun struct {
+	Node string `json:"node"`
+	UPID string `json:"upid"`
+}
+
+// jobOnlyKeys are the settings of a backup job that are not vzdump parameters
+var jobOnlyKeys = []string{"id", "type", "schedule", "enabled", "comment", "next-run", "repeat-missed", "starttime", "dow", "digest"}
+
+// ListJobs retrieves the scheduled backup jobs of the cluster
+func (s *BackupService) ListJobs() ([]BackupJob, error) {
+	jobs, err := Get[[]BackupJob](s.Client, "cluster/backup", nil)
+	if err != nil {
+		s.Logger.Error("Error listing backup jobs: ", err)
+		return nil, err
+	}
+
+	return jobs, nil
+}
+
+// GetJob retrieves a scheduled backup job
+func (s *BackupService) GetJob(id string) (*BackupJob, error) {
+	job, err := Get[BackupJob](s.Client, "cluster/backup/"+url.PathEscape(id), nil)
+	if err != nil {
+		s.Logger.Error("Error getting backup job: ", err)
+		return nil, err
+	}
+
+	return &job, nil
+}
+
+// CreateJob creates a scheduled backup job. Without an ID Proxmox generates one.
+func (s *BackupService) CreateJob(id string, options BackupJobOptions) error {
+	if err := options.Validate(true); err != nil {
+		return err
+	}
+
+	params := options.params(true)
+	if id != "" {
+		params.Set("id", id)
+	}
+
+	if _, err := Post[any](s.Client, "cluster/backup", params); err != nil {
+		s.Logger.Error("Error creating backup job: ", err)
+		return err
+	}
+
+	return nil
+}
+
+// UpdateJob changes the settings of a backup job that are set in options
+func (s *BackupService) UpdateJob(id string, options BackupJobOptions) error {
+	if err := options.Validate(false); err != nil {
+		return err
+	}
+
+	params := options.params(false)
+	if len(params) == 0 {
+		return fmt.Errorf("no changes given for backup job %s", id)
+	}
+
+	if _, err := Put[any](s.Client, "cluster/backup/"+url.PathEscape(id), params); err != nil {
+		s.Logger.Error("Error updating backup job: ", err)
+		return err
+	}
+
+	return nil
+}
+
+// DeleteJob deletes a scheduled backup job
+func (s *BackupService) DeleteJob(id string) error {
+	if _, err := Delete[any](s.Client, "cluster/backup/"+url.PathEscape(id), nil); err != nil {
+		s.Logger.Error("Error deleting backup job: ", err)
+		return err
+	}
+
+	return nil
+}
+
+// RunJob runs a backup job now, like "Run now" in the web interface: vzdump is started with
+// the settings of the job on its node, or on every online node, and the tasks are returned.
+// When starting a task fails, the tasks started before are returned with the error.
+func (s *BackupService) RunJob(id string) ([]BackupJobRun, error) {
+	settings, err := Get[map[string]any](s.Client, "cluster/backup/"+url.PathEscape(id), nil)
+	if err != nil {
+		s.Logger.Error("Error getting backup job: ", err)
+		return nil, err
+	}
+
+	params := url.Values{}
+	for key, value := range settings {
+		if !slices.Contains(jobOnlyKeys, key) {
+			params.Set(key, settingString(value))
+		}
+	}
+
+	nodes := []string{params.Get("node")}
+	if nodes[0] == "" {
+		if nodes, err = onlineNodes(s.Client); err != nil {
+			s.Logger.Error("Error listing nodes: ", err)
+			return nil, err
+		}
+	}
+
+	runs := []BackupJobRun{}
+	for _, node := range nodes {
+		upid, err := Post[string](s.Client, fmt.Sprintf("nodes/%s/vzdump", node), params)
+		if err != nil {
+			s.Logger.Error("Error running backup job: ", err)
+			return runs, fmt.Errorf("starting the backup on node %s: %w", node, err)
+		}
+		runs = append(runs, BackupJobRun{Node: node, UPID: upid})
+	}
+
+	return runs, nil
+}
+
+// settingString formats a setting decoded from JSON as an API parameter
+func settingString(value any) string {
+	switch value := value.(type) {
+	case string:
+		return value
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	case bool:
+		return boolParam(value)
+	case map[string]any:
+		return joinSettings(value)
+	}
+	return fmt.Sprint(value)
+}
+
+// joinSettings formats an object of settings as a property string, e.g. "keep-last=3,keep-weekly=4"
+func joinSettings(settings map[string]any) string {
+	pairs := make([]string, 0, len(settings))
+	for key, value := range settings {
+		pairs = append(pairs, key+"="+settingString(value))
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+// joinVMIDs formats VM IDs as a comma separated list
+func joinVMIDs(vmids []int) string {
+	ids := make([]string, 0, len(vmids))
+	for _, vmid := range vmids {
+		ids = append(ids, strconv.Itoa(vmid))
+	}
+	return strings.Join(ids, ",")
+}
+
+// boolParam formats a boolean as the 0 or 1 of the API
+func boolParam(value bool) string {
+	if value {
+		return "1"
+	}
+	return "0"
+}
@@ -0,0 +1,197 @@
+package services
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// GuestSelector selects the VMs or containers of a bulk operation. Refs select guests
+// explicitly; All, Node, Pool and Tag select them by group. Node, Pool and Tag also narrow
+// down the guests selected by Refs or by each other.
+type GuestSelector struct {
+	// Refs are VM IDs, ID ranges such as 100-120 or name globs such as web-*
+	Refs []string
+	All  bool
+	Node string
+	Pool string
+	Tag  string
+}
+
+// Empty reports whether the selector selects nothing, i.e. neither Refs nor a group are given
+func (s GuestSelector) Empty() bool {
+	return len(s.Refs) == 0 && !s.All && s.Node == "" && s.Pool == "" && s.Tag == ""
+}
+
+// guestRef is a parsed selector ref
+type guestRef struct {
+	text     string
+	from, to int
+	glob     string
+}
+
+// parseGuestRef parses a VM ID, an ID range or a name glob
+func parseGuestRef(ref string) (guestRef, error) {
+	if vmid, err := strconv.Atoi(ref); err == nil {
+		return guestRef{text: ref, from: vmid, to: vmid}, nil
+	}
+
+	if from, to, ok := strings.Cut(ref, "-"); ok {
+		start, fromErr := strconv.Atoi(from)
+		end, toErr := strconv.Atoi(to)
+		if fromErr == nil && toErr == nil {
+			if start > end {
+				return guestRef{}, fmt.Errorf("invalid VM ID range %q: the start is after the end", ref)
+			}
+			return guestRef{text: ref, from: start, to: end}, nil
+		}
+	}
+
+	if _, err := path.Match(ref, ""); err != nil {
+		return guestRef{}, fmt.Errorf("invalid name pattern %q: %w", ref, err)
+	}
+	return guestRef{text: ref, glob: ref}, nil
+}
+
+// matches reports whether the guest matches the ref
+func (r guestRef) matches(guest ClusterResource) bool {
+	if r.glob != "" {
+		matched, _ := path.Match(r.glob, guest.Name)
+		return matched
+	}
+	return guest.VMID >= r.from && guest.VMID <= r.to
+}
+
+// single reports whether the ref names one VM ID, which must exist
+func (r guestRef) single() bool {
+	return r.glob == "" && r.from == r.to
+}
+
+// SelectGuests returns the VMs or containers of guestType matching selector, sorted by VM ID.
+// Templates are only selected when their ID is given explicitly, as power actions do not
+// apply to them. VM IDs that do not exist are an error, and so is an empty selection.
+func (c *ClusterService) SelectGuests(guestType string, selector GuestSelector) ([]ClusterResource, error) {
+	if selector.Empty() {
+		return nil, fmt.Errorf("no %ss selected: give VM IDs, ranges or name patterns, or select them with all, node, pool or tag", guestNoun(guestType))
+	}
+
+	refs := make([]guestRef, 0, len(selector.Refs))
+	for _, text := range selector.Refs {
+		ref, err := parseGuestRef(text)
+		if err != nil {
+			return nil, err
+		}
+		refs = append(refs, ref)
+	}
+
+	resources, err := c.ListResources()
+	if err != nil {
+		return nil, err
+	}
+
+	selected := []ClusterResource{}
+	found := map[int]bool{}
+	for _, resource := range resources {
+		if resource.Type != guestType {
+			continue
+		}
+		found[resource.VMID] = true
+		if selector.Node != "" && resource.Node != selector.Node {
+			continue
+		}
+		if selector.Pool != "" && resource.Pool != selector.Pool {
+			continue
+		}
+		if selector.Tag != "" && !hasTag(resource.Tags, selector.Tag) {
+			continue
+		}
+		if len(refs) == 0 {
+			if resource.Template != 1 {
+				selected = append(selected, resource)
+			}
+			continue
+		}
+		for _, ref := range refs {
+			if ref.matches(resource) && (resource.Template != 1 || ref.single()) {
+				selected = append(selected, resource)
+				break
+			}
+		}
+	}
+
+	for _, ref := range refs {
+		if ref.single() && !found[ref.from] {
+			return nil, fmt.Errorf("no %s with ID %d found in the cluster", guestNoun(guestType), ref.from)
+		}
+	}
+	if len(selected) == 0 {
+		return nil, fmt.Errorf("no %ss match the selection", guestNoun(guestType))
+	}
+
+	sort.Slice(selected, func(i, j int) bool { return selected[i].VMID < selected[j].VMID })
+	return selected, nil
+}
+
+// hasTag reports whether tag is in tags, a list separated by semicolons, commas or spaces
+func hasTag(tags, tag string) bool {
+	for _, candidate := range strings.FieldsFunc(tags, func(r rune) bool {
+		return r == ';' || r == ',' || r == ' '
+	}) {
+		if candidate == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// Results of an operation on one guest of a bulk run
+const (
+	BulkOK      = "ok"
+	BulkFailed  = "failed"
+	BulkSkipped = "skipped"
+)
+
+// BulkResult is the outcome of an operation on one guest of a bulk run
+type BulkResult struct {
+	VMID   int    `json:"vmid"`
+	Name   string `json:"name,omitempty"`
+	Node   string `json:"node"`
+	Result string `json:"result"`
+	// Detail is the task exit status, the error or the reason the guest was skipped
+	Detail string `json:"detail,omitempty"`
+}
+
+// RunBulk runs operation on every guest, at most parallel at a time, and returns the results
+// in the order of guests. operation returns the detail of a successful run or an error.
+func RunBulk(guests []ClusterResource, parallel int, operation func(ClusterResource) (string, error)) []BulkResult {
+	if parallel < 1 {
+		parallel = 1
+	}
+
+	results := make([]BulkResult, len(guests))
+	slots := make(chan struct{}, parallel)
+	var wg sync.WaitGroup
+	for i, guest := range guests {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func(i int, guest ClusterResource) {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			result := BulkResult{VMID: guest.VMID, Name: guest.Name, Node: guest.Node, Result: BulkOK}
+			detail, err := operation(guest)
+			if err != nil {
+				result.Result = BulkFailed
+				detail = err.Error()
+			}
+			result.Detail = detail
+			results[i] = result
+		}(i, guest)
+	}
+	wg.Wait()
+
+	return results
+}
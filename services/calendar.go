@@ -0,0 +1,186 @@
+package services
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// calendarShorthands are the named calendar events Proxmox accepts in place of a full spec
+var calendarShorthands = map[string]bool{
+	"minutely": true, "hourly": true, "daily": true, "weekly": true, "monthly": true,
+	"yearly": true, "annually": true, "quarterly": true, "semiannually": true,
+}
+
+// calendarWeekdays maps the weekday names of calendar events to their order in the week
+var calendarWeekdays = map[string]int{
+	"mon": 1, "monday": 1, "tue": 2, "tuesday": 2, "wed": 3, "wednesday": 3, "thu": 4, "thursday": 4,
+	"fri": 5, "friday": 5, "sat": 6, "saturday": 6, "sun": 7, "sunday": 7,
+}
+
+// ValidateCalendarEvent checks a Proxmox calendar event, the schedule syntax of backup and
+// replication jobs, so a mistake is reported before a job is submitted. An event is either
+// a shorthand such as "daily" or "[WEEKDAYS] [[YYYY-]MM-DD] [HH:]MM[:SS] [UTC]", e.g.
+// "mon..fri 21:00", "sat,sun 02:30", "*-*-01 03:00", "0/2:00" or "*/15".
+func ValidateCalendarEvent(event string) error {
+	if err := validateCalendarEvent(strings.ToLower(strings.TrimSpace(event))); err != nil {
+		return fmt.Errorf("invalid schedule %q: %w", event, err)
+	}
+	return nil
+}
+
+// validateCalendarEvent checks a trimmed, lower case calendar event
+func validateCalendarEvent(event string) error {
+	if event == "" {
+		return fmt.Errorf("the schedule is empty")
+	}
+	if calendarShorthands[event] {
+		return nil
+	}
+
+	fields := strings.Fields(event)
+	if fields[len(fields)-1] == "utc" {
+		fields = fields[:len(fields)-1]
+	}
+	if len(fields) == 0 {
+		return fmt.Errorf("a weekday, date or time is required")
+	}
+
+	if first := fields[0][0]; first >= 'a' && first <= 'z' {
+		if err := validateWeekdays(fields[0]); err != nil {
+			return err
+		}
+		fields = fields[1:]
+	}
+	if len(fields) > 0 && strings.Contains(fields[0], "-") {
+		if err := validateCalendarDate(fields[0]); err != nil {
+			return err
+		}
+		fields = fields[1:]
+	}
+	if len(fields) > 0 {
+		if err := validateCalendarTime(fields[0]); err != nil {
+			return err
+		}
+		fields = fields[1:]
+	}
+	if len(fields) > 0 {
+		return fmt.Errorf("unexpected %q", strings.Join(fields, " "))
+	}
+
+	return nil
+}
+
+// validateWeekdays checks a list of weekdays and weekday ranges, e.g. "mon..fri,sun"
+func validateWeekdays(spec string) error {
+	for _, item := range strings.Split(spec, ",") {
+		from, to, isRange := strings.Cut(item, "..")
+		start, ok := calendarWeekdays[from]
+		if !ok {
+			return fmt.Errorf("unknown weekday %q", from)
+		}
+		if !isRange {
+			continue
+		}
+		end, ok := calendarWeekdays[to]
+		if !ok {
+			return fmt.Errorf("unknown weekday %q", to)
+		}
+		if start > end {
+			return fmt.Errorf("weekday range %q ends before it starts", item)
+		}
+	}
+	return nil
+}
+
+// validateCalendarDate checks a date spec, "[YYYY-]MM-DD" with each part a calendar value
+func validateCalendarDate(spec string) error {
+	parts := strings.Split(spec, "-")
+	switch len(parts) {
+	case 2:
+		parts = append([]string{"*"}, parts...)
+	case 3:
+	default:
+		return fmt.Errorf("date %q is not [YYYY-]MM-DD", spec)
+	}
+
+	if err := validateCalendarValue(parts[0], "year", 1970, 9999); err != nil {
+		return err
+	}
+	if err := validateCalendarValue(parts[1], "month", 1, 12); err != nil {
+		return err
+	}
+	return validateCalendarValue(parts[2], "day", 1, 31)
+}
+
+// validateCalendarTime checks a time spec, "HH:MM[:SS]" or a lone minute such as "*/15"
+func validateCalendarTime(spec string) error {
+	parts := strings.Split(spec, ":")
+	switch len(parts) {
+	case 1:
+		return validateCalendarValue(parts[0], "minute", 0, 59)
+	case 2, 3:
+	default:
+		return fmt.Errorf("time %q is not HH:MM[:SS]", spec)
+	}
+
+	if err := validateCalendarValue(parts[0], "hour", 0, 23); err != nil {
+		return err
+	}
+	if err := validateCalendarValue(parts[1], "minute", 0, 59); err != nil {
+		return err
+	}
+	if len(parts) == 3 {
+		return validateCalendarValue(parts[2], "second", 0, 59)
+	}
+	return nil
+}
+
+// validateCalendarValue checks a list of calendar values between low and high. Each item is
+// "*", a number, a range "a..b" or one of them repeated with "/step", e.g. "*/15" or "8..17/2".
+func validateCalendarValue(spec, unit string, low, high int) error {
+	if spec == "" {
+		return fmt.Errorf("the %s is empty", unit)
+	}
+
+	for _, item := range strings.Split(spec, ",") {
+		value, step, repeated := strings.Cut(item, "/")
+		if repeated {
+			if n, err := strconv.Atoi(step); err != nil || n < 1 {
+				return fmt.Errorf("invalid %s repetition %q", unit, item)
+			}
+		}
+		if value == "*" {
+			continue
+		}
+
+		from, to, isRange := strings.Cut(value, "..")
+		start, err := calendarNumber(from, unit, low, high)
+		if err != nil {
+			return err
+		}
+		if !isRange {
+			continue
+		}
+		end, err := calendarNumber(to, unit, low, high)
+		if err != nil {
+			return err
+		}
+		if start > end {
+			return fmt.Errorf("%s range %q ends before it starts", unit, value)
+		}
+	}
+	return nil
+}
+
+// calendarNumber parses a calendar number between low and high
+func calendarNumber(text, unit string, low, high int) (int, error) {
+	n, err := strconv.Atoi(text)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q", unit, text)
+	}
+	if n < low || n > high {
+		return 0, fmt.Errorf("%s %d is out of range %d-%d", unit, n, low, high)
+	}
+	return n, nil
+}
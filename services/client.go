@@ -0,0 +1,225 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// APIClient sends authenticated requests to the Proxmox API of the current session.
+// It builds the base URL from the session, adds the ticket cookie or API token header
+// and, for write requests, the CSRF token, so services only deal with API paths.
+// Tickets are renewed transparently, so long running commands outlive the ticket lifetime.
+type APIClient struct {
+	Logger         *logrus.Logger
+	HTTPService    HTTPServiceInterface
+	SessionService SessionServiceInterface
+
+	// renewMu keeps concurrent requests from renewing the ticket at the same time
+	renewMu sync.Mutex
+}
+
+// apiResponse is the envelope Proxmox wraps around every API response
+type apiResponse[T any] struct {
+	Data    T                 `json:"data"`
+	Errors  map[string]string `json:"errors,omitempty"`
+	Message string            `json:"message,omitempty"`
+	// Changes is the diff of the pending configuration some endpoints return next to the data
+	Changes string `json:"changes,omitempty"`
+}
+
+// NewAPIClient creates a new APIClient for the selected context with real dependencies
+func NewAPIClient(logger *logrus.Logger, trust bool) (*APIClient, error) {
+	sessionService, err := NewSessionService(logger)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewAPIClientWithDeps(logger, NewHttpService(logger, trust), sessionService), nil
+}
+
+// NewAPIClientWithDeps creates an APIClient with injected dependencies (for testing)
+func NewAPIClientWithDeps(logger *logrus.Logger, httpService HTTPServiceInterface, sessionService SessionServiceInterface) *APIClient {
+	return &APIClient{
+		Logger:         logger,
+		HTTPService:    httpService,
+		SessionService: sessionService,
+	}
+}
+
+// Get sends a GET request for path, relative to /api2/json, and decodes the response data into T
+func Get[T any](c *APIClient, path string, query url.Values) (T, error) {
+	return request[T](c, "GET", path, query)
+}
+
+// Post sends a POST request with form encoded params and decodes the response data into T
+func Post[T any](c *APIClient, path string, params url.Values) (T, error) {
+	return request[T](c, "POST", path, params)
+}
+
+// Put sends a PUT request with form encoded params and decodes the response data into T
+func Put[T any](c *APIClient, path string, params url.Values) (T, error) {
+	return request[T](c, "PUT", path, params)
+}
+
+// Delete sends a DELETE request and decodes the response data into T
+func Delete[T any](c *APIClient, path string, query url.Values) (T, error) {
+	return request[T](c, "DELETE", path, query)
+}
+
+// GetWithChanges sends a GET request like Get and also returns the diff of the pending
+// configuration that endpoints such as nodes/{node}/network report next to the data
+func GetWithChanges[T any](c *APIClient, path string, query url.Values) (T, string, error) {
+	result, err := requestResponse[T](c, "GET", path, query)
+	return result.Data, result.Changes, err
+}
+
+func request[T any](c *APIClient, method, path string, params url.Values) (T, error) {
+	result, err := requestResponse[T](c, method, path, params)
+	return result.Data, err
+}
+
+// requestResponse sends a request and decodes the complete response envelope
+func requestResponse[T any](c *APIClient, method, path string, params url.Values) (apiResponse[T], error) {
+	body, err := c.do(method, path, params)
+	if err != nil {
+		return apiResponse[T]{}, err
+	}
+
+	var result apiResponse[T]
+	if err = json.Unmarshal(body, &result); err != nil {
+		c.Logger.Error("Error parsing response JSON: ", err)
+		return apiResponse[T]{}, err
+	}
+
+	if len(result.Errors) > 0 {
+		message := result.Message
+		if message == "" {
+			message = "Parameter verification failed."
+		}
+		return apiResponse[T]{}, &APIError{StatusCode: http.StatusBadRequest, Message: message, Errors: result.Errors}
+	}
+
+	return result, nil
+}
+
+// do sends an authenticated request and returns the raw response body.
+// Ticket sessions are renewed before the request when the ticket is about to expire,
+// and once more followed by a retry when the server answers 401. Proxmox rejects a request
+// with 401 before running it, so retrying cannot start a task such as vzdump twice.
+func (c *APIClient) do(method, path string, params url.Values) ([]byte, error) {
+	sessionData, err := c.SessionService.ReadSessionFile()
+	if err != nil {
+		c.Logger.Error("Error reading session file: ", err)
+		return nil, err
+	}
+
+	renewed := false
+	if sessionData.TicketNeedsRenewal(time.Now()) {
+		if sessionData, err = c.renewTicket(sessionData); err != nil {
+			return nil, err
+		}
+		renewed = true
+	}
+
+	body, err := c.send(method, path, params, sessionData)
+	if apiErr, ok := AsAPIError(err); ok && apiErr.IsUnauthorized() && !renewed && !sessionData.UsesAPIToken() {
+		c.Logger.Info("Request was rejected with 401, renewing the ticket")
+		// An expired ticket cannot be renewed, so report the original error in that case
+		if renewedSession, renewErr := c.renewTicket(sessionData); renewErr == nil {
+			return c.send(method, path, params, renewedSession)
+		}
+	}
+	return body, err
+}
+
+// LoadSession reads the session and renews its ticket when it is due. Operations that send
+// requests from many goroutines call it first, so the workers find the session loaded and
+// do not all renew the ticket at once.
+func (c *APIClient) LoadSession() error {
+	sessionData, err := c.SessionService.ReadSessionFile()
+	if err != nil {
+		c.Logger.Error("Error reading session file: ", err)
+		return err
+	}
+	if sessionData.TicketNeedsRenewal(time.Now()) {
+		_, err = c.renewTicket(sessionData)
+	}
+	return err
+}
+
+// renewTicket renews the ticket of the stale session through AuthService and returns the
+// updated session. Concurrent requests wait for each other, and when another request has
+// renewed the ticket in the meantime its session is returned instead of renewing again.
+func (c *APIClient) renewTicket(stale SessionData) (SessionData, error) {
+	c.renewMu.Lock()
+	defer c.renewMu.Unlock()
+
+	current, err := c.SessionService.ReadSessionFile()
+	if err != nil {
+		c.Logger.Error("Error reading session file: ", err)
+		return SessionData{}, err
+	}
+	if current.Response.Data.Ticket != stale.Response.Data.Ticket && !current.TicketNeedsRenewal(time.Now()) {
+		return current, nil
+	}
+
+	authService := NewAuthServiceWithDeps(c.Logger, false, c.HTTPService, c.SessionService)
+	if err := authService.RenewTicket(); err != nil {
+		c.Logger.Error("Error renewing ticket: ", err)
+		return SessionData{}, err
+	}
+
+	return c.SessionService.ReadSessionFile()
+}
+
+// send sends a single request with the credentials of sessionData.
+// GET and DELETE send params as the query string, POST and PUT as a form encoded body.
+func (c *APIClient) send(method, path string, params url.Values, sessionData SessionData) ([]byte, error) {
+	uri := fmt.Sprintf("%s://%s:%d/api2/json/%s",
+		sessionData.HttpScheme, sessionData.Server, sessionData.Port, strings.TrimPrefix(path, "/"))
+
+	cookies := sessionData.AuthCookies()
+
+	switch method {
+	case "GET":
+		resp, err := c.HTTPService.Get(withQuery(uri, params), sessionData.AuthHeaders(nil, false), cookies)
+		if err != nil {
+			return nil, err
+		}
+		//nolint:errcheck // Best effort close in defer
+		defer func() { _ = resp.Body.Close() }()
+
+		bodyBytes, err := io.ReadAll(resp.Body)
+		if err != nil {
+			c.Logger.Error("Error reading response body: ", err)
+			return nil, err
+		}
+		return bodyBytes, checkStatus(resp, bodyBytes)
+	case "POST":
+		body, err := c.HTTPService.Post(uri, params.Encode(), sessionData.AuthHeaders(URLEncodedHeader, true), cookies)
+		return []byte(body), err
+	case "PUT":
+		body, err := c.HTTPService.Put(uri, params.Encode(), sessionData.AuthHeaders(URLEncodedHeader, true), cookies)
+		return []byte(body), err
+	case "DELETE":
+		body, err := c.HTTPService.Delete(withQuery(uri, params), sessionData.AuthHeaders(nil, true), cookies)
+		return []byte(body), err
+	}
+
+	return nil, fmt.Errorf("unsupported HTTP method %q", method)
+}
+
+func withQuery(uri string, query url.Values) string {
+	if len(query) == 0 {
+		return uri
+	}
+	return uri + "?" + query.Encode()
+}
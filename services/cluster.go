@@ -1,12 +1,6 @@
 package services
 
 import (
-	"encoding/json"
-	"fmt"
-	"io"
-	"net/http"
-	"net/url"
-
 	"github.com/sirupsen/logrus"
 )
 
@@ -26,6 +20,11 @@ type ClusterResource struct {
 	Disk    int64   `json:"disk,omitempty"`
 	Uptime  int64   `json:"uptime,omitempty"`
 	Level   string  `json:"level,omitempty"`
+	// Pool, Tags and Template are only set for VMs and containers.
+	// Tags are separated by semicolons, e.g. prod;web
+	Pool     string `json:"pool,omitempty"`
+	Tags     string `json:"tags,omitempty"`
+	Template int    `json:"template,omitempty"`
 }
 
 // ClusterStatus represents cluster status information
@@ -41,22 +40,16 @@ type ClusterStatus struct {
 	Local   int    `json:"local,omitempty"`
 }
 
-// ClusterResourcesResponse represents the API response for cluster resources
-type ClusterResourcesResponse struct {
-	Data []ClusterResource `json:"data"`
-}
-
-// ClusterStatusResponse represents the API response for cluster status
-type ClusterStatusResponse struct {
-	Data []ClusterStatus `json:"data"`
-}
-
 // ClusterService handles cluster-related operations
 type ClusterService struct {
-	Logger         *logrus.Logger
-	Trust          bool
-	HTTPService    HTTPServiceInterface
-	SessionService SessionServiceInterface
+	Logger *logrus.Logger
+	Trust  bool
+	Client *APIClient
+	// GuestCachePath is the file caching the guest locations between invocations,
+	// see ResolveGuest. When empty, they are only cached in memory.
+	GuestCachePath string
+
+	guests *guestCache
 }
 
 // NewClusterService creates a new ClusterService with real dependencies
@@ -69,101 +62,38 @@ func NewClusterService(logger *logrus.Logger, trust bool) (*ClusterService, erro
 	return &ClusterService{
 		Logger:         logger,
 		Trust:          trust,
-		HTTPService:    NewHttpService(logger, trust),
-		SessionService: sessionService,
+		Client:         NewAPIClientWithDeps(logger, NewHttpService(logger, trust), sessionService),
+		GuestCachePath: sessionService.cacheFilepath("guests"),
 	}, nil
 }
 
 // NewClusterServiceWithDeps creates a ClusterService with injected dependencies (for testing)
 func NewClusterServiceWithDeps(logger *logrus.Logger, trust bool, httpService HTTPServiceInterface, sessionService SessionServiceInterface) *ClusterService {
 	return &ClusterService{
-		Logger:         logger,
-		Trust:          trust,
-		HTTPService:    httpService,
-		SessionService: sessionService,
+		Logger: logger,
+		Trust:  trust,
+		Client: NewAPIClientWithDeps(logger, httpService, sessionService),
 	}
 }
 
 // ListResources retrieves a list of all cluster resources
 func (c *ClusterService) ListResources() ([]ClusterResource, error) {
-	sessionData, err := c.SessionService.ReadSessionFile()
-	if err != nil {
-		c.Logger.Error("Error reading session file: ", err)
-		return nil, err
-	}
-
-	uri := fmt.Sprintf("%s://%s:%d/api2/json/cluster/resources",
-		sessionData.HttpScheme, sessionData.Server, sessionData.Port)
-
-	cookies := []*http.Cookie{
-		{
-			Name:  "PVEAuthCookie",
-			Value: url.QueryEscape(sessionData.Response.Data.Ticket),
-		},
-	}
-
-	resp, err := c.HTTPService.Get(uri, nil, cookies)
+	resources, err := Get[[]ClusterResource](c.Client, "cluster/resources", nil)
 	if err != nil {
 		c.Logger.Error("Error listing cluster resources: ", err)
 		return nil, err
 	}
-	//nolint:errcheck // Best effort close in defer
-	defer func() { _ = resp.Body.Close() }()
 
-	bodyBytes, err := io.ReadAll(resp.Body)
-	if err != nil {
-		c.Logger.Error("Error reading response body: ", err)
-		return nil, err
-	}
-
-	var result ClusterResourcesResponse
-	err = json.Unmarshal(bodyBytes, &result)
-	if err != nil {
-		c.Logger.Error("Error parsing response JSON: ", err)
-		return nil, err
-	}
-
-	return result.Data, nil
+	return resources, nil
 }
 
 // GetStatus retrieves cluster status information
 func (c *ClusterService) GetStatus() ([]ClusterStatus, error) {
-	sessionData, err := c.SessionService.ReadSessionFile()
-	if err != nil {
-		c.Logger.Error("Error reading session file: ", err)
-		return nil, err
-	}
-
-	uri := fmt.Sprintf("%s://%s:%d/api2/json/cluster/status",
-		sessionData.HttpScheme, sessionData.Server, sessionData.Port)
-
-	cookies := []*http.Cookie{
-		{
-			Name:  "PVEAuthCookie",
-			Value: url.QueryEscape(sessionData.Response.Data.Ticket),
-		},
-	}
-
-	resp, err := c.HTTPService.Get(uri, nil, cookies)
+	statuses, err := Get[[]ClusterStatus](c.Client, "cluster/status", nil)
 	if err != nil {
 		c.Logger.Error("Error getting cluster status: ", err)
 		return nil, err
 	}
-	//nolint:errcheck // Best effort close in defer
-	defer func() { _ = resp.Body.Close() }()
-
-	bodyBytes, err := io.ReadAll(resp.Body)
-	if err != nil {
-		c.Logger.Error("Error reading response body: ", err)
-		return nil, err
-	}
-
-	var result ClusterStatusResponse
-	err = json.Unmarshal(bodyBytes, &result)
-	if err != nil {
-		c.Logger.Error("Error parsing response JSON: ", err)
-		return nil, err
-	}
 
-	return result.Data, nil
+	return statuses, nil
 }
@@ -0,0 +1,209 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GuestCacheTTL is how long guest locations read from cluster/resources are reused.
+// It is kept short because guests move between nodes when they are migrated.
+var GuestCacheTTL = 30 * time.Second
+
+// GuestLocation identifies a VM or container and the node hosting it
+type GuestLocation struct {
+	// Type is GuestTypeQEMU or GuestTypeLXC
+	Type string `json:"type"`
+	VMID int    `json:"vmid"`
+	Name string `json:"name,omitempty"`
+	Node string `json:"node"`
+}
+
+// guestCache holds the guest locations of the cluster and when they were read
+type guestCache struct {
+	Fetched time.Time       `json:"fetched"`
+	Guests  []GuestLocation `json:"guests"`
+}
+
+// errGuestNotFound is returned by matchGuest when no guest matches, so the caller can retry
+// with fresh data
+var errGuestNotFound = errors.New("guest not found")
+
+// ResolveGuest finds the VM or container of guestType given by ref, a VM ID or a name.
+// An empty guestType matches guests of either type. When node is not empty, only guests
+// on that node match, which disambiguates names used on several nodes. The locations are
+// cached for GuestCacheTTL; a guest missing from the cache is looked up again in case it
+// was created since.
+func (c *ClusterService) ResolveGuest(guestType, ref, node string) (*GuestLocation, error) {
+	guests, fresh, err := c.guestLocations(false)
+	if err != nil {
+		return nil, err
+	}
+
+	location, err := matchGuest(guests, guestType, ref, node)
+	if errors.Is(err, errGuestNotFound) && !fresh {
+		if guests, _, err = c.guestLocations(true); err != nil {
+			return nil, err
+		}
+		location, err = matchGuest(guests, guestType, ref, node)
+	}
+	if errors.Is(err, errGuestNotFound) {
+		return nil, guestNotFoundError(guests, guestType, ref, node)
+	}
+	return location, err
+}
+
+// ForgetGuests drops the cached guest locations, e.g. after a guest moved to another node
+func (c *ClusterService) ForgetGuests() {
+	c.guests = nil
+	if c.GuestCachePath == "" {
+		return
+	}
+	if err := os.Remove(c.GuestCachePath); err != nil && !os.IsNotExist(err) {
+		c.Logger.Warn("Could not remove the guest cache: ", err)
+	}
+}
+
+// guestLocations returns the guest locations from the cache or, when it is expired or
+// refresh is set, from cluster/resources. fresh reports whether they were just read.
+func (c *ClusterService) guestLocations(refresh bool) (guests []GuestLocation, fresh bool, err error) {
+	now := time.Now()
+	if !refresh {
+		if c.guests == nil {
+			c.guests = c.readGuestCache()
+		}
+		if c.guests != nil && now.Sub(c.guests.Fetched) < GuestCacheTTL {
+			return c.guests.Guests, false, nil
+		}
+	}
+
+	resources, err := Get[[]ClusterResource](c.Client, "cluster/resources", nil)
+	if err != nil {
+		c.Logger.Error("Error listing cluster resources: ", err)
+		return nil, false, err
+	}
+
+	guests = []GuestLocation{}
+	for _, resource := range resources {
+		if resource.Type == GuestTypeQEMU || resource.Type == GuestTypeLXC {
+			guests = append(guests, GuestLocation{
+				Type: resource.Type,
+				VMID: resource.VMID,
+				Name: resource.Name,
+				Node: resource.Node,
+			})
+		}
+	}
+
+	c.guests = &guestCache{Fetched: now, Guests: guests}
+	c.writeGuestCache()
+	return guests, true, nil
+}
+
+// readGuestCache reads the cached guest locations, returning nil if there are none
+func (c *ClusterService) readGuestCache() *guestCache {
+	if c.GuestCachePath == "" {
+		return nil
+	}
+
+	//nolint:gosec // G304: File path is constructed from trusted homeDir
+	content, err := os.ReadFile(c.GuestCachePath) // #nosec G304 -- File path from trusted homeDir
+	if err != nil {
+		return nil
+	}
+
+	var cache guestCache
+	if err = json.Unmarshal(content, &cache); err != nil {
+		c.Logger.Warn("Ignoring invalid guest cache: ", err)
+		return nil
+	}
+	return &cache
+}
+
+// writeGuestCache stores the guest locations for later invocations. The cache only
+// saves lookups, so failing to write it is not an error.
+func (c *ClusterService) writeGuestCache() {
+	if c.GuestCachePath == "" {
+		return
+	}
+
+	content, err := json.Marshal(c.guests)
+	if err == nil {
+		err = ensurePrivateDir(filepath.Dir(c.GuestCachePath))
+	}
+	if err == nil {
+		err = writeFileAtomic(c.GuestCachePath, content)
+	}
+	if err != nil {
+		c.Logger.Warn("Could not write the guest cache: ", err)
+	}
+}
+
+// matchGuest finds the guest of guestType given by a VM ID or name in guests
+func matchGuest(guests []GuestLocation, guestType, ref, node string) (*GuestLocation, error) {
+	vmid, err := strconv.Atoi(ref)
+	isID := err == nil
+
+	matches := []GuestLocation{}
+	for _, guest := range guests {
+		if (guestType != "" && guest.Type != guestType) || (node != "" && guest.Node != node) {
+			continue
+		}
+		if (isID && guest.VMID == vmid) || (!isID && guest.Name == ref) {
+			matches = append(matches, guest)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return nil, errGuestNotFound
+	case 1:
+		return &matches[0], nil
+	}
+
+	sort.Slice(matches, func(i, j int) bool { return matches[i].VMID < matches[j].VMID })
+	candidates := make([]string, 0, len(matches))
+	for _, match := range matches {
+		candidates = append(candidates, fmt.Sprintf("%d on %s", match.VMID, match.Node))
+	}
+	return nil, fmt.Errorf("%s name %q is ambiguous, it matches %s; use the %s ID instead",
+		guestNoun(guestType), ref, strings.Join(candidates, ", "), guestNoun(guestType))
+}
+
+// guestNotFoundError explains why no guest matches ref, pointing out a guest of the other type
+func guestNotFoundError(guests []GuestLocation, guestType, ref, node string) error {
+	noun := guestNoun(guestType)
+	if vmid, err := strconv.Atoi(ref); err == nil {
+		for _, guest := range guests {
+			if guest.VMID == vmid && guestType != "" && guest.Type != guestType {
+				return fmt.Errorf("%d is a %s, not a %s", vmid, guestNoun(guest.Type), noun)
+			}
+		}
+		if node != "" {
+			return fmt.Errorf("no %s with ID %d found on node %s", noun, vmid, node)
+		}
+		return fmt.Errorf("no %s with ID %d found in the cluster", noun, vmid)
+	}
+
+	if node != "" {
+		return fmt.Errorf("no %s named %q found on node %s", noun, ref, node)
+	}
+	return fmt.Errorf("no %s named %q found in the cluster", noun, ref)
+}
+
+// guestNoun names a guest type in messages
+func guestNoun(guestType string) string {
+	switch guestType {
+	case GuestTypeLXC:
+		return "container"
+	case "":
+		return "guest"
+	}
+	return "VM"
+}
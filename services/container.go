@@ -0,0 +1,273 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Container represents an LXC container in Proxmox
+type Container struct {
+	VMID    int     `json:"vmid"`
+	Name    string  `json:"name"`
+	Status  string  `json:"status"`
+	CPU     float64 `json:"cpu,omitempty"`
+	CPUs    float64 `json:"cpus,omitempty"`
+	Mem     int64   `json:"mem,omitempty"`
+	MaxMem  int64   `json:"maxmem,omitempty"`
+	Swap    int64   `json:"swap,omitempty"`
+	MaxSwap int64   `json:"maxswap,omitempty"`
+	Disk    int64   `json:"disk,omitempty"`
+	MaxDisk int64   `json:"maxdisk,omitempty"`
+	Uptime  int64   `json:"uptime,omitempty"`
+	Tags    string  `json:"tags,omitempty"`
+}
+
+// UnmarshalJSON decodes a container, whose VM ID older Proxmox versions return as a string
+func (c *Container) UnmarshalJSON(data []byte) error {
+	type container Container
+	var raw struct {
+		container
+		VMID json.Number `json:"vmid"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	*c = Container(raw.container)
+	if raw.VMID != "" {
+		vmid, err := strconv.Atoi(raw.VMID.String())
+		if err != nil {
+			return fmt.Errorf("invalid container VM ID %q: %w", raw.VMID, err)
+		}
+		c.VMID = vmid
+	}
+	return nil
+}
+
+// ContainerStatus represents container status details
+type ContainerStatus struct {
+	Status  string  `json:"status"`
+	VMID    int     `json:"vmid"`
+	Name    string  `json:"name,omitempty"`
+	CPU     float64 `json:"cpu,omitempty"`
+	CPUs    float64 `json:"cpus,omitempty"`
+	Mem     int64   `json:"mem,omitempty"`
+	MaxMem  int64   `json:"maxmem,omitempty"`
+	Swap    int64   `json:"swap,omitempty"`
+	MaxSwap int64   `json:"maxswap,omitempty"`
+	Disk    int64   `json:"disk,omitempty"`
+	MaxDisk int64   `json:"maxdisk,omitempty"`
+	Uptime  int64   `json:"uptime,omitempty"`
+}
+
+// ContainerMigrateOptions describes how a container is migrated.
+// Containers cannot be migrated live; a running container is restarted on the target.
+type ContainerMigrateOptions struct {
+	Target string
+	// Restart shuts a running container down, migrates it and starts it on the target
+	Restart bool
+	// Timeout is the number of seconds to wait for the shutdown of a restart migration
+	Timeout int
+	// TargetStorage maps the volumes to a storage on the target
+	TargetStorage string
+}
+
+// ContainerService handles container-related operations
+type ContainerService struct {
+	Logger *logrus.Logger
+	Trust  bool
+	Client *APIClient
+}
+
+// NewContainerService creates a new ContainerService with real dependencies
+func NewContainerService(logger *logrus.Logger, trust bool) (*ContainerService, error) {
+	client, err := NewAPIClient(logger, trust)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ContainerService{
+		Logger: logger,
+		Trust:  trust,
+		Client: client,
+	}, nil
+}
+
+// NewContainerServiceWithDeps creates a ContainerService with injected dependencies (for testing)
+func NewContainerServiceWithDeps(logger *logrus.Logger, trust bool, httpService HTTPServiceInterface, sessionService SessionServiceInterface) *ContainerService {
+	return &ContainerService{
+		Logger: logger,
+		Trust:  trust,
+		Client: NewAPIClientWithDeps(logger, httpService, sessionService),
+	}
+}
+
+// ListContainers retrieves a list of all containers on a specific node
+func (s *ContainerService) ListContainers(nodeName string) ([]Container, error) {
+	containers, err := Get[[]Container](s.Client, fmt.Sprintf("nodes/%s/lxc", nodeName), nil)
+	if err != nil {
+		s.Logger.Error("Error listing containers: ", err)
+		return nil, err
+	}
+
+	return containers, nil
+}
+
+// GetContainerStatus retrieves the current status of a specific container
+func (s *ContainerService) GetContainerStatus(nodeName string, vmid int) (*ContainerStatus, error) {
+	status, err := Get[ContainerStatus](s.Client, guestPath(nodeName, GuestTypeLXC, vmid)+"/status/current", nil)
+	if err != nil {
+		s.Logger.Error("Error getting container status: ", err)
+		return nil, err
+	}
+
+	return &status, nil
+}
+
+// StartContainer starts a container
+func (s *ContainerService) StartContainer(nodeName string, vmid int) (string, error) {
+	return s.statusAction(nodeName, vmid, "start")
+}
+
+// StopContainer stops a container immediately
+func (s *ContainerService) StopContainer(nodeName string, vmid int) (string, error) {
+	return s.statusAction(nodeName, vmid, "stop")
+}
+
+// ShutdownContainer gracefully shuts down a container
+func (s *ContainerService) ShutdownContainer(nodeName string, vmid int) (string, error) {
+	return s.statusAction(nodeName, vmid, "shutdown")
+}
+
+// RebootContainer reboots a container
+func (s *ContainerService) RebootContainer(nodeName string, vmid int) (string, error) {
+	return s.statusAction(nodeName, vmid, "reboot")
+}
+
+// SuspendContainer freezes a container
+func (s *ContainerService) SuspendContainer(nodeName string, vmid int) (string, error) {
+	return s.statusAction(nodeName, vmid, "suspend")
+}
+
+// ResumeContainer thaws a suspended container
+func (s *ContainerService) ResumeContainer(nodeName string, vmid int) (string, error) {
+	return s.statusAction(nodeName, vmid, "resume")
+}
+
+// statusAction performs a status action on a container (start, stop, etc.) and returns the task UPID
+func (s *ContainerService) statusAction(nodeName string, vmid int, action string) (string, error) {
+	upid, err := Post[string](s.Client, fmt.Sprintf("%s/status/%s", guestPath(nodeName, GuestTypeLXC, vmid), action), nil)
+	if err != nil {
+		s.Logger.Error(fmt.Sprintf("Error performing %s on container: ", action), err)
+		return "", err
+	}
+
+	return upid, nil
+}
+
+// DeleteContainer deletes a container and returns the task UPID
+func (s *ContainerService) DeleteContainer(nodeName string, vmid int) (string, error) {
+	upid, err := Delete[string](s.Client, guestPath(nodeName, GuestTypeLXC, vmid), nil)
+	if err != nil {
+		s.Logger.Error("Error deleting container: ", err)
+		return "", err
+	}
+
+	return upid, nil
+}
+
+// NextVMID returns the next free VM ID of the cluster
+func (s *ContainerService) NextVMID() (int, error) {
+	vmid, err := nextVMID(s.Client)
+	if err != nil {
+		s.Logger.Error("Error getting next VM ID: ", err)
+		return 0, err
+	}
+
+	return vmid, nil
+}
+
+// CreateContainer creates a container on a node from config and returns its VM ID and the task UPID.
+// A vmid of zero allocates the next free ID of the cluster.
+func (s *ContainerService) CreateContainer(nodeName string, vmid int, config ContainerConfig) (int, string, error) {
+	if config.OSTemplate == "" {
+		return 0, "", fmt.Errorf("an OS template is required to create a container")
+	}
+	if err := config.Validate(); err != nil {
+		return 0, "", err
+	}
+
+	if vmid == 0 {
+		var err error
+		if vmid, err = s.NextVMID(); err != nil {
+			return 0, "", err
+		}
+	}
+
+	params := config.params()
+	params.Set("vmid", strconv.Itoa(vmid))
+
+	upid, err := Post[string](s.Client, fmt.Sprintf("nodes/%s/lxc", nodeName), params)
+	if err != nil {
+		s.Logger.Error("Error creating container: ", err)
+		return 0, "", err
+	}
+
+	return vmid, upid, nil
+}
+
+// Clone clones a container or template and returns the VM ID of the clone and the task UPID
+func (s *ContainerService) Clone(nodeName string, vmid int, options CloneOptions) (int, string, error) {
+	newID, upid, err := clone(s.Client, guestPath(nodeName, GuestTypeLXC, vmid), "hostname", options)
+	if err != nil {
+		s.Logger.Error("Error cloning container: ", err)
+		return 0, "", err
+	}
+
+	return newID, upid, nil
+}
+
+// ConvertToTemplate converts a stopped container into a template.
+// Unlike for VMs, Proxmox converts containers synchronously and returns no task.
+func (s *ContainerService) ConvertToTemplate(nodeName string, vmid int) error {
+	if _, err := Post[any](s.Client, guestPath(nodeName, GuestTypeLXC, vmid)+"/template", nil); err != nil {
+		s.Logger.Error("Error converting container to template: ", err)
+		return err
+	}
+
+	return nil
+}
+
+// Migrate migrates a container to another node and returns the task UPID
+func (s *ContainerService) Migrate(nodeName string, vmid int, options ContainerMigrateOptions) (string, error) {
+	if options.Target == "" {
+		return "", fmt.Errorf("a target node is required")
+	}
+	if options.Timeout > 0 && !options.Restart {
+		return "", fmt.Errorf("a shutdown timeout can only be used with a restart migration")
+	}
+
+	params := url.Values{}
+	params.Set("target", options.Target)
+	if options.Restart {
+		params.Set("restart", "1")
+	}
+	if options.Timeout > 0 {
+		params.Set("timeout", strconv.Itoa(options.Timeout))
+	}
+	if options.TargetStorage != "" {
+		params.Set("target-storage", options.TargetStorage)
+	}
+
+	upid, err := Post[string](s.Client, guestPath(nodeName, GuestTypeLXC, vmid)+"/migrate", params)
+	if err != nil {
+		s.Logger.Error("Error migrating container: ", err)
+		return "", err
+	}
+
+	return upid, nil
+}
@@ -0,0 +1,211 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+)
+
+// ContainerConfig represents container configuration.
+// Like VMConfig it groups the flat key/value pairs Proxmox returns; mount points and
+// network interfaces are grouped and keys without a dedicated field are kept in Other.
+type ContainerConfig struct {
+	Hostname     string `json:"hostname,omitempty"`
+	Memory       int    `json:"memory,omitempty"`
+	Swap         int    `json:"swap,omitempty"`
+	Cores        int    `json:"cores,omitempty"`
+	OSType       string `json:"ostype,omitempty"`
+	Arch         string `json:"arch,omitempty"`
+	Description  string `json:"description,omitempty"`
+	Unprivileged bool   `json:"unprivileged,omitempty"`
+	Nameserver   string `json:"nameserver,omitempty"`
+	Searchdomain string `json:"searchdomain,omitempty"`
+	// RootFS is the root volume spec, e.g. local-lvm:8 when creating a container
+	RootFS string `json:"rootfs,omitempty"`
+	// MountPoints maps mount point slots to volume specs, e.g. mp0 -> local-lvm:16,mp=/data
+	MountPoints map[string]string `json:"mountPoints,omitempty"`
+	// Networks maps network slots to device specs, e.g. net0 -> name=eth0,bridge=vmbr0,ip=dhcp
+	Networks map[string]string `json:"networks,omitempty"`
+	// Other holds the remaining configuration keys, such as features, onboot or unused volumes
+	Other map[string]string `json:"other,omitempty"`
+	// Digest identifies the configuration version, see ConfigUpdate.Digest
+	Digest string `json:"digest,omitempty"`
+	// Pending lists changes that take effect after the next restart
+	Pending []PendingChange `json:"pending,omitempty"`
+
+	// OSTemplate is the template volume a container is created from,
+	// e.g. local:vztmpl/debian-12-standard_12.2-1_amd64.tar.zst
+	OSTemplate string `json:"-"`
+	// Password is the root password set when creating a container
+	Password string `json:"-"`
+	// SSHPublicKeys are the public keys authorized for root when creating a container
+	SSHPublicKeys string `json:"-"`
+	// Storage is the default storage for volumes given only by size when creating a container
+	Storage string `json:"-"`
+}
+
+var mountPointSlotPattern = regexp.MustCompile(`^mp[0-9]+$`)
+
+// UnmarshalJSON decodes the flat configuration returned by Proxmox
+func (c *ContainerConfig) UnmarshalJSON(data []byte) error {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	*c = ContainerConfig{}
+	for key, value := range raw {
+		c.setKey(key, rawString(value))
+	}
+	return nil
+}
+
+// setKey stores a configuration value in the matching field
+func (c *ContainerConfig) setKey(key, value string) {
+	setInt := func(field *int) {
+		number, err := strconv.Atoi(value)
+		if err != nil {
+			c.setOther(key, value)
+			return
+		}
+		*field = number
+	}
+
+	switch {
+	case key == "hostname":
+		c.Hostname = value
+	case key == "memory":
+		setInt(&c.Memory)
+	case key == "swap":
+		setInt(&c.Swap)
+	case key == "cores":
+		setInt(&c.Cores)
+	case key == "ostype":
+		c.OSType = value
+	case key == "arch":
+		c.Arch = value
+	case key == "description":
+		c.Description = value
+	case key == "unprivileged":
+		c.Unprivileged = value == "1"
+	case key == "nameserver":
+		c.Nameserver = value
+	case key == "searchdomain":
+		c.Searchdomain = value
+	case key == "rootfs":
+		c.RootFS = value
+	case key == "digest":
+		c.Digest = value
+	case mountPointSlotPattern.MatchString(key):
+		if c.MountPoints == nil {
+			c.MountPoints = map[string]string{}
+		}
+		c.MountPoints[key] = value
+	case networkSlotPattern.MatchString(key):
+		if c.Networks == nil {
+			c.Networks = map[string]string{}
+		}
+		c.Networks[key] = value
+	default:
+		c.setOther(key, value)
+	}
+}
+
+func (c *ContainerConfig) setOther(key, value string) {
+	if c.Other == nil {
+		c.Other = map[string]string{}
+	}
+	c.Other[key] = value
+}
+
+// Validate checks the mount point and network slot names of the configuration
+func (c ContainerConfig) Validate() error {
+	for slot := range c.MountPoints {
+		if !mountPointSlotPattern.MatchString(slot) {
+			return fmt.Errorf("invalid mount point slot %q: use mpN", slot)
+		}
+	}
+	for slot := range c.Networks {
+		if !networkSlotPattern.MatchString(slot) {
+			return fmt.Errorf("invalid network slot %q: use netN", slot)
+		}
+	}
+	return nil
+}
+
+// params returns the configuration as API parameters for creating a container
+func (c ContainerConfig) params() url.Values {
+	params := url.Values{}
+	setParam := func(key, value string) {
+		if value != "" {
+			params.Set(key, value)
+		}
+	}
+	setInt := func(key string, value int) {
+		if value > 0 {
+			params.Set(key, strconv.Itoa(value))
+		}
+	}
+	setAll := func(values map[string]string) {
+		for key, value := range values {
+			params.Set(key, value)
+		}
+	}
+
+	setAll(c.Other)
+	setParam("ostemplate", c.OSTemplate)
+	setParam("hostname", c.Hostname)
+	setInt("memory", c.Memory)
+	setInt("swap", c.Swap)
+	setInt("cores", c.Cores)
+	setParam("ostype", c.OSType)
+	setParam("arch", c.Arch)
+	setParam("description", c.Description)
+	if c.Unprivileged {
+		params.Set("unprivileged", "1")
+	}
+	setParam("nameserver", c.Nameserver)
+	setParam("searchdomain", c.Searchdomain)
+	setParam("rootfs", c.RootFS)
+	setParam("storage", c.Storage)
+	setParam("password", c.Password)
+	setParam("ssh-public-keys", c.SSHPublicKeys)
+	setAll(c.MountPoints)
+	setAll(c.Networks)
+
+	return params
+}
+
+// GetConfig retrieves the configuration of a container
+func (s *ContainerService) GetConfig(nodeName string, vmid int) (*ContainerConfig, error) {
+	config, err := Get[ContainerConfig](s.Client, guestPath(nodeName, GuestTypeLXC, vmid)+"/config", nil)
+	if err != nil {
+		s.Logger.Error("Error getting container config: ", err)
+		return nil, err
+	}
+
+	return &config, nil
+}
+
+// GetPendingChanges retrieves the configuration changes of a container that await a restart
+func (s *ContainerService) GetPendingChanges(nodeName string, vmid int) ([]PendingChange, error) {
+	changes, err := getPendingChanges(s.Client, guestPath(nodeName, GuestTypeLXC, vmid))
+	if err != nil {
+		s.Logger.Error("Error getting pending container changes: ", err)
+		return nil, err
+	}
+
+	return changes, nil
+}
+
+// UpdateConfig sets and removes configuration keys of a container
+func (s *ContainerService) UpdateConfig(nodeName string, vmid int, update ConfigUpdate) error {
+	if err := updateConfig(s.Client, guestPath(nodeName, GuestTypeLXC, vmid), update); err != nil {
+		s.Logger.Error("Error updating container config: ", err)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,54 @@
+package services
+
+import "fmt"
+
+// ListSnapshots retrieves the snapshots of a container, including the current state entry
+func (s *ContainerService) ListSnapshots(nodeName string, vmid int) ([]Snapshot, error) {
+	snapshots, err := listSnapshots(s.Client, guestPath(nodeName, GuestTypeLXC, vmid))
+	if err != nil {
+		s.Logger.Error("Error listing snapshots: ", err)
+		return nil, err
+	}
+
+	return snapshots, nil
+}
+
+// CreateSnapshot creates a snapshot of a container and returns the task UPID.
+// Container snapshots cannot include RAM, so vmState must be false.
+func (s *ContainerService) CreateSnapshot(nodeName string, vmid int, name, description string, vmState bool) (string, error) {
+	if vmState {
+		return "", fmt.Errorf("container snapshots cannot include RAM")
+	}
+
+	upid, err := createSnapshot(s.Client, guestPath(nodeName, GuestTypeLXC, vmid), name, description, false)
+	if err != nil {
+		s.Logger.Error("Error creating snapshot: ", err)
+		return "", err
+	}
+
+	return upid, nil
+}
+
+// RollbackSnapshot rolls a container back to a snapshot and returns the task UPID.
+// With start the container is started after the rollback.
+func (s *ContainerService) RollbackSnapshot(nodeName string, vmid int, name string, start bool) (string, error) {
+	upid, err := rollbackSnapshot(s.Client, guestPath(nodeName, GuestTypeLXC, vmid), name, start)
+	if err != nil {
+		s.Logger.Error("Error rolling back snapshot: ", err)
+		return "", err
+	}
+
+	return upid, nil
+}
+
+// DeleteSnapshot deletes a snapshot of a container and returns the task UPID.
+// With force the snapshot is removed from the configuration even if removing its data fails.
+func (s *ContainerService) DeleteSnapshot(nodeName string, vmid int, name string, force bool) (string, error) {
+	upid, err := deleteSnapshot(s.Client, guestPath(nodeName, GuestTypeLXC, vmid), name, force)
+	if err != nil {
+		s.Logger.Error("Error deleting snapshot: ", err)
+		return "", err
+	}
+
+	return upid, nil
+}
@@ -0,0 +1,250 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// DefaultContext is the context used when none has been selected.
+// It is stored in the legacy ~/.proxmox/session file so existing sessions keep working.
+const DefaultContext = "default"
+
+// SelectedContext is the context chosen with the --context flag.
+// When empty, the current context recorded in ~/.proxmox/current-context is used.
+var SelectedContext string
+
+var contextNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// ContextInfo summarizes a stored context
+type ContextInfo struct {
+	Name     string `json:"name"`
+	Server   string `json:"server,omitempty"`
+	Port     int    `json:"port,omitempty"`
+	Username string `json:"username,omitempty"`
+	Current  bool   `json:"current"`
+}
+
+// ContextName returns the name of the context this service reads and writes
+func (s *SessionService) ContextName() string {
+	return s.context
+}
+
+// ListContexts returns all stored contexts sorted by name
+func (s *SessionService) ListContexts() ([]ContextInfo, error) {
+	names := []string{}
+
+	if s.ContextExists(DefaultContext) {
+		names = append(names, DefaultContext)
+	}
+
+	entries, err := os.ReadDir(s.contextsDir())
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() && validateContextName(entry.Name()) == nil && s.ContextExists(entry.Name()) {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+
+	current := s.currentContext()
+	contexts := make([]ContextInfo, 0, len(names))
+	for _, name := range names {
+		info := ContextInfo{Name: name, Current: name == current}
+
+		// Contexts with unreadable session data are still listed so they can be deleted
+		if data, readErr := s.readContextFile(name); readErr == nil {
+			info.Server = data.Server
+			info.Port = data.Port
+			info.Username = data.Response.Data.Username
+		} else {
+			s.logger.Info("Could not read context ", name, ": ", readErr)
+		}
+
+		contexts = append(contexts, info)
+	}
+
+	return contexts, nil
+}
+
+// UseContext makes name the current context for future invocations
+func (s *SessionService) UseContext(name string) error {
+	if err := validateContextName(name); err != nil {
+		return err
+	}
+	if name != DefaultContext && !s.ContextExists(name) {
+		return fmt.Errorf("context %q does not exist", name)
+	}
+
+	if err := ensurePrivateDir(s.baseDir()); err != nil {
+		return err
+	}
+
+	return writeFileAtomic(s.currentContextFilepath(), []byte(name+"\n"))
+}
+
+// RenameContext renames a stored context, keeping it current if it was.
+// Secrets kept in a secret store move along with the session file.
+func (s *SessionService) RenameContext(oldName, newName string) error {
+	if err := validateContextName(oldName); err != nil {
+		return err
+	}
+	if err := validateContextName(newName); err != nil {
+		return err
+	}
+	if !s.ContextExists(oldName) {
+		return fmt.Errorf("context %q does not exist", oldName)
+	}
+	if s.ContextExists(newName) {
+		return fmt.Errorf("context %q already exists", newName)
+	}
+
+	newPath := s.contextFilepath(newName)
+	if err := ensurePrivateDir(filepath.Dir(newPath)); err != nil {
+		return err
+	}
+
+	// Contexts with unreadable session data have no secrets to move
+	var store SecretStore
+	if data, readErr := s.readContextFile(oldName); readErr == nil {
+		var err error
+		if store, err = s.secretStore(data.SecretStore); err != nil {
+			return err
+		}
+	}
+	if store != nil {
+		secrets, err := store.Get(oldName)
+		if err != nil {
+			return fmt.Errorf("error reading secrets of context %q: %w", oldName, err)
+		}
+		if err = store.Set(newName, secrets); err != nil {
+			return err
+		}
+	}
+
+	if err := os.Rename(s.contextFilepath(oldName), newPath); err != nil {
+		return err
+	}
+
+	if store != nil {
+		if err := store.Delete(oldName); err != nil {
+			s.logger.Warn("Could not remove secrets of the old context name: ", err)
+		}
+	}
+
+	if s.currentContext() == oldName {
+		return s.UseContext(newName)
+	}
+	return nil
+}
+
+// DeleteContext removes a stored context and its stored secrets.
+// Deleting the current context falls back to the default one.
+func (s *SessionService) DeleteContext(name string) error {
+	if err := validateContextName(name); err != nil {
+		return err
+	}
+	if !s.ContextExists(name) {
+		return fmt.Errorf("context %q does not exist", name)
+	}
+
+	if data, readErr := s.readContextFile(name); readErr == nil && data.SecretStore != "" {
+		if err := s.deleteSecrets(data.SecretStore, name); err != nil {
+			return fmt.Errorf("error removing secrets of context %q: %w", name, err)
+		}
+	}
+
+	if err := os.Remove(s.contextFilepath(name)); err != nil {
+		return err
+	}
+
+	if s.currentContext() == name {
+		err := os.Remove(s.currentContextFilepath())
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// currentContext returns the persisted current context, or DefaultContext if none is set
+func (s *SessionService) currentContext() string {
+	//nolint:gosec // G304: File path is constructed from trusted homeDir
+	content, err := os.ReadFile(s.currentContextFilepath()) // #nosec G304 -- File path from trusted homeDir
+	if err != nil {
+		return DefaultContext
+	}
+
+	name := strings.TrimSpace(string(content))
+	if validateContextName(name) != nil {
+		return DefaultContext
+	}
+	return name
+}
+
+// readContextFile reads the raw session data of a context without validating it
+func (s *SessionService) readContextFile(name string) (SessionData, error) {
+	//nolint:gosec // G304: File path is constructed from trusted homeDir
+	content, err := os.ReadFile(s.contextFilepath(name)) // #nosec G304 -- File path from trusted homeDir
+	if err != nil {
+		return SessionData{}, err
+	}
+
+	var data SessionData
+	err = json.Unmarshal(content, &data)
+	return data, err
+}
+
+// Trusted reports whether the context was saved with certificate trust. Trust is kept in
+// the session file itself, so this does not open the secret store holding the credentials.
+func (s *SessionService) Trusted() bool {
+	data, err := s.readContextFile(s.context)
+	return err == nil && data.Trust
+}
+
+// ContextExists reports whether a session has been saved for the named context. Empty files
+// left behind by older versions, which created them when reading a context, do not count.
+func (s *SessionService) ContextExists(name string) bool {
+	info, err := os.Stat(s.contextFilepath(name))
+	return err == nil && !info.IsDir() && info.Size() > 0
+}
+
+func (s *SessionService) baseDir() string {
+	return filepath.Join(s.homeDir, ".proxmox")
+}
+
+func (s *SessionService) contextsDir() string {
+	return filepath.Join(s.baseDir(), "contexts")
+}
+
+// cacheFilepath returns the file caching data of the context, such as the guest locations
+func (s *SessionService) cacheFilepath(name string) string {
+	return filepath.Join(s.baseDir(), "cache", s.context+"-"+name+".json")
+}
+
+func (s *SessionService) currentContextFilepath() string {
+	return filepath.Join(s.baseDir(), "current-context")
+}
+
+// contextFilepath returns the session file of a context. The default context
+// lives in the legacy ~/.proxmox/session file.
+func (s *SessionService) contextFilepath(name string) string {
+	if name == DefaultContext {
+		return filepath.Join(s.baseDir(), "session")
+	}
+	return filepath.Join(s.contextsDir(), name)
+}
+
+// validateContextName rejects names that could escape the contexts directory
+func validateContextName(name string) error {
+	if !contextNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid context name %q: use letters, digits, '.', '_' or '-'", name)
+	}
+	return nil
+}
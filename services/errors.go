@@ -0,0 +1,101 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// APIError is returned when the Proxmox API answers with an HTTP error status or
+// rejects request parameters
+type APIError struct {
+	StatusCode int
+	Message    string
+	// Errors holds the per-parameter messages of a "Parameter verification failed" response
+	Errors map[string]string
+}
+
+// Error formats the status code, message and parameter errors on a single line
+func (e *APIError) Error() string {
+	message := fmt.Sprintf("Proxmox API error %d", e.StatusCode)
+	if e.Message != "" {
+		message += ": " + e.Message
+	}
+	if len(e.Errors) > 0 {
+		message += " (" + formatParamErrors(e.Errors) + ")"
+	}
+	return message
+}
+
+// IsUnauthorized reports whether the request was rejected because of missing or invalid credentials
+func (e *APIError) IsUnauthorized() bool {
+	return e.StatusCode == http.StatusUnauthorized
+}
+
+// IsForbidden reports whether the credentials lack the permission for the request
+func (e *APIError) IsForbidden() bool {
+	return e.StatusCode == http.StatusForbidden
+}
+
+// AsAPIError returns the APIError wrapped in err, if any
+func AsAPIError(err error) (*APIError, bool) {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr, true
+	}
+	return nil, false
+}
+
+// NewAPIError builds an APIError from an HTTP status line such as "400 Parameter verification failed."
+// and the response body. Proxmox puts its message in the status line; a "message" or "errors"
+// field in the JSON body is used when present.
+func NewAPIError(statusCode int, status string, body []byte) *APIError {
+	apiErr := &APIError{
+		StatusCode: statusCode,
+		Message:    strings.TrimSpace(strings.TrimPrefix(status, strconv.Itoa(statusCode))),
+	}
+
+	var envelope struct {
+		Message string            `json:"message"`
+		Errors  map[string]string `json:"errors"`
+	}
+	if json.Unmarshal(body, &envelope) == nil {
+		if message := strings.TrimSpace(envelope.Message); message != "" {
+			apiErr.Message = message
+		}
+		apiErr.Errors = envelope.Errors
+	}
+
+	if apiErr.Message == "" {
+		apiErr.Message = http.StatusText(statusCode)
+	}
+
+	return apiErr
+}
+
+// checkStatus returns an APIError for HTTP error status codes
+func checkStatus(resp *http.Response, body []byte) error {
+	if resp.StatusCode < http.StatusBadRequest {
+		return nil
+	}
+	return NewAPIError(resp.StatusCode, resp.Status, body)
+}
+
+// formatParamErrors joins the per-parameter errors Proxmox returns for invalid requests
+func formatParamErrors(paramErrors map[string]string) string {
+	params := make([]string, 0, len(paramErrors))
+	for param := range paramErrors {
+		params = append(params, param)
+	}
+	sort.Strings(params)
+
+	messages := make([]string, len(params))
+	for i, param := range params {
+		messages[i] = fmt.Sprintf("%s: %s", param, strings.TrimSpace(paramErrors[param]))
+	}
+	return strings.Join(messages, "; ")
+}
@@ -0,0 +1,304 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Proxmox manages VMs (qemu) and containers (lxc) through the same API shapes under
+// nodes/{node}/{type}/{vmid}; the helpers in this file implement the shared operations.
+// The guest types are also the resource types cluster/resources reports for them.
+const (
+	GuestTypeQEMU = "qemu"
+	GuestTypeLXC  = "lxc"
+)
+
+// guestPath returns the API path of a VM or container
+func guestPath(nodeName, guestType string, vmid int) string {
+	return fmt.Sprintf("nodes/%s/%s/%d", nodeName, guestType, vmid)
+}
+
+// nextVMID returns the next free VM ID of the cluster, which VMs and containers share
+func nextVMID(c *APIClient) (int, error) {
+	// Proxmox returns the ID as a string
+	next, err := Get[json.Number](c, "cluster/nextid", nil)
+	if err != nil {
+		return 0, err
+	}
+
+	vmid, err := strconv.Atoi(next.String())
+	if err != nil {
+		return 0, fmt.Errorf("invalid next VM ID %q: %w", next, err)
+	}
+	return vmid, nil
+}
+
+// PendingChange represents a configuration change that has not been applied to the running VM or container
+type PendingChange struct {
+	Key string `json:"key"`
+	// Value is the current value, Pending the value after the change
+	Value   string `json:"value,omitempty"`
+	Pending string `json:"pending,omitempty"`
+	Delete  bool   `json:"delete,omitempty"`
+}
+
+// ConfigUpdate describes a change of VM or container configuration
+type ConfigUpdate struct {
+	// Set maps configuration keys to their new values
+	Set map[string]string
+	// Delete lists configuration keys to remove
+	Delete []string
+	// Digest makes the update fail if the configuration changed since it was read
+	Digest string
+}
+
+// UnmarshalJSON decodes a pending change, whose values may be strings or numbers
+func (p *PendingChange) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Key     string          `json:"key"`
+		Value   json.RawMessage `json:"value"`
+		Pending json.RawMessage `json:"pending"`
+		Delete  int             `json:"delete"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	*p = PendingChange{
+		Key:     raw.Key,
+		Value:   rawString(raw.Value),
+		Pending: rawString(raw.Pending),
+		Delete:  raw.Delete > 0,
+	}
+	return nil
+}
+
+// rawString returns a JSON string as is and other JSON values in their literal form
+func rawString(raw json.RawMessage) string {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 || string(raw) == "null" {
+		return ""
+	}
+
+	var str string
+	if json.Unmarshal(raw, &str) == nil {
+		return str
+	}
+	return string(raw)
+}
+
+// getPendingChanges retrieves the pending configuration changes of the guest at path
+func getPendingChanges(c *APIClient, path string) ([]PendingChange, error) {
+	entries, err := Get[[]PendingChange](c, path+"/pending", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// The endpoint lists every key; only keep those with a pending value or deletion
+	changes := []PendingChange{}
+	for _, entry := range entries {
+		if entry.Pending != "" || entry.Delete {
+			changes = append(changes, entry)
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Key < changes[j].Key })
+
+	return changes, nil
+}
+
+// updateConfig sets and removes configuration keys of the guest at path
+func updateConfig(c *APIClient, path string, update ConfigUpdate) error {
+	if len(update.Set) == 0 && len(update.Delete) == 0 {
+		return fmt.Errorf("no configuration changes given")
+	}
+
+	params := url.Values{}
+	for key, value := range update.Set {
+		if key == "delete" || key == "digest" {
+			return fmt.Errorf("invalid configuration key %q", key)
+		}
+		if key == "sshkeys" {
+			value = encodeSSHKeys(value)
+		}
+		params.Set(key, value)
+	}
+	for _, key := range update.Delete {
+		if _, ok := update.Set[key]; ok {
+			return fmt.Errorf("configuration key %q cannot be set and removed at the same time", key)
+		}
+	}
+	if len(update.Delete) > 0 {
+		params.Set("delete", strings.Join(update.Delete, ","))
+	}
+	if update.Digest != "" {
+		params.Set("digest", update.Digest)
+	}
+
+	_, err := Put[any](c, path+"/config", params)
+	return err
+}
+
+// CloneOptions describes the VM or container created by a clone
+type CloneOptions struct {
+	// NewID is the VM ID of the clone; zero allocates the next free ID of the cluster
+	NewID int
+	Name  string
+	// TargetNode is the node to create the clone on, defaults to the node of the source VM
+	TargetNode  string
+	Description string
+	Pool        string
+	// Full copies all disks. Otherwise Proxmox creates a linked clone of a template
+	// and a full clone of a regular VM.
+	Full bool
+	// Storage is the target storage of a full clone
+	Storage string
+	// Snapshot clones the VM as it was at this snapshot
+	Snapshot string
+}
+
+// clone clones the guest at path and returns the VM ID of the clone and the task UPID.
+// nameKey is the parameter that names the clone: "name" for VMs, "hostname" for containers.
+func clone(c *APIClient, path, nameKey string, options CloneOptions) (int, string, error) {
+	if options.Storage != "" && !options.Full {
+		return 0, "", fmt.Errorf("a target storage can only be used with a full clone")
+	}
+
+	newID := options.NewID
+	if newID == 0 {
+		var err error
+		if newID, err = nextVMID(c); err != nil {
+			return 0, "", err
+		}
+	}
+
+	params := url.Values{}
+	params.Set("newid", strconv.Itoa(newID))
+	setParam := func(key, value string) {
+		if value != "" {
+			params.Set(key, value)
+		}
+	}
+	setParam(nameKey, options.Name)
+	setParam("target", options.TargetNode)
+	setParam("description", options.Description)
+	setParam("pool", options.Pool)
+	setParam("storage", options.Storage)
+	setParam("snapname", options.Snapshot)
+	if options.Full {
+		params.Set("full", "1")
+	}
+
+	upid, err := Post[string](c, path+"/clone", params)
+	if err != nil {
+		return 0, "", err
+	}
+
+	return newID, upid, nil
+}
+
+// CurrentSnapshot is the name Proxmox gives the entry marking the current state of a VM in its snapshot list
+const CurrentSnapshot = "current"
+
+// Snapshot represents a VM or container snapshot. The list of snapshots also contains an entry named
+// CurrentSnapshot, whose parent is the snapshot the VM currently runs from.
+type Snapshot struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Parent      string `json:"parent,omitempty"`
+	SnapTime    int64  `json:"snaptime,omitempty"`
+	// VMState is 1 when the snapshot includes the RAM of the running VM
+	VMState int `json:"vmstate,omitempty"`
+}
+
+// SnapshotTreeEntry is a snapshot with its depth in the snapshot tree
+type SnapshotTreeEntry struct {
+	Snapshot
+	Depth int `json:"depth"`
+}
+
+// SnapshotTree orders snapshots depth first, children after their parent and siblings
+// by creation time, with the current state last. Snapshots whose parent is missing are roots.
+func SnapshotTree(snapshots []Snapshot) []SnapshotTreeEntry {
+	names := make(map[string]bool, len(snapshots))
+	for _, snapshot := range snapshots {
+		names[snapshot.Name] = true
+	}
+
+	children := map[string][]Snapshot{}
+	for _, snapshot := range snapshots {
+		parent := snapshot.Parent
+		if !names[parent] {
+			parent = ""
+		}
+		children[parent] = append(children[parent], snapshot)
+	}
+	for _, siblings := range children {
+		sort.SliceStable(siblings, func(i, j int) bool {
+			return snapshotOrder(siblings[i]) < snapshotOrder(siblings[j])
+		})
+	}
+
+	tree := make([]SnapshotTreeEntry, 0, len(snapshots))
+	var walk func(parent string, depth int)
+	walk = func(parent string, depth int) {
+		for _, snapshot := range children[parent] {
+			tree = append(tree, SnapshotTreeEntry{Snapshot: snapshot, Depth: depth})
+			walk(snapshot.Name, depth+1)
+		}
+	}
+	walk("", 0)
+
+	return tree
+}
+
+// snapshotOrder sorts snapshots by creation time, with the current state after all snapshots
+func snapshotOrder(snapshot Snapshot) int64 {
+	if snapshot.Name == CurrentSnapshot {
+		return 1<<63 - 1
+	}
+	return snapshot.SnapTime
+}
+
+// listSnapshots retrieves the snapshots of the guest at path
+func listSnapshots(c *APIClient, path string) ([]Snapshot, error) {
+	return Get[[]Snapshot](c, path+"/snapshot", nil)
+}
+
+// createSnapshot snapshots the guest at path and returns the task UPID
+func createSnapshot(c *APIClient, path, name, description string, vmState bool) (string, error) {
+	params := url.Values{}
+	params.Set("snapname", name)
+	if description != "" {
+		params.Set("description", description)
+	}
+	if vmState {
+		params.Set("vmstate", "1")
+	}
+
+	return Post[string](c, path+"/snapshot", params)
+}
+
+// rollbackSnapshot rolls the guest at path back to a snapshot and returns the task UPID
+func rollbackSnapshot(c *APIClient, path, name string, start bool) (string, error) {
+	params := url.Values{}
+	if start {
+		params.Set("start", "1")
+	}
+
+	return Post[string](c, fmt.Sprintf("%s/snapshot/%s/rollback", path, url.PathEscape(name)), params)
+}
+
+// deleteSnapshot deletes a snapshot of the guest at path and returns the task UPID
+func deleteSnapshot(c *APIClient, path, name string, force bool) (string, error) {
+	query := url.Values{}
+	if force {
+		query.Set("force", "1")
+	}
+
+	return Delete[string](c, fmt.Sprintf("%s/snapshot/%s", path, url.PathEscape(name)), query)
+}
@@ -68,7 +68,7 @@ func (s *HttpService) Get(url string, headers map[string]string, cookies []*http
 }
 
 // Post sends an HTTP POST request to the specified URL with the given payload, headers, and cookies.
-// Returns the response body as a string or an error.
+// Returns the response body as a string, and an *APIError when the server answers with an error status.
 func (s *HttpService) Post(url string, payload string, headers map[string]string, cookies []*http.Cookie) (string, error) {
 	req, err := s.createRequest("POST", url, strings.NewReader(payload), headers, cookies)
 	if err != nil {
@@ -90,11 +90,11 @@ func (s *HttpService) Post(url string, payload string, headers map[string]string
 		return "", err
 	}
 
-	return string(bodyBytes), nil
+	return string(bodyBytes), checkStatus(resp, bodyBytes)
 }
 
 // Put sends an HTTP PUT request to the specified URL with the given payload, headers, and cookies.
-// Returns the response body as a string or an error.
+// Returns the response body as a string, and an *APIError when the server answers with an error status.
 func (s *HttpService) Put(url string, payload string, headers map[string]string, cookies []*http.Cookie) (string, error) {
 	req, err := s.createRequest("PUT", url, strings.NewReader(payload), headers, cookies)
 	if err != nil {
@@ -116,11 +116,11 @@ func (s *HttpService) Put(url string, payload string, headers map[string]string,
 		return "", err
 	}
 
-	return string(bodyBytes), nil
+	return string(bodyBytes), checkStatus(resp, bodyBytes)
 }
 
 // Delete sends an HTTP DELETE request to the specified URL with optional headers and cookies.
-// Returns the response body as a string or an error.
+// Returns the response body as a string, and an *APIError when the server answers with an error status.
 func (s *HttpService) Delete(url string, headers map[string]string, cookies []*http.Cookie) (string, error) {
 	req, err := s.createRequest("DELETE", url, nil, headers, cookies)
 	if err != nil {
@@ -142,7 +142,7 @@ func (s *HttpService) Delete(url string, headers map[string]string, cookies []*h
 		return "", err
 	}
 
-	return string(bodyBytes), nil
+	return string(bodyBytes), checkStatus(resp, bodyBytes)
 }
 
 // createRequest constructs an HTTP request with the specified method, URL, payload, headers, and cookies.
@@ -160,6 +160,10 @@ func (s *HttpService) createRequest(method, url string, payload io.Reader, heade
 
 		for key, value := range headers {
 			req.Header.Set(key, value)
+			if key == "Authorization" {
+				// Never log API token secrets
+				value = "[redacted]"
+			}
 			s.logger.Infof("  %s: %s", key, value)
 		}
 	}
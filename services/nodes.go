@@ -1,11 +1,8 @@
 package services
 
 import (
-	"encoding/json"
 	"fmt"
-	"io"
-	"net/http"
-	"net/url"
+	"sort"
 
 	"github.com/sirupsen/logrus"
 )
@@ -72,176 +69,82 @@ type NodeVersion struct {
 	RepoID  string `json:"repoid"`
 }
 
-// NodeListResponse represents the API response for node list
-type NodeListResponse struct {
-	Data []Node `json:"data"`
-}
-
-// NodeStatusResponse represents the API response for node status
-type NodeStatusResponse struct {
-	Data NodeStatus `json:"data"`
-}
-
-// NodeVersionResponse represents the API response for node version
-type NodeVersionResponse struct {
-	Data NodeVersion `json:"data"`
-}
-
 // NodesService handles node-related operations
 type NodesService struct {
-	Logger         *logrus.Logger
-	Trust          bool
-	HttpService    HTTPServiceInterface
-	SessionService SessionServiceInterface
+	Logger *logrus.Logger
+	Trust  bool
+	Client *APIClient
 }
 
 // NewNodesService creates a new NodesService with real dependencies
 func NewNodesService(logger *logrus.Logger, trust bool) (*NodesService, error) {
-	sessionService, err := NewSessionService(logger)
+	client, err := NewAPIClient(logger, trust)
 	if err != nil {
 		return nil, err
 	}
 
 	return &NodesService{
-		Logger:         logger,
-		Trust:          trust,
-		HttpService:    NewHttpService(logger, trust),
-		SessionService: sessionService,
+		Logger: logger,
+		Trust:  trust,
+		Client: client,
 	}, nil
 }
 
 // NewNodesServiceWithDeps creates a NodesService with injected dependencies (for testing)
 func NewNodesServiceWithDeps(logger *logrus.Logger, trust bool, httpService HTTPServiceInterface, sessionService SessionServiceInterface) *NodesService {
 	return &NodesService{
-		Logger:         logger,
-		Trust:          trust,
-		HttpService:    httpService,
-		SessionService: sessionService,
+		Logger: logger,
+		Trust:  trust,
+		Client: NewAPIClientWithDeps(logger, httpService, sessionService),
 	}
 }
 
 // ListNodes retrieves a list of all nodes in the cluster
 func (n *NodesService) ListNodes() ([]Node, error) {
-	sessionData, err := n.SessionService.ReadSessionFile()
-	if err != nil {
-		n.Logger.Error("Error reading session file: ", err)
-		return nil, err
-	}
-
-	uri := fmt.Sprintf("%s://%s:%d/api2/json/nodes",
-		sessionData.HttpScheme, sessionData.Server, sessionData.Port)
-
-	cookies := []*http.Cookie{
-		{
-			Name:  "PVEAuthCookie",
-			Value: url.QueryEscape(sessionData.Response.Data.Ticket),
-		},
-	}
-
-	resp, err := n.HttpService.Get(uri, nil, cookies)
+	nodes, err := Get[[]Node](n.Client, "nodes", nil)
 	if err != nil {
 		n.Logger.Error("Error listing nodes: ", err)
 		return nil, err
 	}
-	//nolint:errcheck // Best effort close in defer
-	defer func() { _ = resp.Body.Close() }()
-
-	bodyBytes, err := io.ReadAll(resp.Body)
-	if err != nil {
-		n.Logger.Error("Error reading response body: ", err)
-		return nil, err
-	}
-
-	var result NodeListResponse
-	err = json.Unmarshal(bodyBytes, &result)
-	if err != nil {
-		n.Logger.Error("Error parsing response JSON: ", err)
-		return nil, err
-	}
 
-	return result.Data, nil
+	return nodes, nil
 }
 
 // GetNodeStatus retrieves detailed status information for a specific node
 func (n *NodesService) GetNodeStatus(nodeName string) (*NodeStatus, error) {
-	sessionData, err := n.SessionService.ReadSessionFile()
-	if err != nil {
-		n.Logger.Error("Error reading session file: ", err)
-		return nil, err
-	}
-
-	uri := fmt.Sprintf("%s://%s:%d/api2/json/nodes/%s/status",
-		sessionData.HttpScheme, sessionData.Server, sessionData.Port, nodeName)
-
-	cookies := []*http.Cookie{
-		{
-			Name:  "PVEAuthCookie",
-			Value: url.QueryEscape(sessionData.Response.Data.Ticket),
-		},
-	}
-
-	resp, err := n.HttpService.Get(uri, nil, cookies)
+	status, err := Get[NodeStatus](n.Client, fmt.Sprintf("nodes/%s/status", nodeName), nil)
 	if err != nil {
 		n.Logger.Error("Error getting node status: ", err)
 		return nil, err
 	}
-	//nolint:errcheck // Best effort close in defer
-	defer func() { _ = resp.Body.Close() }()
-
-	bodyBytes, err := io.ReadAll(resp.Body)
-	if err != nil {
-		n.Logger.Error("Error reading response body: ", err)
-		return nil, err
-	}
-
-	var result NodeStatusResponse
-	err = json.Unmarshal(bodyBytes, &result)
-	if err != nil {
-		n.Logger.Error("Error parsing response JSON: ", err)
-		return nil, err
-	}
 
-	return &result.Data, nil
+	return &status, nil
 }
 
 // GetNodeVersion retrieves version information for a specific node
 func (n *NodesService) GetNodeVersion(nodeName string) (*NodeVersion, error) {
-	sessionData, err := n.SessionService.ReadSessionFile()
-	if err != nil {
-		n.Logger.Error("Error reading session file: ", err)
-		return nil, err
-	}
-
-	uri := fmt.Sprintf("%s://%s:%d/api2/json/nodes/%s/version",
-		sessionData.HttpScheme, sessionData.Server, sessionData.Port, nodeName)
-
-	cookies := []*http.Cookie{
-		{
-			Name:  "PVEAuthCookie",
-			Value: url.QueryEscape(sessionData.Response.Data.Ticket),
-		},
-	}
-
-	resp, err := n.HttpService.Get(uri, nil, cookies)
+	version, err := Get[NodeVersion](n.Client, fmt.Sprintf("nodes/%s/version", nodeName), nil)
 	if err != nil {
 		n.Logger.Error("Error getting node version: ", err)
 		return nil, err
 	}
-	//nolint:errcheck // Best effort close in defer
-	defer func() { _ = resp.Body.Close() }()
 
-	bodyBytes, err := io.ReadAll(resp.Body)
-	if err != nil {
-		n.Logger.Error("Error reading response body: ", err)
-		return nil, err
-	}
+	return &version, nil
+}
 
-	var result NodeVersionResponse
-	err = json.Unmarshal(bodyBytes, &result)
+// onlineNodes returns the names of the online nodes of the cluster, sorted
+func onlineNodes(c *APIClient) ([]string, error) {
+	nodes, err := Get[[]Node](c, "nodes", nil)
 	if err != nil {
-		n.Logger.Error("Error parsing response JSON: ", err)
 		return nil, err
 	}
 
-	return &result.Data, nil
+	names := []string{}
+	for _, node := range nodes {
+		if node.Status == "online" {
+			names = append(names, node.Node)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
 }
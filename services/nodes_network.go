@@ -0,0 +1,364 @@
+package services
+
+import (
+	"fmt"
+	"net/netip"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Node network interface types
+const (
+	NetworkBridge = "bridge"
+	NetworkBond   = "bond"
+	NetworkVLAN   = "vlan"
+	NetworkEth    = "eth"
+)
+
+// NetworkInterfaceTypes are the interface types NodesService can create
+var NetworkInterfaceTypes = []string{NetworkBridge, NetworkBond, NetworkVLAN}
+
+// BondModes are the Linux bonding modes of bond interfaces
+var BondModes = []string{"balance-rr", "active-backup", "balance-xor", "broadcast", "802.3ad", "balance-tlb", "balance-alb"}
+
+// bondHashPolicies are the transmit hash policies of balance-xor and 802.3ad bonds
+var bondHashPolicies = []string{"layer2", "layer2+3", "layer3+4"}
+
+// networkTypeOptions are the options only interfaces of one type accept
+var networkTypeOptions = map[string][]string{
+	NetworkBridge: {"bridge_ports", "bridge_vlan_aware"},
+	NetworkBond:   {"slaves", "bond_mode", "bond-primary", "bond_xmit_hash_policy"},
+	NetworkVLAN:   {"vlan-raw-device", "vlan-id"},
+}
+
+// vlanSubinterfacePattern matches VLAN interfaces named after their device and tag, e.g. eno1.100
+var vlanSubinterfacePattern = regexp.MustCompile(`^[a-zA-Z0-9_-]+\.\d+$`)
+
+// NetworkInterface is a network interface of a node, as configured in /etc/network/interfaces
+type NetworkInterface struct {
+	Iface string `json:"iface"`
+	Type  string `json:"type"`
+	// Active is 1 when the interface is up
+	Active    int    `json:"active,omitempty"`
+	Autostart int    `json:"autostart,omitempty"`
+	Method    string `json:"method,omitempty"`
+	CIDR      string `json:"cidr,omitempty"`
+	Gateway   string `json:"gateway,omitempty"`
+	CIDR6     string `json:"cidr6,omitempty"`
+	Gateway6  string `json:"gateway6,omitempty"`
+	MTU       int    `json:"mtu,omitempty"`
+	// BridgePorts are the space separated ports of a bridge
+	BridgePorts     string `json:"bridge_ports,omitempty"`
+	BridgeVLANAware int    `json:"bridge_vlan_aware,omitempty"`
+	// Slaves are the space separated members of a bond
+	Slaves             string `json:"slaves,omitempty"`
+	BondMode           string `json:"bond_mode,omitempty"`
+	BondPrimary        string `json:"bond-primary,omitempty"`
+	BondXmitHashPolicy string `json:"bond_xmit_hash_policy,omitempty"`
+	VLANRawDevice      string `json:"vlan-raw-device,omitempty"`
+	VLANID             int    `json:"vlan-id,omitempty"`
+	Comments           string `json:"comments,omitempty"`
+}
+
+// NetworkInterfaceOptions are the options of an interface to create or update. Options
+// that are not set keep their value in an update; Delete lists options to remove.
+type NetworkInterfaceOptions struct {
+	CIDR               string
+	Gateway            string
+	CIDR6              string
+	Gateway6           string
+	Autostart          *bool
+	MTU                int
+	BridgePorts        []string
+	BridgeVLANAware    bool
+	Slaves             []string
+	BondMode           string
+	BondPrimary        string
+	BondXmitHashPolicy string
+	VLANRawDevice      string
+	VLANID             int
+	Comments           string
+	Delete             []string
+}
+
+// params returns the API parameters of the options that are set
+func (o NetworkInterfaceOptions) params() url.Values {
+	params := url.Values{}
+	set := func(key, value string) {
+		if value != "" {
+			params.Set(key, value)
+		}
+	}
+
+	set("cidr", o.CIDR)
+	set("gateway", o.Gateway)
+	set("cidr6", o.CIDR6)
+	set("gateway6", o.Gateway6)
+	if o.Autostart != nil {
+		params.Set("autostart", boolParam(*o.Autostart))
+	}
+	if o.MTU != 0 {
+		params.Set("mtu", strconv.Itoa(o.MTU))
+	}
+	set("bridge_ports", strings.Join(o.BridgePorts, " "))
+	if o.BridgeVLANAware {
+		params.Set("bridge_vlan_aware", "1")
+	}
+	set("slaves", strings.Join(o.Slaves, " "))
+	set("bond_mode", o.BondMode)
+	set("bond-primary", o.BondPrimary)
+	set("bond_xmit_hash_policy", o.BondXmitHashPolicy)
+	set("vlan-raw-device", o.VLANRawDevice)
+	if o.VLANID != 0 {
+		params.Set("vlan-id", strconv.Itoa(o.VLANID))
+	}
+	set("comments", o.Comments)
+	if len(o.Delete) > 0 {
+		params.Set("delete", strings.Join(o.Delete, ","))
+	}
+	return params
+}
+
+// validate checks the options against the type of the interface. When creating, the
+// options each type needs must be given; current is the interface being updated otherwise.
+func (o NetworkInterfaceOptions) validate(iface, ifaceType string, current *NetworkInterface) error {
+	// Physical interfaces exist already, but their addresses and MTU can be updated
+	if current == nil && !slices.Contains(NetworkInterfaceTypes, ifaceType) {
+		return fmt.Errorf("invalid interface type %q: use %s", ifaceType, strings.Join(NetworkInterfaceTypes, ", "))
+	}
+
+	params := o.params()
+	for _, otherType := range NetworkInterfaceTypes {
+		if otherType == ifaceType {
+			continue
+		}
+		for _, option := range networkTypeOptions[otherType] {
+			if params.Has(option) {
+				return fmt.Errorf("option %s is not supported by %s interfaces", option, ifaceType)
+			}
+		}
+	}
+
+	if err := validateAddress("cidr", o.CIDR, o.Gateway, false); err != nil {
+		return err
+	}
+	if err := validateAddress("cidr6", o.CIDR6, o.Gateway6, true); err != nil {
+		return err
+	}
+	if current == nil && o.CIDR == "" && o.Gateway != "" {
+		return fmt.Errorf("a gateway needs an IPv4 address in cidr")
+	}
+	if current == nil && o.CIDR6 == "" && o.Gateway6 != "" {
+		return fmt.Errorf("a gateway6 needs an IPv6 address in cidr6")
+	}
+
+	switch ifaceType {
+	case NetworkBond:
+		return o.validateBond(current)
+	case NetworkVLAN:
+		return o.validateVLAN(iface, current)
+	}
+	return nil
+}
+
+// validateBond checks the members and mode of a bond
+func (o NetworkInterfaceOptions) validateBond(current *NetworkInterface) error {
+	if current == nil && len(o.Slaves) == 0 {
+		return fmt.Errorf("bond interfaces need the slaves option")
+	}
+	if o.BondMode != "" && !slices.Contains(BondModes, o.BondMode) {
+		return fmt.Errorf("invalid bond mode %q: use %s", o.BondMode, strings.Join(BondModes, ", "))
+	}
+
+	mode := o.BondMode
+	if mode == "" && current != nil {
+		mode = current.BondMode
+	}
+	if o.BondPrimary != "" && mode != "active-backup" {
+		return fmt.Errorf("option bond-primary is only supported by active-backup bonds")
+	}
+	if o.BondXmitHashPolicy != "" {
+		if mode != "balance-xor" && mode != "802.3ad" {
+			return fmt.Errorf("option bond_xmit_hash_policy is only supported by balance-xor and 802.3ad bonds")
+		}
+		if !slices.Contains(bondHashPolicies, o.BondXmitHashPolicy) {
+			return fmt.Errorf("invalid hash policy %q: use %s", o.BondXmitHashPolicy, strings.Join(bondHashPolicies, ", "))
+		}
+	}
+	return nil
+}
+
+// validateVLAN checks the device and tag of a VLAN interface. Interfaces named like
+// eno1.100 take them from their name; others, e.g. vlan100, need both options.
+func (o NetworkInterfaceOptions) validateVLAN(iface string, current *NetworkInterface) error {
+	if o.VLANID != 0 && (o.VLANID < 1 || o.VLANID > 4094) {
+		return fmt.Errorf("invalid VLAN ID %d: use 1 to 4094", o.VLANID)
+	}
+
+	if vlanSubinterfacePattern.MatchString(iface) {
+		if o.VLANRawDevice != "" || o.VLANID != 0 {
+			return fmt.Errorf("VLAN interface %s takes its device and tag from its name", iface)
+		}
+		return nil
+	}
+	if current == nil && (o.VLANRawDevice == "" || o.VLANID == 0) {
+		return fmt.Errorf("VLAN interface %s needs the vlan-raw-device and vlan-id options, or a name like eno1.100", iface)
+	}
+	return nil
+}
+
+// validateAddress checks an address in CIDR notation and its gateway
+func validateAddress(option, cidr, gateway string, ipv6 bool) error {
+	family := "IPv4"
+	if ipv6 {
+		family = "IPv6"
+	}
+
+	var prefix netip.Prefix
+	if cidr != "" {
+		var err error
+		if prefix, err = netip.ParsePrefix(cidr); err != nil {
+			return fmt.Errorf("invalid %s %q: %w", option, cidr, err)
+		}
+		if prefix.Addr().Is6() != ipv6 {
+			return fmt.Errorf("invalid %s %q: not an %s address", option, cidr, family)
+		}
+	}
+
+	if gateway != "" {
+		address, err := netip.ParseAddr(gateway)
+		if err != nil {
+			return fmt.Errorf("invalid gateway %q: %w", gateway, err)
+		}
+		if address.Is6() != ipv6 {
+			return fmt.Errorf("invalid gateway %q: not an %s address", gateway, family)
+		}
+		if cidr != "" && !prefix.Masked().Contains(address) {
+			return fmt.Errorf("gateway %s is not in the network of %s", gateway, cidr)
+		}
+	}
+	return nil
+}
+
+// networkPath returns the API path of the network configuration of a node, or of one interface
+func networkPath(nodeName, iface string) string {
+	path := fmt.Sprintf("nodes/%s/network", nodeName)
+	if iface != "" {
+		path += "/" + url.PathEscape(iface)
+	}
+	return path
+}
+
+// ListNetworkInterfaces retrieves the network interfaces of a node, only those of ifaceType
+// when it is not empty. Pending changes are included, and returned as a diff of
+// /etc/network/interfaces, which is empty when nothing is pending.
+func (n *NodesService) ListNetworkInterfaces(nodeName, ifaceType string) ([]NetworkInterface, string, error) {
+	query := url.Values{}
+	if ifaceType != "" {
+		query.Set("type", ifaceType)
+	}
+
+	ifaces, changes, err := GetWithChanges[[]NetworkInterface](n.Client, networkPath(nodeName, ""), query)
+	if err != nil {
+		n.Logger.Error("Error listing network interfaces: ", err)
+		return nil, "", err
+	}
+
+	slices.SortFunc(ifaces, func(a, b NetworkInterface) int { return strings.Compare(a.Iface, b.Iface) })
+	return ifaces, changes, nil
+}
+
+// GetNetworkInterface retrieves the configuration of a network interface, including pending changes
+func (n *NodesService) GetNetworkInterface(nodeName, iface string) (*NetworkInterface, error) {
+	result, err := Get[NetworkInterface](n.Client, networkPath(nodeName, iface), nil)
+	if err != nil {
+		n.Logger.Error("Error getting network interface: ", err)
+		return nil, err
+	}
+
+	// The API leaves out the name of the interface
+	result.Iface = iface
+	return &result, nil
+}
+
+// CreateNetworkInterface adds a bridge, bond or VLAN interface to the pending network
+// configuration of a node
+func (n *NodesService) CreateNetworkInterface(nodeName, iface, ifaceType string, options NetworkInterfaceOptions) error {
+	if len(options.Delete) > 0 {
+		return fmt.Errorf("options cannot be removed from a new interface")
+	}
+	if err := options.validate(iface, ifaceType, nil); err != nil {
+		return err
+	}
+
+	params := options.params()
+	params.Set("iface", iface)
+	params.Set("type", ifaceType)
+
+	if _, err := Post[any](n.Client, networkPath(nodeName, ""), params); err != nil {
+		n.Logger.Error("Error creating network interface: ", err)
+		return err
+	}
+
+	return nil
+}
+
+// UpdateNetworkInterface changes the options of an interface that are set in options. The
+// options are checked against the type of the interface, which the API needs with every update.
+func (n *NodesService) UpdateNetworkInterface(nodeName, iface string, options NetworkInterfaceOptions) error {
+	params := options.params()
+	if len(params) == 0 {
+		return fmt.Errorf("no changes given for interface %s", iface)
+	}
+
+	current, err := n.GetNetworkInterface(nodeName, iface)
+	if err != nil {
+		return err
+	}
+	if err := options.validate(iface, current.Type, current); err != nil {
+		return err
+	}
+	params.Set("type", current.Type)
+
+	if _, err := Put[any](n.Client, networkPath(nodeName, iface), params); err != nil {
+		n.Logger.Error("Error updating network interface: ", err)
+		return err
+	}
+
+	return nil
+}
+
+// DeleteNetworkInterface removes an interface from the pending network configuration of a node
+func (n *NodesService) DeleteNetworkInterface(nodeName, iface string) error {
+	if _, err := Delete[any](n.Client, networkPath(nodeName, iface), nil); err != nil {
+		n.Logger.Error("Error deleting network interface: ", err)
+		return err
+	}
+
+	return nil
+}
+
+// ApplyNetworkChanges reloads the network of a node with its pending configuration and
+// returns the task UPID
+func (n *NodesService) ApplyNetworkChanges(nodeName string) (string, error) {
+	upid, err := Put[string](n.Client, networkPath(nodeName, ""), nil)
+	if err != nil {
+		n.Logger.Error("Error applying network configuration: ", err)
+		return "", err
+	}
+
+	return upid, nil
+}
+
+// RevertNetworkChanges discards the pending network configuration of a node
+func (n *NodesService) RevertNetworkChanges(nodeName string) error {
+	if _, err := Delete[any](n.Client, networkPath(nodeName, ""), nil); err != nil {
+		n.Logger.Error("Error reverting network configuration: ", err)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,369 @@
+package services
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// SDN zone types
+const (
+	SDNZoneSimple = "simple"
+	SDNZoneVLAN   = "vlan"
+	SDNZoneQinQ   = "qinq"
+	SDNZoneVXLAN  = "vxlan"
+	SDNZoneEVPN   = "evpn"
+)
+
+// SDNZoneTypes are the zone types SDNService can create, in the order of the Proxmox documentation
+var SDNZoneTypes = []string{SDNZoneSimple, SDNZoneVLAN, SDNZoneQinQ, SDNZoneVXLAN, SDNZoneEVPN}
+
+// sdnZoneCommonOptions are the options every zone type accepts
+var sdnZoneCommonOptions = []string{"nodes", "ipam", "dns", "reversedns", "dnszone", "mtu"}
+
+// sdnZoneTypeOptions are the options specific to each zone type. The required ones must
+// be given when a zone of the type is created.
+var sdnZoneTypeOptions = map[string]struct{ allowed, required []string }{
+	SDNZoneSimple: {allowed: []string{"dhcp"}},
+	SDNZoneVLAN:   {allowed: []string{"bridge"}, required: []string{"bridge"}},
+	SDNZoneQinQ:   {allowed: []string{"bridge", "tag", "vlan-protocol"}, required: []string{"bridge", "tag"}},
+	SDNZoneVXLAN:  {allowed: []string{"peers", "vxlan-port"}, required: []string{"peers"}},
+	SDNZoneEVPN: {
+		allowed: []string{
+			"controller", "vrf-vxlan", "mac", "exitnodes", "exitnodes-primary", "exitnodes-local-routing",
+			"advertise-subnets", "disable-arp-nd-suppression", "rt-import",
+		},
+		required: []string{"controller", "vrf-vxlan"},
+	},
+}
+
+// sdnIDPattern matches the IDs of SDN zones and VNets: a letter followed by letters or digits,
+// at most 8 characters long
+var sdnIDPattern = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9]{1,7}$`)
+
+// SDNZone is an SDN zone. Only the options of its type are set.
+type SDNZone struct {
+	Zone string `json:"zone"`
+	Type string `json:"type"`
+	// Nodes is the comma separated list of the nodes the zone is deployed on, empty for all
+	Nodes      string `json:"nodes,omitempty"`
+	IPAM       string `json:"ipam,omitempty"`
+	DNS        string `json:"dns,omitempty"`
+	ReverseDNS string `json:"reversedns,omitempty"`
+	DNSZone    string `json:"dnszone,omitempty"`
+	MTU        int    `json:"mtu,omitempty"`
+	// Bridge is the bridge of VLAN and QinQ zones
+	Bridge string `json:"bridge,omitempty"`
+	// Tag is the service VLAN of QinQ zones
+	Tag          int    `json:"tag,omitempty"`
+	VLANProtocol string `json:"vlan-protocol,omitempty"`
+	// Peers is the comma separated list of the VXLAN tunnel endpoints
+	Peers     string `json:"peers,omitempty"`
+	VXLANPort int    `json:"vxlan-port,omitempty"`
+	// Controller is the EVPN controller of EVPN zones
+	Controller              string `json:"controller,omitempty"`
+	VRFVXLAN                int    `json:"vrf-vxlan,omitempty"`
+	MAC                     string `json:"mac,omitempty"`
+	ExitNodes               string `json:"exitnodes,omitempty"`
+	ExitNodesPrimary        string `json:"exitnodes-primary,omitempty"`
+	ExitNodesLocalRouting   int    `json:"exitnodes-local-routing,omitempty"`
+	AdvertiseSubnets        int    `json:"advertise-subnets,omitempty"`
+	DisableARPNDSuppression int    `json:"disable-arp-nd-suppression,omitempty"`
+	RTImport                string `json:"rt-import,omitempty"`
+	// DHCP is the DHCP backend of simple zones, "dnsmasq"
+	DHCP string `json:"dhcp,omitempty"`
+	// State is "new", "changed" or "deleted" for zones with changes that are not applied yet
+	State  string `json:"state,omitempty"`
+	Digest string `json:"digest,omitempty"`
+}
+
+// Settings returns the type-specific options of the zone, e.g. "bridge=vmbr0"
+func (z SDNZone) Settings() string {
+	settings := []string{}
+	add := func(key, value string) {
+		if value != "" && value != "0" {
+			settings = append(settings, key+"="+value)
+		}
+	}
+	add("bridge", z.Bridge)
+	add("tag", strconv.Itoa(z.Tag))
+	add("vlan-protocol", z.VLANProtocol)
+	add("peers", z.Peers)
+	add("vxlan-port", strconv.Itoa(z.VXLANPort))
+	add("controller", z.Controller)
+	add("vrf-vxlan", strconv.Itoa(z.VRFVXLAN))
+	add("exitnodes", z.ExitNodes)
+	add("dhcp", z.DHCP)
+	return strings.Join(settings, ", ")
+}
+
+// SDNZoneOptions are the options of a zone to create or update. Options that are not
+// set keep their value in an update; Delete lists options to remove.
+type SDNZoneOptions struct {
+	Nodes      []string
+	IPAM       string
+	DNS        string
+	ReverseDNS string
+	DNSZone    string
+	MTU        int
+	// Bridge is required by VLAN and QinQ zones
+	Bridge string
+	// Tag is the service VLAN of QinQ zones, required by them
+	Tag int
+	// VLANProtocol is 802.1q or 802.1ad for QinQ zones
+	VLANProtocol string
+	// Peers are the VXLAN tunnel endpoints, required by VXLAN zones
+	Peers     []string
+	VXLANPort int
+	// Controller and VRFVXLAN are required by EVPN zones
+	Controller              string
+	VRFVXLAN                int
+	MAC                     string
+	ExitNodes               []string
+	ExitNodesPrimary        string
+	ExitNodesLocalRouting   bool
+	AdvertiseSubnets        bool
+	DisableARPNDSuppression bool
+	RTImport                string
+	// DHCP is the DHCP backend of simple zones, "dnsmasq"
+	DHCP   string
+	Delete []string
+}
+
+// params returns the API parameters of the options that are set
+func (o SDNZoneOptions) params() url.Values {
+	params := url.Values{}
+	setParam := func(key, value string) {
+		if value != "" {
+			params.Set(key, value)
+		}
+	}
+	setNumber := func(key string, value int) {
+		if value != 0 {
+			params.Set(key, strconv.Itoa(value))
+		}
+	}
+	setFlag := func(key string, value bool) {
+		if value {
+			params.Set(key, "1")
+		}
+	}
+
+	setParam("nodes", strings.Join(o.Nodes, ","))
+	setParam("ipam", o.IPAM)
+	setParam("dns", o.DNS)
+	setParam("reversedns", o.ReverseDNS)
+	setParam("dnszone", o.DNSZone)
+	setNumber("mtu", o.MTU)
+	setParam("bridge", o.Bridge)
+	setNumber("tag", o.Tag)
+	setParam("vlan-protocol", o.VLANProtocol)
+	setParam("peers", strings.Join(o.Peers, ","))
+	setNumber("vxlan-port", o.VXLANPort)
+	setParam("controller", o.Controller)
+	setNumber("vrf-vxlan", o.VRFVXLAN)
+	setParam("mac", o.MAC)
+	setParam("exitnodes", strings.Join(o.ExitNodes, ","))
+	setParam("exitnodes-primary", o.ExitNodesPrimary)
+	setFlag("exitnodes-local-routing", o.ExitNodesLocalRouting)
+	setFlag("advertise-subnets", o.AdvertiseSubnets)
+	setFlag("disable-arp-nd-suppression", o.DisableARPNDSuppression)
+	setParam("rt-import", o.RTImport)
+	setParam("dhcp", o.DHCP)
+	setParam("delete", strings.Join(o.Delete, ","))
+	return params
+}
+
+// validate checks the options against the zone type. Options of other zone types are
+// rejected, and a new zone needs the required options of its type.
+func (o SDNZoneOptions) validate(zoneType string, creating bool) error {
+	typeOptions, ok := sdnZoneTypeOptions[zoneType]
+	if !ok {
+		return fmt.Errorf("invalid zone type %q: use %s", zoneType, strings.Join(SDNZoneTypes, ", "))
+	}
+
+	params := o.params()
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		keys = append(keys, key)
+	}
+	keys = append(keys, o.Delete...)
+	sort.Strings(keys)
+	for _, key := range keys {
+		if key != "delete" && !slices.Contains(sdnZoneCommonOptions, key) && !slices.Contains(typeOptions.allowed, key) {
+			return fmt.Errorf("option %s is not supported by %s zones", key, zoneType)
+		}
+	}
+
+	if creating {
+		for _, key := range typeOptions.required {
+			if params.Get(key) == "" {
+				return fmt.Errorf("%s zones need the %s option", zoneType, key)
+			}
+		}
+	}
+	for _, key := range o.Delete {
+		if slices.Contains(typeOptions.required, key) {
+			return fmt.Errorf("option %s is required by %s zones and cannot be removed", key, zoneType)
+		}
+	}
+
+	if o.VLANProtocol != "" && o.VLANProtocol != "802.1q" && o.VLANProtocol != "802.1ad" {
+		return fmt.Errorf("invalid VLAN protocol %q: use 802.1q or 802.1ad", o.VLANProtocol)
+	}
+	return nil
+}
+
+// validateSDNID checks the ID of a zone or VNet, which Proxmox limits to 8 letters and digits
+func validateSDNID(kind, id string) error {
+	if !sdnIDPattern.MatchString(id) {
+		return fmt.Errorf("invalid %s name %q: use 2 to 8 letters and digits, starting with a letter", kind, id)
+	}
+	return nil
+}
+
+// SDNService handles the Software Defined Networking configuration of the cluster.
+// Changes are staged as pending until they are applied with Apply.
+type SDNService struct {
+	Logger       *logrus.Logger
+	Trust        bool
+	Client       *APIClient
+	PollInterval time.Duration
+}
+
+// NewSDNService creates a new SDNService with real dependencies
+func NewSDNService(logger *logrus.Logger, trust bool) (*SDNService, error) {
+	client, err := NewAPIClient(logger, trust)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SDNService{
+		Logger:       logger,
+		Trust:        trust,
+		Client:       client,
+		PollInterval: DefaultSDNPollInterval,
+	}, nil
+}
+
+// NewSDNServiceWithDeps creates an SDNService with injected dependencies (for testing)
+func NewSDNServiceWithDeps(logger *logrus.Logger, trust bool, httpService HTTPServiceInterface, sessionService SessionServiceInterface) *SDNService {
+	return &SDNService{
+		Logger:       logger,
+		Trust:        trust,
+		Client:       NewAPIClientWithDeps(logger, httpService, sessionService),
+		PollInterval: DefaultSDNPollInterval,
+	}
+}
+
+// ListZones retrieves the SDN zones, only those of zoneType when it is not empty.
+// With pending the zones show their changes that are not applied yet.
+func (s *SDNService) ListZones(zoneType string, pending bool) ([]SDNZone, error) {
+	query := url.Values{}
+	if zoneType != "" {
+		query.Set("type", zoneType)
+	}
+	if pending {
+		query.Set("pending", "1")
+	}
+
+	zones, err := Get[[]SDNZone](s.Client, "cluster/sdn/zones", query)
+	if err != nil {
+		s.Logger.Error("Error listing SDN zones: ", err)
+		return nil, err
+	}
+
+	return zones, nil
+}
+
+// GetZone retrieves an SDN zone, with its pending changes when pending is set
+func (s *SDNService) GetZone(zone string, pending bool) (*SDNZone, error) {
+	query := url.Values{}
+	if pending {
+		query.Set("pending", "1")
+	}
+
+	result, err := Get[SDNZone](s.Client, "cluster/sdn/zones/"+url.PathEscape(zone), query)
+	if err != nil {
+		s.Logger.Error("Error getting SDN zone: ", err)
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// CreateZone creates an SDN zone of zoneType with the options of that type
+func (s *SDNService) CreateZone(zone, zoneType string, options SDNZoneOptions) error {
+	if err := validateSDNID("zone", zone); err != nil {
+		return err
+	}
+	if len(options.Delete) > 0 {
+		return fmt.Errorf("options cannot be removed from a new zone")
+	}
+	if err := options.validate(zoneType, true); err != nil {
+		return err
+	}
+
+	params := options.params()
+	params.Set("zone", zone)
+	params.Set("type", zoneType)
+
+	if _, err := Post[any](s.Client, "cluster/sdn/zones", params); err != nil {
+		s.Logger.Error("Error creating SDN zone: ", err)
+		return err
+	}
+
+	return nil
+}
+
+// UpdateZone changes the options of an SDN zone that are set in options. The type of a
+// zone cannot change, so the options are checked against the type of the existing zone.
+func (s *SDNService) UpdateZone(zone string, options SDNZoneOptions) error {
+	params := options.params()
+	if len(params) == 0 {
+		return fmt.Errorf("no changes given for zone %s", zone)
+	}
+
+	current, err := s.GetZone(zone, false)
+	if err != nil {
+		return err
+	}
+	if err := options.validate(current.Type, false); err != nil {
+		return err
+	}
+
+	if _, err := Put[any](s.Client, "cluster/sdn/zones/"+url.PathEscape(zone), params); err != nil {
+		s.Logger.Error("Error updating SDN zone: ", err)
+		return err
+	}
+
+	return nil
+}
+
+// DeleteZone deletes an SDN zone
+func (s *SDNService) DeleteZone(zone string) error {
+	if _, err := Delete[any](s.Client, "cluster/sdn/zones/"+url.PathEscape(zone), nil); err != nil {
+		s.Logger.Error("Error deleting SDN zone: ", err)
+		return err
+	}
+
+	return nil
+}
+
+// Apply applies the pending SDN changes to all nodes and returns the task UPID
+func (s *SDNService) Apply() (string, error) {
+	upid, err := Put[string](s.Client, "cluster/sdn", nil)
+	if err != nil {
+		s.Logger.Error("Error applying SDN configuration: ", err)
+		return "", err
+	}
+
+	return upid, nil
+}
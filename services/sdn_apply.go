@@ -0,0 +1,323 @@
+package services
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultSDNPollInterval is how often WaitForZones checks the SDN status of the nodes
+const DefaultSDNPollInterval = 2 * time.Second
+
+// Kinds of SDN objects with pending changes
+const (
+	SDNKindZone   = "zone"
+	SDNKindVNet   = "vnet"
+	SDNKindSubnet = "subnet"
+)
+
+// States of SDN objects with pending changes
+const (
+	SDNStateNew     = "new"
+	SDNStateChanged = "changed"
+	SDNStateDeleted = "deleted"
+)
+
+// sdnPendingSkipped are the fields of the pending views that identify or describe an
+// object rather than configure it, and are left out of its option changes
+var sdnPendingSkipped = map[string][]string{
+	SDNKindZone:   {"zone", "state", "pending", "digest"},
+	SDNKindVNet:   {"vnet", "type", "state", "pending", "digest"},
+	SDNKindSubnet: {"subnet", "id", "type", "vnet", "zone", "cidr", "network", "mask", "state", "pending", "digest"},
+}
+
+// SDNOptionChange is a pending change of one option. Old is empty for an added option
+// and New is empty for a removed one.
+type SDNOptionChange struct {
+	Option string `json:"option"`
+	Old    string `json:"old,omitempty"`
+	New    string `json:"new,omitempty"`
+}
+
+// SDNChange is an SDN object with changes that are not applied yet
+type SDNChange struct {
+	Kind string `json:"kind"`
+	ID   string `json:"id"`
+	// VNet is the VNet a changed subnet belongs to
+	VNet    string            `json:"vnet,omitempty"`
+	State   string            `json:"state"`
+	Options []SDNOptionChange `json:"options,omitempty"`
+}
+
+// SDNZoneStatus is the state of a zone on a node, "available", "pending" or "error"
+type SDNZoneStatus struct {
+	Zone   string `json:"zone"`
+	Status string `json:"status"`
+}
+
+// SDNNodeStatus is the deployment state of the SDN zones on a node after an apply
+type SDNNodeStatus struct {
+	Node string `json:"node"`
+	// Reloaded is set once the node has reloaded its network with the applied configuration
+	Reloaded bool `json:"reloaded"`
+	// Ready is set once the node reports all of its zones as available after the reload
+	Ready bool `json:"ready"`
+	// Error is the exit status of a network reload that failed
+	Error string `json:"error,omitempty"`
+	// Failed lists the zones the node reports in error
+	Failed []string `json:"failed,omitempty"`
+	// Waiting lists the zones the node does not report as available yet
+	Waiting []string `json:"waiting,omitempty"`
+}
+
+// failed reports whether the node failed to reload its network or reports a zone in error
+func (n SDNNodeStatus) failed() bool {
+	return n.Error != "" || len(n.Failed) > 0
+}
+
+// settled reports whether the node is ready or has failed
+func (n SDNNodeStatus) settled() bool {
+	return n.Ready || n.failed()
+}
+
+// PendingChanges compares the pending SDN configuration with the applied one and returns
+// the zones, VNets and subnets that differ, in that order
+func (s *SDNService) PendingChanges() ([]SDNChange, error) {
+	query := url.Values{"pending": {"1"}}
+
+	zones, err := Get[[]map[string]any](s.Client, "cluster/sdn/zones", query)
+	if err != nil {
+		s.Logger.Error("Error listing pending zones: ", err)
+		return nil, err
+	}
+	vnets, err := Get[[]map[string]any](s.Client, "cluster/sdn/vnets", query)
+	if err != nil {
+		s.Logger.Error("Error listing pending VNets: ", err)
+		return nil, err
+	}
+
+	changes := []SDNChange{}
+	for _, zone := range zones {
+		if change, ok := pendingChange(SDNKindZone, settingString(zone["zone"]), zone); ok {
+			changes = append(changes, change)
+		}
+	}
+	for _, vnet := range vnets {
+		if change, ok := pendingChange(SDNKindVNet, settingString(vnet["vnet"]), vnet); ok {
+			changes = append(changes, change)
+		}
+	}
+
+	for _, vnet := range vnets {
+		name := settingString(vnet["vnet"])
+		// A VNet can only be deleted once it has no subnets
+		if vnet["state"] == SDNStateDeleted {
+			continue
+		}
+
+		subnets, err := Get[[]map[string]any](s.Client, vnetPath(name)+"/subnets", query)
+		if err != nil {
+			s.Logger.Error("Error listing pending subnets: ", err)
+			return nil, err
+		}
+		for _, subnet := range subnets {
+			if change, ok := pendingChange(SDNKindSubnet, settingString(subnet["cidr"]), subnet); ok {
+				change.VNet = name
+				changes = append(changes, change)
+			}
+		}
+	}
+
+	return changes, nil
+}
+
+// pendingChange returns the change of an object of a pending view. The view holds the
+// applied options of an object and, under "pending", the options that differ in the
+// pending configuration; removed options have the value "deleted".
+func pendingChange(kind, id string, object map[string]any) (SDNChange, bool) {
+	state, _ := object["state"].(string)
+	if state == "" {
+		return SDNChange{}, false
+	}
+
+	change := SDNChange{Kind: kind, ID: id, State: state}
+	pending, _ := object["pending"].(map[string]any)
+	skipped := sdnPendingSkipped[kind]
+
+	switch state {
+	case SDNStateDeleted:
+		for option, value := range object {
+			if !slices.Contains(skipped, option) {
+				change.Options = append(change.Options, SDNOptionChange{Option: option, Old: settingString(value)})
+			}
+		}
+	default:
+		for option, value := range pending {
+			if slices.Contains(skipped, option) {
+				continue
+			}
+
+			optionChange := SDNOptionChange{Option: option, New: settingString(value)}
+			if old, ok := object[option]; ok {
+				optionChange.Old = settingString(old)
+			}
+			if optionChange.New == SDNStateDeleted {
+				optionChange.New = ""
+			}
+			change.Options = append(change.Options, optionChange)
+		}
+	}
+
+	sort.Slice(change.Options, func(i, j int) bool { return change.Options[i].Option < change.Options[j].Option })
+	return change, true
+}
+
+// NodeZoneStatus retrieves the state of the SDN zones deployed on a node
+func (s *SDNService) NodeZoneStatus(node string) ([]SDNZoneStatus, error) {
+	statuses, err := Get[[]SDNZoneStatus](s.Client, fmt.Sprintf("nodes/%s/sdn/zones", node), nil)
+	if err != nil {
+		s.Logger.Error("Error getting SDN zone status: ", err)
+		return nil, err
+	}
+
+	return statuses, nil
+}
+
+// WaitForZones waits until each online node has deployed the SDN configuration applied at
+// since, the start time of the apply task, and returns the state of the nodes. The apply
+// task only starts a network reload on every node, and until a node has reloaded it still
+// reports the zones of the previous configuration. So a node is first polled for a
+// "srvreload" task of its network that started after since, and its zone states are only
+// checked once that task has finished. A timeout of zero waits indefinitely. An error is
+// returned when a node fails to reload, reports a zone in error or the timeout is reached.
+func (s *SDNService) WaitForZones(since time.Time, timeout time.Duration) ([]SDNNodeStatus, error) {
+	var deadline time.Time
+	if timeout > 0 {
+		deadline = time.Now().Add(timeout)
+	}
+
+	zones, err := s.ListZones("", false)
+	if err != nil {
+		return nil, err
+	}
+	nodes, err := onlineNodes(s.Client)
+	if err != nil {
+		s.Logger.Error("Error listing nodes: ", err)
+		return nil, err
+	}
+
+	statuses := make([]SDNNodeStatus, len(nodes))
+	for i, node := range nodes {
+		statuses[i].Node = node
+	}
+
+	for {
+		settled := true
+		for i := range statuses {
+			status := &statuses[i]
+			if status.settled() {
+				continue
+			}
+
+			if !status.Reloaded {
+				if err := s.updateNetworkReload(status, since); err != nil {
+					return statuses, err
+				}
+			}
+			if status.Reloaded && status.Error == "" {
+				zoneStatuses, err := s.NodeZoneStatus(status.Node)
+				if err != nil {
+					return statuses, err
+				}
+				updateNodeStatus(status, zones, zoneStatuses)
+			}
+			if !status.settled() {
+				settled = false
+			}
+		}
+
+		if settled {
+			break
+		}
+		if !deadline.IsZero() && time.Now().After(deadline) {
+			waiting := nodesWhere(statuses, func(status SDNNodeStatus) bool { return !status.settled() })
+			return statuses, fmt.Errorf("timed out after %s waiting for the SDN zones of %s", timeout, strings.Join(waiting, ", "))
+		}
+
+		time.Sleep(s.PollInterval)
+	}
+
+	if failed := nodesWhere(statuses, SDNNodeStatus.failed); len(failed) > 0 {
+		return statuses, fmt.Errorf("SDN configuration failed on %s", strings.Join(failed, ", "))
+	}
+	return statuses, nil
+}
+
+// updateNetworkReload marks the node as reloaded once the latest network reload started
+// after since has finished, recording its exit status when it failed
+func (s *SDNService) updateNetworkReload(status *SDNNodeStatus, since time.Time) error {
+	query := url.Values{"typefilter": {"srvreload"}, "since": {strconv.FormatInt(since.Unix(), 10)}}
+	tasks, err := Get[[]Task](s.Client, fmt.Sprintf("nodes/%s/tasks", status.Node), query)
+	if err != nil {
+		s.Logger.Error("Error listing network reload tasks: ", err)
+		return err
+	}
+
+	var reload *Task
+	for i, task := range tasks {
+		if task.ID == "networking" && task.StartTime >= since.Unix() && task.EndTime != 0 &&
+			(reload == nil || task.StartTime > reload.StartTime) {
+			reload = &tasks[i]
+		}
+	}
+	if reload == nil {
+		return nil
+	}
+
+	status.Reloaded = true
+	if result := (TaskStatus{Status: "stopped", ExitStatus: reload.Status}); !result.Succeeded() {
+		status.Error = reload.Status
+	}
+	return nil
+}
+
+// updateNodeStatus sets the state of a reloaded node from the zone states it reports. Zones
+// restricted to other nodes are not expected on the node. A zone the node does not report
+// yet, or reports as pending, is waited for; as the node has reloaded its network, states
+// other than "error" count as deployed.
+func updateNodeStatus(status *SDNNodeStatus, zones []SDNZone, zoneStatuses []SDNZoneStatus) {
+	reported := map[string]string{}
+	for _, zoneStatus := range zoneStatuses {
+		reported[zoneStatus.Zone] = zoneStatus.Status
+	}
+
+	status.Failed, status.Waiting = nil, nil
+	for _, zone := range zones {
+		if zone.Nodes != "" && !slices.Contains(strings.Split(zone.Nodes, ","), status.Node) {
+			continue
+		}
+
+		switch reported[zone.Zone] {
+		case "error":
+			status.Failed = append(status.Failed, zone.Zone)
+		case "", "pending":
+			status.Waiting = append(status.Waiting, zone.Zone)
+		}
+	}
+	status.Ready = len(status.Failed) == 0 && len(status.Waiting) == 0
+}
+
+// nodesWhere returns the names of the nodes whose status matches
+func nodesWhere(statuses []SDNNodeStatus, match func(SDNNodeStatus) bool) []string {
+	nodes := []string{}
+	for _, status := range statuses {
+		if match(status) {
+			nodes = append(nodes, status.Node)
+		}
+	}
+	return nodes
+}
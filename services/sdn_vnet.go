@@ -0,0 +1,433 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/netip"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// SDNVNet is a virtual network of an SDN zone
+type SDNVNet struct {
+	VNet string `json:"vnet"`
+	Zone string `json:"zone"`
+	// Tag is the VLAN or VXLAN ID of the VNet in its zone
+	Tag   int    `json:"tag,omitempty"`
+	Alias string `json:"alias,omitempty"`
+	// VLANAware is 1 when guests can use VLAN tags inside the VNet
+	VLANAware int `json:"vlanaware,omitempty"`
+	// State is "new", "changed" or "deleted" for VNets with changes that are not applied yet
+	State  string `json:"state,omitempty"`
+	Digest string `json:"digest,omitempty"`
+}
+
+// SDNVNetOptions are the options of a VNet to create or update. Options that are not
+// set keep their value in an update; Delete lists options to remove.
+type SDNVNetOptions struct {
+	Zone      string
+	Tag       int
+	Alias     string
+	VLANAware bool
+	Delete    []string
+}
+
+// params returns the API parameters of the options that are set
+func (o SDNVNetOptions) params() url.Values {
+	params := url.Values{}
+	if o.Zone != "" {
+		params.Set("zone", o.Zone)
+	}
+	if o.Tag != 0 {
+		params.Set("tag", strconv.Itoa(o.Tag))
+	}
+	if o.Alias != "" {
+		params.Set("alias", o.Alias)
+	}
+	if o.VLANAware {
+		params.Set("vlanaware", "1")
+	}
+	if len(o.Delete) > 0 {
+		params.Set("delete", strings.Join(o.Delete, ","))
+	}
+	return params
+}
+
+// validateVNetTag checks the tag of a VNet against the type of its zone: simple zones have
+// no tags, VLAN and QinQ zones need a VLAN ID and VXLAN and EVPN zones a VXLAN ID
+func validateVNetTag(zone SDNZone, tag int) error {
+	switch zone.Type {
+	case SDNZoneSimple:
+		if tag != 0 {
+			return fmt.Errorf("VNets of simple zone %s cannot have a tag", zone.Zone)
+		}
+	case SDNZoneVLAN, SDNZoneQinQ:
+		if tag < 1 || tag > 4094 {
+			return fmt.Errorf("VNets of %s zone %s need a VLAN tag between 1 and 4094", zone.Type, zone.Zone)
+		}
+	case SDNZoneVXLAN, SDNZoneEVPN:
+		if tag < 1 || tag > 16777215 {
+			return fmt.Errorf("VNets of %s zone %s need a VXLAN tag between 1 and 16777215", zone.Type, zone.Zone)
+		}
+	}
+	return nil
+}
+
+// DHCPRange is a range of addresses a subnet hands out by DHCP
+type DHCPRange struct {
+	StartAddress string `json:"start-address"`
+	EndAddress   string `json:"end-address"`
+}
+
+// ParseDHCPRange parses a DHCP range given as "start-end", e.g. "10.0.0.100-10.0.0.200"
+func ParseDHCPRange(text string) (DHCPRange, error) {
+	start, end, ok := strings.Cut(text, "-")
+	if !ok {
+		return DHCPRange{}, fmt.Errorf("invalid DHCP range %q: expected <start>-<end>", text)
+	}
+	return DHCPRange{StartAddress: strings.TrimSpace(start), EndAddress: strings.TrimSpace(end)}, nil
+}
+
+// UnmarshalJSON accepts a DHCP range as an object or in the "start-address=...,end-address=..."
+// format of the SDN configuration
+func (r *DHCPRange) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		type plain DHCPRange
+		return json.Unmarshal(data, (*plain)(r))
+	}
+
+	for _, setting := range strings.Split(text, ",") {
+		key, value, _ := strings.Cut(setting, "=")
+		switch key {
+		case "start-address":
+			r.StartAddress = value
+		case "end-address":
+			r.EndAddress = value
+		}
+	}
+	return nil
+}
+
+// String formats the range in the format of the SDN configuration
+func (r DHCPRange) String() string {
+	return fmt.Sprintf("start-address=%s,end-address=%s", r.StartAddress, r.EndAddress)
+}
+
+// SDNSubnet is an IP subnet of a VNet
+type SDNSubnet struct {
+	// ID identifies the subnet in the API, "<zone>-<network>-<prefix length>"
+	ID      string `json:"subnet,omitempty"`
+	CIDR    string `json:"cidr"`
+	VNet    string `json:"vnet,omitempty"`
+	Zone    string `json:"zone,omitempty"`
+	Gateway string `json:"gateway,omitempty"`
+	// SNAT is 1 when traffic leaving the subnet is masqueraded
+	SNAT          int         `json:"snat,omitempty"`
+	DHCPRanges    []DHCPRange `json:"dhcp-range,omitempty"`
+	DHCPDNSServer string      `json:"dhcp-dns-server,omitempty"`
+	DNSZonePrefix string      `json:"dnszoneprefix,omitempty"`
+	State         string      `json:"state,omitempty"`
+	Digest        string      `json:"digest,omitempty"`
+}
+
+// SDNSubnetOptions are the options of a subnet to create or update. Options that are not
+// set keep their value in an update; Delete lists options to remove. DHCP ranges given
+// in an update replace all ranges of the subnet.
+type SDNSubnetOptions struct {
+	Gateway       string
+	SNAT          bool
+	DHCPRanges    []DHCPRange
+	DHCPDNSServer string
+	DNSZonePrefix string
+	Delete        []string
+}
+
+// params returns the API parameters of the options that are set
+func (o SDNSubnetOptions) params() url.Values {
+	params := url.Values{}
+	if o.Gateway != "" {
+		params.Set("gateway", o.Gateway)
+	}
+	if o.SNAT {
+		params.Set("snat", "1")
+	}
+	for _, dhcpRange := range o.DHCPRanges {
+		params.Add("dhcp-range", dhcpRange.String())
+	}
+	if o.DHCPDNSServer != "" {
+		params.Set("dhcp-dns-server", o.DHCPDNSServer)
+	}
+	if o.DNSZonePrefix != "" {
+		params.Set("dnszoneprefix", o.DNSZonePrefix)
+	}
+	if len(o.Delete) > 0 {
+		params.Set("delete", strings.Join(o.Delete, ","))
+	}
+	return params
+}
+
+// validate checks that the gateway and DHCP ranges are addresses of the subnet prefix
+func (o SDNSubnetOptions) validate(prefix netip.Prefix) error {
+	if o.Gateway != "" {
+		gateway, err := netip.ParseAddr(o.Gateway)
+		if err != nil {
+			return fmt.Errorf("invalid gateway %q: %w", o.Gateway, err)
+		}
+		if !prefix.Contains(gateway) {
+			return fmt.Errorf("gateway %s is not in subnet %s", gateway, prefix)
+		}
+	}
+
+	for _, dhcpRange := range o.DHCPRanges {
+		start, err := netip.ParseAddr(dhcpRange.StartAddress)
+		if err != nil {
+			return fmt.Errorf("invalid DHCP range start %q: %w", dhcpRange.StartAddress, err)
+		}
+		end, err := netip.ParseAddr(dhcpRange.EndAddress)
+		if err != nil {
+			return fmt.Errorf("invalid DHCP range end %q: %w", dhcpRange.EndAddress, err)
+		}
+		if !prefix.Contains(start) || !prefix.Contains(end) {
+			return fmt.Errorf("DHCP range %s-%s is not in subnet %s", start, end, prefix)
+		}
+		if end.Less(start) {
+			return fmt.Errorf("DHCP range %s-%s ends before it starts", start, end)
+		}
+	}
+	return nil
+}
+
+// parseSubnetCIDR parses the prefix of a subnet, which must be its network address
+func parseSubnetCIDR(cidr string) (netip.Prefix, error) {
+	prefix, err := netip.ParsePrefix(cidr)
+	if err != nil {
+		return netip.Prefix{}, fmt.Errorf("invalid subnet %q: %w", cidr, err)
+	}
+	if prefix.Masked() != prefix {
+		return netip.Prefix{}, fmt.Errorf("invalid subnet %q: use the network address %s", cidr, prefix.Masked())
+	}
+	return prefix, nil
+}
+
+// SubnetID returns the API ID of the subnet with prefix cidr in a zone, e.g. "zone1-10.0.0.0-24"
+func SubnetID(zone, cidr string) string {
+	return zone + "-" + strings.Replace(cidr, "/", "-", 1)
+}
+
+// vnetPath returns the API path of a VNet
+func vnetPath(vnet string) string {
+	return "cluster/sdn/vnets/" + url.PathEscape(vnet)
+}
+
+// ListVNets retrieves the VNets, with their pending changes when pending is set
+func (s *SDNService) ListVNets(pending bool) ([]SDNVNet, error) {
+	query := url.Values{}
+	if pending {
+		query.Set("pending", "1")
+	}
+
+	vnets, err := Get[[]SDNVNet](s.Client, "cluster/sdn/vnets", query)
+	if err != nil {
+		s.Logger.Error("Error listing VNets: ", err)
+		return nil, err
+	}
+
+	return vnets, nil
+}
+
+// GetVNet retrieves a VNet, with its pending changes when pending is set
+func (s *SDNService) GetVNet(vnet string, pending bool) (*SDNVNet, error) {
+	query := url.Values{}
+	if pending {
+		query.Set("pending", "1")
+	}
+
+	result, err := Get[SDNVNet](s.Client, vnetPath(vnet), query)
+	if err != nil {
+		s.Logger.Error("Error getting VNet: ", err)
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// CreateVNet creates a VNet in the zone given in options. The tag is checked against
+// the type of the zone.
+func (s *SDNService) CreateVNet(vnet string, options SDNVNetOptions) error {
+	if err := validateSDNID("VNet", vnet); err != nil {
+		return err
+	}
+	if options.Zone == "" {
+		return fmt.Errorf("a zone is required")
+	}
+
+	zone, err := s.GetZone(options.Zone, false)
+	if err != nil {
+		return err
+	}
+	if err := validateVNetTag(*zone, options.Tag); err != nil {
+		return err
+	}
+
+	params := options.params()
+	params.Set("vnet", vnet)
+
+	if _, err := Post[any](s.Client, "cluster/sdn/vnets", params); err != nil {
+		s.Logger.Error("Error creating VNet: ", err)
+		return err
+	}
+
+	return nil
+}
+
+// UpdateVNet changes the options of a VNet that are set in options. Whenever the tag or
+// the zone changes, including a deleted tag, the resulting tag is checked against the type
+// of the zone the VNet is in, or moves to.
+func (s *SDNService) UpdateVNet(vnet string, options SDNVNetOptions) error {
+	params := options.params()
+	if len(params) == 0 {
+		return fmt.Errorf("no changes given for VNet %s", vnet)
+	}
+
+	deletesTag := slices.Contains(options.Delete, "tag")
+	if options.Tag != 0 || options.Zone != "" || deletesTag {
+		current, err := s.GetVNet(vnet, false)
+		if err != nil {
+			return err
+		}
+
+		zoneName, tag := current.Zone, current.Tag
+		if options.Zone != "" {
+			zoneName = options.Zone
+		}
+		if deletesTag {
+			tag = 0
+		}
+		if options.Tag != 0 {
+			tag = options.Tag
+		}
+		zone, err := s.GetZone(zoneName, false)
+		if err != nil {
+			return err
+		}
+		if err := validateVNetTag(*zone, tag); err != nil {
+			return err
+		}
+	}
+
+	if _, err := Put[any](s.Client, vnetPath(vnet), params); err != nil {
+		s.Logger.Error("Error updating VNet: ", err)
+		return err
+	}
+
+	return nil
+}
+
+// DeleteVNet deletes a VNet
+func (s *SDNService) DeleteVNet(vnet string) error {
+	if _, err := Delete[any](s.Client, vnetPath(vnet), nil); err != nil {
+		s.Logger.Error("Error deleting VNet: ", err)
+		return err
+	}
+
+	return nil
+}
+
+// ListSubnets retrieves the subnets of a VNet, with their pending changes when pending is set
+func (s *SDNService) ListSubnets(vnet string, pending bool) ([]SDNSubnet, error) {
+	query := url.Values{}
+	if pending {
+		query.Set("pending", "1")
+	}
+
+	subnets, err := Get[[]SDNSubnet](s.Client, vnetPath(vnet)+"/subnets", query)
+	if err != nil {
+		s.Logger.Error("Error listing subnets: ", err)
+		return nil, err
+	}
+
+	return subnets, nil
+}
+
+// CreateSubnet creates the subnet with prefix cidr, e.g. 10.0.0.0/24, in a VNet
+func (s *SDNService) CreateSubnet(vnet, cidr string, options SDNSubnetOptions) error {
+	prefix, err := parseSubnetCIDR(cidr)
+	if err != nil {
+		return err
+	}
+	if len(options.Delete) > 0 {
+		return fmt.Errorf("options cannot be removed from a new subnet")
+	}
+	if err := options.validate(prefix); err != nil {
+		return err
+	}
+
+	params := options.params()
+	params.Set("subnet", prefix.String())
+	params.Set("type", "subnet")
+
+	if _, err := Post[any](s.Client, vnetPath(vnet)+"/subnets", params); err != nil {
+		s.Logger.Error("Error creating subnet: ", err)
+		return err
+	}
+
+	return nil
+}
+
+// UpdateSubnet changes the options of the subnet with prefix cidr in a VNet
+func (s *SDNService) UpdateSubnet(vnet, cidr string, options SDNSubnetOptions) error {
+	prefix, err := parseSubnetCIDR(cidr)
+	if err != nil {
+		return err
+	}
+	if err := options.validate(prefix); err != nil {
+		return err
+	}
+
+	params := options.params()
+	if len(params) == 0 {
+		return fmt.Errorf("no changes given for subnet %s", prefix)
+	}
+
+	path, err := s.subnetPath(vnet, prefix)
+	if err != nil {
+		return err
+	}
+	if _, err := Put[any](s.Client, path, params); err != nil {
+		s.Logger.Error("Error updating subnet: ", err)
+		return err
+	}
+
+	return nil
+}
+
+// DeleteSubnet deletes the subnet with prefix cidr from a VNet
+func (s *SDNService) DeleteSubnet(vnet, cidr string) error {
+	prefix, err := parseSubnetCIDR(cidr)
+	if err != nil {
+		return err
+	}
+
+	path, err := s.subnetPath(vnet, prefix)
+	if err != nil {
+		return err
+	}
+	if _, err := Delete[any](s.Client, path, nil); err != nil {
+		s.Logger.Error("Error deleting subnet: ", err)
+		return err
+	}
+
+	return nil
+}
+
+// subnetPath returns the API path of a subnet, whose ID contains the zone of its VNet
+func (s *SDNService) subnetPath(vnet string, prefix netip.Prefix) (string, error) {
+	current, err := s.GetVNet(vnet, false)
+	if err != nil {
+		return "", err
+	}
+	return vnetPath(vnet) + "/subnets/" + url.PathEscape(SubnetID(current.Zone, prefix.String())), nil
+}
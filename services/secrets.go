@@ -0,0 +1,301 @@
+package services
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Names of the built-in secret stores
+const (
+	// SecretStoreFile keeps the credentials in the session file itself (the default)
+	SecretStoreFile = "file"
+	// SecretStoreSecretService keeps the credentials in the freedesktop Secret Service
+	// (GNOME Keyring, KWallet) through the secret-tool command
+	SecretStoreSecretService = "secret-service"
+	// SecretStoreEncryptedFile keeps the credentials in files encrypted with a passphrase
+	SecretStoreEncryptedFile = "encrypted-file"
+)
+
+// SecretPassphraseEnv is the environment variable holding the passphrase of the encrypted-file store
+const SecretPassphraseEnv = "PROXMOX_SECRET_PASSPHRASE"
+
+// SelectedSecretStore is the secret store chosen at login for newly written sessions.
+// Existing sessions keep using the store they were written with.
+var SelectedSecretStore string
+
+// SecretToolCommand is the secret-tool executable used by the Secret Service store
+var SecretToolCommand = "secret-tool"
+
+// Secrets holds the credentials of a session that a SecretStore keeps out of the session file
+type Secrets struct {
+	Ticket              string `json:"ticket,omitempty"`
+	CSRFPreventionToken string `json:"csrfPreventionToken,omitempty"`
+	TokenSecret         string `json:"tokenSecret,omitempty"`
+}
+
+// SecretStore stores the secrets of a context outside the session file
+type SecretStore interface {
+	Get(context string) (Secrets, error)
+	Set(context string, secrets Secrets) error
+	Delete(context string) error
+}
+
+// SecretStoreFactory creates a secret store; baseDir is the ~/.proxmox directory
+type SecretStoreFactory func(baseDir string) (SecretStore, error)
+
+var (
+	secretStoresMu sync.RWMutex
+	secretStores   = map[string]SecretStoreFactory{
+		SecretStoreSecretService: func(string) (SecretStore, error) {
+			return NewSecretServiceStore(SecretToolCommand), nil
+		},
+		SecretStoreEncryptedFile: func(baseDir string) (SecretStore, error) {
+			return NewEncryptedFileStore(filepath.Join(baseDir, "secrets"), os.Getenv(SecretPassphraseEnv)), nil
+		},
+	}
+)
+
+// RegisterSecretStore makes a secret store available under name, replacing any store of that name
+func RegisterSecretStore(name string, factory SecretStoreFactory) {
+	secretStoresMu.Lock()
+	defer secretStoresMu.Unlock()
+	secretStores[name] = factory
+}
+
+// SecretStoreNames returns the names of all available secret stores, including "file"
+func SecretStoreNames() []string {
+	secretStoresMu.RLock()
+	defer secretStoresMu.RUnlock()
+
+	names := []string{SecretStoreFile}
+	for name := range secretStores {
+		names = append(names, name)
+	}
+	sort.Strings(names[1:])
+	return names
+}
+
+// ValidateSecretStore returns an error if name is not an available secret store
+func ValidateSecretStore(name string) error {
+	if name == "" || name == SecretStoreFile {
+		return nil
+	}
+
+	secretStoresMu.RLock()
+	_, ok := secretStores[name]
+	secretStoresMu.RUnlock()
+	if !ok {
+		return fmt.Errorf("unknown secret store %q: use one of %s", name, strings.Join(SecretStoreNames(), ", "))
+	}
+	return nil
+}
+
+// openSecretStore returns the named secret store, or nil for the file store
+func openSecretStore(name, baseDir string) (SecretStore, error) {
+	if name == "" || name == SecretStoreFile {
+		return nil, nil
+	}
+	if err := ValidateSecretStore(name); err != nil {
+		return nil, err
+	}
+
+	secretStoresMu.RLock()
+	factory := secretStores[name]
+	secretStoresMu.RUnlock()
+	return factory(baseDir)
+}
+
+// secretServiceStore stores secrets in the freedesktop Secret Service using secret-tool
+type secretServiceStore struct {
+	command string
+}
+
+// NewSecretServiceStore creates a SecretStore backed by the freedesktop Secret Service.
+// command is the secret-tool executable, or a stand-in with the same interface.
+func NewSecretServiceStore(command string) SecretStore {
+	return &secretServiceStore{command: command}
+}
+
+// Get looks up the secrets of a context
+func (s *secretServiceStore) Get(context string) (Secrets, error) {
+	//nolint:gosec // G204: The command is configured by the program, the arguments are attributes
+	cmd := exec.Command(s.command, append([]string{"lookup"}, secretAttributes(context)...)...) // #nosec G204
+	out, err := cmd.Output()
+	if err != nil {
+		return Secrets{}, fmt.Errorf("no secrets found in the Secret Service for context %q: %w", context, err)
+	}
+
+	var secrets Secrets
+	if err = json.Unmarshal(out, &secrets); err != nil {
+		return Secrets{}, fmt.Errorf("invalid secrets in the Secret Service for context %q: %w", context, err)
+	}
+	return secrets, nil
+}
+
+// Set stores the secrets of a context, replacing previous ones
+func (s *secretServiceStore) Set(context string, secrets Secrets) error {
+	content, err := json.Marshal(secrets)
+	if err != nil {
+		return err
+	}
+
+	args := append([]string{"store", "--label", "proxmox-cli " + context}, secretAttributes(context)...)
+	//nolint:gosec // G204: The command is configured by the program, the arguments are attributes
+	cmd := exec.Command(s.command, args...) // #nosec G204
+	cmd.Stdin = bytes.NewReader(content)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("error storing secrets in the Secret Service: %w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// Delete removes the secrets of a context
+func (s *secretServiceStore) Delete(context string) error {
+	//nolint:gosec // G204: The command is configured by the program, the arguments are attributes
+	cmd := exec.Command(s.command, append([]string{"clear"}, secretAttributes(context)...)...) // #nosec G204
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("error removing secrets from the Secret Service: %w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// secretAttributes returns the Secret Service attributes identifying the secrets of a context
+func secretAttributes(context string) []string {
+	return []string{"service", "proxmox-cli", "context", context}
+}
+
+// encryptedFileKeyIterations is the PBKDF2-SHA256 iteration count used to derive the encryption key
+const encryptedFileKeyIterations = 600000
+
+// encryptedFileStore stores the secrets of each context in a file encrypted with AES-256-GCM,
+// using a key derived from a passphrase
+type encryptedFileStore struct {
+	dir        string
+	passphrase string
+
+	// keys caches derived keys by salt, as key derivation is deliberately slow
+	mu   sync.Mutex
+	keys map[string][]byte
+}
+
+// encryptedSecrets is the on-disk format of the encrypted-file store
+type encryptedSecrets struct {
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// NewEncryptedFileStore creates a SecretStore that keeps one encrypted file per context in dir
+func NewEncryptedFileStore(dir, passphrase string) SecretStore {
+	return &encryptedFileStore{dir: dir, passphrase: passphrase, keys: map[string][]byte{}}
+}
+
+// Get decrypts the secrets of a context
+func (s *encryptedFileStore) Get(context string) (Secrets, error) {
+	//nolint:gosec // G304: File path is constructed from the store directory and a validated context name
+	content, err := os.ReadFile(s.path(context)) // #nosec G304
+	if err != nil {
+		return Secrets{}, err
+	}
+
+	var stored encryptedSecrets
+	if err = json.Unmarshal(content, &stored); err != nil {
+		return Secrets{}, fmt.Errorf("invalid encrypted secrets for context %q: %w", context, err)
+	}
+
+	gcm, err := s.cipher(stored.Salt)
+	if err != nil {
+		return Secrets{}, err
+	}
+	plaintext, err := gcm.Open(nil, stored.Nonce, stored.Ciphertext, []byte(context))
+	if err != nil {
+		return Secrets{}, fmt.Errorf("cannot decrypt secrets for context %q: wrong passphrase or corrupted file", context)
+	}
+
+	var secrets Secrets
+	err = json.Unmarshal(plaintext, &secrets)
+	return secrets, err
+}
+
+// Set encrypts the secrets of a context with a fresh salt and nonce
+func (s *encryptedFileStore) Set(context string, secrets Secrets) error {
+	plaintext, err := json.Marshal(secrets)
+	if err != nil {
+		return err
+	}
+
+	stored := encryptedSecrets{Salt: make([]byte, 16)}
+	if _, err = rand.Read(stored.Salt); err != nil {
+		return err
+	}
+	gcm, err := s.cipher(stored.Salt)
+	if err != nil {
+		return err
+	}
+	stored.Nonce = make([]byte, gcm.NonceSize())
+	if _, err = rand.Read(stored.Nonce); err != nil {
+		return err
+	}
+	// The context name is authenticated, so a secrets file cannot be swapped between contexts
+	stored.Ciphertext = gcm.Seal(nil, stored.Nonce, plaintext, []byte(context))
+
+	content, err := json.Marshal(stored)
+	if err != nil {
+		return err
+	}
+	if err = ensurePrivateDir(s.dir); err != nil {
+		return err
+	}
+	return writeFileAtomic(s.path(context), content)
+}
+
+// Delete removes the secrets file of a context. It needs no passphrase.
+func (s *encryptedFileStore) Delete(context string) error {
+	err := os.Remove(s.path(context))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (s *encryptedFileStore) path(context string) string {
+	return filepath.Join(s.dir, context)
+}
+
+// cipher returns the AES-GCM cipher for the key derived from the passphrase and salt
+func (s *encryptedFileStore) cipher(salt []byte) (cipher.AEAD, error) {
+	if s.passphrase == "" {
+		return nil, fmt.Errorf("the encrypted-file secret store needs a passphrase in $%s", SecretPassphraseEnv)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key, ok := s.keys[string(salt)]
+	if !ok {
+		var err error
+		key, err = pbkdf2.Key(sha256.New, s.passphrase, salt, encryptedFileKeyIterations, 32)
+		if err != nil {
+			return nil, err
+		}
+		s.keys[string(salt)] = key
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -1,8 +1,15 @@
 package services
 
 import (
+	"errors"
 	"fmt"
+	"io"
+	"net/http"
+	"net/url"
 	"os"
+	"strings"
+	"sync"
+	"time"
 
 	"encoding/json"
 
@@ -12,26 +19,63 @@ import (
 )
 
 // SessionServiceInterface allows mocking of session file operations for AuthService.
+// Each SessionService reads and writes the session of a single named context.
 type SessionServiceInterface interface {
 	WriteSessionFile(sessionData SessionData) error
 	ReadSessionFile() (SessionData, error)
 	UpdateSessionField(field string, value interface{}) error
+	ContextName() string
+	ListContexts() ([]ContextInfo, error)
+	UseContext(name string) error
+	RenameContext(oldName, newName string) error
+	DeleteContext(name string) error
 }
 
 // SessionService manages Proxmox session data persistence
 type SessionService struct {
 	homeDir string
+	context string
 	logger  *logrus.Logger
+
+	// stores caches the opened secret stores by name; storesMu guards it, as bulk operations
+	// read the session from many goroutines
+	storesMu sync.Mutex
+	stores   map[string]SecretStore
 }
 
 // SessionData represents the Proxmox session information stored locally
 type SessionData struct {
-	Server     string              `json:"server"`
-	Port       int                 `json:"port"`
-	HttpScheme string              `json:"httpScheme"`
-	Response   SessionDataResponse `json:"response"`
+	Server      string              `json:"server"`
+	Port        int                 `json:"port"`
+	HttpScheme  string              `json:"httpScheme"`
+	TokenID     string              `json:"tokenId,omitempty"`
+	TokenSecret string              `json:"tokenSecret,omitempty"`
+	Trust       bool                `json:"trust,omitempty"`
+	IssuedAt    int64               `json:"issuedAt,omitempty"`
+	SecretStore string              `json:"secretStore,omitempty"`
+	Response    SessionDataResponse `json:"response"`
+}
+
+// secrets returns the credentials of the session
+func (s SessionData) secrets() Secrets {
+	return Secrets{
+		Ticket:              s.Response.Data.Ticket,
+		CSRFPreventionToken: s.Response.Data.CSRFPreventionToken,
+		TokenSecret:         s.TokenSecret,
+	}
+}
+
+// setSecrets replaces the credentials of the session
+func (s *SessionData) setSecrets(secrets Secrets) {
+	s.Response.Data.Ticket = secrets.Ticket
+	s.Response.Data.CSRFPreventionToken = secrets.CSRFPreventionToken
+	s.TokenSecret = secrets.TokenSecret
 }
 
+// TicketRenewalAge is the age after which a ticket is renewed before it is used.
+// Proxmox tickets expire after two hours.
+const TicketRenewalAge = 90 * time.Minute
+
 // SessionDataResponse represents the authentication response from Proxmox API
 type SessionDataResponse struct {
 	Data struct {
@@ -41,34 +85,132 @@ type SessionDataResponse struct {
 	} `json:"data"`
 }
 
-// NewSessionService creates a new SessionService instance
+// UsesAPIToken reports whether the session authenticates with an API token instead of a ticket
+func (s SessionData) UsesAPIToken() bool {
+	return s.TokenID != ""
+}
+
+// TicketNeedsRenewal reports whether the ticket is older than TicketRenewalAge.
+// Sessions written before the issue time was recorded are renewed when the server rejects them.
+func (s SessionData) TicketNeedsRenewal(now time.Time) bool {
+	if s.UsesAPIToken() || s.IssuedAt == 0 {
+		return false
+	}
+	return now.Sub(time.Unix(s.IssuedAt, 0)) >= TicketRenewalAge
+}
+
+// AuthCookies returns the cookies needed to authenticate a request with this session.
+// API token sessions authenticate through the Authorization header, so no cookies are returned.
+func (s SessionData) AuthCookies() []*http.Cookie {
+	if s.UsesAPIToken() {
+		return nil
+	}
+
+	return []*http.Cookie{
+		{
+			Name:  "PVEAuthCookie",
+			Value: url.QueryEscape(s.Response.Data.Ticket),
+		},
+	}
+}
+
+// AuthHeaders returns a copy of headers with the authentication headers for this session added.
+// csrf should be true for write requests; it adds the CSRFPreventionToken for ticket sessions,
+// while API token sessions always send the Authorization header and never need a CSRF token.
+func (s SessionData) AuthHeaders(headers map[string]string, csrf bool) map[string]string {
+	result := make(map[string]string, len(headers)+1)
+	for key, value := range headers {
+		result[key] = value
+	}
+
+	if s.UsesAPIToken() {
+		result["Authorization"] = APITokenHeader(s.TokenID, s.TokenSecret)
+	} else if csrf {
+		result["CSRFPreventionToken"] = s.Response.Data.CSRFPreventionToken
+	}
+
+	return result
+}
+
+// APITokenHeader builds the Authorization header value for a Proxmox API token.
+// tokenID has the form user@realm!tokenname.
+func APITokenHeader(tokenID, tokenSecret string) string {
+	return fmt.Sprintf("PVEAPIToken=%s=%s", tokenID, tokenSecret)
+}
+
+// TokenUser returns the user@realm part of an API token ID
+func TokenUser(tokenID string) string {
+	user, _, _ := strings.Cut(tokenID, "!")
+	return user
+}
+
+// NewSessionService creates a new SessionService instance for the selected context.
+// SelectedContext takes precedence; otherwise the current context recorded on disk is used.
 func NewSessionService(logger *logrus.Logger) (*SessionService, error) {
 	dir, err := os.UserHomeDir()
 	if err != nil {
 		return nil, err
 	}
 
-	return &SessionService{
+	s := &SessionService{
 		homeDir: dir,
 		logger:  logger,
-	}, nil
+		stores:  map[string]SecretStore{},
+	}
+
+	s.context = SelectedContext
+	if s.context == "" {
+		s.context = s.currentContext()
+	}
+	if err = validateContextName(s.context); err != nil {
+		return nil, err
+	}
+
+	return s, nil
 }
 
-// WriteSessionFile writes session data to the session file
+// WriteSessionFile writes session data to the session file.
+// The file is only readable by the user and replaced atomically, so a failed write never
+// leaves a truncated session behind. Sessions using a secret store have their ticket,
+// CSRF token and token secret saved in that store instead of the file.
 func (s *SessionService) WriteSessionFile(sessionData SessionData) error {
 	filePath, err := s.getSessionFilepath()
 	if err != nil {
 		return err
 	}
 
-	//nolint:gosec // G304: File path is from trusted getSessionFilepath method
-	file, err := os.Create(filePath) // #nosec G304 -- File path from trusted getSessionFilepath
+	// New sessions use the store selected at login, existing ones keep theirs
+	if sessionData.SecretStore == "" {
+		sessionData.SecretStore = SelectedSecretStore
+	}
+	if sessionData.SecretStore == SecretStoreFile {
+		sessionData.SecretStore = ""
+	}
+
+	// Remove secrets left in a store the session no longer uses
+	if previous, readErr := s.readContextFile(s.context); readErr == nil &&
+		previous.SecretStore != "" && previous.SecretStore != sessionData.SecretStore {
+		if err = s.deleteSecrets(previous.SecretStore, s.context); err != nil {
+			s.logger.Warn("Could not remove secrets from the previous secret store: ", err)
+		}
+	}
+
+	store, err := s.secretStore(sessionData.SecretStore)
 	if err != nil {
 		return err
 	}
+	if store != nil {
+		if err = store.Set(s.context, sessionData.secrets()); err != nil {
+			return err
+		}
+		sessionData.setSecrets(Secrets{})
+	}
 
-	encoder := json.NewEncoder(file)
-	return encoder.Encode(sessionData)
+	content, err := json.Marshal(sessionData)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(filePath, append(content, '\n'))
 }
 
 // ReadSessionFile reads and validates session data from the session file
@@ -80,6 +222,9 @@ func (s *SessionService) ReadSessionFile() (SessionData, error) {
 
 	//nolint:gosec // G304: File path is from trusted getSessionFilepath method
 	file, err := os.Open(filePath) // #nosec G304 -- File path from trusted getSessionFilepath
+	if os.IsNotExist(err) {
+		return SessionData{}, fmt.Errorf("no session for context %q: log in first", s.context)
+	}
 	if err != nil {
 		return SessionData{}, err
 	}
@@ -89,10 +234,25 @@ func (s *SessionService) ReadSessionFile() (SessionData, error) {
 	var sessionData SessionData
 	decoder := json.NewDecoder(file)
 	err = decoder.Decode(&sessionData)
+	if errors.Is(err, io.EOF) {
+		return SessionData{}, fmt.Errorf("no session for context %q: log in first", s.context)
+	}
 	if err != nil {
 		return SessionData{}, err
 	}
 
+	store, err := s.secretStore(sessionData.SecretStore)
+	if err != nil {
+		return SessionData{}, err
+	}
+	if store != nil {
+		secrets, err := store.Get(s.context)
+		if err != nil {
+			return SessionData{}, fmt.Errorf("error reading secrets from the %s store: %w", sessionData.SecretStore, err)
+		}
+		sessionData.setSecrets(secrets)
+	}
+
 	// Validate that all fields in sessionData are not empty values
 	if sessionData.Server == "" {
 		return SessionData{}, fmt.Errorf("invalid session: missing server")
@@ -104,6 +264,13 @@ func (s *SessionService) ReadSessionFile() (SessionData, error) {
 		return SessionData{}, fmt.Errorf("invalid session: missing httpScheme")
 	}
 
+	if sessionData.UsesAPIToken() {
+		if sessionData.TokenSecret == "" {
+			return SessionData{}, fmt.Errorf("invalid session: missing 'tokenSecret' field")
+		}
+		return sessionData, nil
+	}
+
 	if sessionData.Response.Data.Username == "" {
 		return SessionData{}, fmt.Errorf("invalid session: missing 'username' field in data")
 	}
@@ -134,6 +301,12 @@ func (s *SessionService) UpdateSessionField(field string, value interface{}) err
 	case "httpScheme":
 		//nolint:errcheck // Type assertion is safe within switch on field name
 		sessionData.HttpScheme = value.(string)
+	case "trust":
+		//nolint:errcheck // Type assertion is safe within switch on field name
+		sessionData.Trust = value.(bool)
+	case "issuedAt":
+		//nolint:errcheck // Type assertion is safe within switch on field name
+		sessionData.IssuedAt = value.(int64)
 	case "response":
 		var resp SessionDataResponse
 		if str, ok := value.(string); ok {
@@ -147,26 +320,87 @@ func (s *SessionService) UpdateSessionField(field string, value interface{}) err
 	return s.WriteSessionFile(sessionData)
 }
 
+// getSessionFilepath returns the session file of the context, creating its directory if
+// needed. The file itself is only created when a session is written, so reading a context
+// that was never logged in does not leave an empty session behind. Directories and files
+// created by older versions are made private to the user.
 func (s *SessionService) getSessionFilepath() (string, error) {
-	dirPath := filepath.Join(s.homeDir, ".proxmox")
-	var err error
-	if _, err = os.Stat(dirPath); os.IsNotExist(err) {
-		//nolint:gosec // G301: Standard directory permissions for user config
-		err = os.MkdirAll(dirPath, 0755) // #nosec G301 -- Standard permissions for user config directory
-		if err != nil {
-			return "", err
-		}
+	filePath := s.contextFilepath(s.context)
+	if err := ensurePrivateDir(s.baseDir()); err != nil {
+		return "", err
 	}
-	filePath := filepath.Join(dirPath, "session")
-	if _, err = os.Stat(filePath); os.IsNotExist(err) {
-		var file *os.File
-		//nolint:gosec // G304: File path is constructed from trusted homeDir
-		file, err = os.Create(filePath) // #nosec G304 -- File path from trusted homeDir
-		if err != nil {
+	if err := ensurePrivateDir(filepath.Dir(filePath)); err != nil {
+		return "", err
+	}
+
+	info, err := os.Stat(filePath)
+	if err != nil && !os.IsNotExist(err) {
+		return "", err
+	}
+	if err == nil && info.Mode().Perm()&0077 != 0 {
+		if err = os.Chmod(filePath, 0600); err != nil {
 			return "", err
 		}
-		//nolint:errcheck // Best effort close, file was just created
-		_ = file.Close()
 	}
 	return filePath, nil
 }
+
+// secretStore returns the named secret store, or nil when secrets are kept in the session file
+func (s *SessionService) secretStore(name string) (SecretStore, error) {
+	s.storesMu.Lock()
+	defer s.storesMu.Unlock()
+
+	if store, ok := s.stores[name]; ok {
+		return store, nil
+	}
+
+	store, err := openSecretStore(name, s.baseDir())
+	if err != nil {
+		return nil, err
+	}
+	s.stores[name] = store
+	return store, nil
+}
+
+// deleteSecrets removes the secrets of a context from the named store
+func (s *SessionService) deleteSecrets(storeName, context string) error {
+	store, err := s.secretStore(storeName)
+	if err != nil || store == nil {
+		return err
+	}
+	return store.Delete(context)
+}
+
+// ensurePrivateDir creates dir with mode 0700, or restricts an existing dir to that mode
+func ensurePrivateDir(dir string) error {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	return os.Chmod(dir, 0700)
+}
+
+// writeFileAtomic writes content to a temporary file with mode 0600 next to path and
+// renames it over path, so readers see either the old or the new content
+func writeFileAtomic(path string, content []byte) error {
+	file, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpPath := file.Name()
+
+	_, err = file.Write(content)
+	if err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmpPath, path)
+	}
+	if err != nil {
+		//nolint:errcheck // Best effort cleanup of the temporary file
+		_ = os.Remove(tmpPath)
+	}
+	return err
+}
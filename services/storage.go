@@ -1,10 +1,7 @@
 package services
 
 import (
-	"encoding/json"
 	"fmt"
-	"io"
-	"net/http"
 	"net/url"
 
 	"github.com/sirupsen/logrus"
@@ -22,137 +19,100 @@ type Storage struct {
 
 // StorageContent represents content within a storage
 type StorageContent struct {
-	VolID  string `json:"volid"`
-	Format string `json:"format,omitempty"`
-	Size   int64  `json:"size,omitempty"`
-	Used   int64  `json:"used,omitempty"`
-	VMID   int    `json:"vmid,omitempty"`
-	CTime  int64  `json:"ctime,omitempty"`
-}
-
-// StorageListResponse represents the API response for storage list
-type StorageListResponse struct {
-	Data []Storage `json:"data"`
-}
-
-// StorageContentResponse represents the API response for storage content
-type StorageContentResponse struct {
-	Data []StorageContent `json:"data"`
+	VolID string `json:"volid"`
+	// Content is the content type of the volume, e.g. "images", "iso" or "backup"
+	Content string `json:"content,omitempty"`
+	Format  string `json:"format,omitempty"`
+	Size    int64  `json:"size,omitempty"`
+	Used    int64  `json:"used,omitempty"`
+	VMID    int    `json:"vmid,omitempty"`
+	CTime   int64  `json:"ctime,omitempty"`
+	// Subtype is the guest type of a backup, "qemu" or "lxc"
+	Subtype   string `json:"subtype,omitempty"`
+	Notes     string `json:"notes,omitempty"`
+	Protected int    `json:"protected,omitempty"`
 }
 
 // StorageService handles storage-related operations
 type StorageService struct {
-	Logger         *logrus.Logger
-	Trust          bool
-	HTTPService    HTTPServiceInterface
-	SessionService SessionServiceInterface
+	Logger *logrus.Logger
+	Trust  bool
+	Client *APIClient
 }
 
 // NewStorageService creates a new StorageService with real dependencies
 func NewStorageService(logger *logrus.Logger, trust bool) (*StorageService, error) {
-	sessionService, err := NewSessionService(logger)
+	client, err := NewAPIClient(logger, trust)
 	if err != nil {
 		return nil, err
 	}
 
 	return &StorageService{
-		Logger:         logger,
-		Trust:          trust,
-		HTTPService:    NewHttpService(logger, trust),
-		SessionService: sessionService,
+		Logger: logger,
+		Trust:  trust,
+		Client: client,
 	}, nil
 }
 
 // NewStorageServiceWithDeps creates a StorageService with injected dependencies (for testing)
 func NewStorageServiceWithDeps(logger *logrus.Logger, trust bool, httpService HTTPServiceInterface, sessionService SessionServiceInterface) *StorageService {
 	return &StorageService{
-		Logger:         logger,
-		Trust:          trust,
-		HTTPService:    httpService,
-		SessionService: sessionService,
+		Logger: logger,
+		Trust:  trust,
+		Client: NewAPIClientWithDeps(logger, httpService, sessionService),
 	}
 }
 
 // ListStorage retrieves a list of all storage
 func (s *StorageService) ListStorage() ([]Storage, error) {
-	sessionData, err := s.SessionService.ReadSessionFile()
-	if err != nil {
-		s.Logger.Error("Error reading session file: ", err)
-		return nil, err
-	}
-
-	uri := fmt.Sprintf("%s://%s:%d/api2/json/storage",
-		sessionData.HttpScheme, sessionData.Server, sessionData.Port)
-
-	cookies := []*http.Cookie{
-		{
-			Name:  "PVEAuthCookie",
-			Value: url.QueryEscape(sessionData.Response.Data.Ticket),
-		},
-	}
-
-	resp, err := s.HTTPService.Get(uri, nil, cookies)
+	storages, err := Get[[]Storage](s.Client, "storage", nil)
 	if err != nil {
 		s.Logger.Error("Error listing storage: ", err)
 		return nil, err
 	}
-	//nolint:errcheck // Best effort close in defer
-	defer func() { _ = resp.Body.Close() }()
 
-	bodyBytes, err := io.ReadAll(resp.Body)
-	if err != nil {
-		s.Logger.Error("Error reading response body: ", err)
-		return nil, err
+	return storages, nil
+}
+
+// ListNodeStorage retrieves the storage available on a node. With contentType only the
+// enabled storage holding that type of content, e.g. "backup", is returned.
+func (s *StorageService) ListNodeStorage(nodeName, contentType string) ([]Storage, error) {
+	query := url.Values{}
+	if contentType != "" {
+		query.Set("content", contentType)
+		query.Set("enabled", "1")
 	}
 
-	var result StorageListResponse
-	err = json.Unmarshal(bodyBytes, &result)
+	storages, err := Get[[]Storage](s.Client, fmt.Sprintf("nodes/%s/storage", nodeName), query)
 	if err != nil {
-		s.Logger.Error("Error parsing response JSON: ", err)
+		s.Logger.Error("Error listing node storage: ", err)
 		return nil, err
 	}
 
-	return result.Data, nil
+	return storages, nil
 }
 
 // ListStorageContent retrieves the content of a specific storage on a node
 func (s *StorageService) ListStorageContent(nodeName, storageName string) ([]StorageContent, error) {
-	sessionData, err := s.SessionService.ReadSessionFile()
-	if err != nil {
-		s.Logger.Error("Error reading session file: ", err)
-		return nil, err
-	}
-
-	uri := fmt.Sprintf("%s://%s:%d/api2/json/nodes/%s/storage/%s/content",
-		sessionData.HttpScheme, sessionData.Server, sessionData.Port, nodeName, storageName)
+	return s.ListStorageContentOfType(nodeName, storageName, "", 0)
+}
 
-	cookies := []*http.Cookie{
-		{
-			Name:  "PVEAuthCookie",
-			Value: url.QueryEscape(sessionData.Response.Data.Ticket),
-		},
+// ListStorageContentOfType retrieves the content of a specific storage on a node, filtered by
+// content type (e.g. "backup") and VM ID when they are not empty
+func (s *StorageService) ListStorageContentOfType(nodeName, storageName, contentType string, vmid int) ([]StorageContent, error) {
+	query := url.Values{}
+	if contentType != "" {
+		query.Set("content", contentType)
 	}
-
-	resp, err := s.HTTPService.Get(uri, nil, cookies)
-	if err != nil {
-		s.Logger.Error("Error listing storage content: ", err)
-		return nil, err
+	if vmid != 0 {
+		query.Set("vmid", fmt.Sprintf("%d", vmid))
 	}
-	//nolint:errcheck // Best effort close in defer
-	defer func() { _ = resp.Body.Close() }()
 
-	bodyBytes, err := io.ReadAll(resp.Body)
+	contents, err := Get[[]StorageContent](s.Client, fmt.Sprintf("nodes/%s/storage/%s/content", nodeName, storageName), query)
 	if err != nil {
-		s.Logger.Error("Error reading response body: ", err)
-		return nil, err
-	}
-
-	var result StorageContentResponse
-	err = json.Unmarshal(bodyBytes, &result)
-	if err != nil {
-		s.Logger.Error("Error parsing response JSON: ", err)
+		s.Logger.Error("Error listing storage content: ", err)
 		return nil, err
 	}
 
-	return result.Data, nil
+	return contents, nil
 }
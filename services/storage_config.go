@@ -0,0 +1,350 @@
+package services
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Storage backend types
+const (
+	StorageDir     = "dir"
+	StorageNFS     = "nfs"
+	StorageCIFS    = "cifs"
+	StorageLVM     = "lvm"
+	StorageLVMThin = "lvmthin"
+	StorageZFSPool = "zfspool"
+	StorageRBD     = "rbd"
+	StoragePBS     = "pbs"
+)
+
+// StorageTypes are the storage types StorageService can create
+var StorageTypes = []string{StorageDir, StorageNFS, StorageCIFS, StorageLVM, StorageLVMThin, StorageZFSPool, StorageRBD, StoragePBS}
+
+// storageCommonOptions are the options every storage type accepts
+var storageCommonOptions = []string{"content", "nodes", "disable"}
+
+// fileContentTypes are the content types of file based storage; block storage only holds
+// guest disks and PBS only backups
+var (
+	fileContentTypes  = []string{"images", "rootdir", "vztmpl", "iso", "backup", "snippets", "import"}
+	blockContentTypes = []string{"images", "rootdir"}
+)
+
+// storageTypeOptions are the options specific to each storage type. The required ones
+// must be given when a storage of the type is created, and the fixed ones cannot change
+// afterwards.
+var storageTypeOptions = map[string]struct {
+	allowed, required, fixed, content []string
+}{
+	StorageDir: {
+		allowed:  []string{"path", "shared", "preallocation", "prune-backups"},
+		required: []string{"path"},
+		fixed:    []string{"path"},
+		content:  fileContentTypes,
+	},
+	StorageNFS: {
+		allowed:  []string{"server", "export", "path", "options", "preallocation", "prune-backups"},
+		required: []string{"server", "export"},
+		fixed:    []string{"export", "path"},
+		content:  fileContentTypes,
+	},
+	StorageCIFS: {
+		allowed:  []string{"server", "share", "path", "username", "password", "domain", "smbversion", "subdir", "options", "preallocation", "prune-backups"},
+		required: []string{"server", "share"},
+		fixed:    []string{"share", "path"},
+		content:  fileContentTypes,
+	},
+	StorageLVM: {
+		allowed:  []string{"vgname", "base", "shared", "saferemove"},
+		required: []string{"vgname"},
+		fixed:    []string{"vgname", "base"},
+		content:  blockContentTypes,
+	},
+	StorageLVMThin: {
+		allowed:  []string{"vgname", "thinpool"},
+		required: []string{"vgname", "thinpool"},
+		fixed:    []string{"vgname", "thinpool"},
+		content:  blockContentTypes,
+	},
+	StorageZFSPool: {
+		allowed:  []string{"pool", "sparse", "blocksize", "mountpoint"},
+		required: []string{"pool"},
+		fixed:    []string{"pool"},
+		content:  blockContentTypes,
+	},
+	StorageRBD: {
+		allowed:  []string{"pool", "monhost", "username", "krbd", "namespace", "data-pool"},
+		required: []string{"pool"},
+		content:  blockContentTypes,
+	},
+	StoragePBS: {
+		allowed:  []string{"server", "datastore", "username", "password", "fingerprint", "namespace", "port", "prune-backups"},
+		required: []string{"server", "datastore", "username"},
+		content:  []string{"backup"},
+	},
+}
+
+// storageIDPattern matches storage IDs: letters, digits, '-', '_' and '.', starting with a
+// letter and ending with a letter or digit
+var storageIDPattern = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9\-_.]*[a-zA-Z0-9]$`)
+
+// StorageConfig is the configuration of a storage, holding the options of its type
+type StorageConfig map[string]any
+
+// StorageSetting is an option of a storage configuration
+type StorageSetting struct {
+	Option string
+	Value  string
+}
+
+// Type returns the storage type, e.g. "nfs"
+func (c StorageConfig) Type() string {
+	return settingString(c["type"])
+}
+
+// Settings returns the options of the storage sorted by name, without its ID, type and digest
+func (c StorageConfig) Settings() []StorageSetting {
+	settings := []StorageSetting{}
+	for option, value := range c {
+		if option != "storage" && option != "type" && option != "digest" {
+			settings = append(settings, StorageSetting{Option: option, Value: settingString(value)})
+		}
+	}
+	sort.Slice(settings, func(i, j int) bool { return settings[i].Option < settings[j].Option })
+	return settings
+}
+
+// StorageOptions are the options of a storage to create or update. Options that are not
+// set keep their value in an update; Delete lists options to remove.
+type StorageOptions struct {
+	Content []string
+	// Nodes limits the storage to some nodes; it is available on all nodes when empty
+	Nodes []string
+	// Enabled enables or disables the storage when set
+	Enabled *bool
+	// Shared marks dir and lvm storage as the same on all nodes
+	Shared bool
+	Path   string
+	// Server is the NFS, CIFS or Proxmox Backup Server host
+	Server string
+	Export string
+	Share  string
+	// Username and Password authenticate CIFS, RBD and PBS storage
+	Username   string
+	Password   string
+	Domain     string
+	SMBVersion string
+	Subdir     string
+	// MountOptions are the NFS or CIFS mount options
+	MountOptions  string
+	Preallocation string
+	PruneBackups  PruneSettings
+	VGName        string
+	Base          string
+	ThinPool      string
+	SafeRemove    bool
+	// Pool is the ZFS or Ceph pool
+	Pool       string
+	Sparse     bool
+	Blocksize  string
+	MountPoint string
+	MonHost    []string
+	KRBD       bool
+	Namespace  string
+	DataPool   string
+	Datastore  string
+	// Fingerprint is the certificate fingerprint of a Proxmox Backup Server
+	Fingerprint string
+	Port        int
+	Delete      []string
+}
+
+// params returns the API parameters of the options that are set
+func (o StorageOptions) params() url.Values {
+	params := url.Values{}
+	setParam := func(key, value string) {
+		if value != "" {
+			params.Set(key, value)
+		}
+	}
+	setFlag := func(key string, value bool) {
+		if value {
+			params.Set(key, "1")
+		}
+	}
+
+	setParam("content", strings.Join(o.Content, ","))
+	setParam("nodes", strings.Join(o.Nodes, ","))
+	if o.Enabled != nil {
+		params.Set("disable", boolParam(!*o.Enabled))
+	}
+	setFlag("shared", o.Shared)
+	setParam("path", o.Path)
+	setParam("server", o.Server)
+	setParam("export", o.Export)
+	setParam("share", o.Share)
+	setParam("username", o.Username)
+	setParam("password", o.Password)
+	setParam("domain", o.Domain)
+	setParam("smbversion", o.SMBVersion)
+	setParam("subdir", o.Subdir)
+	setParam("options", o.MountOptions)
+	setParam("preallocation", o.Preallocation)
+	setParam("prune-backups", string(o.PruneBackups))
+	setParam("vgname", o.VGName)
+	setParam("base", o.Base)
+	setParam("thinpool", o.ThinPool)
+	setFlag("saferemove", o.SafeRemove)
+	setParam("pool", o.Pool)
+	setFlag("sparse", o.Sparse)
+	setParam("blocksize", o.Blocksize)
+	setParam("mountpoint", o.MountPoint)
+	setParam("monhost", strings.Join(o.MonHost, " "))
+	setFlag("krbd", o.KRBD)
+	setParam("namespace", o.Namespace)
+	setParam("data-pool", o.DataPool)
+	setParam("datastore", o.Datastore)
+	setParam("fingerprint", o.Fingerprint)
+	if o.Port != 0 {
+		params.Set("port", strconv.Itoa(o.Port))
+	}
+	setParam("delete", strings.Join(o.Delete, ","))
+	return params
+}
+
+// validate checks the options against the storage type. Options of other types are
+// rejected, a new storage needs the required options of its type and an existing one
+// keeps its fixed options.
+func (o StorageOptions) validate(storageType string, creating bool) error {
+	typeOptions, ok := storageTypeOptions[storageType]
+	if !ok {
+		return fmt.Errorf("invalid storage type %q: use %s", storageType, strings.Join(StorageTypes, ", "))
+	}
+
+	params := o.params()
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		keys = append(keys, key)
+	}
+	keys = append(keys, o.Delete...)
+	sort.Strings(keys)
+	for _, key := range keys {
+		if key != "delete" && !slices.Contains(storageCommonOptions, key) && !slices.Contains(typeOptions.allowed, key) {
+			return fmt.Errorf("option %s is not supported by %s storage", key, storageType)
+		}
+	}
+
+	if creating {
+		for _, key := range typeOptions.required {
+			if params.Get(key) == "" {
+				return fmt.Errorf("%s storage needs the %s option", storageType, key)
+			}
+		}
+	} else {
+		for _, key := range keys {
+			if slices.Contains(typeOptions.fixed, key) {
+				return fmt.Errorf("option %s of %s storage cannot be changed after it is created", key, storageType)
+			}
+		}
+	}
+	for _, key := range o.Delete {
+		if slices.Contains(typeOptions.required, key) {
+			return fmt.Errorf("option %s is required by %s storage and cannot be removed", key, storageType)
+		}
+	}
+
+	for _, content := range o.Content {
+		if !slices.Contains(typeOptions.content, content) {
+			return fmt.Errorf("content %s is not supported by %s storage: use %s", content, storageType, strings.Join(typeOptions.content, ", "))
+		}
+	}
+	if o.Path != "" && !strings.HasPrefix(o.Path, "/") {
+		return fmt.Errorf("invalid path %q: use an absolute path", o.Path)
+	}
+	if o.Port != 0 && (o.Port < 1 || o.Port > 65535) {
+		return fmt.Errorf("invalid port %d", o.Port)
+	}
+	return o.PruneBackups.Validate()
+}
+
+// storagePath returns the API path of a storage definition
+func storagePath(storage string) string {
+	return "storage/" + url.PathEscape(storage)
+}
+
+// GetStorage retrieves the configuration of a storage
+func (s *StorageService) GetStorage(storage string) (StorageConfig, error) {
+	config, err := Get[StorageConfig](s.Client, storagePath(storage), nil)
+	if err != nil {
+		s.Logger.Error("Error getting storage: ", err)
+		return nil, err
+	}
+
+	return config, nil
+}
+
+// CreateStorage adds a storage of storageType with the options of that type
+func (s *StorageService) CreateStorage(storage, storageType string, options StorageOptions) error {
+	if !storageIDPattern.MatchString(storage) {
+		return fmt.Errorf("invalid storage ID %q: use letters, digits, '-', '_' and '.', starting with a letter", storage)
+	}
+	if len(options.Delete) > 0 {
+		return fmt.Errorf("options cannot be removed from a new storage")
+	}
+	if err := options.validate(storageType, true); err != nil {
+		return err
+	}
+
+	params := options.params()
+	params.Set("storage", storage)
+	params.Set("type", storageType)
+
+	if _, err := Post[any](s.Client, "storage", params); err != nil {
+		s.Logger.Error("Error creating storage: ", err)
+		return err
+	}
+
+	return nil
+}
+
+// UpdateStorage changes the options of a storage that are set in options. The options are
+// checked against the type of the existing storage.
+func (s *StorageService) UpdateStorage(storage string, options StorageOptions) error {
+	params := options.params()
+	if len(params) == 0 {
+		return fmt.Errorf("no changes given for storage %s", storage)
+	}
+
+	current, err := s.GetStorage(storage)
+	if err != nil {
+		return err
+	}
+	if !slices.Contains(StorageTypes, current.Type()) {
+		return fmt.Errorf("storage %s has type %s, which cannot be updated with these options", storage, current.Type())
+	}
+	if err := options.validate(current.Type(), false); err != nil {
+		return err
+	}
+
+	if _, err := Put[any](s.Client, storagePath(storage), params); err != nil {
+		s.Logger.Error("Error updating storage: ", err)
+		return err
+	}
+
+	return nil
+}
+
+// DeleteStorage removes a storage definition. The data on the storage is kept.
+func (s *StorageService) DeleteStorage(storage string) error {
+	if _, err := Delete[any](s.Client, storagePath(storage), nil); err != nil {
+		s.Logger.Error("Error deleting storage: ", err)
+		return err
+	}
+
+	return nil
+}
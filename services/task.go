@@ -0,0 +1,245 @@
+package services
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Task represents an entry of a node's task list in Proxmox
+type Task struct {
+	UPID      string `json:"upid"`
+	Node      string `json:"node"`
+	PID       int    `json:"pid,omitempty"`
+	StartTime int64  `json:"starttime,omitempty"`
+	EndTime   int64  `json:"endtime,omitempty"`
+	Type      string `json:"type"`
+	ID        string `json:"id,omitempty"`
+	User      string `json:"user"`
+	Status    string `json:"status,omitempty"`
+}
+
+// TaskStatus represents the status of a single task
+type TaskStatus struct {
+	UPID       string `json:"upid"`
+	Node       string `json:"node"`
+	PID        int    `json:"pid,omitempty"`
+	StartTime  int64  `json:"starttime,omitempty"`
+	Type       string `json:"type"`
+	ID         string `json:"id,omitempty"`
+	User       string `json:"user"`
+	Status     string `json:"status"`
+	ExitStatus string `json:"exitstatus,omitempty"`
+}
+
+// Running reports whether the task has not finished yet
+func (s TaskStatus) Running() bool {
+	return s.Status == "running"
+}
+
+// Succeeded reports whether the task finished without errors.
+// Proxmox reports "OK", or "WARNINGS: <count>" for tasks that completed with warnings.
+func (s TaskStatus) Succeeded() bool {
+	return !s.Running() && (s.ExitStatus == "OK" || strings.HasPrefix(s.ExitStatus, "WARNINGS"))
+}
+
+// TaskLogLine represents a single line of a task log
+type TaskLogLine struct {
+	N int    `json:"n"`
+	T string `json:"t"`
+}
+
+// TaskListOptions filters the task list of a node
+type TaskListOptions struct {
+	Limit  int
+	VMID   int
+	Errors bool
+}
+
+// TaskLogPageSize is the number of log lines requested per call while following a task
+const TaskLogPageSize = 500
+
+// DefaultTaskPollInterval is how often WaitForTask checks the task status
+const DefaultTaskPollInterval = time.Second
+
+// TaskService handles task (UPID) related operations
+type TaskService struct {
+	Logger       *logrus.Logger
+	Trust        bool
+	Client       *APIClient
+	PollInterval time.Duration
+}
+
+// NewTaskService creates a new TaskService with real dependencies
+func NewTaskService(logger *logrus.Logger, trust bool) (*TaskService, error) {
+	client, err := NewAPIClient(logger, trust)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TaskService{
+		Logger:       logger,
+		Trust:        trust,
+		Client:       client,
+		PollInterval: DefaultTaskPollInterval,
+	}, nil
+}
+
+// NewTaskServiceWithDeps creates a TaskService with injected dependencies (for testing)
+func NewTaskServiceWithDeps(logger *logrus.Logger, trust bool, httpService HTTPServiceInterface, sessionService SessionServiceInterface) *TaskService {
+	return &TaskService{
+		Logger:       logger,
+		Trust:        trust,
+		Client:       NewAPIClientWithDeps(logger, httpService, sessionService),
+		PollInterval: DefaultTaskPollInterval,
+	}
+}
+
+// UPIDNode returns the node a task runs on, as encoded in its UPID.
+// A UPID has the form UPID:node:pid:pstart:starttime:type:id:user:
+func UPIDNode(upid string) (string, error) {
+	parts := strings.Split(upid, ":")
+	if len(parts) < 8 || parts[0] != "UPID" || parts[1] == "" {
+		return "", fmt.Errorf("invalid UPID %q", upid)
+	}
+	return parts[1], nil
+}
+
+// UPIDStartTime returns the time a task was started, as encoded in its UPID in hexadecimal
+// seconds. It is the server's clock, unlike the time a client sent the request.
+func UPIDStartTime(upid string) (time.Time, error) {
+	parts := strings.Split(upid, ":")
+	if len(parts) < 8 || parts[0] != "UPID" {
+		return time.Time{}, fmt.Errorf("invalid UPID %q", upid)
+	}
+	seconds, err := strconv.ParseInt(parts[4], 16, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid UPID %q: %w", upid, err)
+	}
+	return time.Unix(seconds, 0), nil
+}
+
+// ListTasks retrieves the recent tasks of a specific node
+func (t *TaskService) ListTasks(nodeName string, options TaskListOptions) ([]Task, error) {
+	query := url.Values{}
+	if options.Limit > 0 {
+		query.Set("limit", fmt.Sprintf("%d", options.Limit))
+	}
+	if options.VMID > 0 {
+		query.Set("vmid", fmt.Sprintf("%d", options.VMID))
+	}
+	if options.Errors {
+		query.Set("errors", "1")
+	}
+
+	tasks, err := Get[[]Task](t.Client, fmt.Sprintf("nodes/%s/tasks", nodeName), query)
+	if err != nil {
+		t.Logger.Error("Error listing tasks: ", err)
+		return nil, err
+	}
+
+	return tasks, nil
+}
+
+// GetTaskStatus retrieves the status of a task
+func (t *TaskService) GetTaskStatus(nodeName, upid string) (*TaskStatus, error) {
+	status, err := Get[TaskStatus](t.Client, fmt.Sprintf("nodes/%s/tasks/%s/status", nodeName, url.PathEscape(upid)), nil)
+	if err != nil {
+		t.Logger.Error("Error getting task status: ", err)
+		return nil, err
+	}
+
+	return &status, nil
+}
+
+// GetTaskLog retrieves up to limit log lines of a task, skipping the first start lines
+func (t *TaskService) GetTaskLog(nodeName, upid string, start, limit int) ([]TaskLogLine, error) {
+	query := url.Values{}
+	query.Set("start", fmt.Sprintf("%d", start))
+	query.Set("limit", fmt.Sprintf("%d", limit))
+
+	lines, err := Get[[]TaskLogLine](t.Client, fmt.Sprintf("nodes/%s/tasks/%s/log", nodeName, url.PathEscape(upid)), query)
+	if err != nil {
+		t.Logger.Error("Error getting task log: ", err)
+		return nil, err
+	}
+
+	return lines, nil
+}
+
+// StopTask stops a running task
+func (t *TaskService) StopTask(nodeName, upid string) error {
+	_, err := Delete[any](t.Client, fmt.Sprintf("nodes/%s/tasks/%s", nodeName, url.PathEscape(upid)), nil)
+	if err != nil {
+		t.Logger.Error("Error stopping task: ", err)
+		return err
+	}
+
+	return nil
+}
+
+// WaitForTask polls a task until it finishes, passing every new log line to onLog as it appears.
+// A timeout of zero waits indefinitely. An error is returned when the task fails or the timeout
+// is reached; the last known status is returned in both cases.
+func (t *TaskService) WaitForTask(nodeName, upid string, timeout time.Duration, onLog func(TaskLogLine)) (*TaskStatus, error) {
+	var deadline time.Time
+	if timeout > 0 {
+		deadline = time.Now().Add(timeout)
+	}
+
+	logged := 0
+	for {
+		status, err := t.GetTaskStatus(nodeName, upid)
+		if err != nil {
+			return nil, err
+		}
+
+		// Read the log after the status, so a finished task has its complete log printed
+		if onLog != nil {
+			logged, err = t.followLog(nodeName, upid, logged, onLog)
+			if err != nil {
+				return status, err
+			}
+		}
+
+		if !status.Running() {
+			if !status.Succeeded() {
+				return status, fmt.Errorf("task %s failed: %s", upid, status.ExitStatus)
+			}
+			return status, nil
+		}
+
+		if !deadline.IsZero() && time.Now().After(deadline) {
+			return status, fmt.Errorf("timed out after %s waiting for task %s", timeout, upid)
+		}
+
+		time.Sleep(t.PollInterval)
+	}
+}
+
+// followLog passes the log lines after the first start lines to onLog and returns the new line count
+func (t *TaskService) followLog(nodeName, upid string, start int, onLog func(TaskLogLine)) (int, error) {
+	for {
+		lines, err := t.GetTaskLog(nodeName, upid, start, TaskLogPageSize)
+		if err != nil {
+			return start, err
+		}
+
+		for _, line := range lines {
+			// Skip lines that were already passed to onLog
+			if line.N <= start {
+				continue
+			}
+			onLog(line)
+			start = line.N
+		}
+
+		if len(lines) < TaskLogPageSize {
+			return start, nil
+		}
+	}
+}
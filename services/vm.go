@@ -1,11 +1,8 @@
 package services
 
 import (
-	"encoding/json"
 	"fmt"
-	"io"
-	"net/http"
-	"net/url"
+	"strconv"
 
 	"github.com/sirupsen/logrus"
 )
@@ -26,18 +23,6 @@ type VM struct {
 	Node    string  `json:"node,omitempty"`
 }
 
-// VMConfig represents VM configuration
-type VMConfig struct {
-	Name        string `json:"name,omitempty"`
-	Memory      int    `json:"memory,omitempty"`
-	Cores       int    `json:"cores,omitempty"`
-	Sockets     int    `json:"sockets,omitempty"`
-	OSType      string `json:"ostype,omitempty"`
-	Boot        string `json:"boot,omitempty"`
-	Bootdisk    string `json:"bootdisk,omitempty"`
-	Description string `json:"description,omitempty"`
-}
-
 // VMStatus represents VM status details
 type VMStatus struct {
 	Status    string  `json:"status"`
@@ -51,136 +36,56 @@ type VMStatus struct {
 	QMPStatus string  `json:"qmpstatus,omitempty"`
 }
 
-// VMListResponse represents the API response for VM list
-type VMListResponse struct {
-	Data []VM `json:"data"`
-}
-
-// VMStatusResponse represents the API response for VM status
-type VMStatusResponse struct {
-	Data VMStatus `json:"data"`
-}
-
-// VMCreateResponse represents the API response for VM creation
-type VMCreateResponse struct {
-	Data string `json:"data"` // UPID task ID
-}
-
 // VMService handles VM-related operations
 type VMService struct {
-	Logger         *logrus.Logger
-	Trust          bool
-	HTTPService    HTTPServiceInterface
-	SessionService SessionServiceInterface
+	Logger *logrus.Logger
+	Trust  bool
+	Client *APIClient
 }
 
 // NewVMService creates a new VMService with real dependencies
 func NewVMService(logger *logrus.Logger, trust bool) (*VMService, error) {
-	sessionService, err := NewSessionService(logger)
+	client, err := NewAPIClient(logger, trust)
 	if err != nil {
 		return nil, err
 	}
 
 	return &VMService{
-		Logger:         logger,
-		Trust:          trust,
-		HTTPService:    NewHttpService(logger, trust),
-		SessionService: sessionService,
+		Logger: logger,
+		Trust:  trust,
+		Client: client,
 	}, nil
 }
 
 // NewVMServiceWithDeps creates a VMService with injected dependencies (for testing)
 func NewVMServiceWithDeps(logger *logrus.Logger, trust bool, httpService HTTPServiceInterface, sessionService SessionServiceInterface) *VMService {
 	return &VMService{
-		Logger:         logger,
-		Trust:          trust,
-		HTTPService:    httpService,
-		SessionService: sessionService,
+		Logger: logger,
+		Trust:  trust,
+		Client: NewAPIClientWithDeps(logger, httpService, sessionService),
 	}
 }
 
 // ListVMs retrieves a list of all VMs on a specific node
 func (v *VMService) ListVMs(nodeName string) ([]VM, error) {
-	sessionData, err := v.SessionService.ReadSessionFile()
-	if err != nil {
-		v.Logger.Error("Error reading session file: ", err)
-		return nil, err
-	}
-
-	uri := fmt.Sprintf("%s://%s:%d/api2/json/nodes/%s/qemu",
-		sessionData.HttpScheme, sessionData.Server, sessionData.Port, nodeName)
-
-	cookies := []*http.Cookie{
-		{
-			Name:  "PVEAuthCookie",
-			Value: url.QueryEscape(sessionData.Response.Data.Ticket),
-		},
-	}
-
-	resp, err := v.HTTPService.Get(uri, nil, cookies)
+	vms, err := Get[[]VM](v.Client, fmt.Sprintf("nodes/%s/qemu", nodeName), nil)
 	if err != nil {
 		v.Logger.Error("Error listing VMs: ", err)
 		return nil, err
 	}
-	//nolint:errcheck // Best effort close in defer
-	defer func() { _ = resp.Body.Close() }()
 
-	bodyBytes, err := io.ReadAll(resp.Body)
-	if err != nil {
-		v.Logger.Error("Error reading response body: ", err)
-		return nil, err
-	}
-
-	var result VMListResponse
-	err = json.Unmarshal(bodyBytes, &result)
-	if err != nil {
-		v.Logger.Error("Error parsing response JSON: ", err)
-		return nil, err
-	}
-
-	return result.Data, nil
+	return vms, nil
 }
 
 // GetVMStatus retrieves the current status of a specific VM
 func (v *VMService) GetVMStatus(nodeName string, vmid int) (*VMStatus, error) {
-	sessionData, err := v.SessionService.ReadSessionFile()
-	if err != nil {
-		v.Logger.Error("Error reading session file: ", err)
-		return nil, err
-	}
-
-	uri := fmt.Sprintf("%s://%s:%d/api2/json/nodes/%s/qemu/%d/status/current",
-		sessionData.HttpScheme, sessionData.Server, sessionData.Port, nodeName, vmid)
-
-	cookies := []*http.Cookie{
-		{
-			Name:  "PVEAuthCookie",
-			Value: url.QueryEscape(sessionData.Response.Data.Ticket),
-		},
-	}
-
-	resp, err := v.HTTPService.Get(uri, nil, cookies)
+	status, err := Get[VMStatus](v.Client, fmt.Sprintf("nodes/%s/qemu/%d/status/current", nodeName, vmid), nil)
 	if err != nil {
 		v.Logger.Error("Error getting VM status: ", err)
 		return nil, err
 	}
-	//nolint:errcheck // Best effort close in defer
-	defer func() { _ = resp.Body.Close() }()
-
-	bodyBytes, err := io.ReadAll(resp.Body)
-	if err != nil {
-		v.Logger.Error("Error reading response body: ", err)
-		return nil, err
-	}
-
-	var result VMStatusResponse
-	err = json.Unmarshal(bodyBytes, &result)
-	if err != nil {
-		v.Logger.Error("Error parsing response JSON: ", err)
-		return nil, err
-	}
 
-	return &result.Data, nil
+	return &status, nil
 }
 
 // StartVM starts a VM
@@ -218,79 +123,83 @@ func (v *VMService) ResumeVM(nodeName string, vmid int) (string, error) {
 	return v.vmStatusAction(nodeName, vmid, "resume")
 }
 
-// vmStatusAction performs a status action on a VM (start, stop, etc.)
+// vmStatusAction performs a status action on a VM (start, stop, etc.) and returns the task UPID
 func (v *VMService) vmStatusAction(nodeName string, vmid int, action string) (string, error) {
-	sessionData, err := v.SessionService.ReadSessionFile()
+	upid, err := Post[string](v.Client, fmt.Sprintf("nodes/%s/qemu/%d/status/%s", nodeName, vmid, action), nil)
 	if err != nil {
-		v.Logger.Error("Error reading session file: ", err)
+		v.Logger.Error(fmt.Sprintf("Error performing %s on VM: ", action), err)
 		return "", err
 	}
 
-	uri := fmt.Sprintf("%s://%s:%d/api2/json/nodes/%s/qemu/%d/status/%s",
-		sessionData.HttpScheme, sessionData.Server, sessionData.Port, nodeName, vmid, action)
-
-	headers := map[string]string{
-		"Content-Type":        "application/x-www-form-urlencoded; charset=UTF-8",
-		"CSRFPreventionToken": sessionData.Response.Data.CSRFPreventionToken,
-	}
-
-	cookies := []*http.Cookie{
-		{
-			Name:  "PVEAuthCookie",
-			Value: url.QueryEscape(sessionData.Response.Data.Ticket),
-		},
-	}
+	return upid, nil
+}
 
-	body, err := v.HTTPService.Post(uri, "", headers, cookies)
+// DeleteVM deletes a VM and returns the task UPID
+func (v *VMService) DeleteVM(nodeName string, vmid int) (string, error) {
+	upid, err := Delete[string](v.Client, fmt.Sprintf("nodes/%s/qemu/%d", nodeName, vmid), nil)
 	if err != nil {
-		v.Logger.Error(fmt.Sprintf("Error performing %s on VM: ", action), err)
+		v.Logger.Error("Error deleting VM: ", err)
 		return "", err
 	}
 
-	var result VMCreateResponse
-	err = json.Unmarshal([]byte(body), &result)
+	return upid, nil
+}
+
+// NextVMID returns the next free VM ID of the cluster
+func (v *VMService) NextVMID() (int, error) {
+	vmid, err := nextVMID(v.Client)
 	if err != nil {
-		v.Logger.Error("Error parsing response JSON: ", err)
-		return "", err
+		v.Logger.Error("Error getting next VM ID: ", err)
+		return 0, err
 	}
 
-	return result.Data, nil
+	return vmid, nil
 }
 
-// DeleteVM deletes a VM
-func (v *VMService) DeleteVM(nodeName string, vmid int) (string, error) {
-	sessionData, err := v.SessionService.ReadSessionFile()
-	if err != nil {
-		v.Logger.Error("Error reading session file: ", err)
-		return "", err
+// CreateVM creates a VM on a node from config and returns its VM ID and the task UPID.
+// A vmid of zero allocates the next free ID of the cluster.
+func (v *VMService) CreateVM(nodeName string, vmid int, config VMConfig) (int, string, error) {
+	if err := config.Validate(); err != nil {
+		return 0, "", err
 	}
 
-	uri := fmt.Sprintf("%s://%s:%d/api2/json/nodes/%s/qemu/%d",
-		sessionData.HttpScheme, sessionData.Server, sessionData.Port, nodeName, vmid)
-
-	headers := map[string]string{
-		"CSRFPreventionToken": sessionData.Response.Data.CSRFPreventionToken,
+	if vmid == 0 {
+		var err error
+		if vmid, err = v.NextVMID(); err != nil {
+			return 0, "", err
+		}
 	}
 
-	cookies := []*http.Cookie{
-		{
-			Name:  "PVEAuthCookie",
-			Value: url.QueryEscape(sessionData.Response.Data.Ticket),
-		},
+	params := config.params()
+	params.Set("vmid", strconv.Itoa(vmid))
+
+	upid, err := Post[string](v.Client, fmt.Sprintf("nodes/%s/qemu", nodeName), params)
+	if err != nil {
+		v.Logger.Error("Error creating VM: ", err)
+		return 0, "", err
 	}
 
-	body, err := v.HTTPService.Delete(uri, headers, cookies)
+	return vmid, upid, nil
+}
+
+// Clone clones a VM or template and returns the VM ID of the clone and the task UPID
+func (v *VMService) Clone(nodeName string, vmid int, options CloneOptions) (int, string, error) {
+	newID, upid, err := clone(v.Client, guestPath(nodeName, GuestTypeQEMU, vmid), "name", options)
 	if err != nil {
-		v.Logger.Error("Error deleting VM: ", err)
-		return "", err
+		v.Logger.Error("Error cloning VM: ", err)
+		return 0, "", err
 	}
 
-	var result VMCreateResponse
-	err = json.Unmarshal([]byte(body), &result)
+	return newID, upid, nil
+}
+
+// ConvertToTemplate converts a stopped VM into a template and returns the task UPID
+func (v *VMService) ConvertToTemplate(nodeName string, vmid int) (string, error) {
+	upid, err := Post[string](v.Client, fmt.Sprintf("nodes/%s/qemu/%d/template", nodeName, vmid), nil)
 	if err != nil {
-		v.Logger.Error("Error parsing response JSON: ", err)
+		v.Logger.Error("Error converting VM to template: ", err)
 		return "", err
 	}
 
-	return result.Data, nil
+	return upid, nil
 }
@@ -0,0 +1,288 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// VMConfig represents VM configuration.
+// Proxmox returns the configuration as flat key/value pairs; disks, network interfaces and
+// cloud-init settings are grouped here and keys without a dedicated field are kept in Other.
+type VMConfig struct {
+	Name        string `json:"name,omitempty"`
+	Memory      int    `json:"memory,omitempty"`
+	Cores       int    `json:"cores,omitempty"`
+	Sockets     int    `json:"sockets,omitempty"`
+	OSType      string `json:"ostype,omitempty"`
+	Boot        string `json:"boot,omitempty"`
+	Bootdisk    string `json:"bootdisk,omitempty"`
+	Description string `json:"description,omitempty"`
+	// CDROM is the ISO volume (e.g. local:iso/debian.iso) attached as ide2 when creating a VM,
+	// or "none" for an empty drive. Existing CD-ROM drives are listed in Disks.
+	CDROM string `json:"-"`
+	// Disks maps disk slots to volume specs, e.g. scsi0 -> local-lvm:32
+	Disks map[string]string `json:"disks,omitempty"`
+	// Networks maps network slots to device specs, e.g. net0 -> virtio,bridge=vmbr0
+	Networks  map[string]string `json:"networks,omitempty"`
+	CloudInit *VMCloudInit      `json:"cloudInit,omitempty"`
+	// Other holds the remaining configuration keys, such as cpu, agent or unused disks
+	Other map[string]string `json:"other,omitempty"`
+	// Digest identifies the configuration version, see ConfigUpdate.Digest
+	Digest string `json:"digest,omitempty"`
+	// Pending lists changes that take effect after the next restart
+	Pending []PendingChange `json:"pending,omitempty"`
+}
+
+// VMCloudInit represents the cloud-init settings of a VM
+type VMCloudInit struct {
+	Type         string `json:"citype,omitempty"`
+	User         string `json:"ciuser,omitempty"`
+	Password     string `json:"cipassword,omitempty"`
+	Custom       string `json:"cicustom,omitempty"`
+	SSHKeys      string `json:"sshkeys,omitempty"`
+	Nameserver   string `json:"nameserver,omitempty"`
+	Searchdomain string `json:"searchdomain,omitempty"`
+	// IPConfig maps network slots to IP settings, e.g. ipconfig0 -> ip=dhcp
+	IPConfig map[string]string `json:"ipconfig,omitempty"`
+}
+
+var (
+	diskSlotPattern     = regexp.MustCompile(`^(scsi|sata|ide|virtio|efidisk|tpmstate)[0-9]+$`)
+	networkSlotPattern  = regexp.MustCompile(`^net[0-9]+$`)
+	ipConfigSlotPattern = regexp.MustCompile(`^ipconfig[0-9]+$`)
+)
+
+// cdromSlot is the slot Proxmox uses for the CD-ROM drive of new VMs
+const cdromSlot = "ide2"
+
+// UnmarshalJSON decodes the flat configuration returned by Proxmox
+func (c *VMConfig) UnmarshalJSON(data []byte) error {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	*c = VMConfig{}
+	for key, value := range raw {
+		c.setKey(key, rawString(value))
+	}
+	return nil
+}
+
+// setKey stores a configuration value in the matching field
+func (c *VMConfig) setKey(key, value string) {
+	setInt := func(field *int) {
+		// Newer Proxmox versions may return memory as a property string such as current=2048
+		number, err := strconv.Atoi(strings.TrimPrefix(value, "current="))
+		if err != nil {
+			c.setOther(key, value)
+			return
+		}
+		*field = number
+	}
+
+	switch {
+	case key == "name":
+		c.Name = value
+	case key == "memory":
+		setInt(&c.Memory)
+	case key == "cores":
+		setInt(&c.Cores)
+	case key == "sockets":
+		setInt(&c.Sockets)
+	case key == "ostype":
+		c.OSType = value
+	case key == "boot":
+		c.Boot = value
+	case key == "bootdisk":
+		c.Bootdisk = value
+	case key == "description":
+		c.Description = value
+	case key == "digest":
+		c.Digest = value
+	case diskSlotPattern.MatchString(key):
+		if c.Disks == nil {
+			c.Disks = map[string]string{}
+		}
+		c.Disks[key] = value
+	case networkSlotPattern.MatchString(key):
+		if c.Networks == nil {
+			c.Networks = map[string]string{}
+		}
+		c.Networks[key] = value
+	case isCloudInitKey(key):
+		if c.CloudInit == nil {
+			c.CloudInit = &VMCloudInit{}
+		}
+		c.CloudInit.setKey(key, value)
+	default:
+		c.setOther(key, value)
+	}
+}
+
+func (c *VMConfig) setOther(key, value string) {
+	if c.Other == nil {
+		c.Other = map[string]string{}
+	}
+	c.Other[key] = value
+}
+
+// isCloudInitKey reports whether key is a cloud-init setting
+func isCloudInitKey(key string) bool {
+	switch key {
+	case "citype", "ciuser", "cipassword", "cicustom", "sshkeys", "nameserver", "searchdomain":
+		return true
+	}
+	return ipConfigSlotPattern.MatchString(key)
+}
+
+// setKey stores a cloud-init value in the matching field
+func (c *VMCloudInit) setKey(key, value string) {
+	switch key {
+	case "citype":
+		c.Type = value
+	case "ciuser":
+		c.User = value
+	case "cipassword":
+		c.Password = value
+	case "cicustom":
+		c.Custom = value
+	case "sshkeys":
+		// Proxmox stores the keys URL encoded
+		if decoded, err := url.PathUnescape(value); err == nil {
+			value = decoded
+		}
+		c.SSHKeys = value
+	case "nameserver":
+		c.Nameserver = value
+	case "searchdomain":
+		c.Searchdomain = value
+	default:
+		if c.IPConfig == nil {
+			c.IPConfig = map[string]string{}
+		}
+		c.IPConfig[key] = value
+	}
+}
+
+// Validate checks the disk, network and cloud-init slot names of the configuration
+func (c VMConfig) Validate() error {
+	for slot := range c.Disks {
+		if !diskSlotPattern.MatchString(slot) {
+			return fmt.Errorf("invalid disk slot %q: use scsiN, sataN, ideN, virtioN, efidiskN or tpmstateN", slot)
+		}
+		if slot == cdromSlot && c.CDROM != "" {
+			return fmt.Errorf("disk slot %s is used by the CD-ROM drive", cdromSlot)
+		}
+	}
+	for slot := range c.Networks {
+		if !networkSlotPattern.MatchString(slot) {
+			return fmt.Errorf("invalid network slot %q: use netN", slot)
+		}
+	}
+	if c.CloudInit != nil {
+		for slot := range c.CloudInit.IPConfig {
+			if !ipConfigSlotPattern.MatchString(slot) {
+				return fmt.Errorf("invalid IP config slot %q: use ipconfigN", slot)
+			}
+		}
+	}
+	return nil
+}
+
+// params returns the configuration as API parameters. The boot order may be given as a
+// plain device list such as "scsi0;ide2", which is expanded to "order=scsi0;ide2".
+func (c VMConfig) params() url.Values {
+	params := url.Values{}
+	setParam := func(key, value string) {
+		if value != "" {
+			params.Set(key, value)
+		}
+	}
+	setInt := func(key string, value int) {
+		if value > 0 {
+			params.Set(key, strconv.Itoa(value))
+		}
+	}
+	setAll := func(values map[string]string) {
+		for key, value := range values {
+			params.Set(key, value)
+		}
+	}
+
+	setAll(c.Other)
+	setParam("name", c.Name)
+	setInt("memory", c.Memory)
+	setInt("cores", c.Cores)
+	setInt("sockets", c.Sockets)
+	setParam("ostype", c.OSType)
+	if c.Boot != "" && !strings.Contains(c.Boot, "=") {
+		params.Set("boot", "order="+c.Boot)
+	} else {
+		setParam("boot", c.Boot)
+	}
+	setParam("bootdisk", c.Bootdisk)
+	setParam("description", c.Description)
+	if c.CDROM != "" {
+		params.Set(cdromSlot, c.CDROM+",media=cdrom")
+	}
+	setAll(c.Disks)
+	setAll(c.Networks)
+
+	if ci := c.CloudInit; ci != nil {
+		setParam("citype", ci.Type)
+		setParam("ciuser", ci.User)
+		setParam("cipassword", ci.Password)
+		setParam("cicustom", ci.Custom)
+		if ci.SSHKeys != "" {
+			params.Set("sshkeys", encodeSSHKeys(ci.SSHKeys))
+		}
+		setParam("nameserver", ci.Nameserver)
+		setParam("searchdomain", ci.Searchdomain)
+		setAll(ci.IPConfig)
+	}
+
+	return params
+}
+
+// encodeSSHKeys URL encodes SSH public keys the way Proxmox expects them
+func encodeSSHKeys(keys string) string {
+	return strings.ReplaceAll(url.QueryEscape(keys), "+", "%20")
+}
+
+// GetConfig retrieves the configuration of a VM, including pending values
+func (v *VMService) GetConfig(nodeName string, vmid int) (*VMConfig, error) {
+	config, err := Get[VMConfig](v.Client, guestPath(nodeName, GuestTypeQEMU, vmid)+"/config", nil)
+	if err != nil {
+		v.Logger.Error("Error getting VM config: ", err)
+		return nil, err
+	}
+
+	return &config, nil
+}
+
+// GetPendingChanges retrieves the configuration changes of a VM that await a restart
+func (v *VMService) GetPendingChanges(nodeName string, vmid int) ([]PendingChange, error) {
+	changes, err := getPendingChanges(v.Client, guestPath(nodeName, GuestTypeQEMU, vmid))
+	if err != nil {
+		v.Logger.Error("Error getting pending VM changes: ", err)
+		return nil, err
+	}
+
+	return changes, nil
+}
+
+// UpdateConfig sets and removes configuration keys of a VM.
+// SSH keys given in the sshkeys key are URL encoded as Proxmox requires.
+func (v *VMService) UpdateConfig(nodeName string, vmid int, update ConfigUpdate) error {
+	if err := updateConfig(v.Client, guestPath(nodeName, GuestTypeQEMU, vmid), update); err != nil {
+		v.Logger.Error("Error updating VM config: ", err)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,117 @@
+package services
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+)
+
+// MigrationPreflight is the migration check Proxmox runs for a VM and target node.
+// It lists the local disks and resources that can prevent a migration.
+type MigrationPreflight struct {
+	Running         int                            `json:"running"`
+	AllowedNodes    []string                       `json:"allowed_nodes,omitempty"`
+	NotAllowedNodes map[string]MigrationNodeReason `json:"not_allowed_nodes,omitempty"`
+	LocalDisks      []MigrationLocalDisk           `json:"local_disks,omitempty"`
+	LocalResources  []string                       `json:"local_resources,omitempty"`
+}
+
+// MigrationNodeReason explains why a node cannot be a migration target
+type MigrationNodeReason struct {
+	UnavailableStorages []string `json:"unavailable_storages,omitempty"`
+}
+
+// MigrationLocalDisk is a disk on node-local storage, which must be copied during migration
+type MigrationLocalDisk struct {
+	VolID     string `json:"volid"`
+	DriveName string `json:"drivename,omitempty"`
+	Size      int64  `json:"size,omitempty"`
+	IsUnused  int    `json:"is_unused,omitempty"`
+}
+
+// MigrateOptions describes how a VM is migrated
+type MigrateOptions struct {
+	Target string
+	// Online migrates a running VM without stopping it
+	Online bool
+	// WithLocalDisks copies disks on node-local storage to the target
+	WithLocalDisks bool
+	// TargetStorage maps the local disks to a storage on the target
+	TargetStorage string
+}
+
+// Blockers returns the reasons the preflight gives for a migration with options to fail
+func (p MigrationPreflight) Blockers(options MigrateOptions) []string {
+	blockers := []string{}
+
+	if p.Running == 1 && !options.Online {
+		blockers = append(blockers, "the VM is running; use online migration or stop it first")
+	}
+
+	for _, resource := range p.LocalResources {
+		blockers = append(blockers, fmt.Sprintf("local resource %s cannot be migrated", resource))
+	}
+
+	if !options.WithLocalDisks {
+		for _, disk := range p.LocalDisks {
+			blockers = append(blockers, fmt.Sprintf("local disk %s needs to be copied with local disks", disk.VolID))
+		}
+	}
+
+	// A target storage overrides the storages the preflight reports as missing on the target
+	if reason, ok := p.NotAllowedNodes[options.Target]; ok && options.TargetStorage == "" {
+		storages := append([]string{}, reason.UnavailableStorages...)
+		sort.Strings(storages)
+		for _, storage := range storages {
+			blockers = append(blockers, fmt.Sprintf("storage %s is not available on %s", storage, options.Target))
+		}
+	}
+
+	return blockers
+}
+
+// MigrationPreflight checks whether a VM can be migrated to target
+func (v *VMService) MigrationPreflight(nodeName string, vmid int, target string) (*MigrationPreflight, error) {
+	query := url.Values{}
+	if target != "" {
+		query.Set("target", target)
+	}
+
+	preflight, err := Get[MigrationPreflight](v.Client, fmt.Sprintf("nodes/%s/qemu/%d/migrate", nodeName, vmid), query)
+	if err != nil {
+		v.Logger.Error("Error checking VM migration: ", err)
+		return nil, err
+	}
+
+	return &preflight, nil
+}
+
+// Migrate migrates a VM to another node and returns the task UPID
+func (v *VMService) Migrate(nodeName string, vmid int, options MigrateOptions) (string, error) {
+	if options.Target == "" {
+		return "", fmt.Errorf("a target node is required")
+	}
+	if options.Target == nodeName {
+		return "", fmt.Errorf("VM %d is already on node %s", vmid, nodeName)
+	}
+
+	params := url.Values{}
+	params.Set("target", options.Target)
+	if options.Online {
+		params.Set("online", "1")
+	}
+	if options.WithLocalDisks {
+		params.Set("with-local-disks", "1")
+	}
+	if options.TargetStorage != "" {
+		params.Set("targetstorage", options.TargetStorage)
+	}
+
+	upid, err := Post[string](v.Client, fmt.Sprintf("nodes/%s/qemu/%d/migrate", nodeName, vmid), params)
+	if err != nil {
+		v.Logger.Error("Error migrating VM: ", err)
+		return "", err
+	}
+
+	return upid, nil
+}
@@ -0,0 +1,48 @@
+package services
+
+// ListSnapshots retrieves the snapshots of a VM, including the current state entry
+func (v *VMService) ListSnapshots(nodeName string, vmid int) ([]Snapshot, error) {
+	snapshots, err := listSnapshots(v.Client, guestPath(nodeName, GuestTypeQEMU, vmid))
+	if err != nil {
+		v.Logger.Error("Error listing snapshots: ", err)
+		return nil, err
+	}
+
+	return snapshots, nil
+}
+
+// CreateSnapshot creates a snapshot of a VM and returns the task UPID.
+// With vmState the RAM of the running VM is saved as well.
+func (v *VMService) CreateSnapshot(nodeName string, vmid int, name, description string, vmState bool) (string, error) {
+	upid, err := createSnapshot(v.Client, guestPath(nodeName, GuestTypeQEMU, vmid), name, description, vmState)
+	if err != nil {
+		v.Logger.Error("Error creating snapshot: ", err)
+		return "", err
+	}
+
+	return upid, nil
+}
+
+// RollbackSnapshot rolls a VM back to a snapshot and returns the task UPID.
+// With start the VM is started after the rollback if the snapshot has no RAM state.
+func (v *VMService) RollbackSnapshot(nodeName string, vmid int, name string, start bool) (string, error) {
+	upid, err := rollbackSnapshot(v.Client, guestPath(nodeName, GuestTypeQEMU, vmid), name, start)
+	if err != nil {
+		v.Logger.Error("Error rolling back snapshot: ", err)
+		return "", err
+	}
+
+	return upid, nil
+}
+
+// DeleteSnapshot deletes a snapshot of a VM and returns the task UPID.
+// With force the snapshot is removed from the configuration even if removing its disk data fails.
+func (v *VMService) DeleteSnapshot(nodeName string, vmid int, name string, force bool) (string, error) {
+	upid, err := deleteSnapshot(v.Client, guestPath(nodeName, GuestTypeQEMU, vmid), name, force)
+	if err != nil {
+		v.Logger.Error("Error deleting snapshot: ", err)
+		return "", err
+	}
+
+	return upid, nil
+}
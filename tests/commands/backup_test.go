@@ -0,0 +1,61 @@
+package commands_test
+
+import (
+	"testing"
+
+	"proxmox-cli/commands"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackupCommand(t *testing.T) {
+	cmd := commands.BackupCommand()
+	assert.Equal(t, "backup", cmd.Use)
+
+	subcommandNames := []string{}
+	for _, subcmd := range cmd.Commands() {
+		subcommandNames = append(subcommandNames, subcmd.Name())
+		if subcmd.Name() == "job" {
+			continue
+		}
+		assert.NotNil(t, subcmd.Flags().Lookup("node"), subcmd.Name())
+	}
+	assert.ElementsMatch(t, []string{"create", "list", "restore", "delete", "job"}, subcommandNames)
+}
+
+func TestBackupCommandFlags(t *testing.T) {
+	create := commands.CreateBackupCommand()
+	for _, flag := range []string{"vmid", "mode", "storage", "compress", "notes", "protected", "wait"} {
+		assert.NotNil(t, create.Flags().Lookup(flag), flag)
+	}
+	// The node of the guest is looked up when omitted
+	assert.NotContains(t, create.Flags().Lookup("node").Annotations, cobra.BashCompOneRequiredFlag)
+
+	restore := commands.RestoreBackupCommand()
+	for _, flag := range []string{"newid", "storage", "force", "unique", "start", "wait"} {
+		assert.NotNil(t, restore.Flags().Lookup(flag), flag)
+	}
+	assert.Error(t, restore.Args(restore, []string{}))
+	assert.NoError(t, restore.Args(restore, []string{"local:backup/vzdump-qemu-100.vma.zst"}))
+}
+
+func TestBackupJobCommand(t *testing.T) {
+	cmd := commands.BackupJobCommand()
+
+	subcommandNames := []string{}
+	for _, subcmd := range cmd.Commands() {
+		subcommandNames = append(subcommandNames, subcmd.Name())
+	}
+	assert.ElementsMatch(t, []string{"list", "show", "create", "update", "delete", "run-now"}, subcommandNames)
+
+	create := commands.CreateBackupJobCommand()
+	update := commands.UpdateBackupJobCommand()
+	for _, flag := range []string{"schedule", "vmid", "pool", "all", "exclude", "node", "storage", "mode", "compress", "prune-backups", "notes", "comment", "enabled"} {
+		assert.NotNil(t, create.Flags().Lookup(flag), flag)
+		assert.NotNil(t, update.Flags().Lookup(flag), flag)
+	}
+	assert.Contains(t, create.Flags().Lookup("schedule").Annotations, cobra.BashCompOneRequiredFlag)
+	assert.NotContains(t, update.Flags().Lookup("schedule").Annotations, cobra.BashCompOneRequiredFlag)
+	assert.NotNil(t, commands.RunBackupJobCommand().Flags().Lookup("wait"))
+}
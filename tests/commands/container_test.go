@@ -0,0 +1,56 @@
+package commands_test
+
+import (
+	"testing"
+
+	"proxmox-cli/commands"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContainerCommand(t *testing.T) {
+	cmd := commands.ContainerCommand()
+	assert.Equal(t, "ct", cmd.Use)
+
+	subcommandNames := []string{}
+	for _, subcmd := range cmd.Commands() {
+		subcommandNames = append(subcommandNames, subcmd.Name())
+		// These subcommands do not start tasks
+		switch subcmd.Name() {
+		case "list", "status", "config", "snapshot", "template":
+			continue
+		}
+		assert.NotNil(t, subcmd.Flags().Lookup("wait"), subcmd.Name())
+		assert.NotNil(t, subcmd.Flags().Lookup("vmid"), subcmd.Name())
+	}
+	assert.ElementsMatch(t, []string{
+		"list", "status", "create", "config", "clone", "template", "snapshot", "migrate",
+		"start", "stop", "shutdown", "reboot", "suspend", "resume", "delete",
+	}, subcommandNames)
+}
+
+func TestCreateContainerCommandFlags(t *testing.T) {
+	cmd := commands.CreateContainerCommand()
+
+	for _, flag := range []string{"node", "vmid", "ostemplate", "hostname", "memory", "swap", "cores", "rootfs", "mount", "net", "unprivileged", "wait",
+		"password-stdin", "password-file", "ssh-public-keys"} {
+		assert.NotNil(t, cmd.Flags().Lookup(flag), flag)
+	}
+	// The root password must not be passed on the command line
+	assert.Nil(t, cmd.Flags().Lookup("password"))
+}
+
+func TestContainerSharedCommands(t *testing.T) {
+	clone := commands.CloneContainerCommand()
+	assert.NotNil(t, clone.Flags().Lookup("hostname"))
+	assert.Nil(t, clone.Flags().Lookup("name"))
+
+	for _, subcmd := range commands.ContainerSnapshotCommand().Commands() {
+		// Container snapshots cannot include RAM
+		assert.Nil(t, subcmd.Flags().Lookup("vmstate"), subcmd.Name())
+	}
+
+	assert.NotNil(t, commands.SetContainerConfigCommand().Flags().Lookup("digest"))
+	assert.NotNil(t, commands.ShowContainerConfigCommand().Flags().Lookup("pending"))
+	assert.NotNil(t, commands.MigrateContainerCommand().Flags().Lookup("restart"))
+}
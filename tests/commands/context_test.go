@@ -0,0 +1,39 @@
+package commands_test
+
+import (
+	"testing"
+
+	"proxmox-cli/commands"
+	"proxmox-cli/config"
+	"proxmox-cli/services"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContextCommand(t *testing.T) {
+	cmd := commands.ContextCommand()
+
+	assert.Equal(t, "context", cmd.Use)
+
+	subcommandNames := []string{}
+	for _, subcmd := range cmd.Commands() {
+		subcommandNames = append(subcommandNames, subcmd.Name())
+	}
+
+	assert.ElementsMatch(t, []string{"list", "use", "rename", "delete"}, subcommandNames)
+}
+
+func TestApplyContext_MissingContext(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	config.Context = "typo"
+	defer func() {
+		config.Context = ""
+		services.SelectedContext = ""
+	}()
+
+	err := commands.ApplyContext(commands.ContextCommand())
+	assert.EqualError(t, err, `context "typo" does not exist`)
+
+	// Login creates the context it selects
+	assert.NoError(t, commands.ApplyContext(commands.LoginCommand()))
+}
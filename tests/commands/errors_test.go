@@ -0,0 +1,41 @@
+package commands_test
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"os/exec"
+	"testing"
+
+	"proxmox-cli/commands"
+	"proxmox-cli/config"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestFailedCommandKeepsStdoutClean runs a failing command in a child process, as
+// exitWithError exits, and checks that the error goes to stderr only, so scripts reading
+// JSON from stdout get no error text mixed in
+func TestFailedCommandKeepsStdoutClean(t *testing.T) {
+	if os.Getenv("PROXMOX_CLI_TEST_CHILD") == "1" {
+		config.Output = "json"
+		cmd := commands.TaskCommand()
+		cmd.SetArgs([]string{"status", "not-a-upid"})
+		_ = cmd.Execute()
+		return
+	}
+
+	child := exec.Command(os.Args[0], "-test.run=^TestFailedCommandKeepsStdoutClean$")
+	child.Env = append(os.Environ(), "PROXMOX_CLI_TEST_CHILD=1")
+	var stdout, stderr bytes.Buffer
+	child.Stdout = &stdout
+	child.Stderr = &stderr
+	err := child.Run()
+
+	var exitErr *exec.ExitError
+	if assert.True(t, errors.As(err, &exitErr), "expected the command to exit non-zero") {
+		assert.Equal(t, 1, exitErr.ExitCode())
+	}
+	assert.Empty(t, stdout.String())
+	assert.Contains(t, stderr.String(), `Error: Invalid task ID: invalid UPID "not-a-upid"`)
+}
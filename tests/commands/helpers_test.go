@@ -0,0 +1,32 @@
+package commands_test
+
+import (
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+
+	"proxmox-cli/config"
+	"proxmox-cli/services"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// useTestServer logs a temporary home directory in to server with an API token, so commands
+// run against it
+func useTestServer(t *testing.T, server *httptest.Server) {
+	t.Setenv("HOME", t.TempDir())
+	serverURL, err := url.Parse(server.URL)
+	assert.NoError(t, err)
+	port, err := strconv.Atoi(serverURL.Port())
+	assert.NoError(t, err)
+
+	sessionService, err := services.NewSessionService(config.Logger)
+	assert.NoError(t, err)
+	assert.NoError(t, sessionService.WriteSessionFile(services.SessionData{
+		Server: serverURL.Hostname(), Port: port, HttpScheme: "https", TokenID: "root@pam!ci", TokenSecret: "secret",
+	}))
+
+	config.Trust = true
+	t.Cleanup(func() { config.Trust = false })
+}
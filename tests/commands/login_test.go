@@ -1,9 +1,15 @@
 package commands_test
 
 import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"proxmox-cli/commands"
+	"proxmox-cli/services"
 )
 
 func TestLoginCommand(t *testing.T) {
@@ -38,7 +44,7 @@ func TestValidateLoginCommand(t *testing.T) {
 
 func TestLoginCommandFlags(t *testing.T) {
 	cmd := commands.LoginCommand()
-	flags := []string{"server", "username", "port", "httpScheme", "show-log"}
+	flags := []string{"server", "username", "port", "httpScheme", "show-log", "realm", "token-id", "token-secret"}
 
 	for _, flag := range flags {
 		if cmd.Flags().Lookup(flag) == nil {
@@ -57,3 +63,154 @@ func TestValidateLoginCommandFlags(t *testing.T) {
 		}
 	}
 }
+
+func TestRealmsCommand(t *testing.T) {
+	cmd := commands.RealmsCommand()
+	if cmd.Use != "realms" {
+		t.Errorf("Expected command use to be 'realms', got '%s'", cmd.Use)
+	}
+
+	for _, flag := range []string{"server", "port", "httpScheme"} {
+		if cmd.Flags().Lookup(flag) == nil {
+			t.Errorf("Expected flag '%s' to be defined", flag)
+		}
+	}
+}
+
+func TestLoginCommandPasswordFlags(t *testing.T) {
+	cmd := commands.LoginCommand()
+	for _, flag := range []string{"password-stdin", "password-file", "secret-store"} {
+		if cmd.Flags().Lookup(flag) == nil {
+			t.Errorf("Expected flag '%s' to be defined", flag)
+		}
+	}
+}
+
+func TestReadPassword(t *testing.T) {
+	passwordFile := filepath.Join(t.TempDir(), "password")
+	if err := os.WriteFile(passwordFile, []byte("from-file\r\nignored\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PROXMOX_PASSWORD", "from-env")
+
+	tests := []struct {
+		name    string
+		source  commands.PasswordSource
+		stdin   string
+		want    string
+		wantErr bool
+	}{
+		{name: "stdin", source: commands.PasswordSource{Stdin: true}, stdin: "from-stdin\n", want: "from-stdin"},
+		{name: "stdin without newline", source: commands.PasswordSource{Stdin: true}, stdin: "from-stdin", want: "from-stdin"},
+		{name: "file", source: commands.PasswordSource{File: passwordFile}, want: "from-file"},
+		{name: "environment", want: "from-env"},
+		{name: "empty stdin", source: commands.PasswordSource{Stdin: true}, wantErr: true},
+		{name: "missing file", source: commands.PasswordSource{File: passwordFile + ".missing"}, wantErr: true},
+		{name: "stdin and file", source: commands.PasswordSource{Stdin: true, File: passwordFile}, stdin: "x\n", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := commands.ReadPassword(tt.source, strings.NewReader(tt.stdin))
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Expected an error, got password '%s'", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ReadPassword failed: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Expected password '%s', got '%s'", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestTFAPrompt(t *testing.T) {
+	challenge := services.TFAChallenge{TOTP: true, Recovery: true}
+	noTTY := func() (io.ReadCloser, error) {
+		return nil, errors.New("no such device or address")
+	}
+	tty := func(input string) func() (io.ReadCloser, error) {
+		return func() (io.ReadCloser, error) {
+			return io.NopCloser(strings.NewReader(input)), nil
+		}
+	}
+
+	tests := []struct {
+		name    string
+		source  commands.PasswordSource
+		stdin   string
+		openTTY func() (io.ReadCloser, error)
+		want    string
+		wantErr bool
+	}{
+		{name: "stdin", stdin: "123456\n", openTTY: noTTY, want: "totp:123456"},
+		{name: "prefixed code", stdin: "recovery:abcd-1234\n", openTTY: noTTY, want: "recovery:abcd-1234"},
+		{name: "password file reads stdin", source: commands.PasswordSource{File: "password"}, stdin: "123456\n",
+			openTTY: noTTY, want: "totp:123456"},
+		{name: "password stdin reads the terminal", source: commands.PasswordSource{Stdin: true}, stdin: "",
+			openTTY: tty("654321\n"), want: "totp:654321"},
+		{name: "password stdin without a terminal", source: commands.PasswordSource{Stdin: true}, stdin: "123456\n",
+			openTTY: noTTY, wantErr: true},
+		{name: "empty code", stdin: "\n", openTTY: noTTY, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prompt := commands.TFAPrompt(tt.source, strings.NewReader(tt.stdin), tt.openTTY)
+			got, err := prompt(challenge)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Expected an error, got code '%s'", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("TFAPrompt failed: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Expected code '%s', got '%s'", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestTFAPromptRejectsPasswordStdinWithoutTerminal(t *testing.T) {
+	prompt := commands.TFAPrompt(commands.PasswordSource{Stdin: true}, strings.NewReader(""),
+		func() (io.ReadCloser, error) { return nil, os.ErrNotExist })
+
+	_, err := prompt(services.TFAChallenge{TOTP: true})
+	if err == nil {
+		t.Fatal("Expected an error when no terminal is available")
+	}
+	if !strings.Contains(err.Error(), "--password-stdin") {
+		t.Errorf("Expected the error to mention --password-stdin, got '%v'", err)
+	}
+}
+
+func TestReadOptionalPassword(t *testing.T) {
+	t.Setenv("PROXMOX_CT_PASSWORD", "from-env")
+
+	got, err := commands.ReadOptionalPassword(commands.PasswordSource{Stdin: true}, "PROXMOX_CT_PASSWORD", strings.NewReader("from-stdin\n"))
+	if err != nil || got != "from-stdin" {
+		t.Errorf("Expected password 'from-stdin', got '%s' (%v)", got, err)
+	}
+
+	got, err = commands.ReadOptionalPassword(commands.PasswordSource{}, "PROXMOX_CT_PASSWORD", strings.NewReader(""))
+	if err != nil || got != "from-env" {
+		t.Errorf("Expected password 'from-env', got '%s' (%v)", got, err)
+	}
+
+	t.Setenv("PROXMOX_CT_PASSWORD", "")
+	got, err = commands.ReadOptionalPassword(commands.PasswordSource{}, "PROXMOX_CT_PASSWORD", strings.NewReader(""))
+	if err != nil || got != "" {
+		t.Errorf("Expected no password, got '%s' (%v)", got, err)
+	}
+
+	if _, err := commands.ReadOptionalPassword(commands.PasswordSource{Stdin: true}, "PROXMOX_CT_PASSWORD", strings.NewReader("")); err == nil {
+		t.Error("Expected an error for an empty password on standard input")
+	}
+}
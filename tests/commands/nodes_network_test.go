@@ -0,0 +1,47 @@
+package commands_test
+
+import (
+	"testing"
+
+	"proxmox-cli/commands"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNodeNetworkCommand(t *testing.T) {
+	networkCmd, _, err := commands.NodesCommand().Find([]string{"network"})
+	assert.NoError(t, err)
+	assert.Equal(t, "network", networkCmd.Name())
+
+	subcommandNames := []string{}
+	for _, subcmd := range networkCmd.Commands() {
+		subcommandNames = append(subcommandNames, subcmd.Name())
+		// Every network command works on one node
+		assert.Contains(t, subcmd.Flags().Lookup("node").Annotations, cobra.BashCompOneRequiredFlag, subcmd.Name())
+	}
+	assert.ElementsMatch(t, []string{"list", "show", "create", "update", "delete", "apply", "revert"}, subcommandNames)
+}
+
+func TestCreateAndUpdateNetworkInterfaceCommands(t *testing.T) {
+	createCmd := commands.CreateNetworkInterfaceCommand()
+	assert.Contains(t, createCmd.Flags().Lookup("type").Annotations, cobra.BashCompOneRequiredFlag)
+	assert.Equal(t, "true", createCmd.Flags().Lookup("autostart").DefValue)
+	for _, flag := range []string{"cidr", "gateway", "cidr6", "gateway6", "mtu", "bridge-ports", "bridge-vlan-aware", "slaves", "bond-mode", "bond-primary", "bond-hash-policy", "vlan-raw-device", "vlan-id", "comments"} {
+		assert.NotNil(t, createCmd.Flags().Lookup(flag), flag)
+	}
+	assert.Error(t, createCmd.Args(createCmd, []string{}))
+	assert.NoError(t, createCmd.Args(createCmd, []string{"vmbr1"}))
+
+	updateCmd := commands.UpdateNetworkInterfaceCommand()
+	assert.Nil(t, updateCmd.Flags().Lookup("type"))
+	assert.NotNil(t, updateCmd.Flags().Lookup("delete"))
+}
+
+func TestApplyNetworkCommand(t *testing.T) {
+	cmd := commands.ApplyNetworkCommand()
+
+	assert.NotNil(t, cmd.Flags().Lookup("dry-run"))
+	assert.NotNil(t, cmd.Flags().Lookup("wait"))
+	assert.NotNil(t, cmd.Flags().Lookup("timeout"))
+}
@@ -0,0 +1,40 @@
+package commands_test
+
+import (
+	"testing"
+
+	"proxmox-cli/commands"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStorageCommand(t *testing.T) {
+	cmd := commands.StorageCommand()
+
+	subcommandNames := []string{}
+	for _, subcmd := range cmd.Commands() {
+		subcommandNames = append(subcommandNames, subcmd.Name())
+	}
+	assert.ElementsMatch(t, []string{"list", "show", "add", "set", "remove", "content"}, subcommandNames)
+}
+
+func TestAddAndSetStorageCommands(t *testing.T) {
+	addCmd := commands.AddStorageCommand()
+	assert.Equal(t, "t", addCmd.Flags().Lookup("type").Shorthand)
+	assert.Contains(t, addCmd.Flags().Lookup("type").Annotations, cobra.BashCompOneRequiredFlag)
+	for _, flag := range []string{"content", "nodes", "enabled", "path", "server", "export", "share", "username", "password-file", "vgname", "thinpool", "pool", "monhost", "datastore", "fingerprint", "prune-backups"} {
+		assert.NotNil(t, addCmd.Flags().Lookup(flag), flag)
+	}
+	// Passwords are only read from files, so they stay out of the shell history
+	assert.Nil(t, addCmd.Flags().Lookup("password"))
+	assert.Error(t, addCmd.Args(addCmd, []string{}))
+
+	setCmd := commands.SetStorageCommand()
+	assert.Nil(t, setCmd.Flags().Lookup("type"))
+	assert.NotNil(t, setCmd.Flags().Lookup("delete"))
+	assert.NoError(t, setCmd.Args(setCmd, []string{"local"}))
+
+	removeCmd := commands.RemoveStorageCommand()
+	assert.Error(t, removeCmd.Args(removeCmd, []string{"a", "b"}))
+}
@@ -0,0 +1,34 @@
+package commands_test
+
+import (
+	"testing"
+
+	"proxmox-cli/commands"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTaskCommand(t *testing.T) {
+	cmd := commands.TaskCommand()
+
+	assert.Equal(t, "task", cmd.Use)
+
+	subcommandNames := []string{}
+	for _, subcmd := range cmd.Commands() {
+		subcommandNames = append(subcommandNames, subcmd.Name())
+	}
+
+	assert.ElementsMatch(t, []string{"list", "status", "log", "stop"}, subcommandNames)
+}
+
+func TestVMCommandsWaitFlags(t *testing.T) {
+	for _, subcmd := range commands.VMCommand().Commands() {
+		// These subcommands do not start tasks
+		switch subcmd.Name() {
+		case "list", "status", "config", "snapshot":
+			continue
+		}
+		assert.NotNil(t, subcmd.Flags().Lookup("wait"), subcmd.Name())
+		assert.NotNil(t, subcmd.Flags().Lookup("timeout"), subcmd.Name())
+	}
+}
@@ -0,0 +1,177 @@
+package commands_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"testing"
+
+	"proxmox-cli/commands"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateVMCommandFlags(t *testing.T) {
+	cmd := commands.CreateVMCommand()
+	assert.Equal(t, "create", cmd.Use)
+
+	for _, flag := range []string{"node", "vmid", "name", "memory", "cores", "sockets", "ostype", "disk", "net", "iso", "boot", "wait"} {
+		assert.NotNil(t, cmd.Flags().Lookup(flag), flag)
+	}
+}
+
+func TestVMConfigCommand(t *testing.T) {
+	cmd := commands.VMConfigCommand()
+	assert.Equal(t, "config", cmd.Use)
+
+	subcommandNames := []string{}
+	for _, subcmd := range cmd.Commands() {
+		subcommandNames = append(subcommandNames, subcmd.Name())
+		assert.NotNil(t, subcmd.Flags().Lookup("node"), subcmd.Name())
+		assert.NotNil(t, subcmd.Flags().Lookup("vmid"), subcmd.Name())
+	}
+	assert.ElementsMatch(t, []string{"show", "set", "unset"}, subcommandNames)
+
+	assert.NotNil(t, commands.SetVMConfigCommand().Flags().Lookup("digest"))
+	assert.NotNil(t, commands.UnsetVMConfigCommand().Flags().Lookup("digest"))
+	assert.NotNil(t, commands.ShowVMConfigCommand().Flags().Lookup("pending"))
+}
+
+func TestCloneVMCommandFlags(t *testing.T) {
+	cmd := commands.CloneVMCommand()
+	assert.Equal(t, "clone", cmd.Use)
+
+	for _, flag := range []string{"node", "vmid", "newid", "name", "target", "storage", "full", "pool", "wait"} {
+		assert.NotNil(t, cmd.Flags().Lookup(flag), flag)
+	}
+}
+
+func TestVMSnapshotCommand(t *testing.T) {
+	cmd := commands.VMSnapshotCommand()
+	assert.Equal(t, "snapshot", cmd.Use)
+
+	subcommandNames := []string{}
+	for _, subcmd := range cmd.Commands() {
+		subcommandNames = append(subcommandNames, subcmd.Name())
+		if subcmd.Name() != "list" {
+			assert.NotNil(t, subcmd.Flags().Lookup("wait"), subcmd.Name())
+		}
+		if subcmd.Name() == "create" {
+			assert.NotNil(t, subcmd.Flags().Lookup("vmstate"))
+		}
+	}
+	assert.ElementsMatch(t, []string{"list", "create", "rollback", "delete"}, subcommandNames)
+}
+
+func TestMigrateVMCommandFlags(t *testing.T) {
+	cmd := commands.MigrateVMCommand()
+	assert.Equal(t, "migrate", cmd.Use)
+
+	for _, flag := range []string{"node", "vmid", "target", "online", "with-local-disks", "target-storage", "check", "wait"} {
+		assert.NotNil(t, cmd.Flags().Lookup(flag), flag)
+	}
+}
+
+func TestVMCommandsNodeOptional(t *testing.T) {
+	for _, cmd := range []*cobra.Command{
+		commands.DeleteVMCommand(),
+		commands.VMStatusCommand(),
+		commands.CloneVMCommand(),
+		commands.MigrateVMCommand(),
+		commands.SetVMConfigCommand(),
+	} {
+		node := cmd.Flags().Lookup("node")
+		assert.NotContains(t, node.Annotations, cobra.BashCompOneRequiredFlag, cmd.Name())
+
+		// --vmid takes an ID or a name
+		vmid := cmd.Flags().Lookup("vmid")
+		assert.Equal(t, "string", vmid.Value.Type(), cmd.Name())
+		assert.Contains(t, vmid.Annotations, cobra.BashCompOneRequiredFlag, cmd.Name())
+	}
+
+	// Listing and creating still happen on a given node
+	assert.Contains(t, commands.ListVMsCommand().Flags().Lookup("node").Annotations, cobra.BashCompOneRequiredFlag)
+	assert.Contains(t, commands.CreateVMCommand().Flags().Lookup("node").Annotations, cobra.BashCompOneRequiredFlag)
+}
+
+func TestVMPowerCommandsBulkFlags(t *testing.T) {
+	for _, cmd := range []*cobra.Command{
+		commands.StartVMCommand(),
+		commands.StopVMCommand(),
+		commands.ShutdownVMCommand(),
+		commands.RebootVMCommand(),
+		commands.ResetVMCommand(),
+		commands.SuspendVMCommand(),
+		commands.ResumeVMCommand(),
+	} {
+		for _, flag := range []string{"node", "vmid", "all", "pool", "tag", "parallel", "wait", "timeout"} {
+			assert.NotNil(t, cmd.Flags().Lookup(flag), cmd.Name()+" --"+flag)
+		}
+		// Bulk runs select VMs by arguments instead of --vmid
+		assert.NotContains(t, cmd.Flags().Lookup("vmid").Annotations, cobra.BashCompOneRequiredFlag, cmd.Name())
+	}
+}
+
+// TestVMPowerCommandRejectsWaitInBulkRuns runs the command in a child process, as
+// exitWithError exits. Bulk runs always wait, so --wait is an error instead of being ignored.
+func TestVMPowerCommandRejectsWaitInBulkRuns(t *testing.T) {
+	if os.Getenv("PROXMOX_CLI_TEST_CHILD") == "1" {
+		cmd := commands.StartVMCommand()
+		cmd.SetArgs([]string{"--all", "--wait"})
+		_ = cmd.Execute()
+		return
+	}
+
+	child := exec.Command(os.Args[0], "-test.run=^TestVMPowerCommandRejectsWaitInBulkRuns$")
+	child.Env = append(os.Environ(), "PROXMOX_CLI_TEST_CHILD=1", "HOME="+t.TempDir())
+	var stderr bytes.Buffer
+	child.Stderr = &stderr
+	err := child.Run()
+
+	var exitErr *exec.ExitError
+	if assert.True(t, errors.As(err, &exitErr), "expected the command to exit non-zero") {
+		assert.Equal(t, 1, exitErr.ExitCode())
+	}
+	assert.Contains(t, stderr.String(), "bulk runs always wait for each task")
+}
+
+func TestVMPowerCommandSelectsByNode(t *testing.T) {
+	var mu sync.Mutex
+	started := []string{}
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/api2/json/cluster/resources":
+			fmt.Fprint(w, `{"data": [
+				{"type": "qemu", "node": "pve1", "vmid": 100, "name": "web-1", "status": "stopped"},
+				{"type": "qemu", "node": "pve1", "vmid": 101, "name": "web-2", "status": "running"},
+				{"type": "qemu", "node": "pve2", "vmid": 102, "name": "db-1", "status": "stopped"}
+			]}`)
+		case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/status/start"):
+			mu.Lock()
+			started = append(started, r.URL.Path)
+			mu.Unlock()
+			fmt.Fprint(w, `{"data": "UPID:pve1:00001234:00000001:00000001:qmstart:100:root@pam:"}`)
+		case strings.HasSuffix(r.URL.Path, "/status"):
+			fmt.Fprint(w, `{"data": {"status": "stopped", "exitstatus": "OK"}}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	useTestServer(t, server)
+
+	cmd := commands.StartVMCommand()
+	cmd.SetArgs([]string{"--node", "pve1"})
+	assert.NoError(t, cmd.Execute())
+
+	// VM 101 is running already and is skipped, VM 102 runs on another node
+	assert.Equal(t, []string{"/api2/json/nodes/pve1/qemu/100/status/start"}, started)
+}
@@ -0,0 +1,70 @@
+package commands_test
+
+import (
+	"testing"
+
+	"proxmox-cli/commands/cluster"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+// vnetSubcommand finds a subcommand of the vnet command by its path
+func vnetSubcommand(t *testing.T, path ...string) *cobra.Command {
+	subcmd, _, err := cluster.VnetCommand().Find(path)
+	assert.NoError(t, err)
+	return subcmd
+}
+
+func TestVnetCommand(t *testing.T) {
+	cmd := cluster.VnetCommand()
+
+	subcommandNames := []string{}
+	for _, subcmd := range cmd.Commands() {
+		subcommandNames = append(subcommandNames, subcmd.Name())
+	}
+	assert.ElementsMatch(t, []string{"list", "show", "create", "update", "delete", "subnet"}, subcommandNames)
+
+	subnetNames := []string{}
+	for _, subcmd := range vnetSubcommand(t, "subnet").Commands() {
+		subnetNames = append(subnetNames, subcmd.Name())
+	}
+	assert.ElementsMatch(t, []string{"list", "create", "update", "delete"}, subnetNames)
+}
+
+func TestCreateAndUpdateVnetCommands(t *testing.T) {
+	createCmd := vnetSubcommand(t, "create")
+	assert.Equal(t, "n", createCmd.Flags().Lookup("name").Shorthand)
+	assert.Contains(t, createCmd.Flags().Lookup("name").Annotations, cobra.BashCompOneRequiredFlag)
+	assert.Contains(t, createCmd.Flags().Lookup("zone").Annotations, cobra.BashCompOneRequiredFlag)
+	for _, flag := range []string{"tag", "alias", "vlanaware"} {
+		assert.NotNil(t, createCmd.Flags().Lookup(flag), flag)
+	}
+
+	// The free-form --config flag is replaced by typed flags
+	updateCmd := vnetSubcommand(t, "update")
+	assert.Nil(t, updateCmd.Flags().Lookup("config"))
+	assert.NotContains(t, updateCmd.Flags().Lookup("zone").Annotations, cobra.BashCompOneRequiredFlag)
+	for _, flag := range []string{"zone", "tag", "alias", "vlanaware", "delete"} {
+		assert.NotNil(t, updateCmd.Flags().Lookup(flag), flag)
+	}
+
+	deleteCmd := vnetSubcommand(t, "delete")
+	assert.Contains(t, deleteCmd.Flags().Lookup("name").Annotations, cobra.BashCompOneRequiredFlag)
+}
+
+func TestSubnetCommands(t *testing.T) {
+	createCmd := vnetSubcommand(t, "subnet", "create")
+	assert.Contains(t, createCmd.Flags().Lookup("vnet").Annotations, cobra.BashCompOneRequiredFlag)
+	assert.Contains(t, createCmd.Flags().Lookup("subnet").Annotations, cobra.BashCompOneRequiredFlag)
+	for _, flag := range []string{"gateway", "snat", "dhcp-range", "dhcp-dns-server", "dns-zone-prefix"} {
+		assert.NotNil(t, createCmd.Flags().Lookup(flag), flag)
+	}
+	assert.Nil(t, createCmd.Flags().Lookup("delete"))
+
+	updateCmd := vnetSubcommand(t, "subnet", "update")
+	assert.NotNil(t, updateCmd.Flags().Lookup("delete"))
+
+	deleteCmd := vnetSubcommand(t, "subnet", "delete")
+	assert.Contains(t, deleteCmd.Flags().Lookup("subnet").Annotations, cobra.BashCompOneRequiredFlag)
+}
@@ -3,128 +3,90 @@ package commands_test
 import (
 	"bytes"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"proxmox-cli/commands/cluster"
 
+	"github.com/spf13/cobra"
 	"github.com/stretchr/testify/assert"
 )
 
+// zoneSubcommand finds a subcommand of the zone command by name or alias
+func zoneSubcommand(t *testing.T, name string) *cobra.Command {
+	subcmd, _, err := cluster.ZoneCommand().Find([]string{name})
+	assert.NoError(t, err)
+	return subcmd
+}
+
 func TestZoneCommand(t *testing.T) {
 	cmd := cluster.ZoneCommand()
 
 	assert.NotNil(t, cmd)
-	assert.Equal(t, "sdn", cmd.Use)
-	assert.Equal(t, "Manage Software Defined Networking (SDN) in Proxmox", cmd.Short)
-
-	// Check that all subcommands are added
-	subcommands := cmd.Commands()
-	assert.Len(t, subcommands, 3)
+	assert.Equal(t, "zone", cmd.Use)
+	assert.Equal(t, "Manage SDN zones", cmd.Short)
 
 	// Check subcommand names
-	subcommandNames := make([]string, len(subcommands))
-	for i, subcmd := range subcommands {
-		subcommandNames[i] = subcmd.Name()
+	subcommandNames := []string{}
+	for _, subcmd := range cmd.Commands() {
+		subcommandNames = append(subcommandNames, subcmd.Name())
+		assert.Equal(t, cmd, subcmd.Parent())
 	}
+	assert.ElementsMatch(t, []string{"list", "show", "create", "update", "delete"}, subcommandNames)
+}
 
-	assert.Contains(t, subcommandNames, "create-zone")
-	assert.Contains(t, subcommandNames, "delete-zone")
-	assert.Contains(t, subcommandNames, "update-zone")
+func TestZoneCommandAliases(t *testing.T) {
+	// The previous command names keep working
+	assert.Equal(t, "create", zoneSubcommand(t, "create-zone").Name())
+	assert.Equal(t, "update", zoneSubcommand(t, "update-zone").Name())
+	assert.Equal(t, "delete", zoneSubcommand(t, "delete-zone").Name())
 }
 
 func TestCreateZoneCommand(t *testing.T) {
-	cmd := cluster.ZoneCommand()
-	createCmd := cmd.Commands()[0] // create-zone is the first subcommand
+	createCmd := zoneSubcommand(t, "create")
 
-	assert.Equal(t, "create-zone", createCmd.Name())
 	assert.Equal(t, "Create a new SDN zone", createCmd.Short)
 
-	// Test required flags
-	assert.True(t, createCmd.Flags().Lookup("name") != nil)
-	assert.True(t, createCmd.Flags().Lookup("type") != nil)
-
 	// Test flag shorthand
 	assert.Equal(t, "n", createCmd.Flags().Lookup("name").Shorthand)
 	assert.Equal(t, "t", createCmd.Flags().Lookup("type").Shorthand)
-}
+	assert.Contains(t, createCmd.Flags().Lookup("name").Annotations, cobra.BashCompOneRequiredFlag)
+	assert.Contains(t, createCmd.Flags().Lookup("type").Annotations, cobra.BashCompOneRequiredFlag)
 
-func TestDeleteZoneCommand(t *testing.T) {
-	cmd := cluster.ZoneCommand()
-	deleteCmd := cmd.Commands()[1] // delete-zone is the second subcommand
-
-	assert.Equal(t, "delete-zone", deleteCmd.Name())
-	assert.Equal(t, "Delete an existing SDN zone", deleteCmd.Short)
-
-	// Test required flags
-	assert.True(t, deleteCmd.Flags().Lookup("name") != nil)
-
-	// Test flag shorthand
-	assert.Equal(t, "n", deleteCmd.Flags().Lookup("name").Shorthand)
+	// Test the type-specific options
+	for _, flag := range []string{"nodes", "ipam", "mtu", "bridge", "tag", "vlan-protocol", "peers", "vxlan-port", "controller", "vrf-vxlan", "exit-nodes", "dhcp"} {
+		assert.NotNil(t, createCmd.Flags().Lookup(flag), flag)
+	}
 }
 
 func TestUpdateZoneCommand(t *testing.T) {
-	cmd := cluster.ZoneCommand()
-	updateCmd := cmd.Commands()[2] // update-zone is the third subcommand
+	updateCmd := zoneSubcommand(t, "update")
 
-	assert.Equal(t, "update-zone", updateCmd.Name())
 	assert.Equal(t, "Update an existing SDN zone", updateCmd.Short)
-
-	// Test required flags
-	assert.True(t, updateCmd.Flags().Lookup("name") != nil)
-	assert.True(t, updateCmd.Flags().Lookup("new-type") != nil)
-
-	// Test flag shorthand
 	assert.Equal(t, "n", updateCmd.Flags().Lookup("name").Shorthand)
-	assert.Equal(t, "t", updateCmd.Flags().Lookup("new-type").Shorthand)
+	assert.NotNil(t, updateCmd.Flags().Lookup("delete"))
+	assert.NotNil(t, updateCmd.Flags().Lookup("bridge"))
+	// The type of a zone cannot be changed
+	assert.Nil(t, updateCmd.Flags().Lookup("type"))
+	assert.Nil(t, updateCmd.Flags().Lookup("new-type"))
 }
 
-func TestZoneTypeValidation(t *testing.T) {
-	validZoneTypes := []string{
-		"simple", "vlan", "vxlan", "gre", "ipsec",
-		"l2tpv3", "vxlan-ipsec", "l2tpv3-ipsec",
-	}
-
-	invalidZoneTypes := []string{
-		"invalid", "test", "zone", "", "simple-invalid",
-	}
-
-	// Test valid zone types
-	for _, zoneType := range validZoneTypes {
-		t.Run(fmt.Sprintf("ValidZoneType_%s", zoneType), func(t *testing.T) {
-			cmd := cluster.ZoneCommand()
-			createCmd := cmd.Commands()[0]
-
-			// Set valid flags
-			createCmd.Flags().Set("name", "testzone")
-			createCmd.Flags().Set("type", zoneType)
-
-			// Verify flags were set correctly
-			name, _ := createCmd.Flags().GetString("name")
-			zoneTypeFlag, _ := createCmd.Flags().GetString("type")
-
-			assert.Equal(t, "testzone", name)
-			assert.Equal(t, zoneType, zoneTypeFlag)
-		})
-	}
-
-	// Test invalid zone types
-	for _, zoneType := range invalidZoneTypes {
-		t.Run(fmt.Sprintf("InvalidZoneType_%s", zoneType), func(t *testing.T) {
-			cmd := cluster.ZoneCommand()
-			createCmd := cmd.Commands()[0]
+func TestDeleteZoneCommand(t *testing.T) {
+	deleteCmd := zoneSubcommand(t, "delete")
 
-			// Set invalid flags
-			createCmd.Flags().Set("name", "testzone")
-			createCmd.Flags().Set("type", zoneType)
+	assert.Equal(t, "Delete an existing SDN zone", deleteCmd.Short)
+	assert.Equal(t, "n", deleteCmd.Flags().Lookup("name").Shorthand)
+}
 
-			// Verify flags were set correctly
-			name, _ := createCmd.Flags().GetString("name")
-			zoneTypeFlag, _ := createCmd.Flags().GetString("type")
+func TestListAndShowZoneCommands(t *testing.T) {
+	listCmd := zoneSubcommand(t, "list")
+	assert.NotNil(t, listCmd.Flags().Lookup("type"))
+	assert.NotNil(t, listCmd.Flags().Lookup("pending"))
 
-			assert.Equal(t, "testzone", name)
-			assert.Equal(t, zoneType, zoneTypeFlag)
-		})
-	}
+	showCmd := zoneSubcommand(t, "show")
+	assert.Contains(t, showCmd.Flags().Lookup("name").Annotations, cobra.BashCompOneRequiredFlag)
+	assert.NotNil(t, showCmd.Flags().Lookup("pending"))
 }
 
 func TestCommandHelp(t *testing.T) {
@@ -136,13 +98,13 @@ func TestCommandHelp(t *testing.T) {
 	cmd.Help()
 
 	output := buf.String()
-	assert.Contains(t, output, "Manage Software Defined Networking (SDN) in Proxmox")
-	assert.Contains(t, output, "create-zone")
-	assert.Contains(t, output, "delete-zone")
-	assert.Contains(t, output, "update-zone")
+	assert.Contains(t, output, "Manage SDN zones")
+	assert.Contains(t, output, "create")
+	assert.Contains(t, output, "delete")
+	assert.Contains(t, output, "update")
 
 	// Test subcommand help
-	createCmd := cmd.Commands()[0]
+	createCmd, _, _ := cmd.Find([]string{"create"})
 	buf.Reset()
 	createCmd.SetOut(&buf)
 	createCmd.Help()
@@ -151,99 +113,62 @@ func TestCommandHelp(t *testing.T) {
 	assert.Contains(t, output, "Create a new SDN zone")
 	assert.Contains(t, output, "--name")
 	assert.Contains(t, output, "--type")
-
-	// Test delete-zone help
-	deleteCmd := cmd.Commands()[1]
-	buf.Reset()
-	deleteCmd.SetOut(&buf)
-	deleteCmd.Help()
-
-	output = buf.String()
-	assert.Contains(t, output, "Delete an existing SDN zone")
-	assert.Contains(t, output, "--name")
-
-	// Test update-zone help
-	updateCmd := cmd.Commands()[2]
-	buf.Reset()
-	updateCmd.SetOut(&buf)
-	updateCmd.Help()
-
-	output = buf.String()
-	assert.Contains(t, output, "Update an existing SDN zone")
-	assert.Contains(t, output, "--name")
-	assert.Contains(t, output, "--new-type")
+	assert.Contains(t, output, "simple, vlan, qinq, vxlan, evpn")
 }
 
-func TestCommandFlags(t *testing.T) {
-	cmd := cluster.ZoneCommand()
-
-	// Test create-zone flags
-	createCmd := cmd.Commands()[0]
-	createCmd.Flags().Set("name", "testzone")
-	createCmd.Flags().Set("type", "simple")
-
-	name, _ := createCmd.Flags().GetString("name")
-	zoneType, _ := createCmd.Flags().GetString("type")
-
-	assert.Equal(t, "testzone", name)
-	assert.Equal(t, "simple", zoneType)
-
-	// Test delete-zone flags
-	deleteCmd := cmd.Commands()[1]
-	deleteCmd.Flags().Set("name", "testzone")
-
-	deleteName, _ := deleteCmd.Flags().GetString("name")
-	assert.Equal(t, "testzone", deleteName)
+func TestSDNCommand(t *testing.T) {
+	cmd := cluster.SDNCommand()
 
-	// Test update-zone flags
-	updateCmd := cmd.Commands()[2]
-	updateCmd.Flags().Set("name", "testzone")
-	updateCmd.Flags().Set("new-type", "vlan")
-
-	updateName, _ := updateCmd.Flags().GetString("name")
-	updateType, _ := updateCmd.Flags().GetString("new-type")
-
-	assert.Equal(t, "testzone", updateName)
-	assert.Equal(t, "vlan", updateType)
+	subcommandNames := []string{}
+	for _, subcmd := range cmd.Commands() {
+		subcommandNames = append(subcommandNames, subcmd.Name())
+	}
+	assert.ElementsMatch(t, []string{"zone", "vnet", "apply-config"}, subcommandNames)
 }
 
-func TestCommandUsage(t *testing.T) {
-	cmd := cluster.ZoneCommand()
-
-	// Test main command usage
-	assert.Equal(t, "sdn", cmd.Use)
-	assert.Equal(t, "Manage Software Defined Networking (SDN) in Proxmox", cmd.Short)
-	// Note: Long field is not set, so we don't test it
-
-	// Test subcommand usage
-	createCmd := cmd.Commands()[0]
-	assert.Equal(t, "create-zone", createCmd.Use)
-	assert.Equal(t, "Create a new SDN zone", createCmd.Short)
-
-	deleteCmd := cmd.Commands()[1]
-	assert.Equal(t, "delete-zone", deleteCmd.Use)
-	assert.Equal(t, "Delete an existing SDN zone", deleteCmd.Short)
+func TestApplyConfigCommand(t *testing.T) {
+	applyCmd, _, err := cluster.SDNCommand().Find([]string{"apply-config"})
 
-	updateCmd := cmd.Commands()[2]
-	assert.Equal(t, "update-zone", updateCmd.Use)
-	assert.Equal(t, "Update an existing SDN zone", updateCmd.Short)
+	assert.NoError(t, err)
+	assert.NotNil(t, applyCmd.Flags().Lookup("dry-run"))
+	assert.Equal(t, "5m0s", applyCmd.Flags().Lookup("timeout").DefValue)
+	assert.Nil(t, applyCmd.Flags().Lookup("name"))
 }
 
-func TestCommandStructure(t *testing.T) {
-	cmd := cluster.ZoneCommand()
-
-	// Verify command hierarchy
-	assert.Equal(t, "sdn", cmd.Name())
-	assert.Len(t, cmd.Commands(), 3)
-
-	// Verify subcommand names and order
-	subcommands := cmd.Commands()
-	assert.Equal(t, "create-zone", subcommands[0].Name())
-	assert.Equal(t, "delete-zone", subcommands[1].Name())
-	assert.Equal(t, "update-zone", subcommands[2].Name())
-
-	// Verify each subcommand has the correct parent
-	for _, subcmd := range subcommands {
-		assert.Equal(t, cmd, subcmd.Parent())
-	}
+func TestApplyConfigCommandWithoutZoneChanges(t *testing.T) {
+	// Changes to controllers, IPAMs and DNS are not in the diff but must still be applied
+	const applyUPID = "UPID:pve1:00001234:00000001:6553F100:reloadnetworkall::root@pam:"
+	applied := false
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPut && r.URL.Path == "/api2/json/cluster/sdn":
+			applied = true
+			fmt.Fprintf(w, `{"data": %q}`, applyUPID)
+		case r.URL.Path == "/api2/json/cluster/sdn/zones", r.URL.Path == "/api2/json/cluster/sdn/vnets":
+			fmt.Fprint(w, `{"data": []}`)
+		case r.URL.Path == "/api2/json/nodes":
+			fmt.Fprint(w, `{"data": [{"node": "pve1", "status": "online"}]}`)
+		case r.URL.Path == "/api2/json/nodes/pve1/tasks":
+			fmt.Fprintf(w, `{"data": [{"type": "srvreload", "id": "networking", "starttime": %d, "endtime": %d, "status": "OK"}]}`,
+				0x6553F100, 0x6553F102)
+		case r.URL.Path == "/api2/json/nodes/pve1/tasks/"+applyUPID+"/status":
+			fmt.Fprint(w, `{"data": {"status": "stopped", "exitstatus": "OK"}}`)
+		case r.URL.Path == "/api2/json/nodes/pve1/sdn/zones":
+			fmt.Fprint(w, `{"data": []}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	useTestServer(t, server)
+
+	cmd := cluster.SDNCommand()
+	cmd.SetArgs([]string{"apply-config", "--dry-run"})
+	assert.NoError(t, cmd.Execute())
+	assert.False(t, applied)
+
+	cmd = cluster.SDNCommand()
+	cmd.SetArgs([]string{"apply-config"})
+	assert.NoError(t, cmd.Execute())
+	assert.True(t, applied)
 }
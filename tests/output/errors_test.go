@@ -0,0 +1,39 @@
+package output_test
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"proxmox-cli/output"
+	"proxmox-cli/services"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExitCode(t *testing.T) {
+	assert.Equal(t, output.ExitFailure, output.ExitCode(assert.AnError))
+	assert.Equal(t, output.ExitAPIError, output.ExitCode(&services.APIError{StatusCode: 500}))
+	assert.Equal(t, output.ExitAuthError, output.ExitCode(&services.APIError{StatusCode: 401}))
+	assert.Equal(t, output.ExitAuthError, output.ExitCode(fmt.Errorf("wrapped: %w", &services.APIError{StatusCode: 403})))
+}
+
+func TestPrintError_APIError(t *testing.T) {
+	var buf bytes.Buffer
+	output.PrintError(&buf, "Failed to start VM", &services.APIError{
+		StatusCode: 400,
+		Message:    "Parameter verification failed.",
+		Errors:     map[string]string{"vmid": "invalid format", "node": "no such node"},
+	})
+
+	assert.Equal(t, "Error: Failed to start VM: Parameter verification failed. (HTTP 400)\n"+
+		"  node: no such node\n"+
+		"  vmid: invalid format\n", buf.String())
+}
+
+func TestPrintError_OtherError(t *testing.T) {
+	var buf bytes.Buffer
+	output.PrintError(&buf, "Failed to list VMs", fmt.Errorf("connection refused"))
+
+	assert.Equal(t, "Error: Failed to list VMs: connection refused\n", buf.String())
+}
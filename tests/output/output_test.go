@@ -0,0 +1,105 @@
+package output_test
+
+import (
+	"bytes"
+	"strconv"
+	"testing"
+
+	"proxmox-cli/output"
+	"proxmox-cli/services"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testVMs = []services.VM{
+	{VMID: 100, Name: "web", Status: "running", CPUs: 2},
+	{VMID: 101, Name: "db", Status: "stopped", CPUs: 4},
+}
+
+var testColumns = []output.Column[services.VM]{
+	{Header: "VMID", Value: func(vm services.VM) string { return strconv.Itoa(vm.VMID) }},
+	{Header: "NAME", Value: func(vm services.VM) string { return vm.Name }},
+	{Header: "CPUS", Wide: true, Value: func(vm services.VM) string { return strconv.Itoa(vm.CPUs) }},
+}
+
+func render(t *testing.T, format string) string {
+	t.Helper()
+	var buf bytes.Buffer
+	err := output.RenderList(&buf, format, testVMs, testColumns)
+	assert.NoError(t, err)
+	return buf.String()
+}
+
+func TestValidateFormat(t *testing.T) {
+	for _, format := range []string{"table", "wide", "json", "yaml", "csv", "go-template={{.}}", "jsonpath={.name}"} {
+		assert.NoError(t, output.ValidateFormat(format), format)
+	}
+	for _, format := range []string{"xml", "json=x", "jsonpath=", "go-template"} {
+		assert.Error(t, output.ValidateFormat(format), format)
+	}
+}
+
+func TestRenderList_Table(t *testing.T) {
+	assert.Equal(t, "VMID NAME\n=========\n100  web\n101  db\n", render(t, "table"))
+}
+
+func TestRenderList_Wide(t *testing.T) {
+	assert.Equal(t, "VMID NAME CPUS\n==============\n100  web  2\n101  db   4\n", render(t, "wide"))
+}
+
+func TestRenderList_CSV(t *testing.T) {
+	assert.Equal(t, "VMID,NAME,CPUS\n100,web,2\n101,db,4\n", render(t, "csv"))
+}
+
+func TestRenderList_JSON(t *testing.T) {
+	result := render(t, "json")
+	assert.Contains(t, result, `"vmid": 100`)
+	assert.Contains(t, result, `"name": "db"`)
+}
+
+func TestRenderList_JSONEmpty(t *testing.T) {
+	var buf bytes.Buffer
+	err := output.RenderList(&buf, "json", []services.VM(nil), testColumns)
+	assert.NoError(t, err)
+	assert.Equal(t, "[]\n", buf.String())
+}
+
+func TestRenderList_YAML(t *testing.T) {
+	result := render(t, "yaml")
+	assert.Contains(t, result, "- cpus: 2\n")
+	assert.Contains(t, result, "  vmid: 101\n")
+}
+
+func TestRenderList_GoTemplate(t *testing.T) {
+	assert.Equal(t, "web db \n", render(t, "go-template={{range .}}{{.name}} {{end}}"))
+}
+
+func TestRenderList_JSONPath(t *testing.T) {
+	assert.Equal(t, "100 101\n", render(t, "jsonpath={[*].vmid}"))
+	assert.Equal(t, "last: db\n", render(t, "jsonpath=last: {[-1].name}"))
+}
+
+func TestRenderList_JSONPathInvalid(t *testing.T) {
+	var buf bytes.Buffer
+	err := output.RenderList(&buf, "jsonpath={[*].name", testVMs, testColumns)
+	assert.Error(t, err)
+}
+
+func TestRenderObject(t *testing.T) {
+	called := false
+	var buf bytes.Buffer
+
+	err := output.RenderObject(&buf, "table", testVMs[0], func() { called = true })
+	assert.NoError(t, err)
+	assert.True(t, called)
+	assert.Empty(t, buf.String())
+
+	called = false
+	err = output.RenderObject(&buf, "jsonpath={.name}", testVMs[0], func() { called = true })
+	assert.NoError(t, err)
+	assert.False(t, called)
+	assert.Equal(t, "web\n", buf.String())
+
+	err = output.RenderObject(&buf, "csv", testVMs[0], func() {})
+	assert.Error(t, err)
+}
@@ -5,7 +5,10 @@ package tests
 
 import (
 	"fmt"
+	"io"
 	"net/http"
+	"net/url"
+	"strings"
 	"testing"
 
 	"proxmox-cli/services"
@@ -72,6 +75,31 @@ func (m *mockSessionService) UpdateSessionField(field string, value interface{})
 	return m.updateSessionFieldFunc(field, value)
 }
 
+// ContextName mocks returning the name of the active context.
+func (m *mockSessionService) ContextName() string {
+	return services.DefaultContext
+}
+
+// ListContexts mocks listing the stored contexts.
+func (m *mockSessionService) ListContexts() ([]services.ContextInfo, error) {
+	return nil, nil
+}
+
+// UseContext mocks switching the current context.
+func (m *mockSessionService) UseContext(name string) error {
+	return nil
+}
+
+// RenameContext mocks renaming a context.
+func (m *mockSessionService) RenameContext(oldName, newName string) error {
+	return nil
+}
+
+// DeleteContext mocks deleting a context.
+func (m *mockSessionService) DeleteContext(name string) error {
+	return nil
+}
+
 func TestAuthService_ValidateSession_Success(t *testing.T) {
 	logger := logrus.New()
 	validSession := services.SessionData{
@@ -220,7 +248,7 @@ func TestAuthService_LoginToProxmox_Success(t *testing.T) {
 		updateSessionFieldFunc: func(field string, value interface{}) error { return nil },
 	}
 	authService := services.NewAuthServiceWithDeps(logger, true, mockHTTP, mockSession)
-	err := authService.LoginToProxmox("localhost", 8006, "https", "user", "pass")
+	err := authService.LoginToProxmox("localhost", 8006, "https", "user@pam", "", "pass")
 	if err != nil {
 		t.Errorf("expected LoginToProxmox to succeed, got error: %v", err)
 	}
@@ -239,7 +267,7 @@ func TestAuthService_LoginToProxmox_HttpFailure(t *testing.T) {
 		updateSessionFieldFunc: func(field string, value interface{}) error { return nil },
 	}
 	authService := services.NewAuthServiceWithDeps(logger, true, mockHTTP, mockSession)
-	err := authService.LoginToProxmox("localhost", 8006, "https", "user", "pass")
+	err := authService.LoginToProxmox("localhost", 8006, "https", "user@pam", "", "pass")
 	if err == nil {
 		t.Error("expected LoginToProxmox to return error on HTTP failure")
 	}
@@ -258,7 +286,7 @@ func TestAuthService_LoginToProxmox_InvalidJson(t *testing.T) {
 		updateSessionFieldFunc: func(field string, value interface{}) error { return nil },
 	}
 	authService := services.NewAuthServiceWithDeps(logger, true, mockHTTP, mockSession)
-	err := authService.LoginToProxmox("localhost", 8006, "https", "user", "pass")
+	err := authService.LoginToProxmox("localhost", 8006, "https", "user@pam", "", "pass")
 	if err == nil {
 		t.Error("expected LoginToProxmox to return error on invalid JSON")
 	}
@@ -277,8 +305,222 @@ func TestAuthService_LoginToProxmox_WriteSessionFileFailure(t *testing.T) {
 		updateSessionFieldFunc: func(field string, value interface{}) error { return nil },
 	}
 	authService := services.NewAuthServiceWithDeps(logger, true, mockHTTP, mockSession)
-	err := authService.LoginToProxmox("localhost", 8006, "https", "user", "pass")
+	err := authService.LoginToProxmox("localhost", 8006, "https", "user@pam", "", "pass")
 	if err == nil {
 		t.Error("expected LoginToProxmox to return error on write session file failure")
 	}
 }
+
+func TestAuthService_LoginWithAPIToken_Success(t *testing.T) {
+	logger := logrus.New()
+	var written services.SessionData
+	mockHTTP := &mockHTTPService{
+		getFunc: func(url string, headers map[string]string, cookies []*http.Cookie) (*http.Response, error) {
+			if headers["Authorization"] != "PVEAPIToken=ci@pve!build=secret" {
+				t.Errorf("unexpected Authorization header: %q", headers["Authorization"])
+			}
+			if cookies != nil {
+				t.Errorf("expected no cookies for API token login, got %v", cookies)
+			}
+			return &http.Response{
+				StatusCode: 200,
+				Status:     "200 OK",
+				Body:       io.NopCloser(strings.NewReader(`{"data":{"version":"8.1"}}`)),
+			}, nil
+		},
+	}
+	mockSession := &mockSessionService{
+		writeSessionFileFunc: func(data services.SessionData) error {
+			written = data
+			return nil
+		},
+	}
+	authService := services.NewAuthServiceWithDeps(logger, true, mockHTTP, mockSession)
+	err := authService.LoginWithAPIToken("localhost", 8006, "https", "ci@pve!build", "secret")
+	if err != nil {
+		t.Fatalf("expected LoginWithAPIToken to succeed, got error: %v", err)
+	}
+	if written.TokenID != "ci@pve!build" || written.TokenSecret != "secret" {
+		t.Errorf("expected token to be stored in session, got %+v", written)
+	}
+	if written.Response.Data.Username != "ci@pve" {
+		t.Errorf("expected username 'ci@pve', got '%s'", written.Response.Data.Username)
+	}
+}
+
+func TestAuthService_LoginWithAPIToken_Rejected(t *testing.T) {
+	logger := logrus.New()
+	mockHTTP := &mockHTTPService{
+		getFunc: func(url string, headers map[string]string, cookies []*http.Cookie) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: 401,
+				Status:     "401 invalid token value!",
+				Body:       io.NopCloser(strings.NewReader("")),
+			}, nil
+		},
+	}
+	mockSession := &mockSessionService{
+		writeSessionFileFunc: func(data services.SessionData) error {
+			t.Error("session must not be written for a rejected token")
+			return nil
+		},
+	}
+	authService := services.NewAuthServiceWithDeps(logger, true, mockHTTP, mockSession)
+	err := authService.LoginWithAPIToken("localhost", 8006, "https", "ci@pve!build", "wrong")
+	if err == nil {
+		t.Error("expected LoginWithAPIToken to return error for rejected token")
+	}
+}
+
+func TestAuthService_LoginWithAPIToken_InvalidTokenID(t *testing.T) {
+	logger := logrus.New()
+	authService := services.NewAuthServiceWithDeps(logger, true, &mockHTTPService{}, &mockSessionService{})
+	err := authService.LoginWithAPIToken("localhost", 8006, "https", "ci@pve", "secret")
+	if err == nil {
+		t.Error("expected LoginWithAPIToken to reject a token ID without a token name")
+	}
+}
+
+func TestAuthService_LoginToProxmox_RealmFlag(t *testing.T) {
+	logger := logrus.New()
+	mockHTTP := &mockHTTPService{
+		postFunc: func(uri, payload string, headers map[string]string, cookies []*http.Cookie) (string, error) {
+			form, err := url.ParseQuery(payload)
+			if err != nil {
+				t.Fatalf("invalid payload: %v", err)
+			}
+			if form.Get("username") != "alice" || form.Get("realm") != "ldap" {
+				t.Errorf("expected username 'alice' in realm 'ldap', got payload %q", payload)
+			}
+			if form.Get("password") != "p&ss=word" {
+				t.Errorf("expected password to be URL-encoded, got payload %q", payload)
+			}
+			return `{"data":{"username":"alice@ldap","ticket":"ticket","CSRFPreventionToken":"csrf"}}`, nil
+		},
+	}
+	mockSession := &mockSessionService{
+		writeSessionFileFunc: func(data services.SessionData) error { return nil },
+	}
+	authService := services.NewAuthServiceWithDeps(logger, true, mockHTTP, mockSession)
+	err := authService.LoginToProxmox("localhost", 8006, "https", "alice", "ldap", "p&ss=word")
+	if err != nil {
+		t.Errorf("expected LoginToProxmox to succeed, got error: %v", err)
+	}
+}
+
+func TestAuthService_LoginToProxmox_DefaultRealmDiscovery(t *testing.T) {
+	logger := logrus.New()
+	mockHTTP := &mockHTTPService{
+		getFunc: func(url string, headers map[string]string, cookies []*http.Cookie) (*http.Response, error) {
+			if !strings.HasSuffix(url, "/access/domains") {
+				t.Errorf("unexpected GET %s", url)
+			}
+			body := `{"data":[{"realm":"pam","type":"pam"},{"realm":"pve","type":"pve","default":1}]}`
+			return &http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader(body))}, nil
+		},
+		postFunc: func(uri, payload string, headers map[string]string, cookies []*http.Cookie) (string, error) {
+			form, _ := url.ParseQuery(payload)
+			if form.Get("realm") != "pve" {
+				t.Errorf("expected default realm 'pve', got %q", form.Get("realm"))
+			}
+			return `{"data":{"username":"alice@pve","ticket":"ticket","CSRFPreventionToken":"csrf"}}`, nil
+		},
+	}
+	mockSession := &mockSessionService{
+		writeSessionFileFunc: func(data services.SessionData) error { return nil },
+	}
+	authService := services.NewAuthServiceWithDeps(logger, true, mockHTTP, mockSession)
+	err := authService.LoginToProxmox("localhost", 8006, "https", "alice", "", "pass")
+	if err != nil {
+		t.Errorf("expected LoginToProxmox to succeed, got error: %v", err)
+	}
+}
+
+func TestAuthService_LoginToProxmox_InvalidCredentials(t *testing.T) {
+	logger := logrus.New()
+	mockHTTP := &mockHTTPService{
+		postFunc: func(uri, payload string, headers map[string]string, cookies []*http.Cookie) (string, error) {
+			return `{"data":null}`, nil
+		},
+	}
+	mockSession := &mockSessionService{
+		writeSessionFileFunc: func(data services.SessionData) error {
+			t.Error("session must not be written when authentication fails")
+			return nil
+		},
+	}
+	authService := services.NewAuthServiceWithDeps(logger, true, mockHTTP, mockSession)
+	err := authService.LoginToProxmox("localhost", 8006, "https", "user@pam", "", "wrong")
+	if err == nil {
+		t.Error("expected LoginToProxmox to return error for rejected credentials")
+	}
+}
+
+func TestAuthService_LoginToProxmox_TFA(t *testing.T) {
+	logger := logrus.New()
+	challengeTicket := "PVE:!tfa!" + url.QueryEscape(`{"totp":true,"recovery":"available"}`) + ":ABCDEF"
+	calls := 0
+	var written services.SessionData
+	mockHTTP := &mockHTTPService{
+		postFunc: func(uri, payload string, headers map[string]string, cookies []*http.Cookie) (string, error) {
+			calls++
+			form, _ := url.ParseQuery(payload)
+			if calls == 1 {
+				return fmt.Sprintf(`{"data":{"username":"alice@pve","ticket":%q,"CSRFPreventionToken":"csrf","NeedTFA":1}}`, challengeTicket), nil
+			}
+			if form.Get("tfa-challenge") != challengeTicket {
+				t.Errorf("expected tfa-challenge to carry the partial ticket, got %q", form.Get("tfa-challenge"))
+			}
+			if form.Get("password") != "totp:123456" {
+				t.Errorf("expected TOTP response, got %q", form.Get("password"))
+			}
+			return `{"data":{"username":"alice@pve","ticket":"full-ticket","CSRFPreventionToken":"csrf2"}}`, nil
+		},
+	}
+	mockSession := &mockSessionService{
+		writeSessionFileFunc: func(data services.SessionData) error {
+			written = data
+			return nil
+		},
+	}
+	authService := services.NewAuthServiceWithDeps(logger, true, mockHTTP, mockSession)
+	authService.TFAPrompt = func(challenge services.TFAChallenge) (string, error) {
+		if !challenge.TOTP || !challenge.Recovery {
+			t.Errorf("expected TOTP and recovery to be offered, got %+v", challenge)
+		}
+		return "totp:123456", nil
+	}
+	err := authService.LoginToProxmox("localhost", 8006, "https", "alice@pve", "", "pass")
+	if err != nil {
+		t.Fatalf("expected TFA login to succeed, got error: %v", err)
+	}
+	if written.Response.Data.Ticket != "full-ticket" {
+		t.Errorf("expected the full ticket to be stored, got '%s'", written.Response.Data.Ticket)
+	}
+}
+
+func TestAuthService_LoginToProxmox_TFAWithoutPrompt(t *testing.T) {
+	logger := logrus.New()
+	mockHTTP := &mockHTTPService{
+		postFunc: func(uri, payload string, headers map[string]string, cookies []*http.Cookie) (string, error) {
+			return `{"data":{"username":"alice@pve","ticket":"PVE:!tfa!x:y","CSRFPreventionToken":"csrf","NeedTFA":1}}`, nil
+		},
+	}
+	authService := services.NewAuthServiceWithDeps(logger, true, mockHTTP, &mockSessionService{})
+	err := authService.LoginToProxmox("localhost", 8006, "https", "alice@pve", "", "pass")
+	if err == nil {
+		t.Error("expected LoginToProxmox to fail when TFA is required without a prompt")
+	}
+}
+
+func TestParseTFAChallenge(t *testing.T) {
+	challenge := services.ParseTFAChallenge("PVE:!tfa!" + url.QueryEscape(`{"webauthn":{},"recovery":"available"}`) + ":SIG")
+	if challenge.TOTP || !challenge.WebAuthn || !challenge.Recovery {
+		t.Errorf("unexpected challenge: %+v", challenge)
+	}
+
+	fallback := services.ParseTFAChallenge("garbage")
+	if !fallback.TOTP {
+		t.Errorf("expected unparsable challenges to default to TOTP, got %+v", fallback)
+	}
+}
@@ -0,0 +1,184 @@
+package tests
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"proxmox-cli/services"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackupService_ListJobs(t *testing.T) {
+	mockHTTP := &mockHTTPService{
+		getFunc: func(uri string, headers map[string]string, cookies []*http.Cookie) (*http.Response, error) {
+			assert.Equal(t, "https://localhost:8006/api2/json/cluster/backup", uri)
+			return jsonResponse(`{"data": [
+				{"id": "backup-1", "type": "vzdump", "schedule": "sat 02:00", "all": 1, "exclude": "900", "storage": "backups",
+				 "prune-backups": {"keep-weekly": 4, "keep-last": 3}, "next-run": 1706925600},
+				{"id": "backup-2", "type": "vzdump", "schedule": "daily", "enabled": 0, "vmid": "100,101", "prune-backups": "keep-daily=7"}
+			]}`), nil
+		},
+	}
+
+	jobs, err := newTestService(services.NewBackupServiceWithDeps, mockHTTP).ListJobs()
+
+	assert.NoError(t, err)
+	assert.Len(t, jobs, 2)
+	assert.True(t, jobs[0].IsEnabled())
+	assert.Equal(t, "all except 900", jobs[0].Selection())
+	assert.Equal(t, services.PruneSettings("keep-last=3,keep-weekly=4"), jobs[0].PruneBackups)
+	assert.False(t, jobs[1].IsEnabled())
+	assert.Equal(t, "100,101", jobs[1].Selection())
+	assert.Equal(t, services.PruneSettings("keep-daily=7"), jobs[1].PruneBackups)
+}
+
+func TestBackupService_CreateJob(t *testing.T) {
+	var postedURI string
+	var posted url.Values
+	mockHTTP := &mockHTTPService{
+		postFunc: func(uri, payload string, headers map[string]string, cookies []*http.Cookie) (string, error) {
+			postedURI = uri
+			posted, _ = url.ParseQuery(payload)
+			return `{"data": null}`, nil
+		},
+	}
+	enabled := false
+
+	err := newTestService(services.NewBackupServiceWithDeps, mockHTTP).CreateJob("nightly", services.BackupJobOptions{
+		BackupOptions: services.BackupOptions{Storage: "backups", Mode: services.BackupModeSnapshot},
+		Schedule:      "mon..fri 21:00",
+		All:           true,
+		Exclude:       []int{900, 901},
+		PruneBackups:  "keep-daily=7,keep-weekly=4",
+		Enabled:       &enabled,
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "https://localhost:8006/api2/json/cluster/backup", postedURI)
+	assert.Equal(t, url.Values{
+		"id":            {"nightly"},
+		"schedule":      {"mon..fri 21:00"},
+		"all":           {"1"},
+		"exclude":       {"900,901"},
+		"storage":       {"backups"},
+		"mode":          {"snapshot"},
+		"prune-backups": {"keep-daily=7,keep-weekly=4"},
+		"enabled":       {"0"},
+	}, posted)
+}
+
+func TestBackupService_CreateJob_Invalid(t *testing.T) {
+	mockHTTP := &mockHTTPService{
+		postFunc: func(uri, payload string, headers map[string]string, cookies []*http.Cookie) (string, error) {
+			t.Fatal("an invalid job must not be submitted")
+			return "", nil
+		},
+	}
+	backupService := newTestService(services.NewBackupServiceWithDeps, mockHTTP)
+
+	tests := []struct {
+		options services.BackupJobOptions
+		message string
+	}{
+		{services.BackupJobOptions{All: true}, "a schedule is required"},
+		{services.BackupJobOptions{Schedule: "sat 2500", All: true}, `invalid schedule "sat 2500": minute 2500 is out of range 0-59`},
+		{services.BackupJobOptions{Schedule: "daily"}, "a backup job must select guests by VM IDs, pool or all"},
+		{services.BackupJobOptions{Schedule: "daily", All: true, Pool: "prod"}, "select guests by VM IDs, pool or all, not several of them"},
+		{services.BackupJobOptions{Schedule: "daily", Pool: "prod", Exclude: []int{100}}, "excluded VM IDs can only be used when all guests are selected"},
+		{services.BackupJobOptions{Schedule: "daily", VMIDs: []int{100}, PruneBackups: "keep-forever=1"}, `invalid prune-backups setting "keep-forever=1": use keep-all, keep-last, keep-hourly, keep-daily, keep-weekly, keep-monthly, keep-yearly`},
+		{services.BackupJobOptions{Schedule: "daily", VMIDs: []int{100}, PruneBackups: "keep-last"}, `invalid prune-backups setting "keep-last": keep-last needs a count`},
+	}
+	for _, tt := range tests {
+		assert.EqualError(t, backupService.CreateJob("", tt.options), tt.message)
+	}
+}
+
+func TestBackupService_UpdateJob(t *testing.T) {
+	var puttedURI string
+	var putted url.Values
+	mockHTTP := &mockHTTPService{
+		putFunc: func(uri, payload string, headers map[string]string, cookies []*http.Cookie) (string, error) {
+			puttedURI = uri
+			putted, _ = url.ParseQuery(payload)
+			return `{"data": null}`, nil
+		},
+	}
+	backupService := newTestService(services.NewBackupServiceWithDeps, mockHTTP)
+
+	// Selecting guests by pool replaces the previous selection
+	err := backupService.UpdateJob("nightly", services.BackupJobOptions{Schedule: "sat 02:00", Pool: "prod"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "https://localhost:8006/api2/json/cluster/backup/nightly", puttedURI)
+	assert.Equal(t, url.Values{
+		"schedule": {"sat 02:00"},
+		"pool":     {"prod"},
+		"all":      {"0"},
+		"delete":   {"vmid,exclude"},
+	}, putted)
+
+	err = backupService.UpdateJob("nightly", services.BackupJobOptions{Comment: "weekly"})
+	assert.NoError(t, err)
+	assert.Equal(t, url.Values{"comment": {"weekly"}}, putted)
+
+	err = backupService.UpdateJob("nightly", services.BackupJobOptions{})
+	assert.EqualError(t, err, "no changes given for backup job nightly")
+}
+
+func TestBackupService_DeleteJob(t *testing.T) {
+	mockHTTP := &mockHTTPService{
+		deleteFunc: func(uri string, headers map[string]string, cookies []*http.Cookie) (string, error) {
+			assert.Equal(t, "https://localhost:8006/api2/json/cluster/backup/nightly", uri)
+			return `{"data": null}`, nil
+		},
+	}
+
+	assert.NoError(t, newTestService(services.NewBackupServiceWithDeps, mockHTTP).DeleteJob("nightly"))
+}
+
+func TestBackupService_RunJob(t *testing.T) {
+	var postedURIs []string
+	var posted url.Values
+	mockHTTP := &mockHTTPService{
+		getFunc: func(uri string, headers map[string]string, cookies []*http.Cookie) (*http.Response, error) {
+			switch uri {
+			case "https://localhost:8006/api2/json/cluster/backup/nightly":
+				return jsonResponse(`{"data": {"id": "nightly", "type": "vzdump", "schedule": "sat 02:00", "enabled": 1,
+					"all": 1, "storage": "backups", "mode": "snapshot", "prune-backups": {"keep-last": 3}}}`), nil
+			case "https://localhost:8006/api2/json/nodes":
+				return jsonResponse(`{"data": [
+					{"node": "pve2", "status": "online"},
+					{"node": "pve3", "status": "offline"},
+					{"node": "pve1", "status": "online"}
+				]}`), nil
+			}
+			t.Fatalf("unexpected request %s", uri)
+			return nil, nil
+		},
+		postFunc: func(uri, payload string, headers map[string]string, cookies []*http.Cookie) (string, error) {
+			postedURIs = append(postedURIs, uri)
+			posted, _ = url.ParseQuery(payload)
+			return `{"data": "UPID:pve1:00001234:00000000:00000000:vzdump::user@pam:"}`, nil
+		},
+	}
+
+	runs, err := newTestService(services.NewBackupServiceWithDeps, mockHTTP).RunJob("nightly")
+
+	assert.NoError(t, err)
+	assert.Len(t, runs, 2)
+	assert.Equal(t, "pve1", runs[0].Node)
+	assert.Equal(t, "pve2", runs[1].Node)
+	assert.Equal(t, []string{
+		"https://localhost:8006/api2/json/nodes/pve1/vzdump",
+		"https://localhost:8006/api2/json/nodes/pve2/vzdump",
+	}, postedURIs)
+	// Only the vzdump settings of the job are passed on
+	assert.Equal(t, url.Values{
+		"all":           {"1"},
+		"storage":       {"backups"},
+		"mode":          {"snapshot"},
+		"prune-backups": {"keep-last=3"},
+	}, posted)
+}
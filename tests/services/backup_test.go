@@ -0,0 +1,184 @@
+package tests
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"proxmox-cli/services"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackupService_CreateBackup(t *testing.T) {
+	var postedURI string
+	var posted url.Values
+	mockHTTP := &mockHTTPService{
+		postFunc: func(uri, payload string, headers map[string]string, cookies []*http.Cookie) (string, error) {
+			postedURI = uri
+			posted, _ = url.ParseQuery(payload)
+			return `{"data": "UPID:pve1:00001234:00000000:00000000:vzdump:100:user@pam:"}`, nil
+		},
+	}
+
+	taskID, err := newTestService(services.NewBackupServiceWithDeps, mockHTTP).CreateBackup("pve1", 100, services.BackupOptions{
+		Mode:      services.BackupModeStop,
+		Storage:   "backups",
+		Compress:  "zstd",
+		Notes:     "{{guestname}} before upgrade",
+		Protected: true,
+	})
+
+	assert.NoError(t, err)
+	assert.Contains(t, taskID, "vzdump")
+	assert.Equal(t, "https://localhost:8006/api2/json/nodes/pve1/vzdump", postedURI)
+	assert.Equal(t, url.Values{
+		"vmid":           {"100"},
+		"mode":           {"stop"},
+		"storage":        {"backups"},
+		"compress":       {"zstd"},
+		"notes-template": {"{{guestname}} before upgrade"},
+		"protected":      {"1"},
+	}, posted)
+}
+
+func TestBackupService_CreateBackup_InvalidOptions(t *testing.T) {
+	backupService := newTestService(services.NewBackupServiceWithDeps, &mockHTTPService{})
+
+	_, err := backupService.CreateBackup("pve1", 100, services.BackupOptions{Mode: "live"})
+	assert.EqualError(t, err, `invalid backup mode "live": use snapshot, suspend or stop`)
+
+	_, err = backupService.CreateBackup("pve1", 100, services.BackupOptions{Compress: "xz"})
+	assert.EqualError(t, err, `invalid compression "xz": use one of 0, 1, gzip, lzo, zstd`)
+}
+
+func TestBackupService_ListBackups(t *testing.T) {
+	var requested []string
+	mockHTTP := &mockHTTPService{
+		getFunc: func(uri string, headers map[string]string, cookies []*http.Cookie) (*http.Response, error) {
+			requested = append(requested, uri)
+			switch uri {
+			case "https://localhost:8006/api2/json/nodes/pve1/storage?content=backup&enabled=1":
+				return jsonResponse(`{"data": [{"storage": "local", "type": "dir"}, {"storage": "pbs", "type": "pbs"}]}`), nil
+			case "https://localhost:8006/api2/json/nodes/pve1/storage/local/content?content=backup":
+				return jsonResponse(`{"data": [
+					{"volid": "local:backup/vzdump-qemu-100-2024_01_30-02_00_00.vma.zst", "content": "backup", "subtype": "qemu", "vmid": 100, "size": 1024, "ctime": 1706580000}
+				]}`), nil
+			case "https://localhost:8006/api2/json/nodes/pve1/storage/pbs/content?content=backup":
+				return jsonResponse(`{"data": [
+					{"volid": "pbs:backup/ct/200/2024-01-31T02:00:00Z", "content": "backup", "subtype": "lxc", "vmid": 200, "ctime": 1706666400, "notes": "nightly", "protected": 1}
+				]}`), nil
+			}
+			t.Fatalf("unexpected request %s", uri)
+			return nil, nil
+		},
+	}
+
+	backups, err := newTestService(services.NewBackupServiceWithDeps, mockHTTP).ListBackups("pve1", "", 0)
+
+	assert.NoError(t, err)
+	assert.Len(t, requested, 3)
+	assert.Len(t, backups, 2)
+	// The newest backup comes first
+	assert.Equal(t, "pbs:backup/ct/200/2024-01-31T02:00:00Z", backups[0].VolID)
+	assert.Equal(t, "lxc", backups[0].Subtype)
+	assert.Equal(t, "nightly", backups[0].Notes)
+	assert.Equal(t, 1, backups[0].Protected)
+	assert.Equal(t, 100, backups[1].VMID)
+}
+
+func TestBackupService_ListBackups_StorageAndVMID(t *testing.T) {
+	mockHTTP := &mockHTTPService{
+		getFunc: func(uri string, headers map[string]string, cookies []*http.Cookie) (*http.Response, error) {
+			assert.Equal(t, "https://localhost:8006/api2/json/nodes/pve1/storage/local/content?content=backup&vmid=100", uri)
+			return jsonResponse(`{"data": []}`), nil
+		},
+	}
+
+	backups, err := newTestService(services.NewBackupServiceWithDeps, mockHTTP).ListBackups("pve1", "local", 100)
+
+	assert.NoError(t, err)
+	assert.Empty(t, backups)
+}
+
+func TestBackupService_RestoreBackup(t *testing.T) {
+	var postedURI string
+	var posted url.Values
+	mockHTTP := &mockHTTPService{
+		getFunc: func(uri string, headers map[string]string, cookies []*http.Cookie) (*http.Response, error) {
+			assert.Equal(t, "https://localhost:8006/api2/json/cluster/nextid", uri)
+			return jsonResponse(`{"data": "150"}`), nil
+		},
+		postFunc: func(uri, payload string, headers map[string]string, cookies []*http.Cookie) (string, error) {
+			postedURI = uri
+			posted, _ = url.ParseQuery(payload)
+			return `{"data": "UPID:pve1:00001234:00000000:00000000:qmrestore:150:user@pam:"}`, nil
+		},
+	}
+	backupService := newTestService(services.NewBackupServiceWithDeps, mockHTTP)
+
+	guestType, vmid, taskID, err := backupService.RestoreBackup("pve1", "local:backup/vzdump-qemu-100-2024_01_30-02_00_00.vma.zst", services.RestoreOptions{
+		Storage: "local-lvm",
+		Unique:  true,
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, services.GuestTypeQEMU, guestType)
+	assert.Equal(t, 150, vmid)
+	assert.Contains(t, taskID, "qmrestore")
+	assert.Equal(t, "https://localhost:8006/api2/json/nodes/pve1/qemu", postedURI)
+	assert.Equal(t, url.Values{
+		"vmid":    {"150"},
+		"archive": {"local:backup/vzdump-qemu-100-2024_01_30-02_00_00.vma.zst"},
+		"storage": {"local-lvm"},
+		"unique":  {"1"},
+	}, posted)
+
+	guestType, vmid, _, err = backupService.RestoreBackup("pve1", "pbs:backup/ct/200/2024-01-31T02:00:00Z", services.RestoreOptions{VMID: 200, Force: true})
+
+	assert.NoError(t, err)
+	assert.Equal(t, services.GuestTypeLXC, guestType)
+	assert.Equal(t, 200, vmid)
+	assert.Equal(t, "https://localhost:8006/api2/json/nodes/pve1/lxc", postedURI)
+	assert.Equal(t, url.Values{
+		"vmid":       {"200"},
+		"ostemplate": {"pbs:backup/ct/200/2024-01-31T02:00:00Z"},
+		"restore":    {"1"},
+		"force":      {"1"},
+	}, posted)
+}
+
+func TestBackupService_DeleteBackup(t *testing.T) {
+	mockHTTP := &mockHTTPService{
+		deleteFunc: func(uri string, headers map[string]string, cookies []*http.Cookie) (string, error) {
+			assert.Equal(t, "https://localhost:8006/api2/json/nodes/pve1/storage/local/content/local:backup%2Fvzdump-lxc-200-2024_01_30-02_00_00.tar.zst", uri)
+			return `{"data": "UPID:pve1:00001234:00000000:00000000:imgdel:200:user@pam:"}`, nil
+		},
+	}
+	backupService := newTestService(services.NewBackupServiceWithDeps, mockHTTP)
+
+	taskID, err := backupService.DeleteBackup("pve1", "local:backup/vzdump-lxc-200-2024_01_30-02_00_00.tar.zst")
+	assert.NoError(t, err)
+	assert.Contains(t, taskID, "imgdel")
+
+	_, err = backupService.DeleteBackup("pve1", "vzdump-lxc-200.tar.zst")
+	assert.EqualError(t, err, `invalid backup volume "vzdump-lxc-200.tar.zst": expected <storage>:<volume>`)
+}
+
+func TestBackupGuestType(t *testing.T) {
+	tests := map[string]string{
+		"local:backup/vzdump-qemu-100-2024_01_30-02_00_00.vma.zst": services.GuestTypeQEMU,
+		"local:backup/vzdump-lxc-200-2024_01_30-02_00_00.tar.zst":  services.GuestTypeLXC,
+		"local:backup/vzdump-openvz-300-2015_01_30-02_00_00.tar":   services.GuestTypeLXC,
+		"pbs:backup/vm/100/2024-01-31T02:00:00Z":                   services.GuestTypeQEMU,
+		"pbs:backup/ct/200/2024-01-31T02:00:00Z":                   services.GuestTypeLXC,
+	}
+	for volid, want := range tests {
+		guestType, err := services.BackupGuestType(volid)
+		assert.NoError(t, err, volid)
+		assert.Equal(t, want, guestType, volid)
+	}
+
+	_, err := services.BackupGuestType("local:iso/debian-12.iso")
+	assert.Error(t, err)
+}
@@ -0,0 +1,160 @@
+package tests
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"proxmox-cli/services"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+const bulkResourcesResponse = `{"data": [
+	{"id": "node/pve1", "type": "node", "node": "pve1"},
+	{"id": "qemu/100", "type": "qemu", "node": "pve1", "vmid": 100, "name": "web-1", "status": "running", "pool": "prod", "tags": "web;frontend"},
+	{"id": "qemu/101", "type": "qemu", "node": "pve2", "vmid": 101, "name": "web-2", "status": "stopped", "pool": "prod", "tags": "web"},
+	{"id": "qemu/105", "type": "qemu", "node": "pve2", "vmid": 105, "name": "db-1", "status": "running", "pool": "prod", "tags": "db"},
+	{"id": "qemu/120", "type": "qemu", "node": "pve1", "vmid": 120, "name": "web-tpl", "status": "stopped", "template": 1, "tags": "web"},
+	{"id": "qemu/130", "type": "qemu", "node": "pve3", "vmid": 130, "name": "test", "status": "stopped"},
+	{"id": "lxc/110", "type": "lxc", "node": "pve1", "vmid": 110, "name": "web-proxy", "status": "running", "tags": "web"}
+]}`
+
+// selectedIDs returns the VM IDs of the selected guests
+func selectedIDs(guests []services.ClusterResource) []int {
+	ids := []int{}
+	for _, guest := range guests {
+		ids = append(ids, guest.VMID)
+	}
+	return ids
+}
+
+func TestClusterService_SelectGuests(t *testing.T) {
+	body := bulkResourcesResponse
+	gets := 0
+	clusterService := newGuestResolver(t, "", &body, &gets)
+
+	tests := []struct {
+		name     string
+		selector services.GuestSelector
+		want     []int
+	}{
+		{"IDs", services.GuestSelector{Refs: []string{"130", "100"}}, []int{100, 130}},
+		{"range skips templates", services.GuestSelector{Refs: []string{"100-120"}}, []int{100, 101, 105}},
+		{"explicit template ID", services.GuestSelector{Refs: []string{"120"}}, []int{120}},
+		{"name pattern", services.GuestSelector{Refs: []string{"web-*"}}, []int{100, 101}},
+		{"all", services.GuestSelector{All: true}, []int{100, 101, 105, 130}},
+		{"all on a node", services.GuestSelector{All: true, Node: "pve2"}, []int{101, 105}},
+		{"node", services.GuestSelector{Node: "pve1"}, []int{100}},
+		{"pool", services.GuestSelector{Pool: "prod"}, []int{100, 101, 105}},
+		{"tag", services.GuestSelector{Tag: "web"}, []int{100, 101}},
+		{"tag narrows refs", services.GuestSelector{Refs: []string{"100-200"}, Tag: "db"}, []int{105}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			guests, err := clusterService.SelectGuests(services.GuestTypeQEMU, tt.selector)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, selectedIDs(guests))
+		})
+	}
+
+	guests, err := clusterService.SelectGuests(services.GuestTypeLXC, services.GuestSelector{Tag: "web"})
+	assert.NoError(t, err)
+	assert.Equal(t, []int{110}, selectedIDs(guests))
+}
+
+func TestClusterService_SelectGuests_Errors(t *testing.T) {
+	body := bulkResourcesResponse
+	gets := 0
+	clusterService := newGuestResolver(t, "", &body, &gets)
+
+	_, err := clusterService.SelectGuests(services.GuestTypeQEMU, services.GuestSelector{})
+	assert.ErrorContains(t, err, "no VMs selected")
+
+	_, err = clusterService.SelectGuests(services.GuestTypeQEMU, services.GuestSelector{Refs: []string{"100", "999"}})
+	assert.EqualError(t, err, "no VM with ID 999 found in the cluster")
+
+	_, err = clusterService.SelectGuests(services.GuestTypeQEMU, services.GuestSelector{Refs: []string{"120-100"}})
+	assert.EqualError(t, err, `invalid VM ID range "120-100": the start is after the end`)
+
+	_, err = clusterService.SelectGuests(services.GuestTypeQEMU, services.GuestSelector{Refs: []string{"app-*"}})
+	assert.EqualError(t, err, "no VMs match the selection")
+
+	// No request is made for selections that cannot be parsed
+	assert.Equal(t, 2, gets)
+}
+
+func TestRunBulk(t *testing.T) {
+	guests := []services.ClusterResource{
+		{VMID: 100, Name: "web-1", Node: "pve1"},
+		{VMID: 101, Name: "web-2", Node: "pve2"},
+		{VMID: 102, Name: "web-3", Node: "pve1"},
+		{VMID: 103, Name: "web-4", Node: "pve2"},
+		{VMID: 104, Name: "web-5", Node: "pve1"},
+	}
+
+	var mu sync.Mutex
+	running, peak := 0, 0
+	results := services.RunBulk(guests, 2, func(guest services.ClusterResource) (string, error) {
+		mu.Lock()
+		running++
+		peak = max(peak, running)
+		mu.Unlock()
+
+		time.Sleep(10 * time.Millisecond)
+
+		mu.Lock()
+		running--
+		mu.Unlock()
+		if guest.VMID == 102 {
+			return "", errors.New("VM is locked")
+		}
+		return "OK", nil
+	})
+
+	assert.LessOrEqual(t, peak, 2)
+	assert.Len(t, results, 5)
+	for i, result := range results {
+		assert.Equal(t, guests[i].VMID, result.VMID)
+	}
+	assert.Equal(t, services.BulkResult{VMID: 100, Name: "web-1", Node: "pve1", Result: services.BulkOK, Detail: "OK"}, results[0])
+	assert.Equal(t, services.BulkResult{VMID: 102, Name: "web-3", Node: "pve1", Result: services.BulkFailed, Detail: "VM is locked"}, results[2])
+}
+
+func TestRunBulk_SharedSession(t *testing.T) {
+	setupSecretsHome(t)
+	login, err := services.NewSessionService(logrus.New())
+	if err != nil {
+		t.Fatalf("NewSessionService failed: %v", err)
+	}
+	if err = login.WriteSessionFile(ticketSession()); err != nil {
+		t.Fatalf("WriteSessionFile failed: %v", err)
+	}
+	// A fresh SessionService, so the workers are the first to read the session
+	sessionService, err := services.NewSessionService(logrus.New())
+	if err != nil {
+		t.Fatalf("NewSessionService failed: %v", err)
+	}
+
+	// All workers share one VMService, and with it one session, as bulk power actions do
+	vmService := services.NewVMServiceWithDeps(logrus.New(), false, &mockHTTPService{
+		postFunc: func(uri, payload string, headers map[string]string, cookies []*http.Cookie) (string, error) {
+			return `{"data": "` + testUPID + `"}`, nil
+		},
+	}, sessionService)
+
+	guests := []services.ClusterResource{
+		{VMID: 100, Node: "pve1"}, {VMID: 101, Node: "pve1"}, {VMID: 102, Node: "pve2"}, {VMID: 103, Node: "pve2"},
+	}
+	results := services.RunBulk(guests, 4, func(guest services.ClusterResource) (string, error) {
+		return vmService.StartVM(guest.Node, guest.VMID)
+	})
+
+	for _, result := range results {
+		assert.Equal(t, services.BulkOK, result.Result, result.Detail)
+		assert.Equal(t, testUPID, result.Detail)
+	}
+}
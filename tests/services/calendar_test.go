@@ -0,0 +1,48 @@
+package tests
+
+import (
+	"testing"
+
+	"proxmox-cli/services"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateCalendarEvent_Valid(t *testing.T) {
+	for _, event := range []string{
+		"daily",
+		"Weekly",
+		"02:30",
+		"sat 02:00",
+		"mon..fri 21:00",
+		"mon,wed,fri 8..17/2:00",
+		"saturday 03:15:30",
+		"*-*-01 03:00",
+		"2025-06-15 12:00",
+		"12-24 18:00",
+		"0/2:00",
+		"*/15",
+		"15",
+		"sun 04:00 UTC",
+	} {
+		assert.NoError(t, services.ValidateCalendarEvent(event), event)
+	}
+}
+
+func TestValidateCalendarEvent_Invalid(t *testing.T) {
+	tests := map[string]string{
+		"":                "invalid schedule \"\": the schedule is empty",
+		"sometimes":       `invalid schedule "sometimes": unknown weekday "sometimes"`,
+		"fri..mon 02:00":  `invalid schedule "fri..mon 02:00": weekday range "fri..mon" ends before it starts`,
+		"25:00":           `invalid schedule "25:00": hour 25 is out of range 0-23`,
+		"02:60":           `invalid schedule "02:60": minute 60 is out of range 0-59`,
+		"*/0":             `invalid schedule "*/0": invalid minute repetition "*/0"`,
+		"*-13-01 03:00":   `invalid schedule "*-13-01 03:00": month 13 is out of range 1-12`,
+		"17..8:00":        `invalid schedule "17..8:00": hour range "17..8" ends before it starts`,
+		"sat 02:00 03:00": `invalid schedule "sat 02:00 03:00": unexpected "03:00"`,
+		"1:2:3:4":         `invalid schedule "1:2:3:4": time "1:2:3:4" is not HH:MM[:SS]`,
+	}
+	for event, message := range tests {
+		assert.EqualError(t, services.ValidateCalendarEvent(event), message, event)
+	}
+}
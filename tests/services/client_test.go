@@ -0,0 +1,345 @@
+package tests
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"proxmox-cli/services"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestClient(mockHTTP *mockHTTPService, sessionData services.SessionData) *services.APIClient {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	mockSession := &mockSessionService{
+		readSessionFileFunc: func() (services.SessionData, error) {
+			return sessionData, nil
+		},
+	}
+
+	return services.NewAPIClientWithDeps(logger, mockHTTP, mockSession)
+}
+
+func TestAPIClient_Get_DecodesData(t *testing.T) {
+	var requestedURL string
+	var requestCookies []*http.Cookie
+	mockHTTP := &mockHTTPService{
+		getFunc: func(url string, headers map[string]string, cookies []*http.Cookie) (*http.Response, error) {
+			requestedURL = url
+			requestCookies = cookies
+			assert.Empty(t, headers["CSRFPreventionToken"])
+			return jsonResponse(`{"data": [{"node": "pve1", "status": "online"}]}`), nil
+		},
+	}
+
+	client := newTestClient(mockHTTP, getValidSessionData())
+	nodes, err := services.Get[[]services.Node](client, "nodes", url.Values{"type": {"qemu"}})
+
+	assert.NoError(t, err)
+	assert.Len(t, nodes, 1)
+	assert.Equal(t, "pve1", nodes[0].Node)
+	assert.Equal(t, "https://localhost:8006/api2/json/nodes?type=qemu", requestedURL)
+	assert.Len(t, requestCookies, 1)
+	assert.Equal(t, "PVEAuthCookie", requestCookies[0].Name)
+}
+
+func TestAPIClient_Post_SendsFormAndCSRF(t *testing.T) {
+	mockHTTP := &mockHTTPService{
+		postFunc: func(uri, payload string, headers map[string]string, cookies []*http.Cookie) (string, error) {
+			assert.Equal(t, "https://localhost:8006/api2/json/nodes/pve1/qemu/100/status/start", uri)
+			assert.Equal(t, "timeout=30", payload)
+			assert.Equal(t, "csrf123", headers["CSRFPreventionToken"])
+			assert.Contains(t, headers["Content-Type"], "application/x-www-form-urlencoded")
+			return `{"data": "UPID:pve1:0001:0002:0003:qmstart:100:root@pam:"}`, nil
+		},
+	}
+
+	client := newTestClient(mockHTTP, getValidSessionData())
+	upid, err := services.Post[string](client, "nodes/pve1/qemu/100/status/start", url.Values{"timeout": {"30"}})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "UPID:pve1:0001:0002:0003:qmstart:100:root@pam:", upid)
+}
+
+func TestAPIClient_APITokenSession(t *testing.T) {
+	sessionData := services.SessionData{
+		Server:      "localhost",
+		Port:        8006,
+		HttpScheme:  "https",
+		TokenID:     "ci@pve!build",
+		TokenSecret: "secret",
+	}
+
+	mockHTTP := &mockHTTPService{
+		deleteFunc: func(url string, headers map[string]string, cookies []*http.Cookie) (string, error) {
+			assert.Equal(t, "PVEAPIToken=ci@pve!build=secret", headers["Authorization"])
+			assert.Empty(t, headers["CSRFPreventionToken"])
+			assert.Nil(t, cookies)
+			return `{"data": null}`, nil
+		},
+	}
+
+	client := newTestClient(mockHTTP, sessionData)
+	_, err := services.Delete[any](client, "nodes/pve1/qemu/100", nil)
+
+	assert.NoError(t, err)
+}
+
+func TestAPIClient_ParameterErrors(t *testing.T) {
+	mockHTTP := &mockHTTPService{
+		putFunc: func(uri, payload string, headers map[string]string, cookies []*http.Cookie) (string, error) {
+			return `{"data": null, "errors": {"memory": "value must be at least 16", "cores": "invalid format"}}`, nil
+		},
+	}
+
+	client := newTestClient(mockHTTP, getValidSessionData())
+	_, err := services.Put[any](client, "nodes/pve1/qemu/100/config", url.Values{"memory": {"1"}})
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "cores: invalid format; memory: value must be at least 16")
+}
+
+// statefulSession returns a session mock that applies ticket renewals to sessionData
+func statefulSession(sessionData *services.SessionData) *mockSessionService {
+	return &mockSessionService{
+		readSessionFileFunc: func() (services.SessionData, error) {
+			return *sessionData, nil
+		},
+		updateSessionFieldFunc: func(field string, value interface{}) error {
+			switch field {
+			case "response":
+				var resp services.SessionDataResponse
+				if err := json.Unmarshal([]byte(value.(string)), &resp); err != nil {
+					return err
+				}
+				sessionData.Response = resp
+			case "issuedAt":
+				sessionData.IssuedAt = value.(int64)
+			}
+			return nil
+		},
+	}
+}
+
+const renewedTicketBody = `{"data": {"username": "user@pam", "ticket": "ticket456", "CSRFPreventionToken": "csrf456"}}`
+
+func TestAPIClient_RenewsOldTicket(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	sessionData := getValidSessionData()
+	sessionData.IssuedAt = time.Now().Add(-100 * time.Minute).Unix()
+
+	var renewPayload string
+	var usedTicket string
+	mockHTTP := &mockHTTPService{
+		postFunc: func(uri, payload string, headers map[string]string, cookies []*http.Cookie) (string, error) {
+			assert.Equal(t, "https://localhost:8006/api2/json/access/ticket", uri)
+			renewPayload = payload
+			return renewedTicketBody, nil
+		},
+		getFunc: func(url string, headers map[string]string, cookies []*http.Cookie) (*http.Response, error) {
+			usedTicket = cookies[0].Value
+			return jsonResponse(`{"data": []}`), nil
+		},
+	}
+
+	client := services.NewAPIClientWithDeps(logger, mockHTTP, statefulSession(&sessionData))
+	_, err := services.Get[[]services.Node](client, "nodes", nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "password=ticket123&username=user%40pam", renewPayload)
+	assert.Equal(t, "ticket456", usedTicket)
+	assert.WithinDuration(t, time.Now(), time.Unix(sessionData.IssuedAt, 0), time.Minute)
+}
+
+func TestAPIClient_LoadSession(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	sessionData := getValidSessionData()
+	sessionData.IssuedAt = time.Now().Add(-100 * time.Minute).Unix()
+
+	renewals := 0
+	mockHTTP := &mockHTTPService{
+		postFunc: func(uri, payload string, headers map[string]string, cookies []*http.Cookie) (string, error) {
+			renewals++
+			return renewedTicketBody, nil
+		},
+	}
+
+	client := services.NewAPIClientWithDeps(logger, mockHTTP, statefulSession(&sessionData))
+	assert.NoError(t, client.LoadSession())
+	assert.Equal(t, 1, renewals)
+	assert.Equal(t, "ticket456", sessionData.Response.Data.Ticket)
+
+	// A fresh ticket is left alone
+	assert.NoError(t, client.LoadSession())
+	assert.Equal(t, 1, renewals)
+}
+
+func TestAPIClient_RetriesAfterUnauthorized(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	sessionData := getValidSessionData()
+
+	renewals := 0
+	var usedTickets []string
+	mockHTTP := &mockHTTPService{
+		postFunc: func(uri, payload string, headers map[string]string, cookies []*http.Cookie) (string, error) {
+			renewals++
+			return renewedTicketBody, nil
+		},
+		getFunc: func(url string, headers map[string]string, cookies []*http.Cookie) (*http.Response, error) {
+			usedTickets = append(usedTickets, cookies[0].Value)
+			if cookies[0].Value == "ticket123" {
+				return &http.Response{
+					StatusCode: 401,
+					Status:     "401 permission denied - invalid PVE ticket",
+					Body:       io.NopCloser(strings.NewReader("")),
+				}, nil
+			}
+			return jsonResponse(`{"data": [{"node": "pve1"}]}`), nil
+		},
+	}
+
+	client := services.NewAPIClientWithDeps(logger, mockHTTP, statefulSession(&sessionData))
+	nodes, err := services.Get[[]services.Node](client, "nodes", nil)
+
+	assert.NoError(t, err)
+	assert.Len(t, nodes, 1)
+	assert.Equal(t, 1, renewals)
+	assert.Equal(t, []string{"ticket123", "ticket456"}, usedTickets)
+}
+
+func TestAPIClient_UnauthorizedWhenRenewalFails(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	sessionData := getValidSessionData()
+
+	mockHTTP := &mockHTTPService{
+		postFunc: func(uri, payload string, headers map[string]string, cookies []*http.Cookie) (string, error) {
+			return "", services.NewAPIError(401, "401 authentication failure", nil)
+		},
+		getFunc: func(url string, headers map[string]string, cookies []*http.Cookie) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: 401,
+				Status:     "401 permission denied - invalid PVE ticket",
+				Body:       io.NopCloser(strings.NewReader("")),
+			}, nil
+		},
+	}
+
+	client := services.NewAPIClientWithDeps(logger, mockHTTP, statefulSession(&sessionData))
+	_, err := services.Get[[]services.Node](client, "nodes", nil)
+
+	apiErr, ok := services.AsAPIError(err)
+	assert.True(t, ok)
+	assert.Equal(t, "permission denied - invalid PVE ticket", apiErr.Message)
+}
+
+func TestAPIClient_ConcurrentRequestsRenewOnce(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	const workers = 4
+	sessionData := getValidSessionData()
+	sessionData.IssuedAt = time.Now().Add(-100 * time.Minute).Unix()
+
+	// The session mock is shared by the workers, so guard it like the session file
+	var mu sync.Mutex
+	reads := 0
+	stateful := statefulSession(&sessionData)
+	session := &mockSessionService{
+		readSessionFileFunc: func() (services.SessionData, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			reads++
+			return stateful.ReadSessionFile()
+		},
+		updateSessionFieldFunc: func(field string, value interface{}) error {
+			mu.Lock()
+			defer mu.Unlock()
+			return stateful.UpdateSessionField(field, value)
+		},
+	}
+
+	renewals := 0
+	mockHTTP := &mockHTTPService{
+		postFunc: func(uri, payload string, headers map[string]string, cookies []*http.Cookie) (string, error) {
+			// Renew only once every worker has seen the old ticket
+			for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+				mu.Lock()
+				seen := reads
+				mu.Unlock()
+				if seen >= workers {
+					break
+				}
+			}
+			mu.Lock()
+			renewals++
+			mu.Unlock()
+			return renewedTicketBody, nil
+		},
+		getFunc: func(url string, headers map[string]string, cookies []*http.Cookie) (*http.Response, error) {
+			return jsonResponse(`{"data": []}`), nil
+		},
+	}
+
+	client := services.NewAPIClientWithDeps(logger, mockHTTP, session)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := services.Get[[]services.Node](client, "nodes", nil)
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 1, renewals)
+}
+
+func TestAPIClient_RetriesPostAfterUnauthorized(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	sessionData := getValidSessionData()
+
+	renewals := 0
+	var vzdumpTickets []string
+	mockHTTP := &mockHTTPService{
+		postFunc: func(uri, payload string, headers map[string]string, cookies []*http.Cookie) (string, error) {
+			if uri == "https://localhost:8006/api2/json/access/ticket" {
+				renewals++
+				return renewedTicketBody, nil
+			}
+			vzdumpTickets = append(vzdumpTickets, cookies[0].Value)
+			if cookies[0].Value == "ticket123" {
+				return "", services.NewAPIError(401, "401 permission denied - invalid PVE ticket", nil)
+			}
+			assert.Equal(t, "csrf456", headers["CSRFPreventionToken"])
+			return `{"data": "UPID:pve1:00001234:00000001:65F1A2B3:vzdump:100:root@pam:"}`, nil
+		},
+	}
+
+	client := services.NewAPIClientWithDeps(logger, mockHTTP, statefulSession(&sessionData))
+	upid, err := services.Post[string](client, "nodes/pve1/vzdump", url.Values{"vmid": {"100"}})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "UPID:pve1:00001234:00000001:65F1A2B3:vzdump:100:root@pam:", upid)
+	assert.Equal(t, 1, renewals)
+	assert.Equal(t, []string{"ticket123", "ticket456"}, vzdumpTickets)
+}
@@ -0,0 +1,148 @@
+package tests
+
+import (
+	"io"
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"proxmox-cli/services"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+const clusterGuestsResponse = `{"data": [
+	{"id": "node/pve1", "type": "node", "node": "pve1"},
+	{"id": "qemu/100", "type": "qemu", "node": "pve1", "vmid": 100, "name": "web"},
+	{"id": "qemu/101", "type": "qemu", "node": "pve2", "vmid": 101, "name": "db"},
+	{"id": "qemu/102", "type": "qemu", "node": "pve3", "vmid": 102, "name": "db"},
+	{"id": "lxc/200", "type": "lxc", "node": "pve2", "vmid": 200, "name": "proxy"},
+	{"id": "storage/pve1/local", "type": "storage", "node": "pve1"}
+]}`
+
+// newGuestResolver returns a ClusterService answering cluster/resources with body and
+// counting the requests in gets
+func newGuestResolver(t *testing.T, cachePath string, body *string, gets *int) *services.ClusterService {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	mockHTTP := &mockHTTPService{
+		getFunc: func(uri string, headers map[string]string, cookies []*http.Cookie) (*http.Response, error) {
+			assert.Equal(t, "https://localhost:8006/api2/json/cluster/resources", uri)
+			*gets++
+			return jsonResponse(*body), nil
+		},
+	}
+	mockSession := &mockSessionService{
+		readSessionFileFunc: func() (services.SessionData, error) {
+			return getValidSessionData(), nil
+		},
+	}
+
+	clusterService := services.NewClusterServiceWithDeps(logger, true, mockHTTP, mockSession)
+	clusterService.GuestCachePath = cachePath
+	return clusterService
+}
+
+func TestClusterService_ResolveGuest(t *testing.T) {
+	body := clusterGuestsResponse
+	gets := 0
+	clusterService := newGuestResolver(t, "", &body, &gets)
+
+	location, err := clusterService.ResolveGuest(services.GuestTypeQEMU, "100", "")
+	assert.NoError(t, err)
+	assert.Equal(t, services.GuestLocation{Type: "qemu", VMID: 100, Name: "web", Node: "pve1"}, *location)
+
+	location, err = clusterService.ResolveGuest(services.GuestTypeQEMU, "web", "")
+	assert.NoError(t, err)
+	assert.Equal(t, 100, location.VMID)
+
+	location, err = clusterService.ResolveGuest(services.GuestTypeLXC, "proxy", "")
+	assert.NoError(t, err)
+	assert.Equal(t, "pve2", location.Node)
+
+	// The node disambiguates names used more than once
+	location, err = clusterService.ResolveGuest(services.GuestTypeQEMU, "db", "pve3")
+	assert.NoError(t, err)
+	assert.Equal(t, 102, location.VMID)
+
+	// Without a guest type VMs and containers match
+	location, err = clusterService.ResolveGuest("", "200", "")
+	assert.NoError(t, err)
+	assert.Equal(t, services.GuestTypeLXC, location.Type)
+
+	// The resources are only read once within the cache lifetime
+	assert.Equal(t, 1, gets)
+}
+
+func TestClusterService_ResolveGuest_Errors(t *testing.T) {
+	body := clusterGuestsResponse
+	gets := 0
+	clusterService := newGuestResolver(t, "", &body, &gets)
+
+	_, err := clusterService.ResolveGuest(services.GuestTypeQEMU, "db", "")
+	assert.EqualError(t, err, `VM name "db" is ambiguous, it matches 101 on pve2, 102 on pve3; use the VM ID instead`)
+
+	_, err = clusterService.ResolveGuest(services.GuestTypeQEMU, "200", "")
+	assert.EqualError(t, err, "200 is a container, not a VM")
+
+	_, err = clusterService.ResolveGuest(services.GuestTypeQEMU, "999", "")
+	assert.EqualError(t, err, "no VM with ID 999 found in the cluster")
+
+	_, err = clusterService.ResolveGuest(services.GuestTypeLXC, "web", "")
+	assert.EqualError(t, err, `no container named "web" found in the cluster`)
+
+	_, err = clusterService.ResolveGuest(services.GuestTypeQEMU, "web", "pve2")
+	assert.EqualError(t, err, `no VM named "web" found on node pve2`)
+}
+
+func TestClusterService_ResolveGuest_FileCache(t *testing.T) {
+	cachePath := filepath.Join(t.TempDir(), "cache", "default-guests.json")
+	body := clusterGuestsResponse
+	gets := 0
+
+	location, err := newGuestResolver(t, cachePath, &body, &gets).ResolveGuest(services.GuestTypeQEMU, "web", "")
+	assert.NoError(t, err)
+	assert.Equal(t, "pve1", location.Node)
+	assert.FileExists(t, cachePath)
+
+	// A later invocation reuses the cached locations
+	body = `{"data": [{"id": "qemu/100", "type": "qemu", "node": "pve2", "vmid": 100, "name": "web"},
+		{"id": "qemu/300", "type": "qemu", "node": "pve1", "vmid": 300, "name": "new"}]}`
+	clusterService := newGuestResolver(t, cachePath, &body, &gets)
+	location, err = clusterService.ResolveGuest(services.GuestTypeQEMU, "web", "")
+	assert.NoError(t, err)
+	assert.Equal(t, "pve1", location.Node)
+	assert.Equal(t, 1, gets)
+
+	// Guests missing from the cache are looked up again
+	location, err = clusterService.ResolveGuest(services.GuestTypeQEMU, "new", "")
+	assert.NoError(t, err)
+	assert.Equal(t, 300, location.VMID)
+	assert.Equal(t, 2, gets)
+
+	// Forgetting the cache makes the next lookup read the current locations
+	clusterService.ForgetGuests()
+	assert.NoFileExists(t, cachePath)
+	location, err = newGuestResolver(t, cachePath, &body, &gets).ResolveGuest(services.GuestTypeQEMU, "100", "")
+	assert.NoError(t, err)
+	assert.Equal(t, "pve2", location.Node)
+	assert.Equal(t, 3, gets)
+}
+
+func TestClusterService_ResolveGuest_ExpiredCache(t *testing.T) {
+	cachePath := filepath.Join(t.TempDir(), "default-guests.json")
+	body := clusterGuestsResponse
+	gets := 0
+
+	ttl := services.GuestCacheTTL
+	services.GuestCacheTTL = 0
+	defer func() { services.GuestCacheTTL = ttl }()
+
+	for i := 0; i < 2; i++ {
+		_, err := newGuestResolver(t, cachePath, &body, &gets).ResolveGuest(services.GuestTypeQEMU, "web", "")
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, 2, gets)
+}
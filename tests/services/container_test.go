@@ -0,0 +1,233 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"proxmox-cli/services"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContainerService_ListContainers(t *testing.T) {
+	mockHTTP := &mockHTTPService{
+		getFunc: func(uri string, headers map[string]string, cookies []*http.Cookie) (*http.Response, error) {
+			assert.Equal(t, "https://localhost:8006/api2/json/nodes/pve1/lxc", uri)
+			// Older Proxmox versions return the VM ID as a string
+			return jsonResponse(`{"data": [
+				{"vmid": "200", "name": "web", "status": "running", "cpus": 2, "maxmem": 1073741824},
+				{"vmid": 201, "name": "db", "status": "stopped"}
+			]}`), nil
+		},
+	}
+
+	containers, err := newTestService(services.NewContainerServiceWithDeps, mockHTTP).ListContainers("pve1")
+
+	assert.NoError(t, err)
+	assert.Len(t, containers, 2)
+	assert.Equal(t, 200, containers[0].VMID)
+	assert.Equal(t, "web", containers[0].Name)
+	assert.Equal(t, float64(2), containers[0].CPUs)
+	assert.Equal(t, int64(1073741824), containers[0].MaxMem)
+	assert.Equal(t, 201, containers[1].VMID)
+	assert.Equal(t, "stopped", containers[1].Status)
+}
+
+func TestContainerService_StatusActions(t *testing.T) {
+	var postedURIs []string
+	mockHTTP := &mockHTTPService{
+		postFunc: func(uri, payload string, headers map[string]string, cookies []*http.Cookie) (string, error) {
+			postedURIs = append(postedURIs, uri)
+			return `{"data": "UPID:pve1:00001234:00000000:00000000:vzstart:200:user@pam:"}`, nil
+		},
+	}
+	containerService := newTestService(services.NewContainerServiceWithDeps, mockHTTP)
+
+	actions := []func(string, int) (string, error){
+		containerService.StartContainer,
+		containerService.StopContainer,
+		containerService.ShutdownContainer,
+		containerService.RebootContainer,
+		containerService.SuspendContainer,
+		containerService.ResumeContainer,
+	}
+	for _, action := range actions {
+		taskID, err := action("pve1", 200)
+		assert.NoError(t, err)
+		assert.Contains(t, taskID, "UPID:pve1")
+	}
+
+	base := "https://localhost:8006/api2/json/nodes/pve1/lxc/200/status/"
+	assert.Equal(t, []string{
+		base + "start", base + "stop", base + "shutdown", base + "reboot", base + "suspend", base + "resume",
+	}, postedURIs)
+}
+
+func TestContainerService_CreateContainer(t *testing.T) {
+	var postedURI string
+	var posted url.Values
+	mockHTTP := &mockHTTPService{
+		getFunc: func(uri string, headers map[string]string, cookies []*http.Cookie) (*http.Response, error) {
+			assert.Equal(t, "https://localhost:8006/api2/json/cluster/nextid", uri)
+			return jsonResponse(`{"data": "210"}`), nil
+		},
+		postFunc: func(uri, payload string, headers map[string]string, cookies []*http.Cookie) (string, error) {
+			postedURI = uri
+			posted, _ = url.ParseQuery(payload)
+			return `{"data": "UPID:pve1:00001234:00000000:00000000:vzcreate:210:user@pam:"}`, nil
+		},
+	}
+
+	vmid, taskID, err := newTestService(services.NewContainerServiceWithDeps, mockHTTP).CreateContainer("pve1", 0, services.ContainerConfig{
+		OSTemplate:    "local:vztmpl/debian-12-standard_12.2-1_amd64.tar.zst",
+		Hostname:      "web",
+		Memory:        1024,
+		Cores:         2,
+		RootFS:        "local-lvm:8",
+		Unprivileged:  true,
+		SSHPublicKeys: "ssh-ed25519 AAAA user@host",
+		MountPoints:   map[string]string{"mp0": "local-lvm:16,mp=/data"},
+		Networks:      map[string]string{"net0": "name=eth0,bridge=vmbr0,ip=dhcp"},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 210, vmid)
+	assert.Contains(t, taskID, "vzcreate")
+	assert.Equal(t, "https://localhost:8006/api2/json/nodes/pve1/lxc", postedURI)
+	assert.Equal(t, "210", posted.Get("vmid"))
+	assert.Equal(t, "local:vztmpl/debian-12-standard_12.2-1_amd64.tar.zst", posted.Get("ostemplate"))
+	assert.Equal(t, "web", posted.Get("hostname"))
+	assert.Equal(t, "1024", posted.Get("memory"))
+	assert.Equal(t, "2", posted.Get("cores"))
+	assert.NotContains(t, posted, "swap")
+	assert.Equal(t, "local-lvm:8", posted.Get("rootfs"))
+	assert.Equal(t, "1", posted.Get("unprivileged"))
+	assert.Equal(t, "ssh-ed25519 AAAA user@host", posted.Get("ssh-public-keys"))
+	assert.Equal(t, "local-lvm:16,mp=/data", posted.Get("mp0"))
+	assert.Equal(t, "name=eth0,bridge=vmbr0,ip=dhcp", posted.Get("net0"))
+}
+
+func TestContainerService_CreateContainer_Invalid(t *testing.T) {
+	containerService := newTestService(services.NewContainerServiceWithDeps, &mockHTTPService{})
+
+	_, _, err := containerService.CreateContainer("pve1", 200, services.ContainerConfig{Hostname: "web"})
+	assert.ErrorContains(t, err, "OS template")
+
+	_, _, err = containerService.CreateContainer("pve1", 200, services.ContainerConfig{
+		OSTemplate:  "local:vztmpl/debian.tar.zst",
+		MountPoints: map[string]string{"data": "local-lvm:16,mp=/data"},
+	})
+	assert.ErrorContains(t, err, "invalid mount point slot")
+}
+
+func TestContainerConfig_UnmarshalJSON(t *testing.T) {
+	var config services.ContainerConfig
+	err := json.Unmarshal([]byte(`{
+		"hostname": "web", "memory": 1024, "swap": 512, "cores": 2, "ostype": "debian",
+		"arch": "amd64", "unprivileged": 1, "rootfs": "local-lvm:vm-200-disk-0,size=8G",
+		"mp0": "local-lvm:vm-200-disk-1,mp=/data,size=16G", "net0": "name=eth0,bridge=vmbr0,ip=dhcp",
+		"features": "nesting=1", "onboot": 1, "digest": "abc123"
+	}`), &config)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "web", config.Hostname)
+	assert.Equal(t, 1024, config.Memory)
+	assert.Equal(t, 512, config.Swap)
+	assert.Equal(t, 2, config.Cores)
+	assert.True(t, config.Unprivileged)
+	assert.Equal(t, "local-lvm:vm-200-disk-0,size=8G", config.RootFS)
+	assert.Equal(t, map[string]string{"mp0": "local-lvm:vm-200-disk-1,mp=/data,size=16G"}, config.MountPoints)
+	assert.Equal(t, map[string]string{"net0": "name=eth0,bridge=vmbr0,ip=dhcp"}, config.Networks)
+	assert.Equal(t, map[string]string{"features": "nesting=1", "onboot": "1"}, config.Other)
+	assert.Equal(t, "abc123", config.Digest)
+}
+
+func TestContainerService_UpdateConfig(t *testing.T) {
+	var putURI string
+	var put url.Values
+	mockHTTP := &mockHTTPService{
+		putFunc: func(uri, payload string, headers map[string]string, cookies []*http.Cookie) (string, error) {
+			putURI = uri
+			put, _ = url.ParseQuery(payload)
+			return `{"data": null}`, nil
+		},
+	}
+
+	err := newTestService(services.NewContainerServiceWithDeps, mockHTTP).UpdateConfig("pve1", 200, services.ConfigUpdate{
+		Set:    map[string]string{"memory": "2048"},
+		Delete: []string{"mp0"},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "https://localhost:8006/api2/json/nodes/pve1/lxc/200/config", putURI)
+	assert.Equal(t, "2048", put.Get("memory"))
+	assert.Equal(t, "mp0", put.Get("delete"))
+}
+
+func TestContainerService_CreateSnapshot_RejectsVMState(t *testing.T) {
+	_, err := newTestService(services.NewContainerServiceWithDeps, &mockHTTPService{}).CreateSnapshot("pve1", 200, "base", "", true)
+
+	assert.ErrorContains(t, err, "cannot include RAM")
+}
+
+func TestContainerService_CloneAndTemplate(t *testing.T) {
+	var postedURIs []string
+	var cloned url.Values
+	mockHTTP := &mockHTTPService{
+		postFunc: func(uri, payload string, headers map[string]string, cookies []*http.Cookie) (string, error) {
+			postedURIs = append(postedURIs, uri)
+			if len(postedURIs) == 1 {
+				cloned, _ = url.ParseQuery(payload)
+				return `{"data": "UPID:pve1:00001234:00000000:00000000:vzclone:9000:user@pam:"}`, nil
+			}
+			// Converting a container to a template does not start a task
+			return `{"data": null}`, nil
+		},
+	}
+	containerService := newTestService(services.NewContainerServiceWithDeps, mockHTTP)
+
+	newID, _, err := containerService.Clone("pve1", 9000, services.CloneOptions{NewID: 220, Name: "test-42"})
+	assert.NoError(t, err)
+	assert.Equal(t, 220, newID)
+	assert.Equal(t, "test-42", cloned.Get("hostname"))
+	assert.NotContains(t, cloned, "name")
+
+	assert.NoError(t, containerService.ConvertToTemplate("pve1", 220))
+	assert.Equal(t, []string{
+		"https://localhost:8006/api2/json/nodes/pve1/lxc/9000/clone",
+		"https://localhost:8006/api2/json/nodes/pve1/lxc/220/template",
+	}, postedURIs)
+}
+
+func TestContainerService_Migrate(t *testing.T) {
+	var postedURI string
+	var posted url.Values
+	mockHTTP := &mockHTTPService{
+		postFunc: func(uri, payload string, headers map[string]string, cookies []*http.Cookie) (string, error) {
+			postedURI = uri
+			posted, _ = url.ParseQuery(payload)
+			return `{"data": "UPID:pve1:00001234:00000000:00000000:vzmigrate:200:user@pam:"}`, nil
+		},
+	}
+	containerService := newTestService(services.NewContainerServiceWithDeps, mockHTTP)
+
+	taskID, err := containerService.Migrate("pve1", 200, services.ContainerMigrateOptions{
+		Target:        "pve2",
+		Restart:       true,
+		Timeout:       60,
+		TargetStorage: "local-lvm",
+	})
+
+	assert.NoError(t, err)
+	assert.Contains(t, taskID, "vzmigrate")
+	assert.Equal(t, "https://localhost:8006/api2/json/nodes/pve1/lxc/200/migrate", postedURI)
+	assert.Equal(t, "pve2", posted.Get("target"))
+	assert.Equal(t, "1", posted.Get("restart"))
+	assert.Equal(t, "60", posted.Get("timeout"))
+	assert.Equal(t, "local-lvm", posted.Get("target-storage"))
+
+	_, err = containerService.Migrate("pve1", 200, services.ContainerMigrateOptions{Target: "pve2", Timeout: 60})
+	assert.ErrorContains(t, err, "restart migration")
+}
@@ -0,0 +1,103 @@
+package tests
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"proxmox-cli/services"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewAPIError_StatusLine(t *testing.T) {
+	apiErr := services.NewAPIError(401, "401 permission denied - invalid PVE ticket", []byte(""))
+
+	assert.Equal(t, 401, apiErr.StatusCode)
+	assert.Equal(t, "permission denied - invalid PVE ticket", apiErr.Message)
+	assert.True(t, apiErr.IsUnauthorized())
+	assert.Equal(t, "Proxmox API error 401: permission denied - invalid PVE ticket", apiErr.Error())
+}
+
+func TestNewAPIError_ParameterErrors(t *testing.T) {
+	body := `{"data": null, "errors": {"memory": "value must be at least 16\n"}}`
+	apiErr := services.NewAPIError(400, "400 Parameter verification failed.", []byte(body))
+
+	assert.Equal(t, "Parameter verification failed.", apiErr.Message)
+	assert.Equal(t, "Proxmox API error 400: Parameter verification failed. (memory: value must be at least 16)", apiErr.Error())
+}
+
+func TestNewAPIError_HTMLBody(t *testing.T) {
+	apiErr := services.NewAPIError(500, "500", []byte("<html>Internal error</html>"))
+
+	assert.Equal(t, "Internal Server Error", apiErr.Message)
+	assert.Nil(t, apiErr.Errors)
+}
+
+func TestAsAPIError_Wrapped(t *testing.T) {
+	err := fmt.Errorf("starting VM: %w", &services.APIError{StatusCode: 500, Message: "boom"})
+
+	apiErr, ok := services.AsAPIError(err)
+	assert.True(t, ok)
+	assert.Equal(t, 500, apiErr.StatusCode)
+
+	_, ok = services.AsAPIError(assert.AnError)
+	assert.False(t, ok)
+}
+
+func TestVMService_ListVMs_ErrorStatus(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	mockHTTP := &mockHTTPService{
+		getFunc: func(url string, headers map[string]string, cookies []*http.Cookie) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: 403,
+				Status:     "403 Permission check failed (/vms, VM.Audit)",
+				Body:       io.NopCloser(strings.NewReader(`{"data":null}`)),
+			}, nil
+		},
+	}
+
+	mockSession := &mockSessionService{
+		readSessionFileFunc: func() (services.SessionData, error) {
+			return getValidSessionData(), nil
+		},
+	}
+
+	vmService := services.NewVMServiceWithDeps(logger, true, mockHTTP, mockSession)
+	vms, err := vmService.ListVMs("pve1")
+
+	assert.Nil(t, vms)
+	apiErr, ok := services.AsAPIError(err)
+	assert.True(t, ok)
+	assert.True(t, apiErr.IsForbidden())
+	assert.Equal(t, "Permission check failed (/vms, VM.Audit)", apiErr.Message)
+}
+
+func TestVMService_StartVM_ErrorStatus(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	mockHTTP := &mockHTTPService{
+		postFunc: func(uri, payload string, headers map[string]string, cookies []*http.Cookie) (string, error) {
+			body := `{"data":null}`
+			return body, services.NewAPIError(500, "500 VM 100 already running", []byte(body))
+		},
+	}
+
+	mockSession := &mockSessionService{
+		readSessionFileFunc: func() (services.SessionData, error) {
+			return getValidSessionData(), nil
+		},
+	}
+
+	vmService := services.NewVMServiceWithDeps(logger, true, mockHTTP, mockSession)
+	taskID, err := vmService.StartVM("pve1", 100)
+
+	assert.Empty(t, taskID)
+	assert.EqualError(t, err, "Proxmox API error 500: VM 100 already running")
+}
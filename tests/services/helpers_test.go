@@ -0,0 +1,27 @@
+package tests
+
+import (
+	"io"
+
+	"proxmox-cli/services"
+
+	"github.com/sirupsen/logrus"
+)
+
+// newTestService creates a service with newService, a discarding logger, mockHTTP and a
+// session mock returning getValidSessionData, e.g.
+// newTestService(services.NewContainerServiceWithDeps, mockHTTP)
+func newTestService[T any](
+	newService func(*logrus.Logger, bool, services.HTTPServiceInterface, services.SessionServiceInterface) T,
+	mockHTTP *mockHTTPService,
+) T {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	mockSession := &mockSessionService{
+		readSessionFileFunc: func() (services.SessionData, error) {
+			return getValidSessionData(), nil
+		},
+	}
+	return newService(logger, true, mockHTTP, mockSession)
+}
@@ -227,3 +227,26 @@ func TestHttpService_Delete_WithHeadersAndCookies(t *testing.T) {
 		t.Errorf("expected response 'deleted', got '%s'", resp)
 	}
 }
+
+func TestHttpService_Post_ErrorStatus(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"data":null,"errors":{"vmid":"invalid format - value does not look like a valid VM ID"}}`))
+	}))
+	defer ts.Close()
+
+	logger := logrus.New()
+	httpService := services.NewHttpService(logger, false)
+	_, err := httpService.Post(ts.URL, "", nil, nil)
+	apiErr, ok := services.AsAPIError(err)
+	if !ok {
+		t.Fatalf("expected an APIError, got %v", err)
+	}
+	if apiErr.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected status 400, got %d", apiErr.StatusCode)
+	}
+	if apiErr.Errors["vmid"] == "" {
+		t.Errorf("expected the vmid parameter error, got %v", apiErr.Errors)
+	}
+}
@@ -0,0 +1,196 @@
+package tests
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"proxmox-cli/services"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNodesService_ListNetworkInterfaces(t *testing.T) {
+	mockHTTP := &mockHTTPService{
+		getFunc: func(uri string, headers map[string]string, cookies []*http.Cookie) (*http.Response, error) {
+			assert.Equal(t, "https://localhost:8006/api2/json/nodes/pve1/network?type=bridge", uri)
+			return jsonResponse(`{"data": [
+				{"iface": "vmbr1", "type": "bridge", "bridge_ports": "bond0", "bridge_vlan_aware": 1, "autostart": 1},
+				{"iface": "vmbr0", "type": "bridge", "bridge_ports": "eno1", "cidr": "192.168.1.10/24", "gateway": "192.168.1.1", "active": 1, "autostart": 1}
+			], "changes": "--- /etc/network/interfaces\n+++ /etc/network/interfaces.new\n+auto vmbr1\n"}`), nil
+		},
+	}
+
+	ifaces, changes, err := newTestService(services.NewNodesServiceWithDeps, mockHTTP).ListNetworkInterfaces("pve1", services.NetworkBridge)
+
+	assert.NoError(t, err)
+	assert.Len(t, ifaces, 2)
+	assert.Equal(t, "vmbr0", ifaces[0].Iface)
+	assert.Equal(t, "192.168.1.1", ifaces[0].Gateway)
+	assert.Equal(t, 1, ifaces[1].BridgeVLANAware)
+	assert.Contains(t, changes, "+auto vmbr1")
+}
+
+func TestNodesService_CreateNetworkInterface(t *testing.T) {
+	autostart := true
+	tests := []struct {
+		name      string
+		iface     string
+		ifaceType string
+		options   services.NetworkInterfaceOptions
+		want      url.Values
+	}{
+		{
+			name:      "bridge",
+			iface:     "vmbr1",
+			ifaceType: services.NetworkBridge,
+			options: services.NetworkInterfaceOptions{
+				BridgePorts: []string{"eno2", "eno3"}, BridgeVLANAware: true, CIDR: "10.0.0.2/24", Gateway: "10.0.0.1", Autostart: &autostart,
+			},
+			want: url.Values{
+				"bridge_ports": {"eno2 eno3"}, "bridge_vlan_aware": {"1"}, "cidr": {"10.0.0.2/24"}, "gateway": {"10.0.0.1"}, "autostart": {"1"},
+			},
+		},
+		{
+			name:      "bond",
+			iface:     "bond0",
+			ifaceType: services.NetworkBond,
+			options: services.NetworkInterfaceOptions{
+				Slaves: []string{"eno1", "eno2"}, BondMode: "802.3ad", BondXmitHashPolicy: "layer3+4", MTU: 9000,
+			},
+			want: url.Values{"slaves": {"eno1 eno2"}, "bond_mode": {"802.3ad"}, "bond_xmit_hash_policy": {"layer3+4"}, "mtu": {"9000"}},
+		},
+		{
+			name:      "vlan",
+			iface:     "vlan100",
+			ifaceType: services.NetworkVLAN,
+			options:   services.NetworkInterfaceOptions{VLANRawDevice: "vmbr0", VLANID: 100, CIDR6: "fd00::2/64", Gateway6: "fd00::1"},
+			want:      url.Values{"vlan-raw-device": {"vmbr0"}, "vlan-id": {"100"}, "cidr6": {"fd00::2/64"}, "gateway6": {"fd00::1"}},
+		},
+		{
+			name:      "vlan named after its device",
+			iface:     "eno1.200",
+			ifaceType: services.NetworkVLAN,
+			options:   services.NetworkInterfaceOptions{Comments: "storage"},
+			want:      url.Values{"comments": {"storage"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var posted url.Values
+			mockHTTP := &mockHTTPService{
+				postFunc: func(uri, payload string, headers map[string]string, cookies []*http.Cookie) (string, error) {
+					assert.Equal(t, "https://localhost:8006/api2/json/nodes/pve1/network", uri)
+					posted, _ = url.ParseQuery(payload)
+					return `{"data": null}`, nil
+				},
+			}
+
+			err := newTestService(services.NewNodesServiceWithDeps, mockHTTP).CreateNetworkInterface("pve1", tt.iface, tt.ifaceType, tt.options)
+
+			assert.NoError(t, err)
+			tt.want.Set("iface", tt.iface)
+			tt.want.Set("type", tt.ifaceType)
+			assert.Equal(t, tt.want, posted)
+		})
+	}
+}
+
+func TestNodesService_CreateNetworkInterface_Invalid(t *testing.T) {
+	mockHTTP := &mockHTTPService{
+		postFunc: func(uri, payload string, headers map[string]string, cookies []*http.Cookie) (string, error) {
+			t.Fatal("an invalid interface must not be submitted")
+			return "", nil
+		},
+	}
+	nodesService := newTestService(services.NewNodesServiceWithDeps, mockHTTP)
+
+	tests := []struct {
+		iface     string
+		ifaceType string
+		options   services.NetworkInterfaceOptions
+		message   string
+	}{
+		{"eno3", services.NetworkEth, services.NetworkInterfaceOptions{}, `invalid interface type "eth": use bridge, bond, vlan`},
+		{"vmbr1", services.NetworkBridge, services.NetworkInterfaceOptions{Slaves: []string{"eno1"}}, "option slaves is not supported by bridge interfaces"},
+		{"bond0", services.NetworkBond, services.NetworkInterfaceOptions{BondMode: "802.3ad"}, "bond interfaces need the slaves option"},
+		{"bond0", services.NetworkBond, services.NetworkInterfaceOptions{Slaves: []string{"eno1"}, BondMode: "lacp"}, `invalid bond mode "lacp": use balance-rr, active-backup, balance-xor, broadcast, 802.3ad, balance-tlb, balance-alb`},
+		{"bond0", services.NetworkBond, services.NetworkInterfaceOptions{Slaves: []string{"eno1"}, BondPrimary: "eno1"}, "option bond-primary is only supported by active-backup bonds"},
+		{"bond0", services.NetworkBond, services.NetworkInterfaceOptions{Slaves: []string{"eno1"}, BondMode: "active-backup", BondXmitHashPolicy: "layer2"}, "option bond_xmit_hash_policy is only supported by balance-xor and 802.3ad bonds"},
+		{"vlan100", services.NetworkVLAN, services.NetworkInterfaceOptions{VLANRawDevice: "vmbr0"}, "VLAN interface vlan100 needs the vlan-raw-device and vlan-id options, or a name like eno1.100"},
+		{"eno1.100", services.NetworkVLAN, services.NetworkInterfaceOptions{VLANID: 100}, "VLAN interface eno1.100 takes its device and tag from its name"},
+		{"vlan5000", services.NetworkVLAN, services.NetworkInterfaceOptions{VLANRawDevice: "vmbr0", VLANID: 5000}, "invalid VLAN ID 5000: use 1 to 4094"},
+		{"vmbr1", services.NetworkBridge, services.NetworkInterfaceOptions{CIDR: "10.0.0.2/24", Gateway: "10.0.1.1"}, "gateway 10.0.1.1 is not in the network of 10.0.0.2/24"},
+		{"vmbr1", services.NetworkBridge, services.NetworkInterfaceOptions{CIDR: "fd00::2/64"}, `invalid cidr "fd00::2/64": not an IPv4 address`},
+		{"vmbr1", services.NetworkBridge, services.NetworkInterfaceOptions{Gateway: "10.0.0.1"}, "a gateway needs an IPv4 address in cidr"},
+		{"vmbr1", services.NetworkBridge, services.NetworkInterfaceOptions{Delete: []string{"mtu"}}, "options cannot be removed from a new interface"},
+	}
+	for _, tt := range tests {
+		assert.EqualError(t, nodesService.CreateNetworkInterface("pve1", tt.iface, tt.ifaceType, tt.options), tt.message)
+	}
+}
+
+func TestNodesService_UpdateNetworkInterface(t *testing.T) {
+	var putted url.Values
+	mockHTTP := &mockHTTPService{
+		getFunc: func(uri string, headers map[string]string, cookies []*http.Cookie) (*http.Response, error) {
+			switch uri {
+			case "https://localhost:8006/api2/json/nodes/pve1/network/bond0":
+				return jsonResponse(`{"data": {"type": "bond", "slaves": "eno1 eno2", "bond_mode": "active-backup"}}`), nil
+			case "https://localhost:8006/api2/json/nodes/pve1/network/eno1":
+				return jsonResponse(`{"data": {"type": "eth"}}`), nil
+			}
+			t.Fatalf("unexpected request %s", uri)
+			return nil, nil
+		},
+		putFunc: func(uri, payload string, headers map[string]string, cookies []*http.Cookie) (string, error) {
+			putted, _ = url.ParseQuery(payload)
+			return `{"data": null}`, nil
+		},
+	}
+	nodesService := newTestService(services.NewNodesServiceWithDeps, mockHTTP)
+
+	// The API needs the type of the interface with every update
+	err := nodesService.UpdateNetworkInterface("pve1", "bond0", services.NetworkInterfaceOptions{BondPrimary: "eno2", Delete: []string{"comments"}})
+	assert.NoError(t, err)
+	assert.Equal(t, url.Values{"type": {"bond"}, "bond-primary": {"eno2"}, "delete": {"comments"}}, putted)
+
+	// Physical interfaces can be updated, but not given the options of other types
+	err = nodesService.UpdateNetworkInterface("pve1", "eno1", services.NetworkInterfaceOptions{MTU: 9000})
+	assert.NoError(t, err)
+	assert.Equal(t, url.Values{"type": {"eth"}, "mtu": {"9000"}}, putted)
+
+	err = nodesService.UpdateNetworkInterface("pve1", "eno1", services.NetworkInterfaceOptions{BridgePorts: []string{"eno2"}})
+	assert.EqualError(t, err, "option bridge_ports is not supported by eth interfaces")
+
+	err = nodesService.UpdateNetworkInterface("pve1", "bond0", services.NetworkInterfaceOptions{})
+	assert.EqualError(t, err, "no changes given for interface bond0")
+}
+
+func TestNodesService_DeleteApplyAndRevertNetwork(t *testing.T) {
+	var deleted []string
+	mockHTTP := &mockHTTPService{
+		deleteFunc: func(uri string, headers map[string]string, cookies []*http.Cookie) (string, error) {
+			deleted = append(deleted, uri)
+			return `{"data": null}`, nil
+		},
+		putFunc: func(uri, payload string, headers map[string]string, cookies []*http.Cookie) (string, error) {
+			assert.Equal(t, "https://localhost:8006/api2/json/nodes/pve1/network", uri)
+			return `{"data": "UPID:pve1:00001234:00000000:00000000:srvreload:networking:root@pam:"}`, nil
+		},
+	}
+	nodesService := newTestService(services.NewNodesServiceWithDeps, mockHTTP)
+
+	assert.NoError(t, nodesService.DeleteNetworkInterface("pve1", "vmbr1"))
+
+	taskID, err := nodesService.ApplyNetworkChanges("pve1")
+	assert.NoError(t, err)
+	assert.Contains(t, taskID, "srvreload")
+
+	assert.NoError(t, nodesService.RevertNetworkChanges("pve1"))
+	assert.Equal(t, []string{
+		"https://localhost:8006/api2/json/nodes/pve1/network/vmbr1",
+		"https://localhost:8006/api2/json/nodes/pve1/network",
+	}, deleted)
+}
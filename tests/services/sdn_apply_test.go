@@ -0,0 +1,228 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"proxmox-cli/services"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSDNService_PendingChanges(t *testing.T) {
+	mockHTTP := &mockHTTPService{
+		getFunc: func(uri string, headers map[string]string, cookies []*http.Cookie) (*http.Response, error) {
+			switch uri {
+			case "https://localhost:8006/api2/json/cluster/sdn/zones?pending=1":
+				return jsonResponse(`{"data": [
+					{"zone": "local", "type": "simple", "ipam": "pve"},
+					{"zone": "vlans", "type": "vlan", "state": "new", "pending": {"bridge": "vmbr0", "ipam": "pve"}},
+					{"zone": "old", "type": "simple", "ipam": "pve", "state": "deleted"}
+				]}`), nil
+			case "https://localhost:8006/api2/json/cluster/sdn/vnets?pending=1":
+				return jsonResponse(`{"data": [
+					{"vnet": "vnet100", "type": "vnet", "zone": "vlans", "tag": 100, "alias": "web", "state": "changed",
+					 "pending": {"tag": 101, "alias": "deleted", "vlanaware": 1}},
+					{"vnet": "gone", "type": "vnet", "zone": "local", "state": "deleted"}
+				]}`), nil
+			case "https://localhost:8006/api2/json/cluster/sdn/vnets/vnet100/subnets?pending=1":
+				return jsonResponse(`{"data": [
+					{"subnet": "vlans-10.0.0.0-24", "cidr": "10.0.0.0/24", "network": "10.0.0.0", "mask": "24", "zone": "vlans",
+					 "vnet": "vnet100", "type": "subnet", "state": "new", "pending": {"gateway": "10.0.0.1", "snat": 1}}
+				]}`), nil
+			}
+			t.Fatalf("unexpected request %s", uri)
+			return nil, nil
+		},
+	}
+
+	changes, err := newTestService(services.NewSDNServiceWithDeps, mockHTTP).PendingChanges()
+
+	assert.NoError(t, err)
+	assert.Equal(t, []services.SDNChange{
+		{Kind: "zone", ID: "vlans", State: "new", Options: []services.SDNOptionChange{
+			{Option: "bridge", New: "vmbr0"},
+			{Option: "ipam", New: "pve"},
+		}},
+		{Kind: "zone", ID: "old", State: "deleted", Options: []services.SDNOptionChange{
+			{Option: "ipam", Old: "pve"},
+			{Option: "type", Old: "simple"},
+		}},
+		{Kind: "vnet", ID: "vnet100", State: "changed", Options: []services.SDNOptionChange{
+			{Option: "alias", Old: "web"},
+			{Option: "tag", Old: "100", New: "101"},
+			{Option: "vlanaware", New: "1"},
+		}},
+		{Kind: "vnet", ID: "gone", State: "deleted", Options: []services.SDNOptionChange{
+			{Option: "zone", Old: "local"},
+		}},
+		{Kind: "subnet", ID: "10.0.0.0/24", VNet: "vnet100", State: "new", Options: []services.SDNOptionChange{
+			{Option: "gateway", New: "10.0.0.1"},
+			{Option: "snat", New: "1"},
+		}},
+	}, changes)
+}
+
+// sdnAppliedAt is the start time of the apply task in the WaitForZones tests
+var sdnAppliedAt = time.Unix(1700000000, 0)
+
+// sdnReload returns a task list with a finished network reload started at offset seconds
+// after sdnAppliedAt
+func sdnReload(offset int64, status string) string {
+	return fmt.Sprintf(`{"data": [{"upid": "UPID:x", "type": "srvreload", "id": "networking", "starttime": %d, "endtime": %d, "status": %q}]}`,
+		sdnAppliedAt.Unix()+offset, sdnAppliedAt.Unix()+offset+2, status)
+}
+
+// sdnStatusMock answers the zone, node, reload task and per-node zone status requests of
+// WaitForZones. Each reload task and zone status request returns the next response of the
+// node, repeating the last one.
+func sdnStatusMock(t *testing.T, zones string, nodeReloads, nodeStatuses map[string][]string) *mockHTTPService {
+	polls := map[string]int{}
+	next := func(key string, responses []string) *http.Response {
+		response := responses[min(polls[key], len(responses)-1)]
+		polls[key]++
+		return jsonResponse(response)
+	}
+	return &mockHTTPService{
+		getFunc: func(uri string, headers map[string]string, cookies []*http.Cookie) (*http.Response, error) {
+			switch uri {
+			case "https://localhost:8006/api2/json/cluster/sdn/zones":
+				return jsonResponse(zones), nil
+			case "https://localhost:8006/api2/json/nodes":
+				return jsonResponse(`{"data": [
+					{"node": "pve2", "status": "online"}, {"node": "pve1", "status": "online"}, {"node": "pve3", "status": "offline"}
+				]}`), nil
+			}
+			for node, responses := range nodeReloads {
+				if uri == fmt.Sprintf("https://localhost:8006/api2/json/nodes/%s/tasks?since=%d&typefilter=srvreload", node, sdnAppliedAt.Unix()) {
+					return next("tasks/"+node, responses), nil
+				}
+			}
+			for node, responses := range nodeStatuses {
+				if uri == "https://localhost:8006/api2/json/nodes/"+node+"/sdn/zones" {
+					if polls["tasks/"+node] == 0 {
+						t.Fatalf("zone status of %s read before its network reload", node)
+					}
+					return next("zones/"+node, responses), nil
+				}
+			}
+			t.Fatalf("unexpected request %s", uri)
+			return nil, nil
+		},
+	}
+}
+
+func TestSDNService_WaitForZones(t *testing.T) {
+	mockHTTP := sdnStatusMock(t,
+		`{"data": [{"zone": "vlans", "type": "vlan"}, {"zone": "edge", "type": "simple", "nodes": "pve2"}]}`,
+		map[string][]string{
+			"pve1": {`{"data": []}`, sdnReload(1, "OK")},
+			"pve2": {sdnReload(1, "OK")},
+		},
+		map[string][]string{
+			"pve1": {
+				`{"data": [{"zone": "vlans", "status": "pending"}]}`,
+				`{"data": [{"zone": "vlans", "status": "available"}]}`,
+			},
+			// Zones restricted to a node are only expected there
+			"pve2": {
+				`{"data": [{"zone": "vlans", "status": "available"}]}`,
+				`{"data": [{"zone": "vlans", "status": "available"}, {"zone": "edge", "status": "available"}]}`,
+			},
+		})
+	sdnService := newTestService(services.NewSDNServiceWithDeps, mockHTTP)
+	sdnService.PollInterval = time.Millisecond
+
+	statuses, err := sdnService.WaitForZones(sdnAppliedAt, time.Minute)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []services.SDNNodeStatus{
+		{Node: "pve1", Reloaded: true, Ready: true}, {Node: "pve2", Reloaded: true, Ready: true},
+	}, statuses)
+}
+
+func TestSDNService_WaitForZones_StaleStatus(t *testing.T) {
+	// pve2 still reports the zones of the previous configuration as available, and its
+	// last network reload is from an earlier apply
+	mockHTTP := sdnStatusMock(t,
+		`{"data": [{"zone": "vlans", "type": "vlan"}, {"zone": "new", "type": "simple"}]}`,
+		map[string][]string{
+			"pve1": {sdnReload(0, "OK")},
+			"pve2": {sdnReload(-600, "OK")},
+		},
+		map[string][]string{
+			// Zone states other than error and pending count once the node has reloaded
+			"pve1": {`{"data": [{"zone": "vlans", "status": "available"}, {"zone": "new", "status": "deployed"}]}`},
+			"pve2": {`{"data": [{"zone": "vlans", "status": "available"}]}`},
+		})
+	sdnService := newTestService(services.NewSDNServiceWithDeps, mockHTTP)
+	sdnService.PollInterval = time.Millisecond
+
+	statuses, err := sdnService.WaitForZones(sdnAppliedAt, 20*time.Millisecond)
+
+	assert.EqualError(t, err, "timed out after 20ms waiting for the SDN zones of pve2")
+	assert.Equal(t, []services.SDNNodeStatus{{Node: "pve1", Reloaded: true, Ready: true}, {Node: "pve2"}}, statuses)
+}
+
+func TestSDNService_WaitForZones_Failed(t *testing.T) {
+	mockHTTP := sdnStatusMock(t,
+		`{"data": [{"zone": "vlans", "type": "vlan"}]}`,
+		map[string][]string{
+			"pve1": {sdnReload(1, "OK")},
+			"pve2": {sdnReload(1, "OK")},
+		},
+		map[string][]string{
+			"pve1": {`{"data": [{"zone": "vlans", "status": "available"}]}`},
+			"pve2": {`{"data": [{"zone": "vlans", "status": "error"}]}`},
+		})
+	sdnService := newTestService(services.NewSDNServiceWithDeps, mockHTTP)
+	sdnService.PollInterval = time.Millisecond
+
+	statuses, err := sdnService.WaitForZones(sdnAppliedAt, time.Minute)
+
+	assert.EqualError(t, err, "SDN configuration failed on pve2")
+	assert.Equal(t, []services.SDNNodeStatus{
+		{Node: "pve1", Reloaded: true, Ready: true}, {Node: "pve2", Reloaded: true, Failed: []string{"vlans"}},
+	}, statuses)
+}
+
+func TestSDNService_WaitForZones_ReloadFailed(t *testing.T) {
+	mockHTTP := sdnStatusMock(t,
+		`{"data": [{"zone": "vlans", "type": "vlan"}]}`,
+		map[string][]string{
+			"pve1": {sdnReload(1, "OK")},
+			"pve2": {sdnReload(1, "command 'ifreload -a' failed: exit code 1")},
+		},
+		map[string][]string{
+			"pve1": {`{"data": [{"zone": "vlans", "status": "available"}]}`},
+		})
+	sdnService := newTestService(services.NewSDNServiceWithDeps, mockHTTP)
+	sdnService.PollInterval = time.Millisecond
+
+	statuses, err := sdnService.WaitForZones(sdnAppliedAt, time.Minute)
+
+	assert.EqualError(t, err, "SDN configuration failed on pve2")
+	assert.Equal(t, services.SDNNodeStatus{Node: "pve2", Reloaded: true, Error: "command 'ifreload -a' failed: exit code 1"}, statuses[1])
+}
+
+func TestSDNService_WaitForZones_Timeout(t *testing.T) {
+	mockHTTP := sdnStatusMock(t,
+		`{"data": [{"zone": "vlans", "type": "vlan"}]}`,
+		map[string][]string{
+			"pve1": {sdnReload(1, "OK")},
+			"pve2": {sdnReload(1, "OK")},
+		},
+		map[string][]string{
+			"pve1": {`{"data": [{"zone": "vlans", "status": "available"}]}`},
+			"pve2": {`{"data": []}`},
+		})
+	sdnService := newTestService(services.NewSDNServiceWithDeps, mockHTTP)
+	sdnService.PollInterval = time.Millisecond
+
+	statuses, err := sdnService.WaitForZones(sdnAppliedAt, 20*time.Millisecond)
+
+	assert.EqualError(t, err, "timed out after 20ms waiting for the SDN zones of pve2")
+	assert.Equal(t, []string{"vlans"}, statuses[1].Waiting)
+}
@@ -0,0 +1,172 @@
+package tests
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"proxmox-cli/services"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSDNService_ListZones(t *testing.T) {
+	mockHTTP := &mockHTTPService{
+		getFunc: func(uri string, headers map[string]string, cookies []*http.Cookie) (*http.Response, error) {
+			assert.Equal(t, "https://localhost:8006/api2/json/cluster/sdn/zones?pending=1&type=vlan", uri)
+			return jsonResponse(`{"data": [
+				{"zone": "vlans", "type": "vlan", "bridge": "vmbr0", "ipam": "pve", "mtu": 1500, "state": "changed"}
+			]}`), nil
+		},
+	}
+
+	zones, err := newTestService(services.NewSDNServiceWithDeps, mockHTTP).ListZones(services.SDNZoneVLAN, true)
+
+	assert.NoError(t, err)
+	assert.Len(t, zones, 1)
+	assert.Equal(t, "vlans", zones[0].Zone)
+	assert.Equal(t, "bridge=vmbr0", zones[0].Settings())
+	assert.Equal(t, 1500, zones[0].MTU)
+	assert.Equal(t, "changed", zones[0].State)
+}
+
+func TestSDNService_CreateZone(t *testing.T) {
+	tests := []struct {
+		name     string
+		zoneType string
+		options  services.SDNZoneOptions
+		want     url.Values
+	}{
+		{
+			name:     "simple",
+			zoneType: services.SDNZoneSimple,
+			options:  services.SDNZoneOptions{IPAM: "pve", DHCP: "dnsmasq"},
+			want:     url.Values{"ipam": {"pve"}, "dhcp": {"dnsmasq"}},
+		},
+		{
+			name:     "vlan",
+			zoneType: services.SDNZoneVLAN,
+			options:  services.SDNZoneOptions{Bridge: "vmbr0", Nodes: []string{"pve1", "pve2"}},
+			want:     url.Values{"bridge": {"vmbr0"}, "nodes": {"pve1,pve2"}},
+		},
+		{
+			name:     "qinq",
+			zoneType: services.SDNZoneQinQ,
+			options:  services.SDNZoneOptions{Bridge: "vmbr0", Tag: 100, VLANProtocol: "802.1ad"},
+			want:     url.Values{"bridge": {"vmbr0"}, "tag": {"100"}, "vlan-protocol": {"802.1ad"}},
+		},
+		{
+			name:     "vxlan",
+			zoneType: services.SDNZoneVXLAN,
+			options:  services.SDNZoneOptions{Peers: []string{"10.0.0.1", "10.0.0.2"}, MTU: 1450},
+			want:     url.Values{"peers": {"10.0.0.1,10.0.0.2"}, "mtu": {"1450"}},
+		},
+		{
+			name:     "evpn",
+			zoneType: services.SDNZoneEVPN,
+			options: services.SDNZoneOptions{
+				Controller: "evpnctl", VRFVXLAN: 10000, ExitNodes: []string{"pve1"}, AdvertiseSubnets: true,
+			},
+			want: url.Values{"controller": {"evpnctl"}, "vrf-vxlan": {"10000"}, "exitnodes": {"pve1"}, "advertise-subnets": {"1"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var posted url.Values
+			mockHTTP := &mockHTTPService{
+				postFunc: func(uri, payload string, headers map[string]string, cookies []*http.Cookie) (string, error) {
+					assert.Equal(t, "https://localhost:8006/api2/json/cluster/sdn/zones", uri)
+					posted, _ = url.ParseQuery(payload)
+					return `{"data": null}`, nil
+				},
+			}
+
+			err := newTestService(services.NewSDNServiceWithDeps, mockHTTP).CreateZone("zone1", tt.zoneType, tt.options)
+
+			assert.NoError(t, err)
+			tt.want.Set("zone", "zone1")
+			tt.want.Set("type", tt.zoneType)
+			assert.Equal(t, tt.want, posted)
+		})
+	}
+}
+
+func TestSDNService_CreateZone_Invalid(t *testing.T) {
+	mockHTTP := &mockHTTPService{
+		postFunc: func(uri, payload string, headers map[string]string, cookies []*http.Cookie) (string, error) {
+			t.Fatal("an invalid zone must not be submitted")
+			return "", nil
+		},
+	}
+	sdnService := newTestService(services.NewSDNServiceWithDeps, mockHTTP)
+
+	tests := []struct {
+		zone     string
+		zoneType string
+		options  services.SDNZoneOptions
+		message  string
+	}{
+		{"my-zone", services.SDNZoneSimple, services.SDNZoneOptions{}, `invalid zone name "my-zone": use 2 to 8 letters and digits, starting with a letter`},
+		{"zone1", "gre", services.SDNZoneOptions{}, `invalid zone type "gre": use simple, vlan, qinq, vxlan, evpn`},
+		{"zone1", services.SDNZoneVLAN, services.SDNZoneOptions{}, "vlan zones need the bridge option"},
+		{"zone1", services.SDNZoneQinQ, services.SDNZoneOptions{Bridge: "vmbr0"}, "qinq zones need the tag option"},
+		{"zone1", services.SDNZoneEVPN, services.SDNZoneOptions{Controller: "evpnctl"}, "evpn zones need the vrf-vxlan option"},
+		{"zone1", services.SDNZoneSimple, services.SDNZoneOptions{Bridge: "vmbr0"}, "option bridge is not supported by simple zones"},
+		{"zone1", services.SDNZoneVXLAN, services.SDNZoneOptions{Peers: []string{"10.0.0.1"}, Tag: 5}, "option tag is not supported by vxlan zones"},
+		{"zone1", services.SDNZoneQinQ, services.SDNZoneOptions{Bridge: "vmbr0", Tag: 5, VLANProtocol: "802.1x"}, `invalid VLAN protocol "802.1x": use 802.1q or 802.1ad`},
+	}
+	for _, tt := range tests {
+		assert.EqualError(t, sdnService.CreateZone(tt.zone, tt.zoneType, tt.options), tt.message)
+	}
+}
+
+func TestSDNService_UpdateZone(t *testing.T) {
+	var putted url.Values
+	mockHTTP := &mockHTTPService{
+		getFunc: func(uri string, headers map[string]string, cookies []*http.Cookie) (*http.Response, error) {
+			assert.Equal(t, "https://localhost:8006/api2/json/cluster/sdn/zones/overlay", uri)
+			return jsonResponse(`{"data": {"zone": "overlay", "type": "vxlan", "peers": "10.0.0.1,10.0.0.2"}}`), nil
+		},
+		putFunc: func(uri, payload string, headers map[string]string, cookies []*http.Cookie) (string, error) {
+			assert.Equal(t, "https://localhost:8006/api2/json/cluster/sdn/zones/overlay", uri)
+			putted, _ = url.ParseQuery(payload)
+			return `{"data": null}`, nil
+		},
+	}
+	sdnService := newTestService(services.NewSDNServiceWithDeps, mockHTTP)
+
+	err := sdnService.UpdateZone("overlay", services.SDNZoneOptions{MTU: 1400, Delete: []string{"dns"}})
+	assert.NoError(t, err)
+	assert.Equal(t, url.Values{"mtu": {"1400"}, "delete": {"dns"}}, putted)
+
+	// The options are checked against the type of the existing zone
+	err = sdnService.UpdateZone("overlay", services.SDNZoneOptions{Bridge: "vmbr1"})
+	assert.EqualError(t, err, "option bridge is not supported by vxlan zones")
+
+	err = sdnService.UpdateZone("overlay", services.SDNZoneOptions{Delete: []string{"peers"}})
+	assert.EqualError(t, err, "option peers is required by vxlan zones and cannot be removed")
+
+	err = sdnService.UpdateZone("overlay", services.SDNZoneOptions{})
+	assert.EqualError(t, err, "no changes given for zone overlay")
+}
+
+func TestSDNService_DeleteZoneAndApply(t *testing.T) {
+	mockHTTP := &mockHTTPService{
+		deleteFunc: func(uri string, headers map[string]string, cookies []*http.Cookie) (string, error) {
+			assert.Equal(t, "https://localhost:8006/api2/json/cluster/sdn/zones/vlans", uri)
+			return `{"data": null}`, nil
+		},
+		putFunc: func(uri, payload string, headers map[string]string, cookies []*http.Cookie) (string, error) {
+			assert.Equal(t, "https://localhost:8006/api2/json/cluster/sdn", uri)
+			return `{"data": "UPID:pve1:00001234:00000000:00000000:reloadnetworkall::root@pam:"}`, nil
+		},
+	}
+	sdnService := newTestService(services.NewSDNServiceWithDeps, mockHTTP)
+
+	assert.NoError(t, sdnService.DeleteZone("vlans"))
+
+	taskID, err := sdnService.Apply()
+	assert.NoError(t, err)
+	assert.Contains(t, taskID, "reloadnetworkall")
+}
@@ -0,0 +1,229 @@
+package tests
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"proxmox-cli/services"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSDNService_ListVNetsAndSubnets(t *testing.T) {
+	mockHTTP := &mockHTTPService{
+		getFunc: func(uri string, headers map[string]string, cookies []*http.Cookie) (*http.Response, error) {
+			switch uri {
+			case "https://localhost:8006/api2/json/cluster/sdn/vnets":
+				return jsonResponse(`{"data": [{"vnet": "vnet100", "zone": "vlans", "tag": 100, "alias": "web", "type": "vnet"}]}`), nil
+			case "https://localhost:8006/api2/json/cluster/sdn/vnets/vnet100/subnets?pending=1":
+				return jsonResponse(`{"data": [
+					{"subnet": "vlans-10.0.0.0-24", "cidr": "10.0.0.0/24", "gateway": "10.0.0.1", "snat": 1, "state": "new",
+					 "dhcp-range": ["start-address=10.0.0.100,end-address=10.0.0.200"]},
+					{"subnet": "vlans-10.0.1.0-24", "cidr": "10.0.1.0/24",
+					 "dhcp-range": [{"start-address": "10.0.1.10", "end-address": "10.0.1.20"}]}
+				]}`), nil
+			}
+			t.Fatalf("unexpected request %s", uri)
+			return nil, nil
+		},
+	}
+	sdnService := newTestService(services.NewSDNServiceWithDeps, mockHTTP)
+
+	vnets, err := sdnService.ListVNets(false)
+	assert.NoError(t, err)
+	assert.Equal(t, []services.SDNVNet{{VNet: "vnet100", Zone: "vlans", Tag: 100, Alias: "web"}}, vnets)
+
+	// DHCP ranges are read in both the configuration and the object format
+	subnets, err := sdnService.ListSubnets("vnet100", true)
+	assert.NoError(t, err)
+	assert.Len(t, subnets, 2)
+	assert.Equal(t, "vlans-10.0.0.0-24", subnets[0].ID)
+	assert.Equal(t, 1, subnets[0].SNAT)
+	assert.Equal(t, "new", subnets[0].State)
+	assert.Equal(t, []services.DHCPRange{{StartAddress: "10.0.0.100", EndAddress: "10.0.0.200"}}, subnets[0].DHCPRanges)
+	assert.Equal(t, []services.DHCPRange{{StartAddress: "10.0.1.10", EndAddress: "10.0.1.20"}}, subnets[1].DHCPRanges)
+}
+
+func TestSDNService_CreateVNet(t *testing.T) {
+	var posted url.Values
+	mockHTTP := &mockHTTPService{
+		getFunc: func(uri string, headers map[string]string, cookies []*http.Cookie) (*http.Response, error) {
+			switch uri {
+			case "https://localhost:8006/api2/json/cluster/sdn/zones/vlans":
+				return jsonResponse(`{"data": {"zone": "vlans", "type": "vlan", "bridge": "vmbr0"}}`), nil
+			case "https://localhost:8006/api2/json/cluster/sdn/zones/local":
+				return jsonResponse(`{"data": {"zone": "local", "type": "simple"}}`), nil
+			}
+			t.Fatalf("unexpected request %s", uri)
+			return nil, nil
+		},
+		postFunc: func(uri, payload string, headers map[string]string, cookies []*http.Cookie) (string, error) {
+			assert.Equal(t, "https://localhost:8006/api2/json/cluster/sdn/vnets", uri)
+			posted, _ = url.ParseQuery(payload)
+			return `{"data": null}`, nil
+		},
+	}
+	sdnService := newTestService(services.NewSDNServiceWithDeps, mockHTTP)
+
+	err := sdnService.CreateVNet("vnet100", services.SDNVNetOptions{Zone: "vlans", Tag: 100, Alias: "web", VLANAware: true})
+	assert.NoError(t, err)
+	assert.Equal(t, url.Values{"vnet": {"vnet100"}, "zone": {"vlans"}, "tag": {"100"}, "alias": {"web"}, "vlanaware": {"1"}}, posted)
+
+	// The tag is checked against the type of the zone
+	err = sdnService.CreateVNet("vnet101", services.SDNVNetOptions{Zone: "vlans"})
+	assert.EqualError(t, err, "VNets of vlan zone vlans need a VLAN tag between 1 and 4094")
+
+	err = sdnService.CreateVNet("vnet102", services.SDNVNetOptions{Zone: "local", Tag: 5})
+	assert.EqualError(t, err, "VNets of simple zone local cannot have a tag")
+
+	err = sdnService.CreateVNet("vnet-1", services.SDNVNetOptions{Zone: "local"})
+	assert.EqualError(t, err, `invalid VNet name "vnet-1": use 2 to 8 letters and digits, starting with a letter`)
+
+	err = sdnService.CreateVNet("vnet103", services.SDNVNetOptions{})
+	assert.EqualError(t, err, "a zone is required")
+}
+
+func TestSDNService_UpdateAndDeleteVNet(t *testing.T) {
+	var putted url.Values
+	mockHTTP := &mockHTTPService{
+		getFunc: func(uri string, headers map[string]string, cookies []*http.Cookie) (*http.Response, error) {
+			switch uri {
+			case "https://localhost:8006/api2/json/cluster/sdn/vnets/vnet100":
+				return jsonResponse(`{"data": {"vnet": "vnet100", "zone": "vlans", "tag": 100}}`), nil
+			case "https://localhost:8006/api2/json/cluster/sdn/zones/vlans":
+				return jsonResponse(`{"data": {"zone": "vlans", "type": "vlan", "bridge": "vmbr0"}}`), nil
+			case "https://localhost:8006/api2/json/cluster/sdn/zones/simples":
+				return jsonResponse(`{"data": {"zone": "simples", "type": "simple"}}`), nil
+			}
+			t.Fatalf("unexpected request %s", uri)
+			return nil, nil
+		},
+		putFunc: func(uri, payload string, headers map[string]string, cookies []*http.Cookie) (string, error) {
+			assert.Equal(t, "https://localhost:8006/api2/json/cluster/sdn/vnets/vnet100", uri)
+			putted, _ = url.ParseQuery(payload)
+			return `{"data": null}`, nil
+		},
+		deleteFunc: func(uri string, headers map[string]string, cookies []*http.Cookie) (string, error) {
+			assert.Equal(t, "https://localhost:8006/api2/json/cluster/sdn/vnets/vnet100", uri)
+			return `{"data": null}`, nil
+		},
+	}
+	sdnService := newTestService(services.NewSDNServiceWithDeps, mockHTTP)
+
+	err := sdnService.UpdateVNet("vnet100", services.SDNVNetOptions{Tag: 101, Delete: []string{"alias"}})
+	assert.NoError(t, err)
+	assert.Equal(t, url.Values{"tag": {"101"}, "delete": {"alias"}}, putted)
+
+	err = sdnService.UpdateVNet("vnet100", services.SDNVNetOptions{Tag: 5000})
+	assert.EqualError(t, err, "VNets of vlan zone vlans need a VLAN tag between 1 and 4094")
+
+	// Deleting the tag leaves the VNet without one, which VLAN zones do not allow
+	err = sdnService.UpdateVNet("vnet100", services.SDNVNetOptions{Delete: []string{"tag"}})
+	assert.EqualError(t, err, "VNets of vlan zone vlans need a VLAN tag between 1 and 4094")
+
+	// Moving to a simple zone keeps the current tag unless it is deleted
+	err = sdnService.UpdateVNet("vnet100", services.SDNVNetOptions{Zone: "simples"})
+	assert.EqualError(t, err, "VNets of simple zone simples cannot have a tag")
+
+	putted = nil
+	err = sdnService.UpdateVNet("vnet100", services.SDNVNetOptions{Zone: "simples", Delete: []string{"tag"}})
+	assert.NoError(t, err)
+	assert.Equal(t, url.Values{"zone": {"simples"}, "delete": {"tag"}}, putted)
+
+	err = sdnService.UpdateVNet("vnet100", services.SDNVNetOptions{})
+	assert.EqualError(t, err, "no changes given for VNet vnet100")
+
+	assert.NoError(t, sdnService.DeleteVNet("vnet100"))
+}
+
+func TestSDNService_Subnets(t *testing.T) {
+	var posted, putted url.Values
+	var deleted string
+	mockHTTP := &mockHTTPService{
+		getFunc: func(uri string, headers map[string]string, cookies []*http.Cookie) (*http.Response, error) {
+			assert.Equal(t, "https://localhost:8006/api2/json/cluster/sdn/vnets/vnet100", uri)
+			return jsonResponse(`{"data": {"vnet": "vnet100", "zone": "vlans", "tag": 100}}`), nil
+		},
+		postFunc: func(uri, payload string, headers map[string]string, cookies []*http.Cookie) (string, error) {
+			assert.Equal(t, "https://localhost:8006/api2/json/cluster/sdn/vnets/vnet100/subnets", uri)
+			posted, _ = url.ParseQuery(payload)
+			return `{"data": null}`, nil
+		},
+		putFunc: func(uri, payload string, headers map[string]string, cookies []*http.Cookie) (string, error) {
+			assert.Equal(t, "https://localhost:8006/api2/json/cluster/sdn/vnets/vnet100/subnets/vlans-10.0.0.0-24", uri)
+			putted, _ = url.ParseQuery(payload)
+			return `{"data": null}`, nil
+		},
+		deleteFunc: func(uri string, headers map[string]string, cookies []*http.Cookie) (string, error) {
+			deleted = uri
+			return `{"data": null}`, nil
+		},
+	}
+	sdnService := newTestService(services.NewSDNServiceWithDeps, mockHTTP)
+
+	err := sdnService.CreateSubnet("vnet100", "10.0.0.0/24", services.SDNSubnetOptions{
+		Gateway: "10.0.0.1",
+		SNAT:    true,
+		DHCPRanges: []services.DHCPRange{
+			{StartAddress: "10.0.0.100", EndAddress: "10.0.0.149"},
+			{StartAddress: "10.0.0.150", EndAddress: "10.0.0.200"},
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, url.Values{
+		"subnet":  {"10.0.0.0/24"},
+		"type":    {"subnet"},
+		"gateway": {"10.0.0.1"},
+		"snat":    {"1"},
+		"dhcp-range": {
+			"start-address=10.0.0.100,end-address=10.0.0.149",
+			"start-address=10.0.0.150,end-address=10.0.0.200",
+		},
+	}, posted)
+
+	// Updates and deletes address the subnet by the ID derived from the zone of the VNet
+	err = sdnService.UpdateSubnet("vnet100", "10.0.0.0/24", services.SDNSubnetOptions{Delete: []string{"snat"}})
+	assert.NoError(t, err)
+	assert.Equal(t, url.Values{"delete": {"snat"}}, putted)
+
+	assert.NoError(t, sdnService.DeleteSubnet("vnet100", "10.0.0.0/24"))
+	assert.Equal(t, "https://localhost:8006/api2/json/cluster/sdn/vnets/vnet100/subnets/vlans-10.0.0.0-24", deleted)
+}
+
+func TestSDNService_CreateSubnet_Invalid(t *testing.T) {
+	mockHTTP := &mockHTTPService{
+		postFunc: func(uri, payload string, headers map[string]string, cookies []*http.Cookie) (string, error) {
+			t.Fatal("an invalid subnet must not be submitted")
+			return "", nil
+		},
+	}
+	sdnService := newTestService(services.NewSDNServiceWithDeps, mockHTTP)
+
+	tests := []struct {
+		cidr    string
+		options services.SDNSubnetOptions
+		message string
+	}{
+		{"10.0.0.1/24", services.SDNSubnetOptions{}, `invalid subnet "10.0.0.1/24": use the network address 10.0.0.0/24`},
+		{"10.0.0.0/24", services.SDNSubnetOptions{Gateway: "10.0.1.1"}, "gateway 10.0.1.1 is not in subnet 10.0.0.0/24"},
+		{"10.0.0.0/24", services.SDNSubnetOptions{DHCPRanges: []services.DHCPRange{{StartAddress: "10.0.0.200", EndAddress: "10.0.0.100"}}}, "DHCP range 10.0.0.200-10.0.0.100 ends before it starts"},
+		{"10.0.0.0/24", services.SDNSubnetOptions{DHCPRanges: []services.DHCPRange{{StartAddress: "10.0.0.100", EndAddress: "10.0.1.100"}}}, "DHCP range 10.0.0.100-10.0.1.100 is not in subnet 10.0.0.0/24"},
+		{"10.0.0.0/24", services.SDNSubnetOptions{Delete: []string{"gateway"}}, "options cannot be removed from a new subnet"},
+	}
+	for _, tt := range tests {
+		assert.EqualError(t, sdnService.CreateSubnet("vnet100", tt.cidr, tt.options), tt.message)
+	}
+}
+
+func TestParseDHCPRange(t *testing.T) {
+	dhcpRange, err := services.ParseDHCPRange("10.0.0.100-10.0.0.200")
+	assert.NoError(t, err)
+	assert.Equal(t, services.DHCPRange{StartAddress: "10.0.0.100", EndAddress: "10.0.0.200"}, dhcpRange)
+	assert.Equal(t, "start-address=10.0.0.100,end-address=10.0.0.200", dhcpRange.String())
+
+	_, err = services.ParseDHCPRange("10.0.0.100")
+	assert.EqualError(t, err, `invalid DHCP range "10.0.0.100": expected <start>-<end>`)
+
+	assert.Equal(t, "zone1-fd00::-64", services.SubnetID("zone1", "fd00::/64"))
+}
@@ -0,0 +1,349 @@
+package tests
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"proxmox-cli/services"
+
+	"github.com/sirupsen/logrus"
+)
+
+// memorySecretStore is an in-memory stand-in for an OS keyring
+type memorySecretStore struct {
+	mu      sync.Mutex
+	secrets map[string]services.Secrets
+}
+
+func (m *memorySecretStore) Get(context string) (services.Secrets, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	secrets, ok := m.secrets[context]
+	if !ok {
+		return services.Secrets{}, fmt.Errorf("no secrets for %s", context)
+	}
+	return secrets, nil
+}
+
+func (m *memorySecretStore) Set(context string, secrets services.Secrets) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.secrets[context] = secrets
+	return nil
+}
+
+func (m *memorySecretStore) Delete(context string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.secrets, context)
+	return nil
+}
+
+// setupSecretsHome points HOME at a temporary directory and resets the selected context and store afterwards
+func setupSecretsHome(t *testing.T) string {
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	t.Cleanup(func() {
+		services.SelectedContext = ""
+		services.SelectedSecretStore = ""
+	})
+	return dir
+}
+
+func ticketSession() services.SessionData {
+	sd := services.SessionData{Server: "localhost", Port: 8006, HttpScheme: "https"}
+	sd.Response.Data.Username = "root@pam"
+	sd.Response.Data.Ticket = "PVE:root@pam:SECRET-TICKET"
+	sd.Response.Data.CSRFPreventionToken = "CSRF-TOKEN"
+	return sd
+}
+
+func TestSessionService_WriteSessionFile_Permissions(t *testing.T) {
+	dir := setupSecretsHome(t)
+
+	// A directory created by an older version with world readable permissions
+	baseDir := filepath.Join(dir, ".proxmox")
+	if err := os.MkdirAll(baseDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(baseDir, "session"), []byte("{}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ss, err := services.NewSessionService(logrus.New())
+	if err != nil {
+		t.Fatalf("NewSessionService failed: %v", err)
+	}
+	if err = ss.WriteSessionFile(ticketSession()); err != nil {
+		t.Fatalf("WriteSessionFile failed: %v", err)
+	}
+
+	services.SelectedContext = "lab"
+	lab, err := services.NewSessionService(logrus.New())
+	if err != nil {
+		t.Fatalf("NewSessionService failed: %v", err)
+	}
+	if err = lab.WriteSessionFile(ticketSession()); err != nil {
+		t.Fatalf("WriteSessionFile failed: %v", err)
+	}
+	if err = lab.UseContext("lab"); err != nil {
+		t.Fatalf("UseContext failed: %v", err)
+	}
+
+	for path, want := range map[string]os.FileMode{
+		baseDir:                                   0700,
+		filepath.Join(baseDir, "contexts"):        0700,
+		filepath.Join(baseDir, "session"):         0600,
+		filepath.Join(baseDir, "contexts", "lab"): 0600,
+		filepath.Join(baseDir, "current-context"): 0600,
+	} {
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatalf("stat %s: %v", path, err)
+		}
+		if info.Mode().Perm() != want {
+			t.Errorf("expected %s to have mode %o, got %o", path, want, info.Mode().Perm())
+		}
+	}
+
+	// The atomic write must not leave temporary files behind
+	entries, err := os.ReadDir(baseDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		if strings.Contains(entry.Name(), ".tmp-") {
+			t.Errorf("temporary file left behind: %s", entry.Name())
+		}
+	}
+}
+
+func TestSessionService_SecretStore(t *testing.T) {
+	dir := setupSecretsHome(t)
+	store := &memorySecretStore{secrets: map[string]services.Secrets{}}
+	services.RegisterSecretStore("memory", func(string) (services.SecretStore, error) { return store, nil })
+	services.SelectedSecretStore = "memory"
+	services.SelectedContext = "lab"
+
+	ss, err := services.NewSessionService(logrus.New())
+	if err != nil {
+		t.Fatalf("NewSessionService failed: %v", err)
+	}
+	if err = ss.WriteSessionFile(ticketSession()); err != nil {
+		t.Fatalf("WriteSessionFile failed: %v", err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(dir, ".proxmox", "contexts", "lab"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(content), "SECRET-TICKET") || strings.Contains(string(content), "CSRF-TOKEN") {
+		t.Errorf("session file should not contain credentials: %s", content)
+	}
+	if store.secrets["lab"].Ticket != "PVE:root@pam:SECRET-TICKET" {
+		t.Errorf("expected the ticket in the secret store, got %+v", store.secrets)
+	}
+
+	// The session keeps its store even when another one is selected later
+	services.SelectedSecretStore = ""
+	read, err := ss.ReadSessionFile()
+	if err != nil {
+		t.Fatalf("ReadSessionFile failed: %v", err)
+	}
+	if read.Response.Data.Ticket != "PVE:root@pam:SECRET-TICKET" || read.Response.Data.CSRFPreventionToken != "CSRF-TOKEN" {
+		t.Errorf("expected credentials restored from the secret store, got %+v", read.Response.Data)
+	}
+
+	if err = ss.UpdateSessionField("issuedAt", int64(42)); err != nil {
+		t.Fatalf("UpdateSessionField failed: %v", err)
+	}
+	if _, ok := store.secrets["lab"]; !ok {
+		t.Error("expected the secrets to stay in the store after an update")
+	}
+
+	if err = ss.RenameContext("lab", "production"); err != nil {
+		t.Fatalf("RenameContext failed: %v", err)
+	}
+	if _, ok := store.secrets["lab"]; ok {
+		t.Error("expected the secrets of the old context name to be removed")
+	}
+	if store.secrets["production"].Ticket == "" {
+		t.Error("expected the secrets to move to the new context name")
+	}
+
+	if err = ss.DeleteContext("production"); err != nil {
+		t.Fatalf("DeleteContext failed: %v", err)
+	}
+	if len(store.secrets) != 0 {
+		t.Errorf("expected the secrets to be removed with the context, got %+v", store.secrets)
+	}
+}
+
+func TestSessionService_SecretStore_SwitchToFile(t *testing.T) {
+	setupSecretsHome(t)
+	store := &memorySecretStore{secrets: map[string]services.Secrets{}}
+	services.RegisterSecretStore("memory", func(string) (services.SecretStore, error) { return store, nil })
+	services.SelectedSecretStore = "memory"
+
+	ss, err := services.NewSessionService(logrus.New())
+	if err != nil {
+		t.Fatalf("NewSessionService failed: %v", err)
+	}
+	if err = ss.WriteSessionFile(ticketSession()); err != nil {
+		t.Fatalf("WriteSessionFile failed: %v", err)
+	}
+
+	// Logging in again with the file store removes the secrets from the old store
+	services.SelectedSecretStore = services.SecretStoreFile
+	if err = ss.WriteSessionFile(ticketSession()); err != nil {
+		t.Fatalf("WriteSessionFile failed: %v", err)
+	}
+	if len(store.secrets) != 0 {
+		t.Errorf("expected the old secrets to be removed, got %+v", store.secrets)
+	}
+	read, err := ss.ReadSessionFile()
+	if err != nil || read.Response.Data.Ticket != "PVE:root@pam:SECRET-TICKET" {
+		t.Errorf("expected the ticket in the session file, got %+v (err: %v)", read, err)
+	}
+}
+
+func TestEncryptedFileStore(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "secrets")
+	store := services.NewEncryptedFileStore(dir, "correct horse")
+	secrets := services.Secrets{Ticket: "ticket", CSRFPreventionToken: "csrf"}
+
+	if err := store.Set("lab", secrets); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(dir, "lab"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(content), "csrf") {
+		t.Errorf("secrets file is not encrypted: %s", content)
+	}
+	info, err := os.Stat(filepath.Join(dir, "lab"))
+	if err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("expected secrets file mode 0600, got %v (err: %v)", info.Mode().Perm(), err)
+	}
+
+	got, err := services.NewEncryptedFileStore(dir, "correct horse").Get("lab")
+	if err != nil || got != secrets {
+		t.Errorf("expected %+v, got %+v (err: %v)", secrets, got, err)
+	}
+
+	if _, err = services.NewEncryptedFileStore(dir, "wrong").Get("lab"); err == nil {
+		t.Error("expected an error with the wrong passphrase")
+	}
+	if _, err = services.NewEncryptedFileStore(dir, "").Get("lab"); err == nil || !strings.Contains(err.Error(), services.SecretPassphraseEnv) {
+		t.Errorf("expected an error naming %s without a passphrase, got %v", services.SecretPassphraseEnv, err)
+	}
+
+	// A secrets file copied to another context name does not decrypt
+	if err = os.WriteFile(filepath.Join(dir, "prod"), content, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = store.Get("prod"); err == nil {
+		t.Error("expected an error for secrets moved between contexts")
+	}
+
+	if err = store.Delete("lab"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if err = store.Delete("lab"); err != nil {
+		t.Errorf("expected deleting missing secrets to succeed, got %v", err)
+	}
+}
+
+// fakeSecretTool is a stand-in for secret-tool that keeps secrets in files named after the attributes
+const fakeSecretTool = `#!/bin/sh
+dir="$(dirname "$0")/store"
+mkdir -p "$dir"
+cmd="$1"; shift
+if [ "$cmd" = "store" ]; then shift 2; fi
+key="$(echo "$@" | tr ' ' '_')"
+case "$cmd" in
+  store) cat > "$dir/$key" ;;
+  lookup) cat "$dir/$key" 2>/dev/null || exit 1 ;;
+  clear) rm -f "$dir/$key" ;;
+esac
+`
+
+func TestSecretServiceStore(t *testing.T) {
+	dir := t.TempDir()
+	tool := filepath.Join(dir, "secret-tool")
+	if err := os.WriteFile(tool, []byte(fakeSecretTool), 0700); err != nil {
+		t.Fatal(err)
+	}
+	store := services.NewSecretServiceStore(tool)
+	secrets := services.Secrets{TokenSecret: "token-secret"}
+
+	if err := store.Set("lab", secrets); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "store", "service_proxmox-cli_context_lab")); err != nil {
+		t.Errorf("expected secret-tool to be called with the context attributes: %v", err)
+	}
+
+	got, err := store.Get("lab")
+	if err != nil || got != secrets {
+		t.Errorf("expected %+v, got %+v (err: %v)", secrets, got, err)
+	}
+
+	if err = store.Delete("lab"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, err = store.Get("lab"); err == nil {
+		t.Error("expected an error after deleting the secrets")
+	}
+}
+
+func TestValidateSecretStore(t *testing.T) {
+	for _, name := range []string{"", services.SecretStoreFile, services.SecretStoreSecretService, services.SecretStoreEncryptedFile} {
+		if err := services.ValidateSecretStore(name); err != nil {
+			t.Errorf("expected %q to be valid, got %v", name, err)
+		}
+	}
+	if err := services.ValidateSecretStore("vault"); err == nil {
+		t.Error("expected an error for an unknown secret store")
+	}
+}
+
+func TestSessionService_TrustedDoesNotOpenSecretStore(t *testing.T) {
+	setupSecretsHome(t)
+	store := &memorySecretStore{secrets: map[string]services.Secrets{}}
+	opened := 0
+	services.RegisterSecretStore("memory", func(string) (services.SecretStore, error) {
+		opened++
+		return store, nil
+	})
+	services.SelectedSecretStore = "memory"
+	services.SelectedContext = "lab"
+
+	ss, err := services.NewSessionService(logrus.New())
+	if err != nil {
+		t.Fatalf("NewSessionService failed: %v", err)
+	}
+	session := ticketSession()
+	session.Trust = true
+	if err = ss.WriteSessionFile(session); err != nil {
+		t.Fatalf("WriteSessionFile failed: %v", err)
+	}
+
+	reader, err := services.NewSessionService(logrus.New())
+	if err != nil {
+		t.Fatalf("NewSessionService failed: %v", err)
+	}
+	opened = 0
+	if !reader.Trusted() {
+		t.Error("expected the context to be trusted")
+	}
+	if opened != 0 {
+		t.Errorf("expected Trusted not to open the secret store, opened %d times", opened)
+	}
+}
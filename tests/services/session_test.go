@@ -5,6 +5,7 @@ import (
 	"path/filepath"
 	"reflect"
 	"testing"
+	"time"
 
 	"proxmox-cli/services"
 
@@ -148,3 +149,194 @@ func TestSessionService_UpdateSessionField(t *testing.T) {
 		t.Errorf("expected server to be 'newhost', got '%s'", read.Server)
 	}
 }
+
+func TestSessionService_WriteAndReadSessionFile_APIToken(t *testing.T) {
+	dir, err := os.MkdirTemp("", "proxmox-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Setenv("HOME", dir)
+	defer os.RemoveAll(dir)
+	logger := logrus.New()
+	ss, err := services.NewSessionService(logger)
+	if err != nil {
+		t.Fatalf("NewSessionService failed: %v", err)
+	}
+	sd := services.SessionData{
+		Server:      "localhost",
+		Port:        8006,
+		HttpScheme:  "https",
+		TokenID:     "ci@pve!build",
+		TokenSecret: "secret",
+	}
+	err = ss.WriteSessionFile(sd)
+	if err != nil {
+		t.Fatalf("WriteSessionFile failed: %v", err)
+	}
+	read, err := ss.ReadSessionFile()
+	if err != nil {
+		t.Fatalf("ReadSessionFile failed for API token session: %v", err)
+	}
+	if !read.UsesAPIToken() || read.TokenSecret != "secret" {
+		t.Errorf("ReadSessionFile returned wrong token data: %+v", read)
+	}
+}
+
+func TestSessionService_Contexts(t *testing.T) {
+	dir, err := os.MkdirTemp("", "proxmox-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Setenv("HOME", dir)
+	defer os.RemoveAll(dir)
+	defer func() { services.SelectedContext = "" }()
+	logger := logrus.New()
+
+	newSession := func(server string) services.SessionData {
+		return services.SessionData{
+			Server:      server,
+			Port:        8006,
+			HttpScheme:  "https",
+			TokenID:     "ci@pve!build",
+			TokenSecret: "secret",
+		}
+	}
+
+	services.SelectedContext = "lab"
+	lab, err := services.NewSessionService(logger)
+	if err != nil {
+		t.Fatalf("NewSessionService failed: %v", err)
+	}
+	if err = lab.WriteSessionFile(newSession("lab.example.com")); err != nil {
+		t.Fatalf("WriteSessionFile failed: %v", err)
+	}
+
+	services.SelectedContext = "prod"
+	prod, err := services.NewSessionService(logger)
+	if err != nil {
+		t.Fatalf("NewSessionService failed: %v", err)
+	}
+	if err = prod.WriteSessionFile(newSession("prod.example.com")); err != nil {
+		t.Fatalf("WriteSessionFile failed: %v", err)
+	}
+
+	read, err := lab.ReadSessionFile()
+	if err != nil || read.Server != "lab.example.com" {
+		t.Errorf("expected lab context to keep its own server, got %+v (err: %v)", read, err)
+	}
+
+	if err = prod.UseContext("prod"); err != nil {
+		t.Fatalf("UseContext failed: %v", err)
+	}
+	services.SelectedContext = ""
+	current, err := services.NewSessionService(logger)
+	if err != nil {
+		t.Fatalf("NewSessionService failed: %v", err)
+	}
+	if current.ContextName() != "prod" {
+		t.Errorf("expected current context 'prod', got '%s'", current.ContextName())
+	}
+
+	if err = current.RenameContext("prod", "production"); err != nil {
+		t.Fatalf("RenameContext failed: %v", err)
+	}
+	contexts, err := current.ListContexts()
+	if err != nil {
+		t.Fatalf("ListContexts failed: %v", err)
+	}
+	if len(contexts) != 2 || contexts[0].Name != "lab" || contexts[1].Name != "production" || !contexts[1].Current {
+		t.Errorf("unexpected contexts after rename: %+v", contexts)
+	}
+
+	if err = current.DeleteContext("production"); err != nil {
+		t.Fatalf("DeleteContext failed: %v", err)
+	}
+	fallback, err := services.NewSessionService(logger)
+	if err != nil {
+		t.Fatalf("NewSessionService failed: %v", err)
+	}
+	if fallback.ContextName() != services.DefaultContext {
+		t.Errorf("expected fallback to default context, got '%s'", fallback.ContextName())
+	}
+}
+
+func TestSessionService_InvalidContextName(t *testing.T) {
+	dir, err := os.MkdirTemp("", "proxmox-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Setenv("HOME", dir)
+	defer os.RemoveAll(dir)
+	defer func() { services.SelectedContext = "" }()
+
+	services.SelectedContext = "../escape"
+	_, err = services.NewSessionService(logrus.New())
+	if err == nil {
+		t.Error("expected error for context name containing a path separator")
+	}
+}
+
+func TestSessionData_TicketNeedsRenewal(t *testing.T) {
+	now := time.Now()
+
+	sessionData := getValidSessionData()
+	if sessionData.TicketNeedsRenewal(now) {
+		t.Error("expected sessions without an issue time not to be renewed up front")
+	}
+
+	sessionData.IssuedAt = now.Add(-30 * time.Minute).Unix()
+	if sessionData.TicketNeedsRenewal(now) {
+		t.Error("expected a 30 minute old ticket not to need renewal")
+	}
+
+	sessionData.IssuedAt = now.Add(-95 * time.Minute).Unix()
+	if !sessionData.TicketNeedsRenewal(now) {
+		t.Error("expected a 95 minute old ticket to need renewal")
+	}
+
+	sessionData.TokenID = "ci@pve!build"
+	sessionData.TokenSecret = "secret"
+	if sessionData.TicketNeedsRenewal(now) {
+		t.Error("expected API token sessions never to need renewal")
+	}
+}
+
+func TestSessionService_MissingContextIsNotCreated(t *testing.T) {
+	dir, err := os.MkdirTemp("", "proxmox-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Setenv("HOME", dir)
+	defer os.RemoveAll(dir)
+	defer func() { services.SelectedContext = "" }()
+
+	services.SelectedContext = "typo"
+	ss, err := services.NewSessionService(logrus.New())
+	if err != nil {
+		t.Fatalf("NewSessionService failed: %v", err)
+	}
+	if _, err = ss.ReadSessionFile(); err == nil || err.Error() != `no session for context "typo": log in first` {
+		t.Errorf("expected missing session error, got %v", err)
+	}
+	if _, statErr := os.Stat(filepath.Join(dir, ".proxmox", "contexts", "typo")); !os.IsNotExist(statErr) {
+		t.Errorf("reading a missing context must not create its session file, stat error: %v", statErr)
+	}
+	if ss.ContextExists("typo") {
+		t.Error("expected context 'typo' not to exist")
+	}
+
+	// Empty files left behind by older versions are not contexts
+	if err = os.WriteFile(filepath.Join(dir, ".proxmox", "contexts", "typo"), nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if ss.ContextExists("typo") {
+		t.Error("expected an empty session file not to count as a context")
+	}
+	if err = ss.UseContext("typo"); err == nil {
+		t.Error("expected UseContext to reject an empty context")
+	}
+	contexts, err := ss.ListContexts()
+	if err != nil || len(contexts) != 0 {
+		t.Errorf("expected no contexts, got %+v (err: %v)", contexts, err)
+	}
+}
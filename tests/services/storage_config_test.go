@@ -0,0 +1,187 @@
+package tests
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"proxmox-cli/services"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStorageService_GetStorage(t *testing.T) {
+	mockHTTP := &mockHTTPService{
+		getFunc: func(uri string, headers map[string]string, cookies []*http.Cookie) (*http.Response, error) {
+			assert.Equal(t, "https://localhost:8006/api2/json/storage/isos", uri)
+			return jsonResponse(`{"data": {"storage": "isos", "type": "nfs", "server": "10.0.0.5", "export": "/srv/isos",
+				"content": "iso,vztmpl", "prune-backups": {"keep-last": 3}, "digest": "abc"}}`), nil
+		},
+	}
+
+	storageConfig, err := newTestService(services.NewStorageServiceWithDeps, mockHTTP).GetStorage("isos")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "nfs", storageConfig.Type())
+	assert.Equal(t, []services.StorageSetting{
+		{Option: "content", Value: "iso,vztmpl"},
+		{Option: "export", Value: "/srv/isos"},
+		{Option: "prune-backups", Value: "keep-last=3"},
+		{Option: "server", Value: "10.0.0.5"},
+	}, storageConfig.Settings())
+}
+
+func TestStorageService_CreateStorage(t *testing.T) {
+	disabled := false
+	tests := []struct {
+		name        string
+		storageType string
+		options     services.StorageOptions
+		want        url.Values
+	}{
+		{
+			name:        "dir",
+			storageType: services.StorageDir,
+			options:     services.StorageOptions{Path: "/srv/images", Content: []string{"images", "backup"}, Shared: true},
+			want:        url.Values{"path": {"/srv/images"}, "content": {"images,backup"}, "shared": {"1"}},
+		},
+		{
+			name:        "nfs",
+			storageType: services.StorageNFS,
+			options:     services.StorageOptions{Server: "10.0.0.5", Export: "/srv/isos", MountOptions: "vers=4.2", Nodes: []string{"pve1", "pve2"}},
+			want:        url.Values{"server": {"10.0.0.5"}, "export": {"/srv/isos"}, "options": {"vers=4.2"}, "nodes": {"pve1,pve2"}},
+		},
+		{
+			name:        "cifs",
+			storageType: services.StorageCIFS,
+			options:     services.StorageOptions{Server: "fs", Share: "vms", Username: "pve", Password: "secret", Domain: "corp"},
+			want:        url.Values{"server": {"fs"}, "share": {"vms"}, "username": {"pve"}, "password": {"secret"}, "domain": {"corp"}},
+		},
+		{
+			name:        "lvmthin",
+			storageType: services.StorageLVMThin,
+			options:     services.StorageOptions{VGName: "pve", ThinPool: "data", Enabled: &disabled},
+			want:        url.Values{"vgname": {"pve"}, "thinpool": {"data"}, "disable": {"1"}},
+		},
+		{
+			name:        "zfspool",
+			storageType: services.StorageZFSPool,
+			options:     services.StorageOptions{Pool: "rpool/data", Sparse: true, Blocksize: "16k"},
+			want:        url.Values{"pool": {"rpool/data"}, "sparse": {"1"}, "blocksize": {"16k"}},
+		},
+		{
+			name:        "rbd",
+			storageType: services.StorageRBD,
+			options:     services.StorageOptions{Pool: "vms", MonHost: []string{"10.0.0.1", "10.0.0.2"}, Username: "admin", KRBD: true},
+			want:        url.Values{"pool": {"vms"}, "monhost": {"10.0.0.1 10.0.0.2"}, "username": {"admin"}, "krbd": {"1"}},
+		},
+		{
+			name:        "pbs",
+			storageType: services.StoragePBS,
+			options: services.StorageOptions{
+				Server: "pbs", Datastore: "main", Username: "backup@pbs", Fingerprint: "aa:bb", PruneBackups: "keep-daily=7",
+			},
+			want: url.Values{"server": {"pbs"}, "datastore": {"main"}, "username": {"backup@pbs"}, "fingerprint": {"aa:bb"}, "prune-backups": {"keep-daily=7"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var posted url.Values
+			mockHTTP := &mockHTTPService{
+				postFunc: func(uri, payload string, headers map[string]string, cookies []*http.Cookie) (string, error) {
+					assert.Equal(t, "https://localhost:8006/api2/json/storage", uri)
+					posted, _ = url.ParseQuery(payload)
+					return `{"data": null}`, nil
+				},
+			}
+
+			err := newTestService(services.NewStorageServiceWithDeps, mockHTTP).CreateStorage("store1", tt.storageType, tt.options)
+
+			assert.NoError(t, err)
+			tt.want.Set("storage", "store1")
+			tt.want.Set("type", tt.storageType)
+			assert.Equal(t, tt.want, posted)
+		})
+	}
+}
+
+func TestStorageService_CreateStorage_Invalid(t *testing.T) {
+	mockHTTP := &mockHTTPService{
+		postFunc: func(uri, payload string, headers map[string]string, cookies []*http.Cookie) (string, error) {
+			t.Fatal("an invalid storage must not be submitted")
+			return "", nil
+		},
+	}
+	storageService := newTestService(services.NewStorageServiceWithDeps, mockHTTP)
+
+	tests := []struct {
+		storage     string
+		storageType string
+		options     services.StorageOptions
+		message     string
+	}{
+		{"1store", services.StorageDir, services.StorageOptions{Path: "/srv"}, `invalid storage ID "1store": use letters, digits, '-', '_' and '.', starting with a letter`},
+		{"store1", "iscsi", services.StorageOptions{}, `invalid storage type "iscsi": use dir, nfs, cifs, lvm, lvmthin, zfspool, rbd, pbs`},
+		{"store1", services.StorageDir, services.StorageOptions{}, "dir storage needs the path option"},
+		{"store1", services.StorageNFS, services.StorageOptions{Server: "10.0.0.5"}, "nfs storage needs the export option"},
+		{"store1", services.StoragePBS, services.StorageOptions{Server: "pbs", Datastore: "main"}, "pbs storage needs the username option"},
+		{"store1", services.StorageLVM, services.StorageOptions{VGName: "pve", Path: "/srv"}, "option path is not supported by lvm storage"},
+		{"store1", services.StorageZFSPool, services.StorageOptions{Pool: "rpool", Content: []string{"iso"}}, "content iso is not supported by zfspool storage: use images, rootdir"},
+		{"store1", services.StorageDir, services.StorageOptions{Path: "srv"}, `invalid path "srv": use an absolute path`},
+		{"store1", services.StorageDir, services.StorageOptions{Path: "/srv", PruneBackups: "keep-often=1"}, `invalid prune-backups setting "keep-often=1": use keep-all, keep-last, keep-hourly, keep-daily, keep-weekly, keep-monthly, keep-yearly`},
+		{"store1", services.StorageDir, services.StorageOptions{Path: "/srv", Delete: []string{"shared"}}, "options cannot be removed from a new storage"},
+	}
+	for _, tt := range tests {
+		assert.EqualError(t, storageService.CreateStorage(tt.storage, tt.storageType, tt.options), tt.message)
+	}
+}
+
+func TestStorageService_UpdateAndDeleteStorage(t *testing.T) {
+	var putted url.Values
+	mockHTTP := &mockHTTPService{
+		getFunc: func(uri string, headers map[string]string, cookies []*http.Cookie) (*http.Response, error) {
+			switch uri {
+			case "https://localhost:8006/api2/json/storage/isos":
+				return jsonResponse(`{"data": {"storage": "isos", "type": "nfs", "server": "10.0.0.5", "export": "/srv/isos"}}`), nil
+			case "https://localhost:8006/api2/json/storage/san":
+				return jsonResponse(`{"data": {"storage": "san", "type": "iscsi"}}`), nil
+			}
+			t.Fatalf("unexpected request %s", uri)
+			return nil, nil
+		},
+		putFunc: func(uri, payload string, headers map[string]string, cookies []*http.Cookie) (string, error) {
+			assert.Equal(t, "https://localhost:8006/api2/json/storage/isos", uri)
+			putted, _ = url.ParseQuery(payload)
+			return `{"data": null}`, nil
+		},
+		deleteFunc: func(uri string, headers map[string]string, cookies []*http.Cookie) (string, error) {
+			assert.Equal(t, "https://localhost:8006/api2/json/storage/isos", uri)
+			return `{"data": null}`, nil
+		},
+	}
+	storageService := newTestService(services.NewStorageServiceWithDeps, mockHTTP)
+
+	enabled := true
+	err := storageService.UpdateStorage("isos", services.StorageOptions{Server: "10.0.0.6", Enabled: &enabled, Delete: []string{"nodes"}})
+	assert.NoError(t, err)
+	assert.Equal(t, url.Values{"server": {"10.0.0.6"}, "disable": {"0"}, "delete": {"nodes"}}, putted)
+
+	// The options are checked against the type of the existing storage
+	err = storageService.UpdateStorage("isos", services.StorageOptions{Export: "/srv/other"})
+	assert.EqualError(t, err, "option export of nfs storage cannot be changed after it is created")
+
+	err = storageService.UpdateStorage("isos", services.StorageOptions{Delete: []string{"server"}})
+	assert.EqualError(t, err, "option server is required by nfs storage and cannot be removed")
+
+	err = storageService.UpdateStorage("isos", services.StorageOptions{Pool: "tank"})
+	assert.EqualError(t, err, "option pool is not supported by nfs storage")
+
+	err = storageService.UpdateStorage("san", services.StorageOptions{Content: []string{"images"}})
+	assert.EqualError(t, err, "storage san has type iscsi, which cannot be updated with these options")
+
+	err = storageService.UpdateStorage("isos", services.StorageOptions{})
+	assert.EqualError(t, err, "no changes given for storage isos")
+
+	assert.NoError(t, storageService.DeleteStorage("isos"))
+}
@@ -0,0 +1,212 @@
+package tests
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"proxmox-cli/services"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+const testUPID = "UPID:pve1:0000A1B2:0012C3D4:65F1A2B3:qmstart:100:root@pam:"
+
+func jsonResponse(body string) *http.Response {
+	return &http.Response{
+		StatusCode: 200,
+		Body:       io.NopCloser(strings.NewReader(body)),
+	}
+}
+
+func TestUPIDNode(t *testing.T) {
+	node, err := services.UPIDNode(testUPID)
+	assert.NoError(t, err)
+	assert.Equal(t, "pve1", node)
+
+	_, err = services.UPIDNode("not-a-upid")
+	assert.Error(t, err)
+}
+
+func TestUPIDStartTime(t *testing.T) {
+	started, err := services.UPIDStartTime(testUPID)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Unix(0x65F1A2B3, 0), started)
+
+	_, err = services.UPIDStartTime("UPID:pve1:0000A1B2:0012C3D4:later:qmstart:100:root@pam:")
+	assert.Error(t, err)
+}
+
+func TestTaskService_ListTasks_Success(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	var requestedURL string
+	mockHTTP := &mockHTTPService{
+		getFunc: func(url string, headers map[string]string, cookies []*http.Cookie) (*http.Response, error) {
+			requestedURL = url
+			return jsonResponse(`{"data": [
+				{"upid": "` + testUPID + `", "node": "pve1", "type": "qmstart", "id": "100", "user": "root@pam", "starttime": 1710334643, "endtime": 1710334650, "status": "OK"}
+			]}`), nil
+		},
+	}
+
+	mockSession := &mockSessionService{
+		readSessionFileFunc: func() (services.SessionData, error) {
+			return getValidSessionData(), nil
+		},
+	}
+
+	taskService := services.NewTaskServiceWithDeps(logger, true, mockHTTP, mockSession)
+	tasks, err := taskService.ListTasks("pve1", services.TaskListOptions{Limit: 10, VMID: 100})
+
+	assert.NoError(t, err)
+	assert.Len(t, tasks, 1)
+	assert.Equal(t, "qmstart", tasks[0].Type)
+	assert.Equal(t, "OK", tasks[0].Status)
+	assert.Equal(t, "https://localhost:8006/api2/json/nodes/pve1/tasks?limit=10&vmid=100", requestedURL)
+}
+
+func TestTaskService_GetTaskStatus_Success(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	var requestedURL string
+	mockHTTP := &mockHTTPService{
+		getFunc: func(url string, headers map[string]string, cookies []*http.Cookie) (*http.Response, error) {
+			requestedURL = url
+			return jsonResponse(`{"data": {"upid": "` + testUPID + `", "node": "pve1", "status": "stopped", "exitstatus": "OK"}}`), nil
+		},
+	}
+
+	mockSession := &mockSessionService{
+		readSessionFileFunc: func() (services.SessionData, error) {
+			return getValidSessionData(), nil
+		},
+	}
+
+	taskService := services.NewTaskServiceWithDeps(logger, true, mockHTTP, mockSession)
+	status, err := taskService.GetTaskStatus("pve1", testUPID)
+
+	assert.NoError(t, err)
+	assert.False(t, status.Running())
+	assert.True(t, status.Succeeded())
+	assert.Equal(t, "https://localhost:8006/api2/json/nodes/pve1/tasks/"+testUPID+"/status", requestedURL)
+}
+
+func TestTaskService_StopTask_Success(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	var deletedURL string
+	mockHTTP := &mockHTTPService{
+		deleteFunc: func(url string, headers map[string]string, cookies []*http.Cookie) (string, error) {
+			deletedURL = url
+			assert.Equal(t, "csrf123", headers["CSRFPreventionToken"])
+			return `{"data": null}`, nil
+		},
+	}
+
+	mockSession := &mockSessionService{
+		readSessionFileFunc: func() (services.SessionData, error) {
+			return getValidSessionData(), nil
+		},
+	}
+
+	taskService := services.NewTaskServiceWithDeps(logger, true, mockHTTP, mockSession)
+	err := taskService.StopTask("pve1", testUPID)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "https://localhost:8006/api2/json/nodes/pve1/tasks/"+testUPID, deletedURL)
+}
+
+// taskMock serves a task that is running for the first runningCalls status requests and then
+// finishes with exitStatus, adding one log line per status request
+func taskMock(runningCalls int, exitStatus string) *mockHTTPService {
+	calls := 0
+	return &mockHTTPService{
+		getFunc: func(url string, headers map[string]string, cookies []*http.Cookie) (*http.Response, error) {
+			if strings.Contains(url, "/status") {
+				calls++
+				if calls <= runningCalls {
+					return jsonResponse(`{"data": {"status": "running"}}`), nil
+				}
+				return jsonResponse(`{"data": {"status": "stopped", "exitstatus": "` + exitStatus + `"}}`), nil
+			}
+
+			// Return every line up to the current call, the service skips the ones it has seen
+			lines := []string{}
+			for n := 1; n <= calls; n++ {
+				lines = append(lines, fmt.Sprintf(`{"n": %d, "t": "line %d"}`, n, n))
+			}
+			return jsonResponse(`{"data": [` + strings.Join(lines, ",") + `]}`), nil
+		},
+	}
+}
+
+func TestTaskService_WaitForTask_Success(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	mockSession := &mockSessionService{
+		readSessionFileFunc: func() (services.SessionData, error) {
+			return getValidSessionData(), nil
+		},
+	}
+
+	taskService := services.NewTaskServiceWithDeps(logger, true, taskMock(2, "OK"), mockSession)
+	taskService.PollInterval = time.Millisecond
+
+	var logged []string
+	status, err := taskService.WaitForTask("pve1", testUPID, time.Minute, func(line services.TaskLogLine) {
+		logged = append(logged, line.T)
+	})
+
+	assert.NoError(t, err)
+	assert.True(t, status.Succeeded())
+	assert.Equal(t, []string{"line 1", "line 2", "line 3"}, logged)
+}
+
+func TestTaskService_WaitForTask_Failure(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	mockSession := &mockSessionService{
+		readSessionFileFunc: func() (services.SessionData, error) {
+			return getValidSessionData(), nil
+		},
+	}
+
+	taskService := services.NewTaskServiceWithDeps(logger, true, taskMock(1, "command failed"), mockSession)
+	taskService.PollInterval = time.Millisecond
+
+	status, err := taskService.WaitForTask("pve1", testUPID, time.Minute, nil)
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "command failed")
+	assert.False(t, status.Succeeded())
+}
+
+func TestTaskService_WaitForTask_Timeout(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	mockSession := &mockSessionService{
+		readSessionFileFunc: func() (services.SessionData, error) {
+			return getValidSessionData(), nil
+		},
+	}
+
+	taskService := services.NewTaskServiceWithDeps(logger, true, taskMock(1000, "OK"), mockSession)
+	taskService.PollInterval = time.Millisecond
+
+	status, err := taskService.WaitForTask("pve1", testUPID, 5*time.Millisecond, nil)
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "timed out")
+	assert.True(t, status.Running())
+}
@@ -0,0 +1,178 @@
+package tests
+
+import (
+	"io"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"proxmox-cli/services"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVMService_GetConfig(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	mockHTTP := &mockHTTPService{
+		getFunc: func(uri string, headers map[string]string, cookies []*http.Cookie) (*http.Response, error) {
+			assert.Equal(t, "https://localhost:8006/api2/json/nodes/pve1/qemu/100/config", uri)
+			return jsonResponse(`{"data": {
+				"name": "web",
+				"memory": "2048",
+				"cores": 2,
+				"sockets": 1,
+				"ostype": "l26",
+				"boot": "order=scsi0;ide2",
+				"scsi0": "local-lvm:vm-100-disk-0,size=32G",
+				"ide2": "none,media=cdrom",
+				"net0": "virtio=BC:24:11:00:00:01,bridge=vmbr0",
+				"ciuser": "debian",
+				"sshkeys": "ssh-ed25519%20AAAAC3Nza%20user%40host",
+				"ipconfig0": "ip=dhcp",
+				"agent": 1,
+				"unused0": "local-lvm:vm-100-disk-1",
+				"digest": "abc123"
+			}}`), nil
+		},
+	}
+	mockSession := &mockSessionService{
+		readSessionFileFunc: func() (services.SessionData, error) {
+			return getValidSessionData(), nil
+		},
+	}
+
+	vmService := services.NewVMServiceWithDeps(logger, true, mockHTTP, mockSession)
+	config, err := vmService.GetConfig("pve1", 100)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "web", config.Name)
+	assert.Equal(t, 2048, config.Memory)
+	assert.Equal(t, 2, config.Cores)
+	assert.Equal(t, 1, config.Sockets)
+	assert.Equal(t, "l26", config.OSType)
+	assert.Equal(t, "order=scsi0;ide2", config.Boot)
+	assert.Equal(t, map[string]string{
+		"scsi0": "local-lvm:vm-100-disk-0,size=32G",
+		"ide2":  "none,media=cdrom",
+	}, config.Disks)
+	assert.Equal(t, map[string]string{"net0": "virtio=BC:24:11:00:00:01,bridge=vmbr0"}, config.Networks)
+	if assert.NotNil(t, config.CloudInit) {
+		assert.Equal(t, "debian", config.CloudInit.User)
+		assert.Equal(t, "ssh-ed25519 AAAAC3Nza user@host", config.CloudInit.SSHKeys)
+		assert.Equal(t, map[string]string{"ipconfig0": "ip=dhcp"}, config.CloudInit.IPConfig)
+	}
+	assert.Equal(t, map[string]string{"agent": "1", "unused0": "local-lvm:vm-100-disk-1"}, config.Other)
+	assert.Equal(t, "abc123", config.Digest)
+}
+
+func TestVMService_GetPendingChanges(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	mockHTTP := &mockHTTPService{
+		getFunc: func(uri string, headers map[string]string, cookies []*http.Cookie) (*http.Response, error) {
+			assert.Equal(t, "https://localhost:8006/api2/json/nodes/pve1/qemu/100/pending", uri)
+			return jsonResponse(`{"data": [
+				{"key": "name", "value": "web"},
+				{"key": "memory", "value": 2048, "pending": 4096},
+				{"key": "net1", "value": "virtio,bridge=vmbr1", "delete": 1}
+			]}`), nil
+		},
+	}
+	mockSession := &mockSessionService{
+		readSessionFileFunc: func() (services.SessionData, error) {
+			return getValidSessionData(), nil
+		},
+	}
+
+	vmService := services.NewVMServiceWithDeps(logger, true, mockHTTP, mockSession)
+	changes, err := vmService.GetPendingChanges("pve1", 100)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []services.PendingChange{
+		{Key: "memory", Value: "2048", Pending: "4096"},
+		{Key: "net1", Value: "virtio,bridge=vmbr1", Delete: true},
+	}, changes)
+}
+
+func TestVMService_UpdateConfig(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	var putURI string
+	var put url.Values
+	mockHTTP := &mockHTTPService{
+		putFunc: func(uri, payload string, headers map[string]string, cookies []*http.Cookie) (string, error) {
+			putURI = uri
+			put, _ = url.ParseQuery(payload)
+			assert.Equal(t, "csrf123", headers["CSRFPreventionToken"])
+			return `{"data": null}`, nil
+		},
+	}
+	mockSession := &mockSessionService{
+		readSessionFileFunc: func() (services.SessionData, error) {
+			return getValidSessionData(), nil
+		},
+	}
+
+	vmService := services.NewVMServiceWithDeps(logger, true, mockHTTP, mockSession)
+	err := vmService.UpdateConfig("pve1", 100, services.ConfigUpdate{
+		Set:    map[string]string{"memory": "4096", "sshkeys": "ssh-ed25519 AAAA user@host"},
+		Delete: []string{"net1", "ide2"},
+		Digest: "abc123",
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "https://localhost:8006/api2/json/nodes/pve1/qemu/100/config", putURI)
+	assert.Equal(t, "4096", put.Get("memory"))
+	assert.Equal(t, "ssh-ed25519%20AAAA%20user%40host", put.Get("sshkeys"))
+	assert.Equal(t, "net1,ide2", put.Get("delete"))
+	assert.Equal(t, "abc123", put.Get("digest"))
+}
+
+func TestVMService_UpdateConfig_Invalid(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	vmService := services.NewVMServiceWithDeps(logger, true, &mockHTTPService{}, &mockSessionService{})
+
+	assert.Error(t, vmService.UpdateConfig("pve1", 100, services.ConfigUpdate{}))
+	assert.Error(t, vmService.UpdateConfig("pve1", 100, services.ConfigUpdate{
+		Set:    map[string]string{"memory": "4096"},
+		Delete: []string{"memory"},
+	}))
+	assert.Error(t, vmService.UpdateConfig("pve1", 100, services.ConfigUpdate{
+		Set: map[string]string{"delete": "net0"},
+	}))
+}
+
+func TestVMService_UpdateConfig_DigestMismatch(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	mockHTTP := &mockHTTPService{
+		putFunc: func(uri, payload string, headers map[string]string, cookies []*http.Cookie) (string, error) {
+			body := `{"data": null}`
+			return body, services.NewAPIError(500, "500 detected modified configuration - file changed by other user? Try again.", []byte(body))
+		},
+	}
+	mockSession := &mockSessionService{
+		readSessionFileFunc: func() (services.SessionData, error) {
+			return getValidSessionData(), nil
+		},
+	}
+
+	vmService := services.NewVMServiceWithDeps(logger, true, mockHTTP, mockSession)
+	err := vmService.UpdateConfig("pve1", 100, services.ConfigUpdate{
+		Set:    map[string]string{"memory": "4096"},
+		Digest: "outdated",
+	})
+
+	apiErr, ok := services.AsAPIError(err)
+	if assert.True(t, ok) {
+		assert.Contains(t, apiErr.Message, "detected modified configuration")
+	}
+}
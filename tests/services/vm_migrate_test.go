@@ -0,0 +1,95 @@
+package tests
+
+import (
+	"io"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"proxmox-cli/services"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVMService_MigrationPreflight(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	mockHTTP := &mockHTTPService{
+		getFunc: func(uri string, headers map[string]string, cookies []*http.Cookie) (*http.Response, error) {
+			assert.Equal(t, "https://localhost:8006/api2/json/nodes/pve1/qemu/100/migrate?target=pve2", uri)
+			return jsonResponse(`{"data": {
+				"running": 1,
+				"allowed_nodes": ["pve3"],
+				"not_allowed_nodes": {"pve2": {"unavailable_storages": ["local-zfs"]}},
+				"local_disks": [{"volid": "local-zfs:vm-100-disk-0", "drivename": "scsi0", "size": 34359738368}],
+				"local_resources": ["hostpci0"]
+			}}`), nil
+		},
+	}
+	mockSession := &mockSessionService{
+		readSessionFileFunc: func() (services.SessionData, error) {
+			return getValidSessionData(), nil
+		},
+	}
+
+	vmService := services.NewVMServiceWithDeps(logger, true, mockHTTP, mockSession)
+	preflight, err := vmService.MigrationPreflight("pve1", 100, "pve2")
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, preflight.Running)
+	assert.Equal(t, []string{"hostpci0"}, preflight.LocalResources)
+	assert.Equal(t, "scsi0", preflight.LocalDisks[0].DriveName)
+
+	assert.Equal(t, []string{
+		"the VM is running; use online migration or stop it first",
+		"local resource hostpci0 cannot be migrated",
+		"local disk local-zfs:vm-100-disk-0 needs to be copied with local disks",
+		"storage local-zfs is not available on pve2",
+	}, preflight.Blockers(services.MigrateOptions{Target: "pve2"}))
+
+	assert.Equal(t, []string{"local resource hostpci0 cannot be migrated"}, preflight.Blockers(services.MigrateOptions{
+		Target:         "pve2",
+		Online:         true,
+		WithLocalDisks: true,
+		TargetStorage:  "local-lvm",
+	}))
+}
+
+func TestVMService_Migrate(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	var posted url.Values
+	mockHTTP := &mockHTTPService{
+		postFunc: func(uri, payload string, headers map[string]string, cookies []*http.Cookie) (string, error) {
+			assert.Equal(t, "https://localhost:8006/api2/json/nodes/pve1/qemu/100/migrate", uri)
+			posted, _ = url.ParseQuery(payload)
+			return `{"data": "UPID:pve1:00001234:00000000:00000000:qmigrate:100:user@pam:"}`, nil
+		},
+	}
+	mockSession := &mockSessionService{
+		readSessionFileFunc: func() (services.SessionData, error) {
+			return getValidSessionData(), nil
+		},
+	}
+
+	vmService := services.NewVMServiceWithDeps(logger, true, mockHTTP, mockSession)
+	taskID, err := vmService.Migrate("pve1", 100, services.MigrateOptions{
+		Target:         "pve2",
+		Online:         true,
+		WithLocalDisks: true,
+		TargetStorage:  "local-lvm",
+	})
+
+	assert.NoError(t, err)
+	assert.Contains(t, taskID, "qmigrate")
+	assert.Equal(t, "pve2", posted.Get("target"))
+	assert.Equal(t, "1", posted.Get("online"))
+	assert.Equal(t, "1", posted.Get("with-local-disks"))
+	assert.Equal(t, "local-lvm", posted.Get("targetstorage"))
+
+	_, err = vmService.Migrate("pve1", 100, services.MigrateOptions{Target: "pve1"})
+	assert.Error(t, err)
+}
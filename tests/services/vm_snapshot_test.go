@@ -0,0 +1,107 @@
+package tests
+
+import (
+	"io"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"proxmox-cli/services"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSnapshotTree(t *testing.T) {
+	snapshots := []services.Snapshot{
+		{Name: services.CurrentSnapshot, Parent: "update", Description: "You are here!"},
+		{Name: "update", Parent: "base", SnapTime: 300},
+		{Name: "experiment", Parent: "base", SnapTime: 200},
+		{Name: "base", SnapTime: 100},
+		{Name: "orphan", Parent: "removed", SnapTime: 50},
+	}
+
+	tree := services.SnapshotTree(snapshots)
+
+	names := []string{}
+	depths := []int{}
+	for _, entry := range tree {
+		names = append(names, entry.Name)
+		depths = append(depths, entry.Depth)
+	}
+	assert.Equal(t, []string{"orphan", "base", "experiment", "update", services.CurrentSnapshot}, names)
+	assert.Equal(t, []int{0, 0, 1, 1, 2}, depths)
+}
+
+func TestVMService_ListSnapshots(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	mockHTTP := &mockHTTPService{
+		getFunc: func(uri string, headers map[string]string, cookies []*http.Cookie) (*http.Response, error) {
+			assert.Equal(t, "https://localhost:8006/api2/json/nodes/pve1/qemu/100/snapshot", uri)
+			return jsonResponse(`{"data": [
+				{"name": "base", "snaptime": 1700000000, "vmstate": 1, "description": "golden"},
+				{"name": "current", "parent": "base", "description": "You are here!"}
+			]}`), nil
+		},
+	}
+	mockSession := &mockSessionService{
+		readSessionFileFunc: func() (services.SessionData, error) {
+			return getValidSessionData(), nil
+		},
+	}
+
+	vmService := services.NewVMServiceWithDeps(logger, true, mockHTTP, mockSession)
+	snapshots, err := vmService.ListSnapshots("pve1", 100)
+
+	assert.NoError(t, err)
+	assert.Len(t, snapshots, 2)
+	assert.Equal(t, services.Snapshot{Name: "base", SnapTime: 1700000000, VMState: 1, Description: "golden"}, snapshots[0])
+	assert.Equal(t, "base", snapshots[1].Parent)
+}
+
+func TestVMService_SnapshotActions(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	var requests []string
+	var posted url.Values
+	mockHTTP := &mockHTTPService{
+		postFunc: func(uri, payload string, headers map[string]string, cookies []*http.Cookie) (string, error) {
+			requests = append(requests, "POST "+uri)
+			posted, _ = url.ParseQuery(payload)
+			return `{"data": "UPID:pve1:00001234:00000000:00000000:qmsnapshot:100:user@pam:"}`, nil
+		},
+		deleteFunc: func(uri string, headers map[string]string, cookies []*http.Cookie) (string, error) {
+			requests = append(requests, "DELETE "+uri)
+			return `{"data": "UPID:pve1:00001234:00000000:00000000:qmdelsnapshot:100:user@pam:"}`, nil
+		},
+	}
+	mockSession := &mockSessionService{
+		readSessionFileFunc: func() (services.SessionData, error) {
+			return getValidSessionData(), nil
+		},
+	}
+	vmService := services.NewVMServiceWithDeps(logger, true, mockHTTP, mockSession)
+
+	taskID, err := vmService.CreateSnapshot("pve1", 100, "before-upgrade", "Before the upgrade", true)
+	assert.NoError(t, err)
+	assert.Contains(t, taskID, "qmsnapshot")
+	assert.Equal(t, "before-upgrade", posted.Get("snapname"))
+	assert.Equal(t, "Before the upgrade", posted.Get("description"))
+	assert.Equal(t, "1", posted.Get("vmstate"))
+
+	_, err = vmService.RollbackSnapshot("pve1", 100, "before-upgrade", true)
+	assert.NoError(t, err)
+	assert.Equal(t, "1", posted.Get("start"))
+
+	_, err = vmService.DeleteSnapshot("pve1", 100, "before-upgrade", true)
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{
+		"POST https://localhost:8006/api2/json/nodes/pve1/qemu/100/snapshot",
+		"POST https://localhost:8006/api2/json/nodes/pve1/qemu/100/snapshot/before-upgrade/rollback",
+		"DELETE https://localhost:8006/api2/json/nodes/pve1/qemu/100/snapshot/before-upgrade?force=1",
+	}, requests)
+}
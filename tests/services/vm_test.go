@@ -3,6 +3,7 @@ package tests
 import (
 	"io"
 	"net/http"
+	"net/url"
 	"strings"
 	"testing"
 
@@ -444,3 +445,200 @@ func TestVMService_GetVMStatus_InvalidJSON(t *testing.T) {
 	assert.Error(t, err)
 	assert.Nil(t, status)
 }
+
+func TestVMService_StartVM_APIToken(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	mockHTTP := &mockHTTPService{
+		postFunc: func(uri, payload string, headers map[string]string, cookies []*http.Cookie) (string, error) {
+			assert.Equal(t, "PVEAPIToken=ci@pve!build=secret", headers["Authorization"])
+			assert.NotContains(t, headers, "CSRFPreventionToken")
+			assert.Nil(t, cookies)
+			return `{"data": "UPID:pve1:00001234:00000000:00000000:qmstart:100:ci@pve!build:"}`, nil
+		},
+	}
+
+	mockSession := &mockSessionService{
+		readSessionFileFunc: func() (services.SessionData, error) {
+			return services.SessionData{
+				Server:      "localhost",
+				Port:        8006,
+				HttpScheme:  "https",
+				TokenID:     "ci@pve!build",
+				TokenSecret: "secret",
+			}, nil
+		},
+	}
+
+	vmService := services.NewVMServiceWithDeps(logger, true, mockHTTP, mockSession)
+	taskID, err := vmService.StartVM("pve1", 100)
+
+	assert.NoError(t, err)
+	assert.Contains(t, taskID, "qmstart")
+}
+
+func TestVMService_CreateVM_AllocatesVMID(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	var postedURI string
+	var posted url.Values
+	mockHTTP := &mockHTTPService{
+		getFunc: func(uri string, headers map[string]string, cookies []*http.Cookie) (*http.Response, error) {
+			assert.Equal(t, "https://localhost:8006/api2/json/cluster/nextid", uri)
+			return jsonResponse(`{"data": "105"}`), nil
+		},
+		postFunc: func(uri, payload string, headers map[string]string, cookies []*http.Cookie) (string, error) {
+			postedURI = uri
+			posted, _ = url.ParseQuery(payload)
+			return `{"data": "UPID:pve1:00001234:00000000:00000000:qmcreate:105:user@pam:"}`, nil
+		},
+	}
+	mockSession := &mockSessionService{
+		readSessionFileFunc: func() (services.SessionData, error) {
+			return getValidSessionData(), nil
+		},
+	}
+
+	vmService := services.NewVMServiceWithDeps(logger, true, mockHTTP, mockSession)
+	vmid, taskID, err := vmService.CreateVM("pve1", 0, services.VMConfig{
+		Name:     "web",
+		Memory:   2048,
+		Cores:    2,
+		OSType:   "l26",
+		Boot:     "scsi0;ide2",
+		CDROM:    "local:iso/debian.iso",
+		Disks:    map[string]string{"scsi0": "local-lvm:32"},
+		Networks: map[string]string{"net0": "virtio,bridge=vmbr0"},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 105, vmid)
+	assert.Contains(t, taskID, "qmcreate")
+	assert.Equal(t, "https://localhost:8006/api2/json/nodes/pve1/qemu", postedURI)
+	assert.Equal(t, "105", posted.Get("vmid"))
+	assert.Equal(t, "web", posted.Get("name"))
+	assert.Equal(t, "2048", posted.Get("memory"))
+	assert.Equal(t, "2", posted.Get("cores"))
+	assert.NotContains(t, posted, "sockets")
+	assert.Equal(t, "l26", posted.Get("ostype"))
+	assert.Equal(t, "order=scsi0;ide2", posted.Get("boot"))
+	assert.Equal(t, "local:iso/debian.iso,media=cdrom", posted.Get("ide2"))
+	assert.Equal(t, "local-lvm:32", posted.Get("scsi0"))
+	assert.Equal(t, "virtio,bridge=vmbr0", posted.Get("net0"))
+}
+
+func TestVMService_CreateVM_ExplicitVMID(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	mockHTTP := &mockHTTPService{
+		getFunc: func(uri string, headers map[string]string, cookies []*http.Cookie) (*http.Response, error) {
+			t.Errorf("unexpected GET %s", uri)
+			return nil, nil
+		},
+		postFunc: func(uri, payload string, headers map[string]string, cookies []*http.Cookie) (string, error) {
+			posted, _ := url.ParseQuery(payload)
+			assert.Equal(t, "200", posted.Get("vmid"))
+			return `{"data": "UPID:pve1:00001234:00000000:00000000:qmcreate:200:user@pam:"}`, nil
+		},
+	}
+	mockSession := &mockSessionService{
+		readSessionFileFunc: func() (services.SessionData, error) {
+			return getValidSessionData(), nil
+		},
+	}
+
+	vmService := services.NewVMServiceWithDeps(logger, true, mockHTTP, mockSession)
+	vmid, _, err := vmService.CreateVM("pve1", 200, services.VMConfig{Name: "db"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 200, vmid)
+}
+
+func TestVMConfig_Validate(t *testing.T) {
+	assert.NoError(t, services.VMConfig{
+		Disks:    map[string]string{"scsi0": "local-lvm:32", "efidisk0": "local-lvm:1"},
+		Networks: map[string]string{"net0": "virtio,bridge=vmbr0"},
+	}.Validate())
+	assert.Error(t, services.VMConfig{Disks: map[string]string{"disk0": "local-lvm:32"}}.Validate())
+	assert.Error(t, services.VMConfig{Networks: map[string]string{"eth0": "virtio"}}.Validate())
+	assert.Error(t, services.VMConfig{CDROM: "local:iso/a.iso", Disks: map[string]string{"ide2": "local-lvm:8"}}.Validate())
+}
+
+func TestVMService_Clone(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	var postedURI string
+	var posted url.Values
+	mockHTTP := &mockHTTPService{
+		getFunc: func(uri string, headers map[string]string, cookies []*http.Cookie) (*http.Response, error) {
+			return jsonResponse(`{"data": "120"}`), nil
+		},
+		postFunc: func(uri, payload string, headers map[string]string, cookies []*http.Cookie) (string, error) {
+			postedURI = uri
+			posted, _ = url.ParseQuery(payload)
+			return `{"data": "UPID:pve1:00001234:00000000:00000000:qmclone:9000:user@pam:"}`, nil
+		},
+	}
+	mockSession := &mockSessionService{
+		readSessionFileFunc: func() (services.SessionData, error) {
+			return getValidSessionData(), nil
+		},
+	}
+
+	vmService := services.NewVMServiceWithDeps(logger, true, mockHTTP, mockSession)
+	newID, taskID, err := vmService.Clone("pve1", 9000, services.CloneOptions{
+		Name:       "test-42",
+		TargetNode: "pve2",
+		Full:       true,
+		Storage:    "local-lvm",
+		Pool:       "ci",
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 120, newID)
+	assert.Contains(t, taskID, "qmclone")
+	assert.Equal(t, "https://localhost:8006/api2/json/nodes/pve1/qemu/9000/clone", postedURI)
+	assert.Equal(t, "120", posted.Get("newid"))
+	assert.Equal(t, "test-42", posted.Get("name"))
+	assert.Equal(t, "pve2", posted.Get("target"))
+	assert.Equal(t, "1", posted.Get("full"))
+	assert.Equal(t, "local-lvm", posted.Get("storage"))
+	assert.Equal(t, "ci", posted.Get("pool"))
+}
+
+func TestVMService_Clone_LinkedWithStorage(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	vmService := services.NewVMServiceWithDeps(logger, true, &mockHTTPService{}, &mockSessionService{})
+	_, _, err := vmService.Clone("pve1", 9000, services.CloneOptions{NewID: 121, Storage: "local-lvm"})
+
+	assert.Error(t, err)
+}
+
+func TestVMService_ConvertToTemplate(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	mockHTTP := &mockHTTPService{
+		postFunc: func(uri, payload string, headers map[string]string, cookies []*http.Cookie) (string, error) {
+			assert.Equal(t, "https://localhost:8006/api2/json/nodes/pve1/qemu/9000/template", uri)
+			return `{"data": "UPID:pve1:00001234:00000000:00000000:qmtemplate:9000:user@pam:"}`, nil
+		},
+	}
+	mockSession := &mockSessionService{
+		readSessionFileFunc: func() (services.SessionData, error) {
+			return getValidSessionData(), nil
+		},
+	}
+
+	vmService := services.NewVMServiceWithDeps(logger, true, mockHTTP, mockSession)
+	taskID, err := vmService.ConvertToTemplate("pve1", 9000)
+
+	assert.NoError(t, err)
+	assert.Contains(t, taskID, "qmtemplate")
+}